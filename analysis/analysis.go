@@ -0,0 +1,276 @@
+// Package analysis computes training metrics from the streams of an activity.
+//
+// Every metric is computed over moving time. The streams are expanded into one value per second of moving time (see `timeline`):
+// samples where the athlete was stopped (per the moving stream) are dropped, and long gaps between samples (the device paused)
+// only count as a single second. This way pauses neither dilute averages nor count as time at an intensity.
+package analysis
+
+import (
+	"errors"
+	"math"
+
+	"github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// the longest time (in seconds) between two samples that is treated as continuous recording.
+// anything longer is a pause and is counted as a single second
+const MaxGap = 10
+
+// if the streams have no time stream, will throw this error
+var NoTimeStreamError = errors.New("Streams have no time stream")
+
+// The athlete thresholds that metrics are relative to. Metrics that need a value that is 0 are not computed.
+type Athlete struct {
+	// functional threshold power, in watts
+	FTP float64
+	// lactate threshold heart rate, in beats per minute
+	ThresholdHeartRate float64
+	MaxHeartRate       float64
+	RestingHeartRate   float64
+	// TRIMP weights heart rate differently for women (1.67) and men (1.92)
+	Female bool
+	// the athlete's zones (see `api.GetAthleteZones`). time in zones is only computed for the zones that are set
+	Zones *swagger.Zones
+}
+
+// Metrics of an activity. Metrics that could not be computed (missing streams or thresholds) are 0.
+type Metrics struct {
+	// seconds, from the first to the last sample
+	ElapsedTime int
+	// seconds, excluding stops and pauses
+	MovingTime int
+
+	AveragePower    float64
+	NormalizedPower float64
+	// normalized power / ftp
+	IntensityFactor float64
+	// training stress score, from power
+	TSS float64
+	// normalized power / average power
+	VariabilityIndex float64
+
+	AverageHeartRate float64
+	// banister's training impulse
+	TRIMP float64
+	// training stress score, from heart rate (TRIMP relative to an hour at threshold)
+	HRTSS float64
+
+	// meters per second
+	AverageSpeed float64
+
+	// output per heart beat: normalized power / average heart rate, or (without power) meters per minute / average heart rate
+	EfficiencyFactor float64
+	// how much the ratio of output to heart rate fell from the first half to the second, in percent (Pa:HR).
+	// under 5% is generally considered aerobically coupled
+	Decoupling float64
+
+	HeartRateZones swagger.TimedZoneDistribution
+	PowerZones     swagger.TimedZoneDistribution
+
+	// best efforts over `StandardDurations`. only durations that are no longer than the moving time are included
+	BestPower     []Effort
+	BestHeartRate []Effort
+	BestSpeed     []Effort
+}
+
+// the seconds of moving time in an activity
+type timeline struct {
+	// for each second, the index of the sample it belongs to
+	index []int
+	// for each second, its offset from the start of the activity (per the time stream)
+	offsets []int32
+}
+
+// expand the time stream into seconds of moving time.
+//
+// each sample lasts until the next sample. the last sample is not counted (like `api.TimeInZones`).
+func newTimeline(streams *swagger.StreamSet) timeline {
+	t := streams.Time.Data
+	var moving []bool
+	if streams.Moving != nil && len(streams.Moving.Data) == len(t) {
+		moving = streams.Moving.Data
+	}
+	tl := timeline{}
+	for i := 0; i < len(t)-1; i++ {
+		if moving != nil && !moving[i] {
+			continue
+		}
+		dt := t[i+1] - t[i]
+		if dt <= 0 {
+			continue
+		}
+		if dt > MaxGap {
+			dt = 1
+		}
+		for s := int32(0); s < dt; s++ {
+			tl.index = append(tl.index, i)
+			tl.offsets = append(tl.offsets, t[i]+s)
+		}
+	}
+	return tl
+}
+
+// the value of each second of moving time. `values` is indexed like the time stream
+func (tl timeline) series(values []float64) []float64 {
+	series := make([]float64, len(tl.index))
+	for s, i := range tl.index {
+		if i < len(values) {
+			series[s] = values[i]
+		}
+	}
+	return series
+}
+
+func toFloats(data []int32) []float64 {
+	f := make([]float64, len(data))
+	for i, v := range data {
+		f[i] = float64(v)
+	}
+	return f
+}
+
+// the speed of each sample. it comes from the distance stream when there is one (it is more accurate), otherwise the velocity stream
+func speeds(streams *swagger.StreamSet) []float64 {
+	t := streams.Time.Data
+	if streams.Distance != nil && len(streams.Distance.Data) == len(t) {
+		d := streams.Distance.Data
+		v := make([]float64, len(t))
+		for i := 0; i < len(t)-1; i++ {
+			if dt := t[i+1] - t[i]; dt > 0 {
+				v[i] = float64(d[i+1]-d[i]) / float64(dt)
+			}
+		}
+		return v
+	}
+	if streams.VelocitySmooth != nil {
+		v := make([]float64, len(streams.VelocitySmooth.Data))
+		for i, s := range streams.VelocitySmooth.Data {
+			v[i] = float64(s)
+		}
+		return v
+	}
+	return nil
+}
+
+func mean(series []float64) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	var total float64
+	for _, v := range series {
+		total += v
+	}
+	return total / float64(len(series))
+}
+
+// Time in each zone, in seconds of moving time. The result is in the same order as `zones`.
+func TimeInZones(zones swagger.ZoneRanges, series []float64) swagger.TimedZoneDistribution {
+	dist := make(swagger.TimedZoneDistribution, len(zones))
+	for i, zone := range zones {
+		dist[i] = swagger.TimedZoneRange{Min: zone.Min, Max: zone.Max}
+	}
+	for _, v := range series {
+		if idx := api.ZoneIndex(zones, v); idx != -1 {
+			dist[idx].Time++
+		}
+	}
+	return dist
+}
+
+// Compute the metrics of an activity.
+//
+// `streams` needs a time stream. Power metrics need a watts stream, heart rate metrics need a heartrate stream
+// and speed metrics need a distance (or velocity) stream. Gaps are handled with the moving stream if there is one.
+func Analyze(streams *swagger.StreamSet, athlete Athlete) (*Metrics, error) {
+	if streams == nil || streams.Time == nil || len(streams.Time.Data) == 0 {
+		return nil, NoTimeStreamError
+	}
+	t := streams.Time.Data
+	tl := newTimeline(streams)
+	m := &Metrics{
+		ElapsedTime: int(t[len(t)-1] - t[0]),
+		MovingTime:  len(tl.index),
+	}
+	var power, hr, speed []float64
+	if streams.Watts != nil {
+		power = tl.series(toFloats(streams.Watts.Data))
+		m.AveragePower = mean(power)
+		m.NormalizedPower = NormalizedPower(power)
+		if m.AveragePower > 0 {
+			m.VariabilityIndex = m.NormalizedPower / m.AveragePower
+		}
+		if athlete.FTP > 0 {
+			m.IntensityFactor = m.NormalizedPower / athlete.FTP
+			m.TSS = TSS(m.MovingTime, m.NormalizedPower, athlete.FTP)
+		}
+		m.BestPower = BestEfforts(power, tl.offsets, StandardDurations)
+	}
+	if streams.Heartrate != nil {
+		hr = tl.series(toFloats(streams.Heartrate.Data))
+		m.AverageHeartRate = mean(hr)
+		if athlete.MaxHeartRate > athlete.RestingHeartRate && athlete.RestingHeartRate > 0 {
+			m.TRIMP = TRIMP(hr, athlete.RestingHeartRate, athlete.MaxHeartRate, athlete.Female)
+			if athlete.ThresholdHeartRate > athlete.RestingHeartRate {
+				m.HRTSS = HRTSS(m.TRIMP, athlete.RestingHeartRate, athlete.MaxHeartRate, athlete.ThresholdHeartRate, athlete.Female)
+			}
+		}
+		m.BestHeartRate = BestEfforts(hr, tl.offsets, StandardDurations)
+	}
+	if v := speeds(streams); v != nil {
+		speed = tl.series(v)
+		m.AverageSpeed = mean(speed)
+		m.BestSpeed = BestEfforts(speed, tl.offsets, StandardDurations)
+	}
+	if m.AverageHeartRate > 0 {
+		switch {
+		case power != nil:
+			m.EfficiencyFactor = m.NormalizedPower / m.AverageHeartRate
+		case speed != nil:
+			m.EfficiencyFactor = m.AverageSpeed * 60 / m.AverageHeartRate
+		}
+	}
+	if hr != nil {
+		switch {
+		case power != nil:
+			m.Decoupling = Decoupling(power, hr)
+		case speed != nil:
+			m.Decoupling = Decoupling(speed, hr)
+		}
+	}
+	if athlete.Zones != nil {
+		if z := athlete.Zones.HeartRate; z != nil && z.Zones != nil && hr != nil {
+			m.HeartRateZones = TimeInZones(*z.Zones, hr)
+		}
+		if z := athlete.Zones.Power; z != nil && z.Zones != nil && power != nil {
+			m.PowerZones = TimeInZones(*z.Zones, power)
+		}
+	}
+	return m, nil
+}
+
+// The decoupling of output (power or speed) from heart rate between the first and second half, in percent.
+//
+// Positive means the heart rate drifted up relative to output. Both series are per second of moving time.
+func Decoupling(output []float64, hr []float64) float64 {
+	n := min(len(output), len(hr))
+	if n < 2 {
+		return 0
+	}
+	half := n / 2
+	firstHR, secondHR := mean(hr[:half]), mean(hr[half:n])
+	if firstHR == 0 || secondHR == 0 {
+		return 0
+	}
+	first := mean(output[:half]) / firstHR
+	second := mean(output[half:n]) / secondHR
+	if first == 0 {
+		return 0
+	}
+	return (first - second) / first * 100
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow10(places)
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,280 @@
+package analysis
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// streams sampled every second for `n` seconds
+func steady(n int, watts int32, hr int32, speed float32) *swagger.StreamSet {
+	set := &swagger.StreamSet{
+		Time:      &swagger.TimeStream{},
+		Watts:     &swagger.PowerStream{},
+		Heartrate: &swagger.HeartrateStream{},
+		Distance:  &swagger.DistanceStream{},
+	}
+	for i := 0; i <= n; i++ {
+		set.Time.Data = append(set.Time.Data, int32(i))
+		set.Watts.Data = append(set.Watts.Data, watts)
+		set.Heartrate.Data = append(set.Heartrate.Data, hr)
+		set.Distance.Data = append(set.Distance.Data, float32(i)*speed)
+	}
+	return set
+}
+
+func near(got, want, within float64) bool {
+	return math.Abs(got-want) <= within
+}
+
+func TestNormalizedPower(t *testing.T) {
+	alternating := []float64{}
+	for i := 0; i < 600; i++ {
+		// 1 minute at 300W, 1 minute at 100W
+		if (i/60)%2 == 0 {
+			alternating = append(alternating, 300)
+		} else {
+			alternating = append(alternating, 100)
+		}
+	}
+	tests := []struct {
+		name  string
+		power []float64
+		want  float64
+	}{
+		{"steady", steady1Hz(3600, 200), 200},
+		{"shorter than the window", []float64{100, 200}, 150},
+		{"empty", nil, 0},
+		// normalized power weights the hard minutes more than an average would
+		{"intervals", alternating, 239.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizedPower(tt.power); !near(got, tt.want, 0.1) {
+				t.Errorf("NormalizedPower() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func steady1Hz(n int, v float64) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = v
+	}
+	return s
+}
+
+func TestAnalyze_Power(t *testing.T) {
+	tests := []struct {
+		name    string
+		streams *swagger.StreamSet
+		athlete Athlete
+		// expected metrics
+		moving int
+		np     float64
+		ifac   float64
+		tss    float64
+	}{
+		{"an hour at ftp", steady(3600, 250, 150, 10), Athlete{FTP: 250}, 3600, 250, 1, 100},
+		{"an hour at 80%", steady(3600, 200, 150, 10), Athlete{FTP: 250}, 3600, 200, 0.8, 64},
+		{"half an hour at ftp", steady(1800, 250, 150, 10), Athlete{FTP: 250}, 1800, 250, 1, 50},
+		{"no ftp", steady(1800, 250, 150, 10), Athlete{}, 1800, 250, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Analyze(tt.streams, tt.athlete)
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			if m.MovingTime != tt.moving || !near(m.NormalizedPower, tt.np, 0.01) || !near(m.IntensityFactor, tt.ifac, 0.001) || !near(m.TSS, tt.tss, 0.01) {
+				t.Errorf("Analyze() = moving %d, np %v, if %v, tss %v", m.MovingTime, m.NormalizedPower, m.IntensityFactor, m.TSS)
+			}
+			if !near(m.VariabilityIndex, 1, 0.001) {
+				t.Errorf("VariabilityIndex = %v, want 1", m.VariabilityIndex)
+			}
+		})
+	}
+}
+
+func TestAnalyze_Gaps(t *testing.T) {
+	// 10 minutes, a 20 minute pause with no samples, then 10 more minutes
+	set := steady(600, 200, 140, 3)
+	for i := 1; i <= 600; i++ {
+		set.Time.Data = append(set.Time.Data, int32(600+1200+i))
+		set.Watts.Data = append(set.Watts.Data, 200)
+		set.Heartrate.Data = append(set.Heartrate.Data, 140)
+		set.Distance.Data = append(set.Distance.Data, float32(1800+i*3))
+	}
+	m, err := Analyze(set, Athlete{FTP: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pause counts as a single second: 600 + 1 + 599
+	if m.ElapsedTime != 2400 || m.MovingTime != 1200 {
+		t.Errorf("elapsed = %d, moving = %d, want 2400 and 1200", m.ElapsedTime, m.MovingTime)
+	}
+	if !near(m.AveragePower, 200, 0.01) || !near(m.NormalizedPower, 200, 0.01) {
+		t.Errorf("average power = %v, np = %v, want 200 (the pause is not zero filled)", m.AveragePower, m.NormalizedPower)
+	}
+
+	// the same, but recorded through the pause with the moving stream marking it
+	set = steady(1800, 200, 140, 3)
+	set.Moving = &swagger.MovingStream{}
+	for i := range set.Time.Data {
+		stopped := i >= 600 && i < 1200
+		if stopped {
+			set.Watts.Data[i] = 0
+		}
+		set.Moving.Data = append(set.Moving.Data, !stopped)
+	}
+	m, err = Analyze(set, Athlete{FTP: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MovingTime != 1200 || !near(m.AveragePower, 200, 0.01) || !near(m.TSS, 100.0/3, 0.01) {
+		t.Errorf("moving = %d, average power = %v, tss = %v", m.MovingTime, m.AveragePower, m.TSS)
+	}
+}
+
+func TestAnalyze_HeartRate(t *testing.T) {
+	athlete := Athlete{RestingHeartRate: 50, MaxHeartRate: 190, ThresholdHeartRate: 170}
+	tests := []struct {
+		name    string
+		hr      int32
+		athlete Athlete
+		trimp   float64
+		hrtss   float64
+	}{
+		{"an hour at threshold", 170, athlete, 170.7, 100},
+		{"an hour at threshold, female", 170, Athlete{RestingHeartRate: 50, MaxHeartRate: 190, ThresholdHeartRate: 170, Female: true}, 137.7, 100},
+		{"an hour easy", 120, athlete, 50.1, 29.4},
+		{"no thresholds", 170, Athlete{}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := steady(3600, 0, tt.hr, 3)
+			set.Watts = nil
+			m, err := Analyze(set, tt.athlete)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !near(m.TRIMP, tt.trimp, 0.1) || !near(m.HRTSS, tt.hrtss, 0.1) {
+				t.Errorf("trimp = %v, hrtss = %v, want %v and %v", m.TRIMP, m.HRTSS, tt.trimp, tt.hrtss)
+			}
+			// without power the efficiency factor is meters per minute per beat
+			if !near(m.EfficiencyFactor, 180/float64(tt.hr), 0.001) || m.TSS != 0 {
+				t.Errorf("ef = %v, tss = %v", m.EfficiencyFactor, m.TSS)
+			}
+		})
+	}
+}
+
+func TestDecoupling(t *testing.T) {
+	tests := []struct {
+		name   string
+		output []float64
+		hr     []float64
+		want   float64
+	}{
+		{"coupled", []float64{200, 200, 200, 200}, []float64{140, 140, 140, 140}, 0},
+		// the same power for a higher heart rate in the second half
+		{"drift", []float64{200, 200, 200, 200}, []float64{140, 140, 154, 154}, 9.09},
+		{"too short", []float64{200}, []float64{140}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Decoupling(tt.output, tt.hr); !near(got, tt.want, 0.01) {
+				t.Errorf("Decoupling() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestEfforts(t *testing.T) {
+	series := steady1Hz(120, 200)
+	for i := 40; i < 45; i++ {
+		series[i] = 600
+	}
+	offsets := make([]int32, len(series))
+	for i := range offsets {
+		offsets[i] = int32(i + 100)
+	}
+	efforts := BestEfforts(series, offsets, []int{5, 60, 300})
+	if len(efforts) != 2 {
+		t.Fatalf("BestEfforts() = %+v, want 2 efforts (300s is longer than the series)", efforts)
+	}
+	if efforts[0].Value != 600 || efforts[0].Start != 140 {
+		t.Errorf("5s effort = %+v, want 600 at 140", efforts[0])
+	}
+	if !near(efforts[1].Value, 200+400*5/60.0, 0.001) {
+		t.Errorf("60s effort = %+v", efforts[1])
+	}
+}
+
+func TestBestEfforts_paused(t *testing.T) {
+	series := steady1Hz(200, 200)
+	for i := 40; i < 45; i++ {
+		series[i] = 600
+	}
+	offsets := make([]int32, len(series))
+	for i := range offsets {
+		offsets[i] = int32(i)
+		// a pause in the middle of the surge, and a longer one later on
+		if i >= 43 {
+			offsets[i] += 30
+		}
+		if i >= 100 {
+			offsets[i] += 600
+		}
+	}
+	efforts := BestEfforts(series, offsets, []int{5, 3, 60, 150})
+	if len(efforts) != 3 {
+		t.Fatalf("BestEfforts() = %+v, want 3 efforts (no stretch is 150s long)", efforts)
+	}
+	// the surge is split by the pause, so 5s can't be all 600
+	if !near(efforts[0].Value, (3*600+2*200)/5.0, 0.001) || efforts[0].Start != 38 {
+		t.Errorf("5s effort = %+v, want the 3s of surge before the pause", efforts[0])
+	}
+	if efforts[1].Value != 600 || efforts[1].Start != 40 {
+		t.Errorf("3s effort = %+v, want 600 at 40", efforts[1])
+	}
+	// the only stretch long enough is the last one
+	if efforts[2].Value != 200 || efforts[2].Start != 730 {
+		t.Errorf("60s effort = %+v, want 200 at 730", efforts[2])
+	}
+}
+
+func TestAnalyze_Zones(t *testing.T) {
+	set := steady(100, 0, 0, 3)
+	for i := range set.Heartrate.Data {
+		set.Heartrate.Data[i] = 130
+		if i >= 60 {
+			set.Heartrate.Data[i] = 160
+		}
+		set.Watts.Data[i] = int32(100 + i)
+	}
+	zones := &swagger.Zones{
+		HeartRate: &swagger.HeartRateZoneRanges{Zones: &swagger.ZoneRanges{{Min: 0, Max: 150}, {Min: 150, Max: -1}}},
+		Power:     &swagger.PowerZoneRanges{Zones: &swagger.ZoneRanges{{Min: 0, Max: 150}, {Min: 150, Max: -1}}},
+	}
+	m, err := Analyze(set, Athlete{Zones: zones})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.HeartRateZones) != 2 || m.HeartRateZones[0].Time != 60 || m.HeartRateZones[1].Time != 40 {
+		t.Errorf("HeartRateZones = %+v", m.HeartRateZones)
+	}
+	if len(m.PowerZones) != 2 || m.PowerZones[0].Time != 50 || m.PowerZones[1].Time != 50 {
+		t.Errorf("PowerZones = %+v", m.PowerZones)
+	}
+}
+
+func TestAnalyze_NoTime(t *testing.T) {
+	_, err := Analyze(&swagger.StreamSet{}, Athlete{})
+	if !errors.Is(err, NoTimeStreamError) {
+		t.Errorf("Analyze() error = %v, want NoTimeStreamError", err)
+	}
+}
@@ -0,0 +1,112 @@
+package analysis
+
+import (
+	"errors"
+)
+
+// if there are not enough points in the range to fit a model, will throw this error
+var NotEnoughDataError = errors.New("Not enough data to fit the model")
+
+// the range of efforts (in seconds) that critical power and critical speed are fit to by default.
+// shorter efforts are limited by anaerobic power, longer ones by fatigue, and neither fit the model well
+const (
+	DefaultMinFitDuration = 120
+	DefaultMaxFitDuration = 1200
+)
+
+// CriticalPower is the 2 parameter critical power model: work = CP * time + W'
+type CriticalPower struct {
+	// watts
+	CP float64
+	// joules
+	WPrime float64
+	// how well the model fits the points (1 is a perfect fit)
+	R2 float64
+}
+
+// CriticalSpeed is the running equivalent of critical power: distance = CS * time + D'
+type CriticalSpeed struct {
+	// meters per second
+	CS float64
+	// meters
+	DPrime float64
+	// how well the model fits the points (1 is a perfect fit)
+	R2 float64
+}
+
+// least squares fit of y = slope * x + intercept
+func linearFit(x, y []float64) (slope, intercept, r2 float64) {
+	n := float64(len(x))
+	var sx, sy, sxx, sxy, syy float64
+	for i := range x {
+		sx += x[i]
+		sy += y[i]
+		sxx += x[i] * x[i]
+		sxy += x[i] * y[i]
+		syy += y[i] * y[i]
+	}
+	denominator := n*sxx - sx*sx
+	if denominator == 0 {
+		return 0, 0, 0
+	}
+	slope = (n*sxy - sx*sy) / denominator
+	intercept = (sy - slope*sx) / n
+	total := syy - sy*sy/n
+	if total == 0 {
+		return slope, intercept, 1
+	}
+	var residual float64
+	for i := range x {
+		e := y[i] - (slope*x[i] + intercept)
+		residual += e * e
+	}
+	return slope, intercept, 1 - residual/total
+}
+
+// Fit critical power and W' to the points of a power curve with a duration in [minDuration, maxDuration] seconds.
+//
+// Needs at least 2 points in the range.
+func FitCriticalPower(curve []PowerPoint, minDuration, maxDuration int) (*CriticalPower, error) {
+	x, y := []float64{}, []float64{}
+	for _, p := range curve {
+		if p.Duration < minDuration || p.Duration > maxDuration {
+			continue
+		}
+		x = append(x, float64(p.Duration))
+		y = append(y, p.Watts*float64(p.Duration))
+	}
+	if len(x) < 2 {
+		return nil, NotEnoughDataError
+	}
+	slope, intercept, r2 := linearFit(x, y)
+	return &CriticalPower{CP: round(slope, 1), WPrime: round(intercept, 0), R2: round(r2, 4)}, nil
+}
+
+// Whether a sport type (e.g. "TrailRun") is running. Critical speed is a running model, so only the pace curve of runs should be fit.
+func IsRun(sportType string) bool {
+	switch sportType {
+	case "Run", "TrailRun", "VirtualRun":
+		return true
+	}
+	return false
+}
+
+// Fit critical speed and D' to the points of a pace curve with a time in [minDuration, maxDuration] seconds.
+// The curve should only be built from runs (see `IsRun`): ride speeds would dominate it otherwise.
+//
+// Needs at least 2 points in the range.
+func FitCriticalSpeed(curve []PacePoint, minDuration, maxDuration int) (*CriticalSpeed, error) {
+	x, y := []float64{}, []float64{}
+	for _, p := range curve {
+		if p.Time < float64(minDuration) || p.Time > float64(maxDuration) {
+			continue
+		}
+		x = append(x, p.Time)
+		y = append(y, p.Distance)
+	}
+	if len(x) < 2 {
+		return nil, NotEnoughDataError
+	}
+	slope, intercept, r2 := linearFit(x, y)
+	return &CriticalSpeed{CS: round(slope, 3), DPrime: round(intercept, 1), R2: round(r2, 4)}, nil
+}
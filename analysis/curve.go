@@ -0,0 +1,174 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// the durations (in seconds) of a power curve: 1 second to 2 hours, densest where critical power is fit
+var CurveDurations = []int{1, 2, 5, 10, 15, 20, 30, 45, 60, 90, 120, 180, 240, 300, 360, 480, 600, 720, 900, 1200, 1800, 2700, 3600, 5400, 7200}
+
+// the distances (in meters) of a pace curve: 400m to the marathon
+var CurveDistances = []float64{400, 800, 1000, 1609.344, 3000, 5000, 10000, 15000, 21097.5, 42195}
+
+// ActivityStreams are the streams of one activity, along with what is needed to attribute a best to it
+type ActivityStreams struct {
+	ActivityID int64
+	StartDate  time.Time
+	// e.g. "Run" or "Ride"
+	SportType string
+	Streams   *swagger.StreamSet
+}
+
+// A PowerPoint is the best average power over a duration
+type PowerPoint struct {
+	// seconds of moving time
+	Duration int
+	Watts    float64
+	// the activity the best came from
+	ActivityID int64
+	StartDate  time.Time
+	// when the effort started, in seconds from the start of the activity
+	Offset int32
+}
+
+// A PacePoint is the fastest time over a distance
+type PacePoint struct {
+	// meters
+	Distance float64
+	// seconds of moving time
+	Time float64
+	// meters per second
+	Speed float64
+	// the activity the best came from
+	ActivityID int64
+	StartDate  time.Time
+	// when the effort started, in seconds from the start of the activity
+	Offset int32
+}
+
+// The mean-maximal power of an activity over each duration. Durations longer than the activity are left out.
+func ActivityPowerCurve(a ActivityStreams, durations []int) []PowerPoint {
+	streams := a.Streams
+	if streams == nil || streams.Time == nil || streams.Watts == nil {
+		return []PowerPoint{}
+	}
+	tl := newTimeline(streams)
+	curve := []PowerPoint{}
+	for _, e := range BestEfforts(tl.series(toFloats(streams.Watts.Data)), tl.offsets, durations) {
+		curve = append(curve, PowerPoint{Duration: e.Duration, Watts: e.Value, ActivityID: a.ActivityID, StartDate: a.StartDate, Offset: e.Start})
+	}
+	return curve
+}
+
+// The fastest time of an activity over each distance. Distances longer than the activity are left out.
+//
+// Only moving time counts, so a stop at a traffic light does not ruin a best.
+func ActivityPaceCurve(a ActivityStreams, distances []float64) []PacePoint {
+	streams := a.Streams
+	if streams == nil || streams.Time == nil {
+		return []PacePoint{}
+	}
+	v := speeds(streams)
+	if v == nil {
+		return []PacePoint{}
+	}
+	tl := newTimeline(streams)
+	speed := tl.series(v)
+	// cumulative[s] is the distance covered before second s
+	cumulative := make([]float64, len(speed)+1)
+	for s, sp := range speed {
+		cumulative[s+1] = cumulative[s] + sp
+	}
+	curve := []PacePoint{}
+	for _, d := range distances {
+		if d <= 0 || cumulative[len(speed)] < d {
+			continue
+		}
+		best := PacePoint{Distance: d, Time: math.Inf(1), ActivityID: a.ActivityID, StartDate: a.StartDate}
+		// the window [start, end) is the shortest one ending at `end` that covers the distance
+		start := 0
+		for end := 1; end <= len(speed); end++ {
+			if cumulative[end]-cumulative[start] < d {
+				continue
+			}
+			for cumulative[end]-cumulative[start+1] >= d {
+				start++
+			}
+			// the effort starts part way through the first second
+			elapsed := float64(end - start)
+			if sp := speed[start]; sp > 0 {
+				elapsed -= (cumulative[end] - cumulative[start] - d) / sp
+			}
+			if elapsed < best.Time {
+				best.Time = elapsed
+				best.Offset = tl.offsets[start]
+			}
+		}
+		best.Time = round(best.Time, 1)
+		best.Speed = round(d/best.Time, 3)
+		curve = append(curve, best)
+	}
+	return curve
+}
+
+// A CurveBuilder builds the envelope (the best of every activity) of power and pace curves.
+//
+// Activities are added one at a time, so the streams of a whole history never need to be held at once.
+type CurveBuilder struct {
+	durations []int
+	distances []float64
+	power     map[int]PowerPoint
+	pace      map[float64]PacePoint
+}
+
+// Create a curve builder. nil durations or distances use `CurveDurations` and `CurveDistances`.
+func NewCurveBuilder(durations []int, distances []float64) *CurveBuilder {
+	if durations == nil {
+		durations = CurveDurations
+	}
+	if distances == nil {
+		distances = CurveDistances
+	}
+	return &CurveBuilder{durations: durations, distances: distances, power: map[int]PowerPoint{}, pace: map[float64]PacePoint{}}
+}
+
+// Add an activity. Returns the activity's own curves.
+func (b *CurveBuilder) Add(a ActivityStreams) ([]PowerPoint, []PacePoint) {
+	power := ActivityPowerCurve(a, b.durations)
+	for _, p := range power {
+		if best, ok := b.power[p.Duration]; !ok || p.Watts > best.Watts {
+			b.power[p.Duration] = p
+		}
+	}
+	pace := ActivityPaceCurve(a, b.distances)
+	for _, p := range pace {
+		if best, ok := b.pace[p.Distance]; !ok || p.Time < best.Time {
+			b.pace[p.Distance] = p
+		}
+	}
+	return power, pace
+}
+
+// The best power over each duration of every activity added so far, shortest duration first
+func (b *CurveBuilder) PowerCurve() []PowerPoint {
+	curve := []PowerPoint{}
+	for _, p := range b.power {
+		curve = append(curve, p)
+	}
+	sort.Slice(curve, func(i, j int) bool { return curve[i].Duration < curve[j].Duration })
+	return curve
+}
+
+// The fastest time over each distance of every activity added so far, shortest distance first
+func (b *CurveBuilder) PaceCurve() []PacePoint {
+	curve := []PacePoint{}
+	for _, p := range b.pace {
+		curve = append(curve, p)
+	}
+	sort.Slice(curve, func(i, j int) bool { return curve[i].Distance < curve[j].Distance })
+	return curve
+}
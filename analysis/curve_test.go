@@ -0,0 +1,184 @@
+package analysis
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+func TestCurveBuilder(t *testing.T) {
+	// a steady ride, and a ride with a 5 minute effort
+	steadyRide := ActivityStreams{ActivityID: 1, Streams: steady(3600, 200, 140, 8)}
+	efforts := steady(1800, 150, 140, 8)
+	for i := 600; i < 900; i++ {
+		efforts.Watts.Data[i] = 350
+	}
+	effortRide := ActivityStreams{ActivityID: 2, Streams: efforts}
+
+	b := NewCurveBuilder([]int{5, 300, 3600}, []float64{})
+	power, _ := b.Add(steadyRide)
+	if len(power) != 3 || power[2].Watts != 200 {
+		t.Errorf("steady ride curve = %+v", power)
+	}
+	power, _ = b.Add(effortRide)
+	if len(power) != 2 {
+		t.Errorf("effort ride curve = %+v, want 2 points (it is shorter than an hour)", power)
+	}
+	curve := b.PowerCurve()
+	want := []struct {
+		duration int
+		watts    float64
+		activity int64
+		offset   int32
+	}{
+		{5, 350, 2, 600},
+		{300, 350, 2, 600},
+		{3600, 200, 1, 0},
+	}
+	if len(curve) != len(want) {
+		t.Fatalf("PowerCurve() = %+v", curve)
+	}
+	for i, w := range want {
+		p := curve[i]
+		if p.Duration != w.duration || p.Watts != w.watts || p.ActivityID != w.activity || p.Offset != w.offset {
+			t.Errorf("point %d = %+v, want %+v", i, p, w)
+		}
+	}
+}
+
+func TestActivityPaceCurve(t *testing.T) {
+	// 500m at 4m/s, stopped for 2 minutes, then 500m at 5m/s
+	stopInTheMiddle := &swagger.StreamSet{Time: &swagger.TimeStream{}, Distance: &swagger.DistanceStream{}, Moving: &swagger.MovingStream{}}
+	var d float32
+	for i := 0; i <= 345; i++ {
+		stopInTheMiddle.Time.Data = append(stopInTheMiddle.Time.Data, int32(i))
+		stopInTheMiddle.Distance.Data = append(stopInTheMiddle.Distance.Data, d)
+		stopped := i >= 125 && i < 245
+		stopInTheMiddle.Moving.Data = append(stopInTheMiddle.Moving.Data, !stopped)
+		switch {
+		case i < 125:
+			d += 4
+		case i >= 245:
+			d += 5
+		}
+	}
+	tests := []struct {
+		name      string
+		streams   *swagger.StreamSet
+		distances []float64
+		want      []PacePoint
+	}{
+		{"steady", steady(1000, 0, 0, 4), []float64{1000}, []PacePoint{{Distance: 1000, Time: 250, Speed: 4}}},
+		// the stop does not count, so the kilometer takes 125s + 100s
+		{"stop in the middle", stopInTheMiddle, []float64{400, 1000}, []PacePoint{{Distance: 400, Time: 80, Speed: 5, Offset: 245}, {Distance: 1000, Time: 225, Speed: 4.444}}},
+		{"too short", steady(10, 0, 0, 4), []float64{400}, []PacePoint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ActivityPaceCurve(ActivityStreams{Streams: tt.streams}, tt.distances)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ActivityPaceCurve() = %+v, want %+v", got, tt.want)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Distance != w.Distance || g.Time != w.Time || g.Speed != w.Speed || g.Offset != w.Offset {
+					t.Errorf("point %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestFitCriticalPower(t *testing.T) {
+	// a curve that follows the model exactly: P = CP + W' / t
+	curve := []PowerPoint{}
+	for _, d := range CurveDurations {
+		curve = append(curve, PowerPoint{Duration: d, Watts: 250 + 20000/float64(d)})
+	}
+	cp, err := FitCriticalPower(curve, DefaultMinFitDuration, DefaultMaxFitDuration)
+	if err != nil {
+		t.Fatalf("FitCriticalPower() error = %v", err)
+	}
+	if cp.CP != 250 || cp.WPrime != 20000 || cp.R2 != 1 {
+		t.Errorf("FitCriticalPower() = %+v, want CP 250, W' 20000", cp)
+	}
+	_, err = FitCriticalPower(curve[:3], DefaultMinFitDuration, DefaultMaxFitDuration)
+	if !errors.Is(err, NotEnoughDataError) {
+		t.Errorf("FitCriticalPower(short efforts) error = %v, want NotEnoughDataError", err)
+	}
+}
+
+func TestFitCriticalSpeed(t *testing.T) {
+	// distance = 4.5 * t + 200
+	curve := []PacePoint{}
+	for _, d := range []float64{1000, 1609.344, 3000, 5000, 10000} {
+		time := (d - 200) / 4.5
+		curve = append(curve, PacePoint{Distance: d, Time: time, Speed: d / time})
+	}
+	cs, err := FitCriticalSpeed(curve, DefaultMinFitDuration, DefaultMaxFitDuration)
+	if err != nil {
+		t.Fatalf("FitCriticalSpeed() error = %v", err)
+	}
+	// the 10k takes longer than 20 minutes, so it is not fit
+	if cs.CS != 4.5 || cs.DPrime != 200 {
+		t.Errorf("FitCriticalSpeed() = %+v, want CS 4.5, D' 200", cs)
+	}
+	if !IsRun("TrailRun") || IsRun("Ride") {
+		t.Errorf("IsRun() = %v for TrailRun and %v for Ride, want only runs", IsRun("TrailRun"), IsRun("Ride"))
+	}
+}
+
+func TestSources(t *testing.T) {
+	run := swagger.RUN_SportType
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	bundles := []api.ActivityBundle{
+		{ActivityID: 1, Activity: &swagger.DetailedActivity{StartDate: start, SportType: &run}, Streams: steady(60, 0, 0, 4)},
+		{ActivityID: 2, Err: api.NotFoundError},
+	}
+	activities := FromBundles(bundles)
+	if len(activities) != 1 || activities[0].SportType != "Run" || !activities[0].StartDate.Equal(start) {
+		t.Errorf("FromBundles() = %+v", activities)
+	}
+
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for i, id := range []int64{10, 11} {
+		err = db.PutStravaSummaryActivity(1, swagger.SummaryActivity{Id: id, StartDate: start.AddDate(0, 0, i), SportType: &run})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// only the first activity has streams
+	err = db.PutStravaStreams(1, 10, steady(120, 0, 0, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewCurveBuilder(nil, nil)
+	err = FromStore(db, store.ActivityQuery{}, func(a ActivityStreams) error {
+		b.Add(a)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FromStore() error = %v", err)
+	}
+	pace := b.PaceCurve()
+	if len(pace) != 1 || pace[0].ActivityID != 10 || pace[0].Time != 100 {
+		t.Errorf("PaceCurve() = %+v, want 400m in 100s from activity 10", pace)
+	}
+
+	// streams under an id that can't be an activity id are reported, not passed on as activity 0
+	db.Put(store.Record{Source: "other", Kind: store.KindActivity, ID: "abc", StartDate: start, Data: []byte(`{}`)})
+	db.Put(store.Record{Source: "other", Kind: store.KindStreams, ID: "abc", Data: []byte(`{}`)})
+	err = FromStore(db, store.ActivityQuery{}, func(a ActivityStreams) error { return nil })
+	if err == nil {
+		t.Errorf("FromStore() expected an error for a non-numeric id")
+	}
+}
@@ -0,0 +1,51 @@
+package analysis
+
+// the durations (in seconds) that best efforts are computed for: 5s, 15s, 30s, 1, 5, 10, 20, 30 and 60 minutes
+var StandardDurations = []int{5, 15, 30, 60, 300, 600, 1200, 1800, 3600}
+
+// An Effort is the highest average of a series over a duration
+type Effort struct {
+	// seconds of moving time
+	Duration int
+	Value    float64
+	// when the effort started, in seconds from the start of the activity
+	Start int32
+}
+
+// The best effort over each duration of a series (one value per second of moving time).
+//
+// `offsets` is the offset of each second from the start of the activity. An effort never spans a gap in the offsets (a stop or a pause),
+// so it is always a continuous stretch of the activity. Durations longer than every continuous stretch are left out.
+func BestEfforts(series []float64, offsets []int32, durations []int) []Effort {
+	efforts := []Effort{}
+	for _, d := range durations {
+		if d <= 0 || d > len(series) {
+			continue
+		}
+		var sum float64
+		best, bestStart := -1.0, 0
+		// where the current continuous stretch started
+		stretch := 0
+		for i, v := range series {
+			if i > 0 && i < len(offsets) && offsets[i]-offsets[i-1] != 1 {
+				stretch, sum = i, 0
+			}
+			sum += v
+			if i-stretch >= d {
+				sum -= series[i-d]
+			}
+			if i-stretch >= d-1 && sum > best {
+				best, bestStart = sum, i-d+1
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		effort := Effort{Duration: d, Value: round(best/float64(d), 3)}
+		if bestStart < len(offsets) {
+			effort.Start = offsets[bestStart]
+		}
+		efforts = append(efforts, effort)
+	}
+	return efforts
+}
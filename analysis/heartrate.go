@@ -0,0 +1,45 @@
+package analysis
+
+import (
+	"math"
+)
+
+// the weighting of banister's TRIMP
+func trimpWeight(hrr float64, female bool) float64 {
+	k := 1.92
+	if female {
+		k = 1.67
+	}
+	return 0.64 * math.Exp(k*hrr)
+}
+
+// the fraction of heart rate reserve, clamped to [0, 1]
+func heartRateReserve(hr, resting, max float64) float64 {
+	return math.Max(0, math.Min(1, (hr-resting)/(max-resting)))
+}
+
+// Banister's training impulse of a series of heart rates (one per second of moving time)
+func TRIMP(hr []float64, resting, max float64, female bool) float64 {
+	if max <= resting {
+		return 0
+	}
+	var total float64
+	for _, v := range hr {
+		hrr := heartRateReserve(v, resting, max)
+		total += hrr * trimpWeight(hrr, female) / 60
+	}
+	return total
+}
+
+// A TRIMP as a training stress score: 100 is the TRIMP of an hour at threshold heart rate
+func HRTSS(trimp float64, resting, max, threshold float64, female bool) float64 {
+	if max <= resting {
+		return 0
+	}
+	hrr := heartRateReserve(threshold, resting, max)
+	hour := 60 * hrr * trimpWeight(hrr, female)
+	if hour == 0 {
+		return 0
+	}
+	return trimp / hour * 100
+}
@@ -0,0 +1,170 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// the default time constants (in days) of the chronic and acute training load
+const (
+	DefaultChronicDays = 42
+	DefaultAcuteDays   = 7
+)
+
+// the intensity factor assumed for activities that have no power or heart rate to compute a load from
+const estimatedIntensity = 0.7
+
+// How a load was computed
+type LoadMethod string
+
+const (
+	LoadPower     LoadMethod = "tss"      // from power and ftp
+	LoadHeartRate LoadMethod = "hrtss"    // from heart rate and thresholds
+	LoadDuration  LoadMethod = "duration" // estimated from the duration alone
+)
+
+// A LoadEntry is the training load of one activity
+type LoadEntry struct {
+	// the platform the activity came from (e.g. "strava")
+	Source string
+	ID     string
+	// the day the activity was done, in the athlete's timezone (at midnight UTC, so that it can be compared without a location)
+	Day    time.Time
+	Load   float64
+	Method LoadMethod
+}
+
+// LoadOptions configure the training load model. Zero values use the defaults.
+type LoadOptions struct {
+	// the time constant of the chronic training load (fitness). (default 42)
+	ChronicDays float64
+	// the time constant of the acute training load (fatigue). (default 7)
+	AcuteDays float64
+}
+
+// A LoadDay is the state of the training load model at the end of a day
+type LoadDay struct {
+	Date time.Time
+	// the sum of the loads of the day's activities
+	Load float64
+	// chronic training load (fitness)
+	CTL float64
+	// acute training load (fatigue)
+	ATL float64
+	// training stress balance (form): yesterday's CTL - yesterday's ATL, so it is the form going into the day
+	TSB float64
+	// the change in CTL over the last 7 days
+	RampRate float64
+	// acute:chronic workload ratio (ATL / CTL). 0 while there is no chronic load
+	ACWR float64
+}
+
+// midnight UTC of a date
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// The day an activity was done in the athlete's timezone.
+//
+// `timezone` is strava's format (e.g. "(GMT-08:00) America/Los_Angeles") or an IANA name.
+// If it cannot be loaded, `local` (strava's start date local, which is the local time labeled as UTC) is used if set, otherwise the UTC day of `start`.
+func LocalDay(start time.Time, local time.Time, timezone string) time.Time {
+	name := timezone
+	if i := strings.LastIndex(name, ") "); i != -1 {
+		name = name[i+2:]
+	}
+	if name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return day(start.In(loc))
+		}
+	}
+	if !local.IsZero() {
+		return day(local)
+	}
+	return day(start.UTC())
+}
+
+// The load of `seconds` at an assumed moderate intensity
+func estimateLoad(seconds float64) float64 {
+	return seconds / 3600 * estimatedIntensity * estimatedIntensity * 100
+}
+
+// Run the training load model over every day in [from, to) and return the days.
+//
+// Entries before `from` warm up the model (so start it well before `from`, ideally by a few chronic time constants). Days with no entries are rest days with a load of 0.
+func LoadTimeline(entries []LoadEntry, from, to time.Time, opts LoadOptions) []LoadDay {
+	chronic, acute := opts.ChronicDays, opts.AcuteDays
+	if chronic <= 0 {
+		chronic = DefaultChronicDays
+	}
+	if acute <= 0 {
+		acute = DefaultAcuteDays
+	}
+	from, to = day(from), day(to)
+	loads := map[time.Time]float64{}
+	start := from
+	for _, e := range entries {
+		d := day(e.Day)
+		loads[d] += e.Load
+		if d.Before(start) {
+			start = d
+		}
+	}
+	days := []LoadDay{}
+	var ctl, atl float64
+	// the ctl at the end of today and each of the 7 days before, for the ramp rate
+	history := []float64{}
+	for d := start; d.Before(to); d = d.AddDate(0, 0, 1) {
+		load := loads[d]
+		tsb := ctl - atl
+		ctl += (load - ctl) / chronic
+		atl += (load - atl) / acute
+		history = append(history, ctl)
+		// before the model has run for a week, the ctl a week ago was 0
+		ramp := ctl
+		if len(history) > 8 {
+			history = history[1:]
+		}
+		if len(history) == 8 {
+			ramp = ctl - history[0]
+		}
+		if d.Before(from) {
+			continue
+		}
+		ld := LoadDay{Date: d, Load: round(load, 1), CTL: round(ctl, 1), ATL: round(atl, 1), TSB: round(tsb, 1), RampRate: round(ramp, 1)}
+		if ctl > 0 {
+			ld.ACWR = round(atl/ctl, 2)
+		}
+		days = append(days, ld)
+	}
+	return days
+}
+
+// sort entries by day, then source and id
+func sortEntries(entries []LoadEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.ID < b.ID
+	})
+}
+
+// the load from power, heart rate or duration, whichever is possible first
+func activityLoad(seconds float64, normalizedPower float64, averageHR float64, athlete Athlete) (float64, LoadMethod) {
+	if normalizedPower > 0 && athlete.FTP > 0 {
+		return TSS(int(seconds), normalizedPower, athlete.FTP), LoadPower
+	}
+	if averageHR > 0 && athlete.MaxHeartRate > athlete.RestingHeartRate && athlete.RestingHeartRate > 0 && athlete.ThresholdHeartRate > athlete.RestingHeartRate {
+		// the trimp of holding the average heart rate for the whole activity
+		hrr := heartRateReserve(averageHR, athlete.RestingHeartRate, athlete.MaxHeartRate)
+		trimp := seconds / 60 * hrr * trimpWeight(hrr, athlete.Female)
+		return HRTSS(trimp, athlete.RestingHeartRate, athlete.MaxHeartRate, athlete.ThresholdHeartRate, athlete.Female), LoadHeartRate
+	}
+	return estimateLoad(seconds), LoadDuration
+}
@@ -0,0 +1,173 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestLocalDay(t *testing.T) {
+	// 8pm in los angeles is the next day in UTC
+	start := time.Date(2024, 5, 2, 3, 0, 0, 0, time.UTC)
+	local := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		local    time.Time
+		timezone string
+		want     string
+	}{
+		{"strava timezone", time.Time{}, "(GMT-08:00) America/Los_Angeles", "2024-05-01"},
+		{"iana name", time.Time{}, "Asia/Tokyo", "2024-05-02"},
+		{"unknown timezone, local start", local, "(GMT-08:00) Nowhere/Special", "2024-05-01"},
+		{"nothing", time.Time{}, "", "2024-05-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LocalDay(start, tt.local, tt.timezone); !got.Equal(date(tt.want)) {
+				t.Errorf("LocalDay() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTimeline(t *testing.T) {
+	entries := []LoadEntry{
+		// before the range, so it only warms up the model
+		{Day: date("2024-04-30"), Load: 100},
+		{Day: date("2024-05-02"), Load: 60},
+		{Day: date("2024-05-02"), Load: 40},
+	}
+	days := LoadTimeline(entries, date("2024-05-01"), date("2024-05-04"), LoadOptions{ChronicDays: 42, AcuteDays: 7})
+	want := []LoadDay{
+		// a rest day after the warm up load of 100
+		{Date: date("2024-05-01"), Load: 0, CTL: 2.3, ATL: 12.2, TSB: -11.9, RampRate: 2.3, ACWR: 5.27},
+		// two activities on the same day add up
+		{Date: date("2024-05-02"), Load: 100, CTL: 4.6, ATL: 24.8, TSB: -9.9, RampRate: 4.6, ACWR: 5.33},
+		{Date: date("2024-05-03"), Load: 0, CTL: 4.5, ATL: 21.2, TSB: -20.1, RampRate: 4.5, ACWR: 4.68},
+	}
+	if len(days) != len(want) {
+		t.Fatalf("LoadTimeline() = %+v", days)
+	}
+	for i, w := range want {
+		if days[i] != w {
+			t.Errorf("day %d = %+v, want %+v", i, days[i], w)
+		}
+	}
+
+	// a steady load converges on that load, with a balanced form
+	entries = []LoadEntry{}
+	for d := date("2024-01-01"); d.Before(date("2024-12-31")); d = d.AddDate(0, 0, 1) {
+		entries = append(entries, LoadEntry{Day: d, Load: 50})
+	}
+	days = LoadTimeline(entries, date("2024-12-30"), date("2024-12-31"), LoadOptions{})
+	if len(days) != 1 || days[0].CTL != 50 || days[0].ATL != 50 || days[0].TSB != 0 || days[0].RampRate != 0 || days[0].ACWR != 1 {
+		t.Errorf("LoadTimeline(steady) = %+v", days)
+	}
+
+	// the ramp rate is the change in ctl over the last 7 days
+	entries = []LoadEntry{{Day: date("2024-05-01"), Load: 100}}
+	days = LoadTimeline(entries, date("2024-05-01"), date("2024-05-10"), LoadOptions{ChronicDays: 42, AcuteDays: 7})
+	if days[0].RampRate != 2.4 || days[6].RampRate != 2.1 {
+		t.Errorf("ramp rates in the first week = %v and %v, want 2.4 and 2.1 (the ctl before the load was 0)", days[0].RampRate, days[6].RampRate)
+	}
+	if days[7].RampRate != -0.4 {
+		t.Errorf("ramp rate a week after the load = %v, want -0.4", days[7].RampRate)
+	}
+}
+
+func TestStravaLoad(t *testing.T) {
+	athlete := Athlete{FTP: 250, RestingHeartRate: 50, MaxHeartRate: 190, ThresholdHeartRate: 170}
+	noPower := steady(3600, 0, 170, 3)
+	noPower.Watts = nil
+	tests := []struct {
+		name     string
+		activity swagger.SummaryActivity
+		streams  *swagger.StreamSet
+		load     float64
+		method   LoadMethod
+	}{
+		{"power streams", swagger.SummaryActivity{MovingTime: 3600}, steady(3600, 250, 150, 10), 100, LoadPower},
+		{"heart rate streams", swagger.SummaryActivity{MovingTime: 3600}, noPower, 100, LoadHeartRate},
+		{"weighted average watts", swagger.SummaryActivity{MovingTime: 1800, WeightedAverageWatts: 250}, nil, 50, LoadPower},
+		{"duration", swagger.SummaryActivity{MovingTime: 3600}, nil, 49, LoadDuration},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := StravaLoad(tt.activity, tt.streams, athlete)
+			if entry.Load != tt.load || entry.Method != tt.method || entry.Source != "strava" {
+				t.Errorf("StravaLoad() = %+v, want %v by %s", entry, tt.load, tt.method)
+			}
+		})
+	}
+}
+
+const finalSurgeWorkouts = `{"data": [
+	{"key": "done", "workout_date": "2024-05-01T00:00:00", "has_actual_data": true, "Activities": [{"duration": 3600, "hr_avg": 170}]},
+	{"key": "planned", "workout_date": "2024-05-02T00:00:00", "has_actual_data": false, "Activities": [{"planned_duration": 3600}]}
+]}`
+
+func TestFinalSurgeLoads(t *testing.T) {
+	var workouts app.WorkoutListResponse
+	err := json.Unmarshal([]byte(finalSurgeWorkouts), &workouts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	athlete := Athlete{RestingHeartRate: 50, MaxHeartRate: 190, ThresholdHeartRate: 170}
+	entries, err := FinalSurgeLoads(&workouts, athlete)
+	if err != nil {
+		t.Fatalf("FinalSurgeLoads() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("FinalSurgeLoads() = %+v, want only the completed workout", entries)
+	}
+	e := entries[0]
+	if e.ID != "done" || e.Source != "finalsurge" || !e.Day.Equal(date("2024-05-01")) || e.Load != 100 || e.Method != LoadHeartRate {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestLoadsFromStore(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var workouts app.WorkoutListResponse
+	err = json.Unmarshal([]byte(finalSurgeWorkouts), &workouts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.PutFinalSurgeWorkouts(&workouts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.PutStravaSummaryActivity(1, swagger.SummaryActivity{Id: 7, StartDate: time.Date(2024, 4, 30, 12, 0, 0, 0, time.UTC), MovingTime: 1800})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.SourceSink(store.SourcePolar).Put(context.Background(), 52, swagger.SummaryActivity{Id: 8, StartDate: time.Date(2024, 4, 29, 12, 0, 0, 0, time.UTC), MovingTime: 1800})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := LoadsFromStore(db, store.ActivityQuery{}, Athlete{})
+	if err != nil {
+		t.Fatalf("LoadsFromStore() error = %v", err)
+	}
+	if len(entries) != 3 || entries[0].ID != "8" || entries[0].Load != 24.5 || entries[1].ID != "7" || entries[1].Load != 24.5 || entries[2].ID != "done" || entries[2].Load != 49 {
+		t.Errorf("LoadsFromStore() = %+v", entries)
+	}
+}
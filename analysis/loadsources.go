@@ -0,0 +1,148 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// The load of a strava activity.
+//
+// With streams, the load is the TSS (or hrTSS without power) from `Analyze`. Without them, it comes from the weighted average watts if there are any.
+// Otherwise it is estimated from the moving time.
+func StravaLoad(activity swagger.SummaryActivity, streams *swagger.StreamSet, athlete Athlete) LoadEntry {
+	entry := LoadEntry{
+		Source: string(store.SourceStrava),
+		ID:     fmt.Sprint(activity.Id),
+		Day:    LocalDay(activity.StartDate, activity.StartDateLocal, activity.Timezone),
+	}
+	if streams != nil {
+		m, err := Analyze(streams, athlete)
+		if err == nil {
+			switch {
+			case m.TSS > 0:
+				entry.Load, entry.Method = round(m.TSS, 1), LoadPower
+				return entry
+			case m.HRTSS > 0:
+				entry.Load, entry.Method = round(m.HRTSS, 1), LoadHeartRate
+				return entry
+			}
+		}
+	}
+	load, method := activityLoad(float64(activity.MovingTime), float64(activity.WeightedAverageWatts), 0, athlete)
+	entry.Load, entry.Method = round(load, 1), method
+	return entry
+}
+
+// the parts of a final surge workout that a load is computed from
+type finalSurgeWorkout struct {
+	Key           string `json:"key"`
+	WorkoutDate   string `json:"workout_date"`
+	HasActualData bool   `json:"has_actual_data"`
+	Activities    []struct {
+		Duration      float64 `json:"duration"`
+		TimeMoving    float64 `json:"time_moving"`
+		WeightedPower int     `json:"weighted_power"`
+		HrAvg         int     `json:"hr_avg"`
+	} `json:"Activities"`
+}
+
+// the load of a final surge workout. returns false if the workout has not been done (it is only planned)
+func finalSurgeLoad(data []byte, athlete Athlete) (LoadEntry, bool, error) {
+	var w finalSurgeWorkout
+	err := json.Unmarshal(data, &w)
+	if err != nil {
+		return LoadEntry{}, false, err
+	}
+	if !w.HasActualData {
+		return LoadEntry{}, false, nil
+	}
+	// the workout date is already the athlete's local date
+	d, err := time.Parse("2006-01-02", w.WorkoutDate[:min(len(w.WorkoutDate), 10)])
+	if err != nil {
+		return LoadEntry{}, false, fmt.Errorf("invalid workout date %q: %w", w.WorkoutDate, err)
+	}
+	entry := LoadEntry{Source: string(store.SourceFinalSurge), ID: w.Key, Day: d}
+	// a workout can have several activities (e.g. a brick). their loads add up, and the least accurate method is reported
+	for _, a := range w.Activities {
+		seconds := a.TimeMoving
+		if seconds == 0 {
+			seconds = a.Duration
+		}
+		load, method := activityLoad(seconds, float64(a.WeightedPower), float64(a.HrAvg), athlete)
+		entry.Load += load
+		if entry.Method == "" || method == LoadDuration || (method == LoadHeartRate && entry.Method == LoadPower) {
+			entry.Method = method
+		}
+	}
+	entry.Load = round(entry.Load, 1)
+	if entry.Method == "" {
+		entry.Method = LoadDuration
+	}
+	return entry, true, nil
+}
+
+// The loads of the completed workouts in a final surge workout list. Planned workouts are left out.
+//
+// Each workout's load is computed like a strava activity without streams, from its weighted power, average heart rate or duration.
+func FinalSurgeLoads(workouts *app.WorkoutListResponse, athlete Athlete) ([]LoadEntry, error) {
+	entries := []LoadEntry{}
+	for _, workout := range workouts.Data {
+		data, err := json.Marshal(workout)
+		if err != nil {
+			return nil, err
+		}
+		entry, done, err := finalSurgeLoad(data, athlete)
+		if err != nil {
+			return nil, fmt.Errorf("workout %s: %w", workout.Key, err)
+		}
+		if done {
+			entries = append(entries, entry)
+		}
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// The loads of every activity in the local archive that matches the query, from strava, local files, polar and final surge alike, ordered by day.
+//
+// Activities in strava's shape (strava, local and polar ones) use their streams if they are in the archive.
+func LoadsFromStore(db *store.Store, q store.ActivityQuery, athlete Athlete) ([]LoadEntry, error) {
+	records, err := db.QueryActivities(q)
+	if err != nil {
+		return nil, err
+	}
+	entries := []LoadEntry{}
+	for _, rec := range records {
+		switch rec.Source {
+		case store.SourceStrava, store.SourceLocal, store.SourcePolar:
+			var activity swagger.SummaryActivity
+			err := rec.Decode(&activity)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode activity %s: %w", rec.ID, err)
+			}
+			var streams *swagger.StreamSet
+			if streamsRec, err := db.Latest(rec.Source, store.KindStreams, rec.ID); err == nil {
+				streams = &swagger.StreamSet{}
+				if streamsRec.Decode(streams) != nil {
+					streams = nil
+				}
+			}
+			entries = append(entries, StravaLoad(activity, streams, athlete))
+		case store.SourceFinalSurge:
+			entry, done, err := finalSurgeLoad(rec.Data, athlete)
+			if err != nil {
+				return nil, fmt.Errorf("workout %s: %w", rec.ID, err)
+			}
+			if done {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	sortEntries(entries)
+	return entries, nil
+}
@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"math"
+)
+
+// the rolling window (in seconds) of normalized power
+const normalizedPowerWindow = 30
+
+// The normalized power of a series of watts (one per second of moving time).
+//
+// The 30 second rolling average is raised to the 4th power, averaged and the 4th root taken.
+// Series shorter than the window fall back to the average power.
+func NormalizedPower(power []float64) float64 {
+	if len(power) < normalizedPowerWindow {
+		return mean(power)
+	}
+	var sum, total float64
+	count := 0
+	for i, p := range power {
+		sum += p
+		if i >= normalizedPowerWindow {
+			sum -= power[i-normalizedPowerWindow]
+		}
+		if i >= normalizedPowerWindow-1 {
+			avg := sum / normalizedPowerWindow
+			total += avg * avg * avg * avg
+			count++
+		}
+	}
+	return math.Pow(total/float64(count), 0.25)
+}
+
+// The training stress score of `seconds` at `normalizedPower`. An hour at ftp is 100.
+func TSS(seconds int, normalizedPower float64, ftp float64) float64 {
+	if ftp <= 0 {
+		return 0
+	}
+	intensity := normalizedPower / ftp
+	return float64(seconds) * normalizedPower * intensity / (ftp * 3600) * 100
+}
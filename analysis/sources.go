@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// The streams of the bundles from `api.FetchActivitiesDetailed`. Bundles that failed or have no streams are left out.
+//
+// The start date and sport are only set if the bundles were fetched with `Detail`.
+func FromBundles(bundles []api.ActivityBundle) []ActivityStreams {
+	activities := []ActivityStreams{}
+	for _, b := range bundles {
+		if b.Err != nil || b.Streams == nil {
+			continue
+		}
+		a := ActivityStreams{ActivityID: int64(b.ActivityID), Streams: b.Streams}
+		if b.Activity != nil {
+			a.StartDate = b.Activity.StartDate
+			if b.Activity.SportType != nil {
+				a.SportType = string(*b.Activity.SportType)
+			}
+		}
+		activities = append(activities, a)
+	}
+	return activities
+}
+
+// Call `fn` with the streams of every activity in the local archive that matches the query, ordered by start date.
+// Activities without streams in the archive are skipped.
+//
+// Streams are read one activity at a time, so a whole history never needs to be held at once.
+func FromStore(db *store.Store, q store.ActivityQuery, fn func(ActivityStreams) error) error {
+	records, err := db.QueryActivities(q)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		streamsRec, err := db.Latest(rec.Source, store.KindStreams, rec.ID)
+		if errors.Is(err, store.NotFoundError) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		streams := &swagger.StreamSet{}
+		err = streamsRec.Decode(streams)
+		if err != nil {
+			return fmt.Errorf("failed to decode the streams of %s: %w", rec.ID, err)
+		}
+		// only sources in strava's shape have streams, and their ids are numbers
+		id, err := strconv.ParseInt(rec.ID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s activity %s has streams but no numeric id: %w", rec.Source, rec.ID, err)
+		}
+		err = fn(ActivityStreams{ActivityID: id, StartDate: rec.StartDate, SportType: rec.SportType, Streams: streams})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
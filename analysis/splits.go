@@ -0,0 +1,302 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/plan"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// split distances, in meters
+const (
+	Kilometer = 1000.0
+	Mile      = 1609.344
+)
+
+// A Segment is part of an activity, recomputed from its streams.
+//
+// It embeds strava's lap, so `Segment.Lap` (or `Laps`) can be used anywhere a lap is expected. The lap's start index
+// is the first sample of the segment and its end index is the first sample of the next segment, so consecutive segments share a sample and no time is lost between them.
+type Segment struct {
+	swagger.Lap
+	// set by `Splitter.ByClimbs`
+	Climb bool
+	// beats per minute, over moving time
+	AverageHeartrate float64
+	MaxHeartrate     int32
+	// watts, over moving time
+	AverageWatts float64
+	MaxWatts     int32
+	MaxCadence   int32
+	// meters
+	TotalElevationLoss float32
+	// seconds per kilometer of moving time. 0 if the segment has no distance
+	Pace float64
+}
+
+// A planned interval (e.g. from a structured workout). The interval ends after `Duration` seconds or `Distance` meters, whichever is set.
+type Interval struct {
+	Name     string
+	Duration int
+	Distance float64
+}
+
+// The intervals of a planned workout (see `Splitter.ByIntervals`), in the order they are done with repeats expanded.
+//
+// Steps without a name are named after their intensity (e.g. "warmup").
+// An open step (one that ends when the athlete presses lap) can't be placed from the streams, so the intervals stop before the first one; split by laps instead.
+func PlanIntervals(w plan.PlannedWorkout) []Interval {
+	intervals := []Interval{}
+	for _, step := range w.Flatten() {
+		interval := Interval{Name: step.Name}
+		if interval.Name == "" {
+			interval.Name = string(step.Intensity)
+		}
+		switch step.Duration {
+		case plan.DurationTime:
+			interval.Duration = int(math.Round(step.Value))
+		case plan.DurationDistance:
+			interval.Distance = step.Value
+		default:
+			return intervals
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
+
+// A Splitter splits the streams of an activity into segments
+type Splitter struct {
+	streams *swagger.StreamSet
+	start   time.Time
+	// the moving seconds of each sample (see `timeline`)
+	weights []float64
+	speeds  []float64
+}
+
+// Create a splitter for the streams of an activity that started at `start`. The streams need a time stream.
+func NewSplitter(streams *swagger.StreamSet, start time.Time) (*Splitter, error) {
+	if streams == nil || streams.Time == nil || len(streams.Time.Data) == 0 {
+		return nil, NoTimeStreamError
+	}
+	s := &Splitter{streams: streams, start: start, speeds: speeds(streams)}
+	s.weights = make([]float64, len(streams.Time.Data))
+	for _, i := range newTimeline(streams).index {
+		s.weights[i]++
+	}
+	return s, nil
+}
+
+func (s *Splitter) last() int {
+	return len(s.streams.Time.Data) - 1
+}
+
+// Split every `meters` (e.g. `Kilometer` or `Mile`). The last segment is whatever is left over. Needs a distance stream.
+func (s *Splitter) ByDistance(meters float64) []Segment {
+	d := s.streams.Distance
+	if d == nil || len(d.Data) == 0 || meters <= 0 {
+		return s.ByIndexes(nil, nil)
+	}
+	boundaries := []int{0}
+	next := float64(d.Data[0]) + meters
+	for i, v := range d.Data {
+		if float64(v) >= next {
+			boundaries = append(boundaries, i)
+			for float64(v) >= next {
+				next += meters
+			}
+		}
+	}
+	return s.ByIndexes(boundaries, nil)
+}
+
+// Split at the laps of an activity (e.g. from strava or a fit or tcx file), recomputing each lap from the streams.
+//
+// Laps are matched to the streams by their start index.
+func (s *Splitter) ByLaps(laps []swagger.Lap) []Segment {
+	boundaries := []int{}
+	names := []string{}
+	for _, lap := range laps {
+		boundaries = append(boundaries, int(lap.StartIndex))
+		names = append(names, lap.Name)
+	}
+	return s.ByIndexes(boundaries, names)
+}
+
+// Split into planned intervals, one after the other from the start of the activity.
+// If the activity is longer than the intervals, what is left over is a final segment.
+//
+// A distance interval needs a distance stream. Without one, the intervals stop before it and the rest of the activity is what is left over.
+func (s *Splitter) ByIntervals(intervals []Interval) []Segment {
+	t := s.streams.Time.Data
+	var d []float32
+	if s.streams.Distance != nil && len(s.streams.Distance.Data) == len(t) {
+		d = s.streams.Distance.Data
+	}
+	boundaries := []int{0}
+	names := []string{}
+	i := 0
+	for _, interval := range intervals {
+		if interval.Duration <= 0 && (interval.Distance <= 0 || d == nil) {
+			break
+		}
+		start := i
+		for i < s.last() {
+			if interval.Duration > 0 && int(t[i]-t[start]) >= interval.Duration {
+				break
+			}
+			if interval.Distance > 0 && d != nil && float64(d[i]-d[start]) >= interval.Distance {
+				break
+			}
+			i++
+		}
+		names = append(names, interval.Name)
+		if i >= s.last() {
+			break
+		}
+		boundaries = append(boundaries, i)
+	}
+	return s.ByIndexes(boundaries, names)
+}
+
+// Split into climbs and the parts between them. Needs an altitude stream.
+//
+// A climb gains at least `minGain` meters. Dips of up to `tolerance` meters (e.g. altitude noise, or a short descent) do not end a climb.
+func (s *Splitter) ByClimbs(minGain float64, tolerance float64) []Segment {
+	if s.streams.Altitude == nil {
+		return s.ByIndexes(nil, nil)
+	}
+	alt := s.streams.Altitude.Data
+	climbs := [][2]int{}
+	start, peak := 0, 0
+	for i := 1; i < len(alt); i++ {
+		if alt[i] > alt[peak] {
+			peak = i
+		}
+		if float64(alt[peak]-alt[i]) > tolerance || i == len(alt)-1 {
+			if float64(alt[peak]-alt[start]) >= minGain {
+				climbs = append(climbs, [2]int{start, peak})
+			}
+			start, peak = i, i
+		}
+		// a climb starts at the last of its lowest points
+		if alt[i] < alt[start] || (alt[i] == alt[start] && peak == start) {
+			start, peak = i, i
+		}
+	}
+	boundaries := []int{0}
+	isClimb := map[int]bool{}
+	for _, c := range climbs {
+		if c[0] != boundaries[len(boundaries)-1] {
+			boundaries = append(boundaries, c[0])
+		}
+		isClimb[len(boundaries)-1] = true
+		if c[1] < s.last() {
+			boundaries = append(boundaries, c[1])
+		}
+	}
+	segments := s.ByIndexes(boundaries, nil)
+	climbCount := 0
+	for i := range segments {
+		segments[i].Climb = isClimb[i]
+		if segments[i].Climb {
+			climbCount++
+			segments[i].Name = fmt.Sprintf("Climb %d", climbCount)
+		}
+	}
+	return segments
+}
+
+// Split at the samples in `boundaries` (each is the first sample of a segment). The first segment always starts at 0 and the last ends at the last sample.
+//
+// `names` names the segments in order. Segments without a name are called "Split n".
+func (s *Splitter) ByIndexes(boundaries []int, names []string) []Segment {
+	clean := []int{0}
+	for _, b := range boundaries {
+		if b > clean[len(clean)-1] && b < s.last() {
+			clean = append(clean, b)
+		}
+	}
+	clean = append(clean, s.last())
+	segments := []Segment{}
+	for k := 0; k < len(clean)-1; k++ {
+		seg := s.aggregate(clean[k], clean[k+1])
+		seg.LapIndex = int32(k + 1)
+		seg.Split = int32(k + 1)
+		seg.Name = fmt.Sprintf("Split %d", k+1)
+		if k < len(names) && names[k] != "" {
+			seg.Name = names[k]
+		}
+		segments = append(segments, seg)
+	}
+	return segments
+}
+
+// the aggregates of samples [start, end]. each sample lasts until the next, so the samples that are counted are [start, end)
+func (s *Splitter) aggregate(start, end int) Segment {
+	streams := s.streams
+	t := streams.Time.Data
+	seg := Segment{}
+	seg.StartIndex, seg.EndIndex = int32(start), int32(end)
+	seg.StartDate = s.start.Add(time.Duration(t[start]) * time.Second)
+	seg.ElapsedTime = t[end] - t[start]
+	var moving float64
+	var hr, watts, cadence float64
+	for i := start; i < end; i++ {
+		w := s.weights[i]
+		moving += w
+		if streams.Heartrate != nil && w > 0 && i < len(streams.Heartrate.Data) {
+			hr += float64(streams.Heartrate.Data[i]) * w
+			seg.MaxHeartrate = max(seg.MaxHeartrate, streams.Heartrate.Data[i])
+		}
+		if streams.Watts != nil && w > 0 && i < len(streams.Watts.Data) {
+			watts += float64(streams.Watts.Data[i]) * w
+			seg.MaxWatts = max(seg.MaxWatts, streams.Watts.Data[i])
+		}
+		if streams.Cadence != nil && w > 0 && i < len(streams.Cadence.Data) {
+			cadence += float64(streams.Cadence.Data[i]) * w
+			seg.MaxCadence = max(seg.MaxCadence, streams.Cadence.Data[i])
+		}
+		if s.speeds != nil && w > 0 && i < len(s.speeds) {
+			seg.MaxSpeed = max(seg.MaxSpeed, float32(s.speeds[i]))
+		}
+		if streams.Altitude != nil && i+1 < len(streams.Altitude.Data) {
+			diff := streams.Altitude.Data[i+1] - streams.Altitude.Data[i]
+			if diff > 0 {
+				seg.TotalElevationGain += diff
+			} else {
+				seg.TotalElevationLoss -= diff
+			}
+		}
+	}
+	seg.MovingTime = int32(moving)
+	if moving > 0 {
+		seg.AverageHeartrate = round(hr/moving, 1)
+		seg.AverageWatts = round(watts/moving, 1)
+		seg.AverageCadence = float32(round(cadence/moving, 1))
+	}
+	switch {
+	case streams.Distance != nil && end < len(streams.Distance.Data):
+		seg.Distance = streams.Distance.Data[end] - streams.Distance.Data[start]
+	case s.speeds != nil:
+		for i := start; i < end && i < len(s.speeds); i++ {
+			seg.Distance += float32(s.speeds[i] * s.weights[i])
+		}
+	}
+	if moving > 0 && seg.Distance > 0 {
+		seg.AverageSpeed = float32(float64(seg.Distance) / moving)
+		seg.Pace = math.Round(moving / float64(seg.Distance) * 1000)
+	}
+	return seg
+}
+
+// The laps of segments
+func Laps(segments []Segment) []swagger.Lap {
+	laps := make([]swagger.Lap, 0, len(segments))
+	for _, seg := range segments {
+		laps = append(laps, seg.Lap)
+	}
+	return laps
+}
@@ -0,0 +1,185 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/plan"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+func TestSplitter_ByDistance(t *testing.T) {
+	// 2500m at 5m/s
+	s, err := NewSplitter(steady(500, 200, 150, 5), time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments := s.ByDistance(Kilometer)
+	want := []struct {
+		start, end int32
+		distance   float32
+		moving     int32
+	}{
+		{0, 200, 1000, 200},
+		{200, 400, 1000, 200},
+		{400, 500, 500, 100},
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("ByDistance() = %d segments, want %d", len(segments), len(want))
+	}
+	for i, w := range want {
+		seg := segments[i]
+		if seg.StartIndex != w.start || seg.EndIndex != w.end || seg.Distance != w.distance || seg.MovingTime != w.moving {
+			t.Errorf("segment %d = %+v, want %+v", i, seg.Lap, w)
+		}
+		if seg.AverageSpeed != 5 || seg.Pace != 200 || seg.AverageWatts != 200 || seg.AverageHeartrate != 150 || seg.LapIndex != int32(i+1) {
+			t.Errorf("segment %d aggregates = %+v", i, seg)
+		}
+	}
+	if got := segments[1].StartDate; !got.Equal(time.Date(2024, 5, 1, 8, 3, 20, 0, time.UTC)) {
+		t.Errorf("segment 1 start = %v", got)
+	}
+	if laps := Laps(segments); len(laps) != 3 || laps[2].Name != "Split 3" {
+		t.Errorf("Laps() = %+v", laps)
+	}
+}
+
+func TestSplitter_Stops(t *testing.T) {
+	// a minute stopped in the middle of the first kilometer
+	streams := steady(400, 200, 150, 5)
+	streams.Moving = &swagger.MovingStream{}
+	for i := range streams.Time.Data {
+		streams.Moving.Data = append(streams.Moving.Data, true)
+		if i >= 100 {
+			streams.Time.Data[i] += 60
+		}
+	}
+	streams.Moving.Data[99] = false
+	for i := 100; i < len(streams.Distance.Data); i++ {
+		streams.Distance.Data[i] -= 5
+	}
+	s, _ := NewSplitter(streams, time.Time{})
+	segments := s.ByDistance(Kilometer)
+	if len(segments) != 2 {
+		t.Fatalf("ByDistance() = %+v", segments)
+	}
+	first := segments[0]
+	if first.ElapsedTime != 261 || first.MovingTime != 200 || first.Pace != 200 {
+		t.Errorf("first kilometer = elapsed %d, moving %d, pace %v", first.ElapsedTime, first.MovingTime, first.Pace)
+	}
+}
+
+func TestSplitter_ByClimbs(t *testing.T) {
+	// flat, a 50m climb with a 3m dip in it, a descent, then a 5m bump
+	alt := []float32{}
+	for i := 0; i < 100; i++ {
+		alt = append(alt, 100)
+	}
+	for i := 1; i <= 50; i++ {
+		alt = append(alt, 100+float32(i))
+		if i == 20 {
+			alt = append(alt, 117, 118, 119)
+		}
+	}
+	for i := 1; i <= 40; i++ {
+		alt = append(alt, 150-float32(i))
+	}
+	alt = append(alt, 112, 115, 113, 110)
+	streams := steady(len(alt)-1, 250, 160, 4)
+	streams.Altitude = &swagger.AltitudeStream{Data: alt}
+
+	s, _ := NewSplitter(streams, time.Time{})
+	segments := s.ByClimbs(20, 10)
+	if len(segments) != 3 {
+		t.Fatalf("ByClimbs() = %d segments, want 3", len(segments))
+	}
+	climb := segments[1]
+	if segments[0].Climb || !climb.Climb || segments[2].Climb {
+		t.Errorf("climbs = %v %v %v", segments[0].Climb, climb.Climb, segments[2].Climb)
+	}
+	if climb.StartIndex != 99 || climb.EndIndex != 152 || climb.TotalElevationGain != 53 || climb.TotalElevationLoss != 3 || climb.Name != "Climb 1" {
+		t.Errorf("climb = %+v, loss %v", climb.Lap, climb.TotalElevationLoss)
+	}
+	if last := segments[2]; last.TotalElevationGain != 5 || last.TotalElevationLoss != 45 {
+		t.Errorf("after the climb = gain %v, loss %v", last.TotalElevationGain, last.TotalElevationLoss)
+	}
+}
+
+func TestSplitter_ByLapsAndIntervals(t *testing.T) {
+	// 10 minutes easy, 5 minutes hard, then easy
+	streams := steady(1200, 150, 130, 3)
+	for i := 600; i < 900; i++ {
+		streams.Watts.Data[i] = 300
+		streams.Heartrate.Data[i] = 170
+	}
+	s, _ := NewSplitter(streams, time.Time{})
+
+	laps := s.ByLaps([]swagger.Lap{{Name: "warm up", StartIndex: 0}, {Name: "effort", StartIndex: 600}, {Name: "cool down", StartIndex: 900}})
+	intervals := s.ByIntervals([]Interval{{Name: "warm up", Duration: 600}, {Name: "effort", Distance: 900}, {Name: "cool down", Duration: 3600}})
+	for name, segments := range map[string][]Segment{"ByLaps": laps, "ByIntervals": intervals} {
+		if len(segments) != 3 {
+			t.Fatalf("%s() = %d segments, want 3", name, len(segments))
+		}
+		effort := segments[1]
+		if effort.Name != "effort" || effort.StartIndex != 600 || effort.EndIndex != 900 || effort.AverageWatts != 300 || effort.MaxHeartrate != 170 || effort.Distance != 900 {
+			t.Errorf("%s() effort = %+v", name, effort)
+		}
+		if segments[2].ElapsedTime != 300 || segments[2].AverageWatts != 150 {
+			t.Errorf("%s() cool down = %+v", name, segments[2])
+		}
+	}
+}
+
+func TestPlanIntervals(t *testing.T) {
+	w := plan.PlannedWorkout{Steps: []plan.Step{
+		{Intensity: plan.Warmup, Duration: plan.DurationTime, Value: 600},
+		{Repeat: 2, Steps: []plan.Step{
+			{Name: "effort", Intensity: plan.Active, Duration: plan.DurationDistance, Value: 450},
+			{Intensity: plan.Rest, Duration: plan.DurationTime, Value: 60},
+		}},
+		{Intensity: plan.Cooldown, Duration: plan.DurationOpen},
+		{Intensity: plan.Active, Duration: plan.DurationTime, Value: 60},
+	}}
+	got := PlanIntervals(w)
+	want := []Interval{
+		{Name: "warmup", Duration: 600},
+		{Name: "effort", Distance: 450},
+		{Name: "rest", Duration: 60},
+		{Name: "effort", Distance: 450},
+		{Name: "rest", Duration: 60},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("PlanIntervals() = %+v, want %+v (up to the open cool down)", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("interval %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	s, _ := NewSplitter(steady(1200, 150, 130, 3), time.Time{})
+	segments := s.ByIntervals(got)
+	if len(segments) != 6 || segments[1].Name != "effort" || segments[1].StartIndex != 600 || segments[1].EndIndex != 750 || segments[2].ElapsedTime != 60 {
+		t.Errorf("ByIntervals(PlanIntervals()) = %+v", segments)
+	}
+}
+
+func TestSplitter_NoDistance(t *testing.T) {
+	streams := steady(1200, 150, 130, 3)
+	streams.Distance = &swagger.DistanceStream{}
+	s, _ := NewSplitter(streams, time.Time{})
+	if segments := s.ByDistance(Kilometer); len(segments) != 1 || segments[0].EndIndex != 1200 {
+		t.Errorf("ByDistance() with an empty distance stream = %+v, want the whole activity", segments)
+	}
+	streams.Distance = nil
+	segments := s.ByIntervals([]Interval{{Name: "warm up", Duration: 600}, {Name: "effort", Distance: 900}, {Name: "cool down", Duration: 300}})
+	if len(segments) != 2 || segments[0].Name != "warm up" || segments[1].StartIndex != 600 || segments[1].EndIndex != 1200 || segments[1].Name != "Split 2" {
+		t.Errorf("ByIntervals() without a distance stream = %+v, want the warm up and the rest", segments)
+	}
+}
+
+func TestNewSplitter_NoTime(t *testing.T) {
+	if _, err := NewSplitter(&swagger.StreamSet{}, time.Time{}); err != NoTimeStreamError {
+		t.Errorf("NewSplitter() error = %v, want %v", err, NoTimeStreamError)
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jcocozza/cassidy-connector/analysis"
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/spf13/cobra"
+)
+
+var perActivity bool
+
+// the output of `cassidy curve`
+type curveOutput struct {
+	Power         []analysis.PowerPoint   `json:"power"`
+	Pace          []analysis.PacePoint    `json:"pace"`
+	CriticalPower *analysis.CriticalPower `json:"critical_power,omitempty"`
+	CriticalSpeed *analysis.CriticalSpeed `json:"critical_speed,omitempty"`
+	// by activity id, with --per-activity
+	Activities map[int64]activityCurves `json:"activities,omitempty"`
+}
+
+type activityCurves struct {
+	Power []analysis.PowerPoint `json:"power"`
+	Pace  []analysis.PacePoint  `json:"pace"`
+}
+
+var curveCmd = &cobra.Command{
+	Use:   "curve",
+	Short: "power and pace curves from the local activity archive",
+	Long: `power and pace curves from the local activity archive
+
+Builds the mean-maximal power curve and the best pace for standard distances over every activity (with streams) in the archive that matches the filters.
+Each best records the activity it came from. Critical power/W' and critical speed/D' are fit to the 2-20 minute bests.
+Critical speed is only fit to the pace of runs, whatever --sport is.
+
+Streams are put in the archive by 'cassidy cassidy-strava import-archive --db'.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := archivePath()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		q, err := activityQuery()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		db, err := store.Open(path)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		defer db.Close()
+		out := curveOutput{}
+		if perActivity {
+			out.Activities = map[int64]activityCurves{}
+		}
+		b := analysis.NewCurveBuilder(nil, nil)
+		// critical speed is a running model
+		runs := analysis.NewCurveBuilder(nil, nil)
+		err = analysis.FromStore(db, q, func(a analysis.ActivityStreams) error {
+			power, pace := b.Add(a)
+			if analysis.IsRun(a.SportType) {
+				runs.Add(a)
+			}
+			if perActivity {
+				out.Activities[a.ActivityID] = activityCurves{Power: power, Pace: pace}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		out.Power, out.Pace = b.PowerCurve(), b.PaceCurve()
+		// not enough data is not an error; the model is just left out
+		out.CriticalPower, _ = analysis.FitCriticalPower(out.Power, analysis.DefaultMinFitDuration, analysis.DefaultMaxFitDuration)
+		out.CriticalSpeed, _ = analysis.FitCriticalSpeed(runs.PaceCurve(), analysis.DefaultMinFitDuration, analysis.DefaultMaxFitDuration)
+		outBytes, err := json.Marshal(out)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(string(outBytes))
+	},
+}
+
+func init() {
+	curveCmd.Flags().StringVar(&dbPath, "db", "", fmt.Sprintf("the path to the local archive. (default is $HOME/%s)", defaultDB))
+	curveCmd.Flags().StringVar(&from, "from", "", fmt.Sprintf("only include activities that start on or after this date. Must be of the format: %s", dateLayoutFormat))
+	curveCmd.Flags().StringVar(&to, "to", "", fmt.Sprintf("only include activities that start before this date. Must be of the format: %s", dateLayoutFormat))
+	curveCmd.Flags().StringVar(&sport, "sport", "", "only include activities of this sport (e.g. Run)")
+	curveCmd.Flags().BoolVar(&perActivity, "per-activity", false, "also output the curves of each activity")
+	rootCmd.AddCommand(curveCmd)
+}
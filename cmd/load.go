@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/analysis"
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+var loadAthlete analysis.Athlete
+var loadOpts analysis.LoadOptions
+var loadCSV bool
+var loadOutput string
+
+// the timeline as csv, one row per day
+func loadTimelineCSV(days []analysis.LoadDay) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"date", "load", "ctl", "atl", "tsb", "ramp_rate", "acwr"})
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, d := range days {
+		w.Write([]string{d.Date.Format(dateLayout), f(d.Load), f(d.CTL), f(d.ATL), f(d.TSB), f(d.RampRate), f(d.ACWR)})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+var loadCmd = &cobra.Command{
+	Use:   "load",
+	Short: "the daily training load (fitness, fatigue and form) from the local activity archive",
+	Long: `the daily training load (fitness, fatigue and form) from the local activity archive
+
+Every strava activity and completed final surge workout in the archive gets a load: TSS from power (needs --ftp),
+hrTSS from heart rate (needs --threshold-hr, --max-hr and --resting-hr) or an estimate from the duration.
+The loads are fed into an exponentially weighted model of chronic (CTL) and acute (ATL) load, one day at a time, with rest days in between.
+Days are in the athlete's timezone.
+
+Activities from before --from warm up the model, so the first days of the timeline are not computed from nothing.
+(default --from is 90 days ago and default --to is tomorrow)`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := archivePath()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		q, err := activityQuery()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if q.From.IsZero() {
+			q.From = time.Now().AddDate(0, 0, -90)
+		}
+		if q.To.IsZero() {
+			q.To = time.Now().AddDate(0, 0, 1)
+		}
+		timelineFrom := q.From
+		// warm up over 3 chronic time constants, by which point the starting value barely matters
+		chronic := loadOpts.ChronicDays
+		if chronic <= 0 {
+			chronic = analysis.DefaultChronicDays
+		}
+		q.From = q.From.AddDate(0, 0, -int(3*chronic))
+		// the start date is in UTC, so include the day after in case an activity is on the last day in the athlete's timezone
+		q.To = q.To.AddDate(0, 0, 1)
+		db, err := store.Open(path)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		defer db.Close()
+		entries, err := analysis.LoadsFromStore(db, q, loadAthlete)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		days := analysis.LoadTimeline(entries, timelineFrom, q.To.AddDate(0, 0, -1), loadOpts)
+		var out []byte
+		if loadCSV {
+			out, err = loadTimelineCSV(days)
+		} else {
+			out, err = json.Marshal(days)
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if loadOutput != "" {
+			err = utils.WriteOutput(loadOutput, out)
+			if err != nil {
+				fmt.Println(err.Error())
+			}
+			return
+		}
+		fmt.Println(string(out))
+	},
+}
+
+func init() {
+	loadCmd.Flags().StringVar(&dbPath, "db", "", fmt.Sprintf("the path to the local archive. (default is $HOME/%s)", defaultDB))
+	loadCmd.Flags().StringVar(&from, "from", "", fmt.Sprintf("the first day of the timeline. Must be of the format: %s", dateLayoutFormat))
+	loadCmd.Flags().StringVar(&to, "to", "", fmt.Sprintf("the day after the last day of the timeline. Must be of the format: %s", dateLayoutFormat))
+	loadCmd.Flags().StringVar(&sport, "sport", "", "only include activities of this sport (e.g. Run)")
+	loadCmd.Flags().Float64Var(&loadAthlete.FTP, "ftp", 0, "functional threshold power, in watts")
+	loadCmd.Flags().Float64Var(&loadAthlete.ThresholdHeartRate, "threshold-hr", 0, "lactate threshold heart rate")
+	loadCmd.Flags().Float64Var(&loadAthlete.MaxHeartRate, "max-hr", 0, "max heart rate")
+	loadCmd.Flags().Float64Var(&loadAthlete.RestingHeartRate, "resting-hr", 0, "resting heart rate")
+	loadCmd.Flags().BoolVar(&loadAthlete.Female, "female", false, "use the female weighting of heart rate load")
+	loadCmd.Flags().Float64Var(&loadOpts.ChronicDays, "ctl-days", analysis.DefaultChronicDays, "the time constant of the chronic load, in days")
+	loadCmd.Flags().Float64Var(&loadOpts.AcuteDays, "atl-days", analysis.DefaultAcuteDays, "the time constant of the acute load, in days")
+	loadCmd.Flags().BoolVar(&loadCSV, "csv", false, "output csv instead of json")
+	loadCmd.Flags().StringVarP(&loadOutput, "output", "o", "", "write the timeline to this file instead of printing it")
+	rootCmd.AddCommand(loadCmd)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultDB        string = ".cassidy-connector.db"
+	dateLayout       string = "2006-01-02"
+	dateLayoutFormat string = "YYYY-MM-DD"
+)
+
+var dbPath string
+var from string
+var to string
+var sport string
+var gear string
+var source string
+var kind string
+
+// the path of the local archive: --db, or the default in the home directory
+func archivePath() (string, error) {
+	if dbPath != "" {
+		return dbPath, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, defaultDB), nil
+}
+
+// the query of the date range and filter flags
+func activityQuery() (store.ActivityQuery, error) {
+	q := store.ActivityQuery{
+		SportType: sport,
+		GearID:    gear,
+		Source:    store.Source(source),
+		Kind:      store.Kind(kind),
+	}
+	var err error
+	if from != "" {
+		q.From, err = time.Parse(dateLayout, from)
+		if err != nil {
+			return q, err
+		}
+	}
+	if to != "" {
+		q.To, err = time.Parse(dateLayout, to)
+		if err != nil {
+			return q, err
+		}
+	}
+	return q, nil
+}
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "query the local activity archive",
+	Long: fmt.Sprintf(`query the local activity archive
+
+The archive is filled by 'cassidy cassidy-strava sync --db', 'cassidy cassidy-strava webhook launch-server --db' and 'cassidy cassidy-final-surge activities --db'.
+The latest version of each matching activity is printed as json, ordered by start date.
+(default db is $HOME/%s)`, defaultDB),
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := archivePath()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		q, err := activityQuery()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		db, err := store.Open(path)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		defer db.Close()
+		records, err := db.QueryActivities(q)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		recordsBytes, err := json.Marshal(records)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(string(recordsBytes))
+	},
+}
+
+func init() {
+	queryCmd.Flags().StringVar(&dbPath, "db", "", fmt.Sprintf("the path to the local archive. (default is $HOME/%s)", defaultDB))
+	queryCmd.Flags().StringVar(&from, "from", "", fmt.Sprintf("only include activities that start on or after this date. Must be of the format: %s", dateLayoutFormat))
+	queryCmd.Flags().StringVar(&to, "to", "", fmt.Sprintf("only include activities that start before this date. Must be of the format: %s", dateLayoutFormat))
+	queryCmd.Flags().StringVar(&sport, "sport", "", "only include activities of this sport (e.g. Run)")
+	queryCmd.Flags().StringVar(&gear, "gear", "", "only include activities that used this gear id")
+	queryCmd.Flags().StringVar(&source, "source", "", "only include activities from this platform (strava, finalsurge, local, polar)")
+	queryCmd.Flags().StringVar(&kind, "kind", "", "the kind of activity record to return (activity, detailed_activity). (default activity)")
+	rootCmd.AddCommand(queryCmd)
+}
@@ -6,6 +6,10 @@ import (
 
 	stravaCmd "github.com/jcocozza/cassidy-connector/strava/cmd"
 	finalSurgeCmd "github.com/jcocozza/cassidy-connector/finalSurge/cmd"
+	garminCmd "github.com/jcocozza/cassidy-connector/garmin/cmd"
+	intervalsCmd "github.com/jcocozza/cassidy-connector/intervals/cmd"
+	polarCmd "github.com/jcocozza/cassidy-connector/polar/cmd"
+	trainingPeaksCmd "github.com/jcocozza/cassidy-connector/trainingPeaks/cmd"
 	"github.com/spf13/cobra"
 )
 
@@ -20,13 +24,21 @@ var rootCmd = &cobra.Command{
 	Long: `cassidy is a cli tool for interacting with different activity API's
 The project is currently developing support for:
 - Strava
-- Final Surge`,
+- Final Surge
+- Garmin (against a local stand-in server for now)
+- intervals.icu
+- TrainingPeaks (plans and completed workouts, against a local stand-in server for now)
+- Polar AccessLink`,
 	Run: func(cmd *cobra.Command, args []string) {},
 }
 
 func init() {
 	rootCmd.AddCommand(stravaCmd.RootCmd)
 	rootCmd.AddCommand(finalSurgeCmd.RootCmd)
+	rootCmd.AddCommand(garminCmd.RootCmd)
+	rootCmd.AddCommand(intervalsCmd.RootCmd)
+	rootCmd.AddCommand(trainingPeaksCmd.RootCmd)
+	rootCmd.AddCommand(polarCmd.RootCmd)
 }
 
 func Execute() {
@@ -0,0 +1,15 @@
+// Package fakefiles holds the activity files that the local stand-ins of platforms (e.g. `garmin/fake`) serve, so they all serve the same recordings.
+package fakefiles
+
+import _ "embed"
+
+// A short run with positions, heart rate, power and developer fields.
+// It is a copy of fit/testdata/run.fit, which the fit decoder is tested against (see fit/testdata/generate.go).
+//
+//go:embed run.fit
+var RunFit []byte
+
+// The same run as a gpx track
+//
+//go:embed run.gpx
+var RunGpx []byte
@@ -0,0 +1,181 @@
+// Package fakeoauth is an oauth2 authorization server for the local stand-ins of platforms (e.g. `garmin/fake`),
+// so the whole oauth flow can be developed and tested without network.
+//
+// Every authorization request is approved straight away: the authorize endpoint redirects back with a code, as if the athlete had clicked "allow".
+package fakeoauth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// how long access tokens last by default
+const DefaultTokenLifetime = time.Hour
+
+// A Server issues and checks tokens for a single client
+type Server struct {
+	ClientID     string
+	ClientSecret string
+	// how long access tokens last (default 1 hour). 0 means they never expire (and no refresh token is issued)
+	TokenLifetime time.Duration
+	// optional; extra fields for each token response (e.g. the id of the user the token is for)
+	TokenExtra map[string]interface{}
+
+	mu sync.Mutex
+	// authorization code -> redirect uri it was issued for
+	codes map[string]string
+	// access token -> expiry (zero for never)
+	accessTokens  map[string]time.Time
+	refreshTokens map[string]bool
+}
+
+func New(clientID string, clientSecret string) *Server {
+	return &Server{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		TokenLifetime: DefaultTokenLifetime,
+		codes:         map[string]string{},
+		accessTokens:  map[string]time.Time{},
+		refreshTokens: map[string]bool{},
+	}
+}
+
+// A random token (or code)
+func RandomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// oauth errors, per rfc 6749
+func oauthError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+// Revoke every access token, as if they had all expired. Refresh tokens still work.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessTokens = map[string]time.Time{}
+}
+
+// The authorization endpoint: approve the request and redirect back with a code
+func (s *Server) Authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if q.Get("client_id") != s.ClientID || q.Get("response_type") != "code" {
+		http.Error(w, "invalid client_id or response_type", http.StatusBadRequest)
+		return
+	}
+	code := RandomString()
+	s.mu.Lock()
+	s.codes[code] = redirect.String()
+	s.mu.Unlock()
+	params := redirect.Query()
+	params.Set("code", code)
+	if state := q.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// The token endpoint: exchange an authorization code or refresh token for a new token
+func (s *Server) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	// the client can authenticate with basic auth or in the form
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != s.ClientID || secret != s.ClientSecret {
+		oauthError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		redirect, ok := s.codes[code]
+		if !ok || (r.PostForm.Get("redirect_uri") != "" && r.PostForm.Get("redirect_uri") != redirect) {
+			oauthError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		// codes can only be used once
+		delete(s.codes, code)
+	case "refresh_token":
+		refresh := r.PostForm.Get("refresh_token")
+		if !s.refreshTokens[refresh] {
+			oauthError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		delete(s.refreshTokens, refresh)
+	default:
+		oauthError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+	access := RandomString()
+	resp := map[string]interface{}{
+		"access_token": access,
+		"token_type":   "Bearer",
+	}
+	for k, v := range s.TokenExtra {
+		resp[k] = v
+	}
+	if s.TokenLifetime > 0 {
+		refresh := RandomString()
+		s.accessTokens[access] = time.Now().Add(s.TokenLifetime)
+		s.refreshTokens[refresh] = true
+		resp["expires_in"] = int(s.TokenLifetime.Seconds())
+		resp["refresh_token"] = refresh
+	} else {
+		s.accessTokens[access] = time.Time{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Whether a request has a valid access token
+func (s *Server) Valid(r *http.Request) bool {
+	access, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiry, known := s.accessTokens[access]
+	return known && (expiry.IsZero() || time.Now().Before(expiry))
+}
+
+// Reject requests without a valid access token (with 401 unauthorized)
+func (s *Server) Authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.Valid(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,92 @@
+package fakeoauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// A platform's local stand-in that embeds a `Server` (e.g. `garmin/fake.Server`)
+type Platform interface {
+	http.Handler
+	OAuth() *Server
+}
+
+// The oauth server of the stand-in
+func (s *Server) OAuth() *Server {
+	return s
+}
+
+// A stand-in started for a test, with a token from going through the oauth flow against it
+type TestConfig struct {
+	// the url the stand-in is served at
+	URL    string
+	Server *Server
+	Config *oauth2.Config
+	Token  *oauth2.Token
+}
+
+// Serve `platform` for the duration of the test and go through the oauth flow against it, the way an athlete would:
+// open the approval url and exchange the code that it redirects back with (see `ApprovalCode`).
+//
+// `endpoint` has the paths of the platform's authorize and token endpoints (e.g. "/oauth2/authorize"), relative to the stand-in.
+func NewTestConfig(t testing.TB, platform Platform, endpoint oauth2.Endpoint) *TestConfig {
+	t.Helper()
+	ts := httptest.NewServer(platform)
+	t.Cleanup(ts.Close)
+	endpoint.AuthURL = ts.URL + endpoint.AuthURL
+	endpoint.TokenURL = ts.URL + endpoint.TokenURL
+	server := platform.OAuth()
+	cfg := &oauth2.Config{
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  "http://localhost/exchange_token",
+		Endpoint:     endpoint,
+	}
+	token, err := cfg.Exchange(context.Background(), ApprovalCode(t, cfg.AuthCodeURL("xyz")))
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	return &TestConfig{URL: ts.URL, Server: server, Config: cfg, Token: token}
+}
+
+// Open the approval url (made with the state "xyz") and return the code that the stand-in redirects back with
+func ApprovalCode(t testing.TB, approvalURL string) string {
+	t.Helper()
+	noRedirect := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Get(approvalURL)
+	if err != nil {
+		t.Fatalf("authorize error = %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize = %d %q, want a redirect", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if location.Query().Get("state") != "xyz" {
+		t.Errorf("authorize state = %q, want xyz", location.Query().Get("state"))
+	}
+	return location.Query().Get("code")
+}
+
+// Check that `call` refreshes the token once the stand-in no longer accepts it, and fails with a token the stand-in never issued.
+func (tc *TestConfig) CheckRefresh(t testing.TB, call func(token *oauth2.Token) error) {
+	t.Helper()
+	tc.Server.ExpireTokens()
+	expired := *tc.Token
+	expired.Expiry = time.Now().Add(-time.Minute)
+	if err := call(&expired); err != nil {
+		t.Errorf("call with an expired token error = %v", err)
+	}
+	bad := &oauth2.Token{AccessToken: "nope", Expiry: time.Now().Add(time.Hour)}
+	if err := call(bad); err == nil {
+		t.Errorf("call with a bad token error = nil, want an error")
+	}
+}
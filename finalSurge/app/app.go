@@ -6,13 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 )
 
 const (
-	authUrl = "https://beta.finalsurge.com/api/login"
-	activitiesUrl = "https://beta.finalsurge.com/api/WorkoutList"
+	baseUrl       = "https://beta.finalsurge.com/api"
+	authUrl       = "/login"
+	activitiesUrl = "/WorkoutList"
 )
 
 type AuthResponse struct {
@@ -34,12 +36,20 @@ type AuthResponse struct {
 type App struct {
 	Email    string
 	Password string
+	// the root of the api. defaults to final surge's, but can point anywhere (e.g. a fake server in tests)
+	BaseUrl string
+	// check every response against the structs it is decoded into, and log differences (see `Drifts`)
+	Strict bool
+	// where strict mode logs to. (default slog.Default())
+	Logger *slog.Logger
+	drifts driftLog
 }
 
 func NewApp(email, password string) *App {
 	return &App{
 		Email:    email,
 		Password: password,
+		BaseUrl:  baseUrl,
 	}
 }
 // Authenticate the app created by the user
@@ -49,7 +59,7 @@ func (a *App) Authenticate(ctx context.Context) (*AuthResponse, error) {
 	authPayload := map[string]string{"email": a.Email, "password": a.Password}
 	jsonPayload, _ := json.Marshal(authPayload)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", authUrl, bytes.NewBuffer(jsonPayload))
+	req, err := http.NewRequestWithContext(ctx, "POST", a.BaseUrl+authUrl, bytes.NewBuffer(jsonPayload))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create auth request: %w", err)
 	}
@@ -69,6 +79,7 @@ func (a *App) Authenticate(ctx context.Context) (*AuthResponse, error) {
 	}
 
 	var authResp AuthResponse
+	a.checkSchema(ctx, authUrl, responseData, &authResp)
 	err1 := json.Unmarshal(responseData, &authResp)
 	if err1 != nil {
 		return nil, err1
@@ -77,49 +88,23 @@ func (a *App) Authenticate(ctx context.Context) (*AuthResponse, error) {
 }
 // Get activities between start and end date
 func (a *App) GetActivities(ctx context.Context, userToken, scopeKey string, startDate, endDate time.Time) (*WorkoutListResponse, error) {
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %s", userToken),
-	}
+	return a.GetActivitiesScope(ctx, userToken, ScopeUser, scopeKey, startDate, endDate)
+}
 
+// Get activities between start and end date on another calendar: an athlete's (`ScopeUser` with their user key) or a team's (`ScopeTeam` with the team key)
+//
+// A response that is not a success (by http status or final surge's `success` flag) is a `RequestFailedError`.
+func (a *App) GetActivitiesScope(ctx context.Context, userToken string, scope Scope, scopeKey string, startDate, endDate time.Time) (*WorkoutListResponse, error) {
 	params := map[string]string{
-		"scope":     "USER",
+		"scope":     string(scope),
 		"scopekey":  scopeKey,
 		"startdate": startDate.Format("2006-01-02"),
 		"enddate":   endDate.Format("2006-01-02"),
 	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", activitiesUrl, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
-
-	// Set query parameters
-	q := req.URL.Query()
-	for key, value := range params {
-		q.Add(key, value)
-	}
-	req.URL.RawQuery = q.Encode()
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	responseData, err := io.ReadAll(resp.Body)
+	var workoutListResponse WorkoutListResponse
+	err := a.getJSON(ctx, userToken, activitiesUrl, params, &workoutListResponse)
 	if err != nil {
 		return nil, err
 	}
-
-	var workoutListResponse WorkoutListResponse
-	err1 := json.Unmarshal(responseData, &workoutListResponse)
-	if err1 != nil {
-		return nil, err1
-	}
 	return &workoutListResponse, nil
 }
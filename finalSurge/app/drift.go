@@ -0,0 +1,265 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The kind of a difference between a response and the struct it is decoded into
+type DriftKind string
+
+const (
+	// the response has a field that the struct does not
+	DriftUnknownField DriftKind = "unknown field"
+	// the struct has a field that the response does not
+	DriftMissingField DriftKind = "missing field"
+	// the field has a different json type than the struct expects (e.g. a number became a string)
+	DriftTypeChanged DriftKind = "type changed"
+)
+
+// A Drift is a single difference between a final surge response and what this package expects
+type Drift struct {
+	Endpoint string    `json:"endpoint"`
+	Kind     DriftKind `json:"kind"`
+	// the json path of the field. array elements are [] (e.g. data[].Activities[].hr_avg)
+	Path     string `json:"path"`
+	Expected string `json:"expected,omitempty"`
+	Got      string `json:"got,omitempty"`
+}
+
+func (d Drift) String() string {
+	switch d.Kind {
+	case DriftTypeChanged:
+		return fmt.Sprintf("%s: %s %s: expected %s, got %s", d.Endpoint, d.Kind, d.Path, d.Expected, d.Got)
+	default:
+		return fmt.Sprintf("%s: %s %s", d.Endpoint, d.Kind, d.Path)
+	}
+}
+
+// the drifts found in strict mode, deduplicated
+type driftLog struct {
+	mu     sync.Mutex
+	seen   map[Drift]bool
+	drifts []Drift
+}
+
+// the json type of a decoded value
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		if v != math.Trunc(v) {
+			return "number (with a fraction)"
+		}
+		return "number"
+	case bool:
+		return "bool"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// the json type that a go type decodes from
+func expectedType(t reflect.Type) string {
+	if t == timeType {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return expectedType(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "number"
+	case reflect.Float32, reflect.Float64:
+		return "number (with a fraction)"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	// interface{}: anything goes
+	return ""
+}
+
+// whether a json value of type `got` decodes into a go value that expects `expected`
+func compatible(expected, got string) bool {
+	switch {
+	case expected == "" || got == "null" || expected == got:
+		return true
+	case expected == "number (with a fraction)" && got == "number":
+		return true
+	}
+	return false
+}
+
+// the json name of a struct field. returns false for fields that json skips
+func jsonName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// compare a decoded json value with the go type it is decoded into
+func compare(path string, value interface{}, t reflect.Type, drifts *[]Drift) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	expected := expectedType(t)
+	got := jsonType(value)
+	if !compatible(expected, got) {
+		*drifts = append(*drifts, Drift{Kind: DriftTypeChanged, Path: path, Expected: expected, Got: got})
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct || t == timeType {
+			return
+		}
+		fields := map[string]reflect.StructField{}
+		for i := 0; i < t.NumField(); i++ {
+			if name, ok := jsonName(t.Field(i)); ok {
+				fields[name] = t.Field(i)
+			}
+		}
+		prefix := path
+		if prefix != "" {
+			prefix += "."
+		}
+		for key, fieldValue := range v {
+			f, ok := fields[key]
+			if !ok {
+				// encoding/json falls back to a case insensitive match
+				for name, candidate := range fields {
+					if strings.EqualFold(name, key) {
+						f, ok = candidate, true
+						break
+					}
+				}
+			}
+			if !ok {
+				*drifts = append(*drifts, Drift{Kind: DriftUnknownField, Path: prefix + key, Got: jsonType(fieldValue)})
+				continue
+			}
+			compare(prefix+key, fieldValue, f.Type, drifts)
+		}
+		for name, f := range fields {
+			found := false
+			for key := range v {
+				if strings.EqualFold(name, key) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				*drifts = append(*drifts, Drift{Kind: DriftMissingField, Path: prefix + name, Expected: expectedType(f.Type)})
+			}
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for _, elem := range v {
+			compare(path+"[]", elem, t.Elem(), drifts)
+		}
+	}
+}
+
+// Compare a json response with the struct `v` points to (e.g. a `*WorkoutListResponse`).
+//
+// Every difference is returned once, sorted by path: fields in the response that `v` does not have, fields of `v` that the response does not have,
+// and fields whose json type does not match (null matches anything, and an `interface{}` field matches any type).
+func CheckSchema(data []byte, v interface{}) ([]Drift, error) {
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return nil, err
+	}
+	all := []Drift{}
+	compare("", value, reflect.TypeOf(v), &all)
+	seen := map[Drift]bool{}
+	drifts := []Drift{}
+	for _, d := range all {
+		if !seen[d] {
+			seen[d] = true
+			drifts = append(drifts, d)
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Path != drifts[j].Path {
+			return drifts[i].Path < drifts[j].Path
+		}
+		return drifts[i].Kind < drifts[j].Kind
+	})
+	return drifts, nil
+}
+
+// in strict mode, check a response against the struct it is decoded into, and log and record anything new
+func (a *App) checkSchema(ctx context.Context, endpoint string, data []byte, v interface{}) {
+	if !a.Strict {
+		return
+	}
+	logger := a.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	drifts, err := CheckSchema(data, v)
+	if err != nil {
+		logger.WarnContext(ctx, "final surge response is not json", slog.String("endpoint", endpoint), slog.String("error", err.Error()))
+		return
+	}
+	a.drifts.mu.Lock()
+	defer a.drifts.mu.Unlock()
+	if a.drifts.seen == nil {
+		a.drifts.seen = map[Drift]bool{}
+	}
+	for _, d := range drifts {
+		d.Endpoint = endpoint
+		if a.drifts.seen[d] {
+			continue
+		}
+		a.drifts.seen[d] = true
+		a.drifts.drifts = append(a.drifts.drifts, d)
+		logger.WarnContext(ctx, "final surge schema drift",
+			slog.String("endpoint", d.Endpoint),
+			slog.String("kind", string(d.Kind)),
+			slog.String("path", d.Path),
+			slog.String("expected", d.Expected),
+			slog.String("got", d.Got),
+		)
+	}
+}
+
+// The drifts found so far in strict mode, in the order they were found. Each is only reported once.
+func (a *App) Drifts() []Drift {
+	a.drifts.mu.Lock()
+	defer a.drifts.mu.Unlock()
+	return append([]Drift{}, a.drifts.drifts...)
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckSchema(t *testing.T) {
+	type lap struct {
+		HrAvg int `json:"hr_avg"`
+	}
+	type workout struct {
+		Key      string      `json:"key"`
+		Duration float64     `json:"duration"`
+		Count    int         `json:"count"`
+		Felt     interface{} `json:"felt"`
+		When     time.Time   `json:"when"`
+		Laps     []lap       `json:"Laps"`
+	}
+	type response struct {
+		Data    []workout `json:"data"`
+		Success bool      `json:"success"`
+	}
+	data := `{"success": true, "data": [
+		{"key": "a", "duration": 10, "count": 1, "felt": "great", "when": "2024-05-01T00:00:00Z", "Laps": [{"hr_avg": 140}, {"hr_avg": "150"}]},
+		{"key": 2, "duration": null, "count": 1.5, "felt": 3, "when": "2024-05-01T00:00:00Z", "laps": [{"hr_avg": 140, "hr_min": 120}], "new_field": true}
+	]}`
+	got, err := CheckSchema([]byte(data), &response{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Drift{
+		{Kind: DriftTypeChanged, Path: "data[].Laps[].hr_avg", Expected: "number", Got: "string"},
+		{Kind: DriftTypeChanged, Path: "data[].count", Expected: "number", Got: "number (with a fraction)"},
+		{Kind: DriftTypeChanged, Path: "data[].key", Expected: "string", Got: "number"},
+		// paths use the key in the response
+		{Kind: DriftUnknownField, Path: "data[].laps[].hr_min", Got: "number"},
+		{Kind: DriftUnknownField, Path: "data[].new_field", Got: "bool"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("CheckSchema() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("drift %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	missing, _ := CheckSchema([]byte(`{"data": []}`), &response{})
+	if len(missing) != 1 || missing[0] != (Drift{Kind: DriftMissingField, Path: "success", Expected: "bool"}) {
+		t.Errorf("CheckSchema() = %v, want success to be missing", missing)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"server_time": "2024-05-01T00:00:00Z", "success": true, "data": [{"key": "a", "workout_completion": "100%", "brand_new": 1}]}`))
+	}))
+	defer server.Close()
+	var logs bytes.Buffer
+	a := NewApp("email", "password")
+	a.BaseUrl = server.URL
+	a.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	a.GetActivities(context.Background(), "token", "user", day, day)
+	if len(a.Drifts()) != 0 || logs.Len() != 0 {
+		t.Errorf("drifts were checked without strict mode: %v", a.Drifts())
+	}
+
+	a.Strict = true
+	a.GetActivities(context.Background(), "token", "user", day, day)
+	a.GetActivities(context.Background(), "token", "user", day, day)
+	drifts := a.Drifts()
+	changed := 0
+	for _, d := range drifts {
+		if d.Endpoint != activitiesUrl {
+			t.Errorf("drift %v endpoint = %s, want %s", d, d.Endpoint, activitiesUrl)
+		}
+		if d.Kind == DriftTypeChanged && d.Path == "data[].workout_completion" {
+			changed++
+		}
+	}
+	if changed != 1 {
+		t.Errorf("Drifts() = %v, want workout_completion to have changed type once", drifts)
+	}
+	if !strings.Contains(logs.String(), "path=data[].brand_new") || strings.Count(logs.String(), "path=data[].brand_new") != 1 {
+		t.Errorf("logs = %s, want the unknown field logged once", logs.String())
+	}
+}
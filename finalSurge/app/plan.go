@@ -0,0 +1,148 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/plan"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// the source of planned workouts from final surge (see `plan.PlannedWorkout`)
+const PlanSource = "finalsurge"
+
+// the seconds in a final surge duration unit. false if it is not a unit of time
+func unitSeconds(unit string) (float64, bool) {
+	switch strings.ToLower(unit) {
+	case "s", "sec", "second", "seconds":
+		return 1, true
+	case "min", "minute", "minutes":
+		return 60, true
+	case "h", "hr", "hour", "hours":
+		return 3600, true
+	}
+	return 0, false
+}
+
+// the meters in a final surge distance unit. false if it is not a unit of distance
+func unitMeters(unit string) (float64, bool) {
+	switch strings.ToLower(unit) {
+	case "m", "meter", "meters":
+		return 1, true
+	case "km", "kilometer", "kilometers":
+		return 1000, true
+	case "mi", "mile", "miles":
+		return 1609.344, true
+	case "yd", "yard", "yards":
+		return 0.9144, true
+	}
+	return 0, false
+}
+
+// map a final surge activity type onto a strava sport type
+func planSport(activityType string) swagger.SportType {
+	switch strings.ToLower(activityType) {
+	case "run", "running":
+		return swagger.RUN_SportType
+	case "bike", "cycling", "ride":
+		return swagger.RIDE_SportType
+	case "swim", "swimming":
+		return swagger.SWIM_SportType
+	case "walk", "walking":
+		return swagger.WALK_SportType
+	case "hike", "hiking":
+		return swagger.HIKE_SportType
+	case "strength training", "strength":
+		return swagger.WEIGHT_TRAINING_SportType
+	case "yoga":
+		return swagger.YOGA_SportType
+	case "rowing", "row":
+		return swagger.ROWING_SportType
+	}
+	return swagger.WORKOUT_SportType
+}
+
+func planStep(s StructuredWorkoutStep) plan.Step {
+	step := plan.Step{
+		Name:   s.Name,
+		Notes:  s.Notes,
+		Target: plan.Target{Type: plan.TargetNone},
+	}
+	if s.StepType == "repeat" || len(s.Steps) > 0 {
+		step.Repeat = max(s.Repeat, 1)
+		for _, inner := range s.Steps {
+			step.Steps = append(step.Steps, planStep(inner))
+		}
+		return step
+	}
+	switch s.StepType {
+	case "warmup":
+		step.Intensity = plan.Warmup
+	case "active":
+		step.Intensity = plan.Active
+	case "rest":
+		step.Intensity = plan.Rest
+	case "recovery":
+		step.Intensity = plan.Recovery
+	case "cooldown":
+		step.Intensity = plan.Cooldown
+	default:
+		step.Intensity = plan.Other
+	}
+	step.Duration = plan.DurationOpen
+	switch s.DurationType {
+	case "time":
+		if scale, ok := unitSeconds(s.DurationUnit); ok {
+			step.Duration, step.Value = plan.DurationTime, s.DurationValue*scale
+		}
+	case "distance":
+		if scale, ok := unitMeters(s.DurationUnit); ok {
+			step.Duration, step.Value = plan.DurationDistance, s.DurationValue*scale
+		}
+	}
+	// final surge targets are absolute. paces are seconds per kilometer
+	switch s.TargetType {
+	case "pace":
+		step.Target = plan.Target{Type: plan.TargetPace, Low: s.TargetLow, High: s.TargetHigh}
+	case "heart_rate":
+		step.Target = plan.Target{Type: plan.TargetHeartRate, Low: s.TargetLow, High: s.TargetHigh}
+	case "power":
+		step.Target = plan.Target{Type: plan.TargetPower, Low: s.TargetLow, High: s.TargetHigh}
+	case "cadence":
+		step.Target = plan.Target{Type: plan.TargetCadence, Low: s.TargetLow, High: s.TargetHigh}
+	}
+	return step
+}
+
+// Map a workout onto the common plan model.
+//
+// `structured` is optional: pass the result of `GetStructuredWorkout` for workouts that have one (see `Workout.HasStructuredWorkout`) to get the steps.
+// The planned totals come from the workout's activities.
+func PlannedWorkout(w Workout, structured *StructuredWorkoutResponse) plan.PlannedWorkout {
+	p := plan.PlannedWorkout{
+		Source:      PlanSource,
+		ID:          w.Key,
+		Name:        w.Name,
+		Description: w.Description,
+		Sport:       swagger.WORKOUT_SportType,
+	}
+	p.Date, _ = time.Parse("2006-01-02", w.WorkoutDate[:min(len(w.WorkoutDate), 10)])
+	for i, a := range w.Activities {
+		if i == 0 {
+			p.Sport = planSport(a.ActivityTypeName)
+		}
+		p.PlannedDuration += a.PlannedDuration
+		if scale, ok := unitMeters(a.PlannedAmountType); ok {
+			p.PlannedDistance += a.PlannedAmount * scale
+		}
+	}
+	if structured != nil {
+		if structured.Data.ActivityType != "" {
+			p.Sport = planSport(structured.Data.ActivityType)
+		}
+		for _, s := range structured.Data.Steps {
+			p.Steps = append(p.Steps, planStep(s))
+		}
+	}
+	return p
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jcocozza/cassidy-connector/plan"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+func TestPlannedWorkout(t *testing.T) {
+	a := fakeServer(t)
+	ctx := context.Background()
+	workout, err := a.GetWorkout(ctx, "token", "user-1", "workout-1")
+	if err != nil {
+		t.Fatalf("GetWorkout() error = %v", err)
+	}
+	structured, err := a.GetStructuredWorkout(ctx, "token", "user-1", "workout-1")
+	if err != nil {
+		t.Fatalf("GetStructuredWorkout() error = %v", err)
+	}
+	p := PlannedWorkout(workout.Data, structured)
+	if p.Source != PlanSource || p.ID != "workout-1" || p.Sport != swagger.RUN_SportType || p.Date.Format("2006-01-02") != "2024-05-01" {
+		t.Errorf("PlannedWorkout() = %+v", p)
+	}
+	if p.PlannedDuration != 3600 || p.PlannedDistance != 10000 {
+		t.Errorf("PlannedWorkout() totals = %v s, %v m, want 3600 s, 10000 m", p.PlannedDuration, p.PlannedDistance)
+	}
+	if len(p.Steps) != 3 || p.Steps[1].Repeat != 6 || len(p.Steps[1].Steps) != 2 {
+		t.Fatalf("PlannedWorkout() steps = %+v", p.Steps)
+	}
+	rep := p.Steps[1].Steps[0]
+	if rep.Intensity != plan.Active || rep.Duration != plan.DurationDistance || rep.Value != 800 || rep.Target.Type != plan.TargetPace || rep.Target.Low != 230 {
+		t.Errorf("PlannedWorkout() repeat step = %+v", rep)
+	}
+	seconds, meters := p.StepTotals()
+	if seconds != 1500 || meters != 7200 {
+		t.Errorf("StepTotals() = %v, %v, want 1500, 7200", seconds, meters)
+	}
+
+	// without the structured version there are only totals
+	p = PlannedWorkout(workout.Data, nil)
+	if p.Structured() || p.PlannedDistance != 10000 {
+		t.Errorf("PlannedWorkout(no structure) = %+v", p)
+	}
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+)
+
+// defaults for `RangeOpts`
+const (
+	defaultRangeWorkers  = 2
+	defaultRangeThrottle = 250 * time.Millisecond
+)
+
+// RangeOpts control how `WalkActivities` splits up and fetches a date range
+type RangeOpts struct {
+	// the number of days in each window. 0 means calendar months
+	WindowDays int
+	// the number of windows fetched at once. (default 2)
+	Workers int
+	// the minimum time between the start of two requests. (default 250ms, negative means no throttle)
+	Throttle time.Duration
+	// whose calendar to walk. (default `ScopeUser`; the scope key is then a user key)
+	Scope Scope
+}
+
+// A Window is part of a date range. Both days are included.
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Split the days from start to end (both included) into windows of `days` days, or calendar months if `days` is 0.
+// The first and last windows are cut to the range.
+func Windows(start, end time.Time, days int) []Window {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	windows := []Window{}
+	for !start.After(end) {
+		var next time.Time
+		if days > 0 {
+			next = start.AddDate(0, 0, days)
+		} else {
+			next = time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		last := next.AddDate(0, 0, -1)
+		if last.After(end) {
+			last = end
+		}
+		windows = append(windows, Window{Start: start, End: last})
+		start = next
+	}
+	return windows
+}
+
+// spaces out requests so that they start at least `interval` apart
+type throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait for a turn. returns the context's error if it is done first
+func (t *throttle) wait(ctx context.Context) error {
+	if t.interval <= 0 {
+		return ctx.Err()
+	}
+	t.mu.Lock()
+	now := time.Now()
+	at := t.next
+	if at.Before(now) {
+		at = now
+	}
+	t.next = at.Add(t.interval)
+	t.mu.Unlock()
+	select {
+	case <-time.After(time.Until(at)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Walk the workouts between start and end (both included), one window at a time (see `RangeOpts`).
+//
+// Windows are fetched concurrently, but only a few ahead of the one being read, so a history of any length can be streamed.
+// Workouts come out in window order, and a workout that shows up in more than one window (by `Key`) only comes out once.
+//
+// If a window can't be fetched, its error is yielded. Stop there, or keep going to skip the window.
+func (a *App) WalkActivities(ctx context.Context, userToken, scopeKey string, startDate, endDate time.Time, opts *RangeOpts) iter.Seq2[Workout, error] {
+	if opts == nil {
+		opts = &RangeOpts{}
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultRangeWorkers
+	}
+	t := &throttle{interval: opts.Throttle}
+	if t.interval == 0 {
+		t.interval = defaultRangeThrottle
+	}
+	scope := opts.Scope
+	if scope == "" {
+		scope = ScopeUser
+	}
+	windows := Windows(startDate, endDate, opts.WindowDays)
+
+	return func(yield func(Workout, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type result struct {
+			workouts *WorkoutListResponse
+			err      error
+		}
+		// each window has its own result, so they can be read in order whatever order they finish in
+		results := make([]chan result, len(windows))
+		fetch := func(i int) {
+			results[i] = make(chan result, 1)
+			go func() {
+				err := t.wait(ctx)
+				if err != nil {
+					results[i] <- result{err: err}
+					return
+				}
+				workouts, err := a.GetActivitiesScope(ctx, userToken, scope, scopeKey, windows[i].Start, windows[i].End)
+				results[i] <- result{workouts: workouts, err: err}
+			}()
+		}
+		next := 0
+		for ; next < min(workers, len(windows)); next++ {
+			fetch(next)
+		}
+
+		seen := map[string]bool{}
+		for i, w := range windows {
+			r := <-results[i]
+			if next < len(windows) {
+				fetch(next)
+				next++
+			}
+			if r.err != nil {
+				err := fmt.Errorf("failed to get workouts from %s to %s: %w", w.Start.Format("2006-01-02"), w.End.Format("2006-01-02"), r.err)
+				if !yield(Workout{}, err) {
+					return
+				}
+				continue
+			}
+			for _, workout := range r.workouts.Data {
+				if workout.Key != "" {
+					if seen[workout.Key] {
+						continue
+					}
+					seen[workout.Key] = true
+				}
+				if !yield(workout, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Get the workouts between start and end like `GetActivities`, but a window at a time (see `WalkActivities`).
+//
+// Stops at the first window that fails.
+func (a *App) GetActivitiesRange(ctx context.Context, userToken, scopeKey string, startDate, endDate time.Time, opts *RangeOpts) (*WorkoutListResponse, error) {
+	workouts := &WorkoutListResponse{ServerTime: time.Now(), Data: []Workout{}, Success: true}
+	for workout, err := range a.WalkActivities(ctx, userToken, scopeKey, startDate, endDate, opts) {
+		if err != nil {
+			return nil, err
+		}
+		workouts.Data = append(workouts.Data, workout)
+	}
+	return workouts, nil
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestWindows(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		days       int
+		want       [][2]string
+	}{
+		{"months", "2024-01-15", "2024-03-10", 0, [][2]string{{"2024-01-15", "2024-01-31"}, {"2024-02-01", "2024-02-29"}, {"2024-03-01", "2024-03-10"}}},
+		{"a week at a time", "2024-01-01", "2024-01-20", 7, [][2]string{{"2024-01-01", "2024-01-07"}, {"2024-01-08", "2024-01-14"}, {"2024-01-15", "2024-01-20"}}},
+		{"a single day", "2024-01-01", "2024-01-01", 0, [][2]string{{"2024-01-01", "2024-01-01"}}},
+		{"backwards", "2024-02-01", "2024-01-01", 0, [][2]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Windows(date(tt.start), date(tt.end), tt.days)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Windows() = %v, want %v", got, tt.want)
+			}
+			for i, w := range tt.want {
+				if !got[i].Start.Equal(date(w[0])) || !got[i].End.Equal(date(w[1])) {
+					t.Errorf("window %d = %v - %v, want %v", i, got[i].Start, got[i].End, w)
+				}
+			}
+		})
+	}
+}
+
+// a fake workout list with a workout on the 1st and 15th of every month, and one on the last day of january that every window returns.
+//
+// the window starting on `failing` gets an http error and the one starting on `rejected` gets `success: false`, both with an otherwise valid (empty) list
+func fakeWorkoutList(t *testing.T, inFlight, maxInFlight *int32, failing, rejected string) *App {
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(inFlight, 1)
+		defer atomic.AddInt32(inFlight, -1)
+		mu.Lock()
+		if n > *maxInFlight {
+			*maxInFlight = n
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+
+		q := r.URL.Query()
+		switch q.Get("startdate") {
+		case failing:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"success": true, "data": []}`))
+			return
+		case rejected:
+			w.Write([]byte(`{"success": false, "error_description": "date range too long", "data": []}`))
+			return
+		}
+		start, end := date(q.Get("startdate")), date(q.Get("enddate"))
+		list := WorkoutListResponse{Success: true}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			if d.Day() == 1 || d.Day() == 15 {
+				list.Data = append(list.Data, Workout{Key: d.Format("2006-01-02"), WorkoutDate: d.Format("2006-01-02T15:04:05")})
+			}
+		}
+		list.Data = append(list.Data, Workout{Key: "duplicate", WorkoutDate: "2024-01-31T00:00:00"})
+		json.NewEncoder(w).Encode(list)
+	}))
+	t.Cleanup(server.Close)
+	a := NewApp("email", "password")
+	a.BaseUrl = server.URL
+	return a
+}
+
+func TestWalkActivities(t *testing.T) {
+	var inFlight, maxInFlight int32
+	a := fakeWorkoutList(t, &inFlight, &maxInFlight, "", "")
+	keys := []string{}
+	for workout, err := range a.WalkActivities(context.Background(), "token", "user", date("2024-01-01"), date("2024-12-31"), &RangeOpts{Workers: 3, Throttle: -1}) {
+		if err != nil {
+			t.Fatalf("WalkActivities() error = %v", err)
+		}
+		keys = append(keys, workout.Key)
+	}
+	// 24 workouts and the duplicate, once
+	if len(keys) != 25 || keys[0] != "2024-01-01" || keys[2] != "duplicate" || keys[len(keys)-1] != "2024-12-15" {
+		t.Errorf("WalkActivities() = %v", keys)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("%d requests at once, want at most 3", maxInFlight)
+	}
+}
+
+func TestWalkActivities_Errors(t *testing.T) {
+	var inFlight, maxInFlight int32
+	a := fakeWorkoutList(t, &inFlight, &maxInFlight, "2024-02-01", "2024-03-01")
+	opts := &RangeOpts{Throttle: -1}
+
+	// keep going past the failed windows
+	count, errs := 0, 0
+	for _, err := range a.WalkActivities(context.Background(), "token", "user", date("2024-01-01"), date("2024-04-30"), opts) {
+		if err != nil {
+			if !errors.Is(err, RequestFailedError) {
+				t.Errorf("WalkActivities() error = %v, want RequestFailedError", err)
+			}
+			errs++
+			continue
+		}
+		count++
+	}
+	// january and april
+	if count != 5 || errs != 2 {
+		t.Errorf("WalkActivities() = %d workouts and %d errors, want 5 and 2", count, errs)
+	}
+
+	if _, err := a.GetActivitiesRange(context.Background(), "token", "user", date("2024-01-01"), date("2024-03-31"), opts); err == nil {
+		t.Error("GetActivitiesRange() error = nil, want the failed window")
+	}
+}
+
+func TestWalkActivities_Throttle(t *testing.T) {
+	var inFlight, maxInFlight int32
+	a := fakeWorkoutList(t, &inFlight, &maxInFlight, "", "")
+	start := time.Now()
+	got, err := a.GetActivitiesRange(context.Background(), "token", "user", date("2024-01-01"), date("2024-01-04"), &RangeOpts{WindowDays: 1, Workers: 4, Throttle: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4 windows start at least 20ms apart
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("4 throttled requests took %v, want at least 60ms", elapsed)
+	}
+	if len(got.Data) != 2 {
+		t.Errorf("GetActivitiesRange() = %d workouts, want 2", len(got.Data))
+	}
+}
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	teamsUrl        = "/TeamList"
+	teamAthletesUrl = "/TeamAthleteList"
+)
+
+// A Scope is whose calendar a request is for
+type Scope string
+
+const (
+	// a single user's calendar (the scope key is their user key). coaches can use the user key of any athlete they manage
+	ScopeUser Scope = "USER"
+	// a whole team's calendar (the scope key is the team key)
+	ScopeTeam Scope = "TEAM"
+)
+
+// if no athlete on the roster matches a selector, will throw this error
+var AthleteNotFoundError = errors.New("No athlete matches")
+
+// if more than one athlete on the roster matches a selector, will throw this error
+var AmbiguousAthleteError = errors.New("More than one athlete matches")
+
+// The teams the user belongs to (as a coach or an athlete)
+type TeamsResponse struct {
+	ServerTime time.Time `json:"server_time"`
+	Data       []struct {
+		TeamKey      string `json:"team_key"`
+		TeamName     string `json:"team_name"`
+		IsCoach      bool   `json:"is_coach"`
+		AthleteCount int    `json:"athlete_count"`
+	} `json:"data"`
+	Success          bool        `json:"success"`
+	ErrorNumber      interface{} `json:"error_number"`
+	ErrorDescription interface{} `json:"error_description"`
+	CallID           string      `json:"call_id"`
+}
+
+// An athlete on a team roster
+type Athlete struct {
+	UserKey   string `json:"user_key"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	TeamKey   string `json:"team_key"`
+	TeamName  string `json:"team_name"`
+}
+
+// The athletes of a team
+type TeamAthletesResponse struct {
+	ServerTime       time.Time   `json:"server_time"`
+	Data             []Athlete   `json:"data"`
+	Success          bool        `json:"success"`
+	ErrorNumber      interface{} `json:"error_number"`
+	ErrorDescription interface{} `json:"error_description"`
+	CallID           string      `json:"call_id"`
+}
+
+// send an authorized GET and decode its json into `out`
+func (a *App) getJSON(ctx context.Context, userToken, path string, params map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", a.BaseUrl+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userToken))
+	q := req.URL.Query()
+	for key, value := range params {
+		q.Add(key, value)
+	}
+	req.URL.RawQuery = q.Encode()
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var status struct {
+		Success          bool        `json:"success"`
+		ErrorDescription interface{} `json:"error_description"`
+	}
+	err = json.Unmarshal(responseData, &status)
+	if err != nil {
+		return err
+	}
+	if !status.Success {
+		return fmt.Errorf("%w: %v", RequestFailedError, status.ErrorDescription)
+	}
+	a.checkSchema(ctx, path, responseData, out)
+	return json.Unmarshal(responseData, out)
+}
+
+// Get the teams of the user
+func (a *App) GetTeams(ctx context.Context, userToken string) (*TeamsResponse, error) {
+	var teams TeamsResponse
+	err := a.getJSON(ctx, userToken, teamsUrl, nil, &teams)
+	if err != nil {
+		return nil, err
+	}
+	return &teams, nil
+}
+
+// Get the athletes of a team
+func (a *App) GetTeamAthletes(ctx context.Context, userToken, teamKey string) (*TeamAthletesResponse, error) {
+	var athletes TeamAthletesResponse
+	err := a.getJSON(ctx, userToken, teamAthletesUrl, map[string]string{"teamkey": teamKey}, &athletes)
+	if err != nil {
+		return nil, err
+	}
+	for i := range athletes.Data {
+		if athletes.Data[i].TeamKey == "" {
+			athletes.Data[i].TeamKey = teamKey
+		}
+	}
+	return &athletes, nil
+}
+
+// Get every athlete on the teams that the user coaches. An athlete on more than one team is only listed once (with their first team).
+func (a *App) GetRoster(ctx context.Context, userToken string) ([]Athlete, error) {
+	teams, err := a.GetTeams(ctx, userToken)
+	if err != nil {
+		return nil, err
+	}
+	roster := []Athlete{}
+	seen := map[string]bool{}
+	for _, team := range teams.Data {
+		if !team.IsCoach {
+			continue
+		}
+		athletes, err := a.GetTeamAthletes(ctx, userToken, team.TeamKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the athletes of %s: %w", team.TeamName, err)
+		}
+		for _, athlete := range athletes.Data {
+			if seen[athlete.UserKey] {
+				continue
+			}
+			seen[athlete.UserKey] = true
+			if athlete.TeamName == "" {
+				athlete.TeamName = team.TeamName
+			}
+			roster = append(roster, athlete)
+		}
+	}
+	return roster, nil
+}
+
+// Find the athlete on a roster that `selector` picks out: their user key, their email, or (part of) their name. Case does not matter.
+//
+// An exact user key or email wins over names. Returns an `AthleteNotFoundError` or `AmbiguousAthleteError` if there is not exactly one match.
+func FindAthlete(roster []Athlete, selector string) (Athlete, error) {
+	s := strings.ToLower(strings.TrimSpace(selector))
+	matches := []Athlete{}
+	for _, athlete := range roster {
+		if strings.ToLower(athlete.UserKey) == s || strings.ToLower(athlete.Email) == s {
+			return athlete, nil
+		}
+		name := strings.ToLower(athlete.FirstName + " " + athlete.LastName)
+		if s != "" && strings.Contains(name, s) {
+			matches = append(matches, athlete)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Athlete{}, fmt.Errorf("%w: %s", AthleteNotFoundError, selector)
+	case 1:
+		return matches[0], nil
+	}
+	names := []string{}
+	for _, m := range matches {
+		names = append(names, m.FirstName+" "+m.LastName)
+	}
+	return Athlete{}, fmt.Errorf("%w %s: %s", AmbiguousAthleteError, selector, strings.Join(names, ", "))
+}
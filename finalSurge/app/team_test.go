@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// a fake final surge for a coach of two teams. each athlete's calendar has a single workout with their key, and the team calendar has a team workout
+func fakeTeamServer(t *testing.T) *App {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer coach" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		fixture := ""
+		switch r.URL.Path {
+		case teamsUrl:
+			fixture = "teams.json"
+		case teamAthletesUrl:
+			switch q.Get("teamkey") {
+			case "team-1":
+				fixture = "team_athletes_1.json"
+			case "team-2":
+				fixture = "team_athletes_2.json"
+			default:
+				fixture = "not_found.json"
+			}
+		case activitiesUrl:
+			list := WorkoutListResponse{Success: true}
+			switch Scope(q.Get("scope")) {
+			case ScopeUser:
+				list.Data = []Workout{{Key: "workout-of-" + q.Get("scopekey"), UserKey: q.Get("scopekey"), WorkoutDate: "2024-05-01T00:00:00"}}
+			case ScopeTeam:
+				list.Data = []Workout{{Key: "team-workout", IsTeamWorkout: true, WorkoutDate: "2024-05-01T00:00:00"}}
+			}
+			json.NewEncoder(w).Encode(list)
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	a := NewApp("email", "password")
+	a.BaseUrl = server.URL
+	return a
+}
+
+func TestGetRoster(t *testing.T) {
+	a := fakeTeamServer(t)
+	roster, err := a.GetRoster(context.Background(), "coach")
+	if err != nil {
+		t.Fatalf("GetRoster() error = %v", err)
+	}
+	// sam is on both teams. the club is not coached, so its athletes are left out
+	want := []struct{ key, team string }{{"athlete-1", "Track"}, {"athlete-2", "Track"}, {"athlete-3", "Masters"}}
+	if len(roster) != len(want) {
+		t.Fatalf("GetRoster() = %+v", roster)
+	}
+	for i, w := range want {
+		if roster[i].UserKey != w.key || roster[i].TeamName != w.team {
+			t.Errorf("athlete %d = %+v, want %+v", i, roster[i], w)
+		}
+	}
+
+	if _, err := a.GetRoster(context.Background(), "athlete"); !errors.Is(err, RequestFailedError) || !errors.Is(err, UnauthorizedError) {
+		t.Errorf("GetRoster() error = %v, want %v and %v", err, RequestFailedError, UnauthorizedError)
+	}
+	if _, err := a.GetTeamAthletes(context.Background(), "coach", "team-9"); !errors.Is(err, RequestFailedError) {
+		t.Errorf("GetTeamAthletes() error = %v, want %v", err, RequestFailedError)
+	}
+}
+
+func TestFindAthlete(t *testing.T) {
+	roster := []Athlete{
+		{UserKey: "athlete-1", FirstName: "Ana", LastName: "Lopez", Email: "ana@example.com"},
+		{UserKey: "athlete-2", FirstName: "Sam", LastName: "Okafor", Email: "sam@example.com"},
+		{UserKey: "athlete-3", FirstName: "Sam", LastName: "Lee", Email: "slee@example.com"},
+	}
+	tests := []struct {
+		selector string
+		want     string
+		err      error
+	}{
+		{"athlete-3", "athlete-3", nil},
+		{"SAM@example.com", "athlete-2", nil},
+		{"lopez", "athlete-1", nil},
+		{"sam lee", "athlete-3", nil},
+		{"sam", "", AmbiguousAthleteError},
+		{"nobody", "", AthleteNotFoundError},
+		{"", "", AthleteNotFoundError},
+	}
+	for _, tt := range tests {
+		got, err := FindAthlete(roster, tt.selector)
+		if !errors.Is(err, tt.err) || got.UserKey != tt.want {
+			t.Errorf("FindAthlete(%q) = %q, %v, want %q, %v", tt.selector, got.UserKey, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestGetActivitiesScope(t *testing.T) {
+	a := fakeTeamServer(t)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	athlete, err := a.GetActivitiesScope(context.Background(), "coach", ScopeUser, "athlete-2", day, day)
+	if err != nil || len(athlete.Data) != 1 || athlete.Data[0].Key != "workout-of-athlete-2" {
+		t.Errorf("GetActivitiesScope(user) = %+v, %v", athlete, err)
+	}
+	team, err := a.GetActivitiesRange(context.Background(), "coach", "team-1", day, day, &RangeOpts{Scope: ScopeTeam, Throttle: -1})
+	if err != nil || len(team.Data) != 1 || !team.Data[0].IsTeamWorkout {
+		t.Errorf("GetActivitiesRange(team) = %+v, %v", team, err)
+	}
+}
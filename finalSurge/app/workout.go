@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// the detail endpoints, as called by the final surge web app. all of them take the workout key
+const (
+	workoutUrl           = "/Workout"
+	workoutIntervalsUrl  = "/WorkoutIntervals"
+	workoutStructuredUrl = "/WorkoutBuilder"
+	workoutMapUrl        = "/WorkoutMap"
+	workoutFileUrl       = "/WorkoutFile"
+)
+
+// if final surge answers with `success: false` (or a non 200 status), will throw this error (wrapped with the description)
+var RequestFailedError = errors.New("Final Surge request failed")
+
+// if final surge rejects the token (it expired early, or was revoked), will throw this error (wrapped in a `RequestFailedError`)
+var UnauthorizedError = errors.New("Final Surge rejected the token")
+
+// the error of a response with a non 200 status
+func statusError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%w: %w", RequestFailedError, UnauthorizedError)
+	}
+	return fmt.Errorf("%w: %s", RequestFailedError, resp.Status)
+}
+
+// if a workout has no original device file, will throw this error
+var NoDownloadFileError = errors.New("Workout has no download file")
+
+// The full workout, by key
+type WorkoutResponse struct {
+	ServerTime       time.Time   `json:"server_time"`
+	Data             Workout     `json:"data"`
+	Success          bool        `json:"success"`
+	ErrorNumber      interface{} `json:"error_number"`
+	ErrorDescription interface{} `json:"error_description"`
+	CallID           string      `json:"call_id"`
+}
+
+// The intervals of a workout. Planned values are 0 for intervals that were not planned, and actual values are 0 until the workout is done.
+type WorkoutIntervalsResponse struct {
+	ServerTime time.Time `json:"server_time"`
+	Data       []struct {
+		Number int    `json:"number"`
+		Name   string `json:"name"`
+		// e.g. "warmup", "active", "rest" or "cooldown"
+		IntervalType      string      `json:"interval_type"`
+		Repeat            int         `json:"repeat"`
+		PlannedDuration   float64     `json:"planned_duration"`
+		PlannedAmount     float64     `json:"planned_amount"`
+		PlannedAmountType string      `json:"planned_amount_type"`
+		PlannedPaceLow    interface{} `json:"planned_pace_low"`
+		PlannedPaceHigh   interface{} `json:"planned_pace_high"`
+		Duration          float64     `json:"duration"`
+		Amount            float64     `json:"amount"`
+		AmountType        string      `json:"amount_type"`
+		AmountNormalized  float64     `json:"amount_normalized"`
+		PaceDisplay       string      `json:"pace_display"`
+		SpeedAvg          float64     `json:"speed_avg"`
+		PowerAvg          int         `json:"power_avg"`
+		HrAvg             int         `json:"hr_avg"`
+		HrMax             int         `json:"hr_max"`
+	} `json:"data"`
+	Success          bool        `json:"success"`
+	ErrorNumber      interface{} `json:"error_number"`
+	ErrorDescription interface{} `json:"error_description"`
+	CallID           string      `json:"call_id"`
+}
+
+// A step of a structured workout. Repeats have their own steps.
+type StructuredWorkoutStep struct {
+	Name string `json:"name"`
+	// e.g. "warmup", "active", "rest", "cooldown" or "repeat"
+	StepType string `json:"step_type"`
+	Repeat   int    `json:"repeat"`
+	// e.g. "time", "distance" or "open"
+	DurationType  string  `json:"duration_type"`
+	DurationValue float64 `json:"duration_value"`
+	DurationUnit  string  `json:"duration_unit"`
+	// e.g. "pace", "heart_rate", "power" or "none"
+	TargetType string                  `json:"target_type"`
+	TargetLow  float64                 `json:"target_low"`
+	TargetHigh float64                 `json:"target_high"`
+	Notes      string                  `json:"notes"`
+	Steps      []StructuredWorkoutStep `json:"steps"`
+}
+
+// The structured (workout builder) version of a planned workout
+type StructuredWorkoutResponse struct {
+	ServerTime time.Time `json:"server_time"`
+	Data       struct {
+		WorkoutKey   string                  `json:"workout_key"`
+		Name         string                  `json:"name"`
+		ActivityType string                  `json:"activity_type"`
+		Steps        []StructuredWorkoutStep `json:"steps"`
+	} `json:"data"`
+	Success          bool        `json:"success"`
+	ErrorNumber      interface{} `json:"error_number"`
+	ErrorDescription interface{} `json:"error_description"`
+	CallID           string      `json:"call_id"`
+}
+
+// The route of a workout
+type WorkoutMapResponse struct {
+	ServerTime time.Time `json:"server_time"`
+	Data       struct {
+		// a google encoded polyline of the route
+		Polyline string `json:"polyline"`
+		Points   []struct {
+			Lat       float64 `json:"lat"`
+			Lng       float64 `json:"lng"`
+			Elevation float64 `json:"elevation"`
+		} `json:"points"`
+	} `json:"data"`
+	Success          bool        `json:"success"`
+	ErrorNumber      interface{} `json:"error_number"`
+	ErrorDescription interface{} `json:"error_description"`
+	CallID           string      `json:"call_id"`
+}
+
+// send an authorized GET for a workout and return the response
+func (a *App) getWorkout(ctx context.Context, userToken, scopeKey, workoutKey, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", a.BaseUrl+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userToken))
+	q := req.URL.Query()
+	q.Add("scope", string(ScopeUser))
+	q.Add("scopekey", scopeKey)
+	q.Add("workoutkey", workoutKey)
+	req.URL.RawQuery = q.Encode()
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, statusError(resp)
+	}
+	return resp, nil
+}
+
+// GET a workout endpoint and decode its json into `out`
+func (a *App) getWorkoutJSON(ctx context.Context, userToken, scopeKey, workoutKey, path string, out interface{}) error {
+	params := map[string]string{"scope": string(ScopeUser), "scopekey": scopeKey, "workoutkey": workoutKey}
+	return a.getJSON(ctx, userToken, path, params, out)
+}
+
+// Get the full workout with key `workoutKey`
+func (a *App) GetWorkout(ctx context.Context, userToken, scopeKey, workoutKey string) (*WorkoutResponse, error) {
+	var workout WorkoutResponse
+	err := a.getWorkoutJSON(ctx, userToken, scopeKey, workoutKey, workoutUrl, &workout)
+	if err != nil {
+		return nil, err
+	}
+	return &workout, nil
+}
+
+// Get the intervals of a workout (see `Workout.HasIntervals`)
+func (a *App) GetWorkoutIntervals(ctx context.Context, userToken, scopeKey, workoutKey string) (*WorkoutIntervalsResponse, error) {
+	var intervals WorkoutIntervalsResponse
+	err := a.getWorkoutJSON(ctx, userToken, scopeKey, workoutKey, workoutIntervalsUrl, &intervals)
+	if err != nil {
+		return nil, err
+	}
+	return &intervals, nil
+}
+
+// Get the structured version of a workout (see `Workout.HasStructuredWorkout`)
+func (a *App) GetStructuredWorkout(ctx context.Context, userToken, scopeKey, workoutKey string) (*StructuredWorkoutResponse, error) {
+	var structured StructuredWorkoutResponse
+	err := a.getWorkoutJSON(ctx, userToken, scopeKey, workoutKey, workoutStructuredUrl, &structured)
+	if err != nil {
+		return nil, err
+	}
+	return &structured, nil
+}
+
+// Get the route of a workout (see `Workout.HasMap`)
+func (a *App) GetWorkoutMap(ctx context.Context, userToken, scopeKey, workoutKey string) (*WorkoutMapResponse, error) {
+	var workoutMap WorkoutMapResponse
+	err := a.getWorkoutJSON(ctx, userToken, scopeKey, workoutKey, workoutMapUrl, &workoutMap)
+	if err != nil {
+		return nil, err
+	}
+	return &workoutMap, nil
+}
+
+// Download the original device file of a workout (see `Workout.HasDownloadFile`) into `w`.
+//
+// The file is in the format of `Workout.DownloadFileExtension`. Returns a `NoDownloadFileError` if the workout does not have one.
+func (a *App) DownloadWorkoutFile(ctx context.Context, userToken, scopeKey string, workout Workout, w io.Writer) error {
+	if !workout.HasDownloadFile {
+		return NoDownloadFileError
+	}
+	resp, err := a.getWorkout(ctx, userToken, scopeKey, workout.Key, workoutFileUrl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download workout file: %w", err)
+	}
+	return nil
+}
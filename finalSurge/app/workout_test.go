@@ -0,0 +1,143 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// a fake final surge that serves the fixtures in testdata for workout-1, and a failure for any other workout
+func fakeServer(t *testing.T) *App {
+	fixtures := map[string]string{
+		workoutUrl:           "workout.json",
+		workoutIntervalsUrl:  "intervals.json",
+		workoutStructuredUrl: "structured.json",
+		workoutMapUrl:        "map.json",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.URL.Query().Get("scopekey") != "user-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		key := r.URL.Query().Get("workoutkey")
+		if r.URL.Path == workoutFileUrl {
+			if key != "workout-1" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("original fit file"))
+			return
+		}
+		fixture, ok := fixtures[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if key != "workout-1" {
+			fixture = "not_found.json"
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	a := NewApp("email", "password")
+	a.BaseUrl = server.URL
+	return a
+}
+
+func TestGetWorkout(t *testing.T) {
+	a := fakeServer(t)
+	workout, err := a.GetWorkout(context.Background(), "token", "user-1", "workout-1")
+	if err != nil {
+		t.Fatalf("GetWorkout() error = %v", err)
+	}
+	w := workout.Data
+	if w.Key != "workout-1" || w.Name != "6 x 800m" || !w.HasDownloadFile || w.DownloadFileExtension != ".fit" || !w.HasIntervals || !w.HasMap || !w.HasStructuredWorkout {
+		t.Errorf("GetWorkout() = %+v", w)
+	}
+	if len(w.Activities) != 1 || w.Activities[0].PlannedDuration != 3600 || len(w.Activities[0].Laps) != 1 {
+		t.Errorf("GetWorkout() activities = %+v", w.Activities)
+	}
+
+	_, err = a.GetWorkout(context.Background(), "token", "user-1", "missing")
+	if !errors.Is(err, RequestFailedError) {
+		t.Errorf("GetWorkout() error = %v, want %v", err, RequestFailedError)
+	}
+	_, err = a.GetWorkout(context.Background(), "wrong token", "user-1", "workout-1")
+	if !errors.Is(err, RequestFailedError) {
+		t.Errorf("GetWorkout() with a bad token error = %v, want %v", err, RequestFailedError)
+	}
+}
+
+func TestGetWorkoutIntervals(t *testing.T) {
+	a := fakeServer(t)
+	intervals, err := a.GetWorkoutIntervals(context.Background(), "token", "user-1", "workout-1")
+	if err != nil {
+		t.Fatalf("GetWorkoutIntervals() error = %v", err)
+	}
+	if len(intervals.Data) != 3 {
+		t.Fatalf("GetWorkoutIntervals() = %d intervals, want 3", len(intervals.Data))
+	}
+	effort := intervals.Data[1]
+	if effort.Name != "800m" || effort.Repeat != 6 || effort.PlannedAmount != 0.8 || effort.HrMax != 181 {
+		t.Errorf("interval 2 = %+v", effort)
+	}
+}
+
+func TestGetStructuredWorkout(t *testing.T) {
+	a := fakeServer(t)
+	structured, err := a.GetStructuredWorkout(context.Background(), "token", "user-1", "workout-1")
+	if err != nil {
+		t.Fatalf("GetStructuredWorkout() error = %v", err)
+	}
+	steps := structured.Data.Steps
+	if len(steps) != 3 || steps[1].StepType != "repeat" || steps[1].Repeat != 6 || len(steps[1].Steps) != 2 {
+		t.Fatalf("GetStructuredWorkout() steps = %+v", steps)
+	}
+	if rep := steps[1].Steps[0]; rep.DurationType != "distance" || rep.DurationValue != 800 || rep.TargetType != "pace" {
+		t.Errorf("repeat step = %+v", rep)
+	}
+}
+
+func TestGetWorkoutMap(t *testing.T) {
+	a := fakeServer(t)
+	workoutMap, err := a.GetWorkoutMap(context.Background(), "token", "user-1", "workout-1")
+	if err != nil {
+		t.Fatalf("GetWorkoutMap() error = %v", err)
+	}
+	if workoutMap.Data.Polyline == "" || len(workoutMap.Data.Points) != 3 || workoutMap.Data.Points[2].Lat != 43.252 {
+		t.Errorf("GetWorkoutMap() = %+v", workoutMap.Data)
+	}
+}
+
+func TestDownloadWorkoutFile(t *testing.T) {
+	a := fakeServer(t)
+	workout, err := a.GetWorkout(context.Background(), "token", "user-1", "workout-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = a.DownloadWorkoutFile(context.Background(), "token", "user-1", workout.Data, &buf)
+	if err != nil || buf.String() != "original fit file" {
+		t.Errorf("DownloadWorkoutFile() = %q, %v", buf.String(), err)
+	}
+
+	noFile := workout.Data
+	noFile.HasDownloadFile = false
+	if err := a.DownloadWorkoutFile(context.Background(), "token", "user-1", noFile, &buf); err != NoDownloadFileError {
+		t.Errorf("DownloadWorkoutFile() error = %v, want %v", err, NoDownloadFileError)
+	}
+	missing := workout.Data
+	missing.Key = "missing"
+	if err := a.DownloadWorkoutFile(context.Background(), "token", "user-1", missing, &buf); !errors.Is(err, RequestFailedError) {
+		t.Errorf("DownloadWorkoutFile() error = %v, want %v", err, RequestFailedError)
+	}
+}
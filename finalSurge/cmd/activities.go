@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+	"github.com/jcocozza/cassidy-connector/store"
 	"github.com/jcocozza/cassidy-connector/utils"
 	"github.com/spf13/cobra"
 )
@@ -14,35 +16,70 @@ import (
 const layout string = "2006-01-02"
 const layoutInterpretation string = "YYYY-MM-DD"
 
+// parse a date flag. "today" is today
+func parseDate(s string) (time.Time, error) {
+	if s == "today" {
+		return time.Now(), nil
+	}
+	return time.Parse(layout, s)
+}
+
+// add the flags of a date range that is fetched a window at a time
+func addRangeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&start, "start", "s", "", fmt.Sprintf("Filter to only include activities on or after this date. Must be of the format: %s (or today)", layoutInterpretation))
+	cmd.Flags().StringVarP(&end, "end", "e", "today", fmt.Sprintf("Filter to only include activities on or before this date. Must be of the format: %s (or today)", layoutInterpretation))
+	cmd.Flags().IntVar(&rangeOpts.WindowDays, "window-days", 0, "fetch this many days at a time. 0 fetches a calendar month at a time")
+	cmd.Flags().IntVar(&rangeOpts.Workers, "workers", 2, "the number of windows fetched at once")
+	cmd.Flags().DurationVar(&rangeOpts.Throttle, "throttle", 250*time.Millisecond, "the minimum time between requests")
+	cmd.MarkFlagRequired("start")
+}
+
 var start string
 var end string
+var rangeOpts app.RangeOpts
+var dbPath string
 var getActivities = &cobra.Command{
 	Use: "activities",
 	Short: "get user activities",
 	Args: cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
-		finalSurgeApp := createApp(email, password)
-		auth, err := finalSurgeApp.Authenticate(context.TODO())
+		startDate, err := parseDate(start)
 		if err != nil {
 			fmt.Println(err.Error())
 			return
 		}
-
-		startDate, err := time.Parse(layout, start)
+		endDate, err := parseDate(end)
 		if err != nil {
 			fmt.Println(err.Error())
 			return
 		}
-		endDate, err := time.Parse(layout, end)
+
+		var activities *app.WorkoutListResponse
+		err = withLogin(context.TODO(), func(finalSurgeApp *app.App, auth *app.AuthResponse) error {
+			scopeKey, err := athleteKey(context.TODO(), finalSurgeApp, auth)
+			if err != nil {
+				return err
+			}
+			activities, err = finalSurgeApp.GetActivitiesRange(context.TODO(), auth.Data.Token, scopeKey, startDate, endDate, &rangeOpts)
+			return err
+		})
 		if err != nil {
 			fmt.Println(err.Error())
 			return
 		}
 
-		activities, err := finalSurgeApp.GetActivities(context.TODO(), auth.Data.Token, auth.Data.UserKey, startDate, endDate)
-		if err != nil {
-			fmt.Println(err.Error())
-			return
+		if dbPath != "" {
+			db, err := store.Open(dbPath)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			defer db.Close()
+			err = db.PutFinalSurgeWorkouts(activities)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
 		}
 
 		activitiesBytes, err := json.Marshal(activities)
@@ -65,9 +102,6 @@ var getActivities = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(getActivities)
 
-	getActivities.Flags().StringVarP(&start, "start", "s", "", fmt.Sprintf("Filter to only include activities after this date. Must be of the format: %s", layoutInterpretation))
-	getActivities.Flags().StringVarP(&end, "end", "e", "", fmt.Sprintf("Filter to only include activities before this date. Must be of the format: %s", layoutInterpretation))
-
-	getActivities.MarkFlagRequired("start")
-	getActivities.MarkFlagRequired("end")
+	addRangeFlags(getActivities)
+	getActivities.Flags().StringVar(&dbPath, "db", "", "the path to a local archive to also write the activities into")
 }
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+// the user key of the calendar to read: the user's own, or the athlete picked by --athlete
+func athleteKey(ctx context.Context, finalSurgeApp *app.App, auth *app.AuthResponse) (string, error) {
+	if athlete == "" {
+		return auth.Data.UserKey, nil
+	}
+	roster, err := finalSurgeApp.GetRoster(ctx, auth.Data.Token)
+	if err != nil {
+		return "", err
+	}
+	a, err := app.FindAthlete(roster, athlete)
+	if err != nil {
+		return "", err
+	}
+	return a.UserKey, nil
+}
+
+var getAthletes = &cobra.Command{
+	Use:   "athletes",
+	Short: "list the athletes on the teams you coach",
+	Long:  "list the athletes on the teams you coach. any of them can be passed to --athlete (by user key, email or name)",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		var roster []app.Athlete
+		err := withLogin(context.TODO(), func(finalSurgeApp *app.App, auth *app.AuthResponse) error {
+			var err error
+			roster, err = finalSurgeApp.GetRoster(context.TODO(), auth.Data.Token)
+			return err
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		rosterBytes, err := json.Marshal(roster)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			err := utils.WriteOutput(outputPath, rosterBytes)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+		fmt.Println(string(rosterBytes))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(getAthletes)
+}
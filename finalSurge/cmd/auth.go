@@ -15,8 +15,7 @@ var authenticate = &cobra.Command{
 	Short: "Authenticate the app",
 	Args:  cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
-		finalSurgeApp := createApp(email, password)
-		auth, err := finalSurgeApp.Authenticate(context.TODO())
+		_, auth, err := freshAuth(context.TODO())
 		if err != nil {
 			fmt.Println(err.Error())
 			return
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+	"github.com/jcocozza/cassidy-connector/finalSurge/compliance"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+// format seconds as h:mm:ss
+func formatSeconds(seconds float64) string {
+	s := int(seconds)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// print a compliance report as two tables: the entries, then the weeks
+func printCompliance(report compliance.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tWORKOUT\tTYPE\tPLANNED\tACTUAL\tRATIO\tSTATUS\tPACE")
+	for _, e := range report.Entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%.2f\t%s\t%s\n",
+			e.Date.Format(layout),
+			e.Name,
+			e.ActivityType,
+			formatSeconds(e.PlannedDuration),
+			formatSeconds(e.Duration),
+			e.Ratio,
+			e.Status,
+			e.PaceStatus,
+		)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "WEEK\tPLANNED\tCOMPLETED\tEXTENDED\tSHORTENED\tMISSED\tUNPLANNED\tOFF PACE\tCOMPLIANCE (%)")
+	for _, wk := range report.Weeks {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%.1f\n",
+			wk.Start.Format(layout),
+			wk.Planned,
+			wk.Completed,
+			wk.Extended,
+			wk.Shortened,
+			wk.Missed,
+			wk.Unplanned,
+			wk.OffPace,
+			wk.Compliance,
+		)
+	}
+	w.Flush()
+}
+
+var complianceJSON bool
+var complianceOpts compliance.Options
+var complianceCmd = &cobra.Command{
+	Use:   "compliance",
+	Short: "compare planned workouts with what was done",
+	Long:  "compare planned workouts with what was done. every activity is flagged as completed, missed, shortened, extended, unplanned or rest (and off pace if it was outside the planned band), and rolled up by week",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		startDate, err := parseDate(start)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		endDate, err := parseDate(end)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		var workouts *app.WorkoutListResponse
+		err = withLogin(context.TODO(), func(finalSurgeApp *app.App, auth *app.AuthResponse) error {
+			scopeKey, err := athleteKey(context.TODO(), finalSurgeApp, auth)
+			if err != nil {
+				return err
+			}
+			workouts, err = finalSurgeApp.GetActivitiesRange(context.TODO(), auth.Data.Token, scopeKey, startDate, endDate, &rangeOpts)
+			return err
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		report, err := compliance.Compare(workouts, complianceOpts)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		reportBytes, err := json.Marshal(report)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			err := utils.WriteOutput(outputPath, reportBytes)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+		if complianceJSON {
+			fmt.Println(string(reportBytes))
+			return
+		}
+		printCompliance(report)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(complianceCmd)
+
+	addRangeFlags(complianceCmd)
+	complianceCmd.Flags().BoolVar(&complianceJSON, "json", false, "output json instead of a table")
+	complianceCmd.Flags().Float64Var(&complianceOpts.Shortened, "shortened", compliance.DefaultShortened, "workouts done at less than this fraction of the plan are shortened")
+	complianceCmd.Flags().Float64Var(&complianceOpts.Extended, "extended", compliance.DefaultExtended, "workouts done at more than this fraction of the plan are extended")
+	complianceCmd.Flags().Float64Var(&complianceOpts.PaceTolerance, "pace-tolerance", 0, "seconds (per unit of pace) that a pace can be outside the planned band")
+}
@@ -1,7 +1,76 @@
 package cmd
 
-import "github.com/jcocozza/cassidy-connector/finalSurge/app"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+	"github.com/jcocozza/cassidy-connector/finalSurge/credentials"
+)
 
 func createApp(email, password string) *app.App {
-	return app.NewApp(email, password)
+	a := app.NewApp(email, password)
+	a.Strict = strict
+	return a
+}
+
+// authenticate the app. an answer without a token is a `app.RequestFailedError`
+func checkedAuth(ctx context.Context, finalSurgeApp *app.App) (*app.AuthResponse, error) {
+	auth, err := finalSurgeApp.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.Success || auth.Data.Token == "" {
+		return nil, fmt.Errorf("%w: %v", app.RequestFailedError, auth.ErrorDescription)
+	}
+	return auth, nil
+}
+
+// authenticate with the user's credentials (see `credentials.Resolve`) and cache the token
+func freshAuth(ctx context.Context) (*app.App, *app.AuthResponse, error) {
+	creds, err := credentials.Resolve(credentials.Credentials{Email: email, Password: password}, config, credentialsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSurgeApp := createApp(creds.Email, creds.Password)
+	auth, err := checkedAuth(ctx, finalSurgeApp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tokenPath != "" {
+		// cached under the email that logged in, which is what later commands look it up by
+		token := credentials.NewToken(auth)
+		token.Email = creds.Email
+		err = credentials.SaveToken(tokenPath, token)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to cache the token: %w", err)
+		}
+	}
+	return finalSurgeApp, auth, nil
+}
+
+// run `f` logged in: with the cached token of the account the credentials name (if it is still valid), otherwise a fresh one.
+// if final surge rejects the cached token, it is dropped and `f` runs once more after a fresh login
+func withLogin(ctx context.Context, f func(finalSurgeApp *app.App, auth *app.AuthResponse) error) error {
+	if tokenPath != "" {
+		accountEmail := credentials.Email(credentials.Credentials{Email: email, Password: password}, config)
+		token, err := credentials.LoadToken(tokenPath, accountEmail, time.Now())
+		if err == nil {
+			err = f(createApp(token.Email, ""), token.AuthResponse())
+			if !errors.Is(err, app.UnauthorizedError) {
+				return err
+			}
+			err = credentials.ForgetToken(tokenPath, token.Email)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	finalSurgeApp, auth, err := freshAuth(ctx)
+	if err != nil {
+		return err
+	}
+	return f(finalSurgeApp, auth)
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+	"github.com/jcocozza/cassidy-connector/finalSurge/credentials"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+// the verdicts of the doctor
+const (
+	verdictCompatible = "compatible"
+	// only new fields, which are ignored
+	verdictWarnings = "compatible with warnings"
+	// fields are missing or changed type, so some values are probably coming back empty
+	verdictIncompatible = "incompatible"
+	verdictBroken       = "broken"
+)
+
+type doctorReport struct {
+	Verdict  string      `json:"verdict"`
+	Auth     string      `json:"auth"`
+	List     string      `json:"list"`
+	Workouts int         `json:"workouts"`
+	Drifts   []app.Drift `json:"drifts"`
+}
+
+func printDoctor(report doctorReport) {
+	fmt.Printf("authenticate: %s\n", report.Auth)
+	fmt.Printf("workout list: %s\n", report.List)
+	if len(report.Drifts) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ENDPOINT\tKIND\tPATH\tEXPECTED\tGOT")
+		for _, d := range report.Drifts {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Endpoint, d.Kind, d.Path, d.Expected, d.Got)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+	fmt.Printf("verdict: %s\n", report.Verdict)
+}
+
+// log in afresh (so the auth response is checked too) and list the last `days` days of workouts in strict mode.
+// the drifts are always reported, since a changed type can be what made a request fail
+func diagnose(ctx context.Context, finalSurgeApp *app.App, days int) doctorReport {
+	report := doctorReport{Verdict: verdictBroken, Drifts: []app.Drift{}}
+	auth, err := checkedAuth(ctx, finalSurgeApp)
+	if err != nil {
+		report.Auth = err.Error()
+		report.Drifts = finalSurgeApp.Drifts()
+		return report
+	}
+	report.Auth = "ok"
+	end := time.Now()
+	workouts, err := finalSurgeApp.GetActivities(ctx, auth.Data.Token, auth.Data.UserKey, end.AddDate(0, 0, -days), end)
+	// an answer final surge itself marks as failed is not compatible, whatever its shape
+	if err == nil && !workouts.Success {
+		err = fmt.Errorf("%w: %v", app.RequestFailedError, workouts.ErrorDescription)
+	}
+	report.Drifts = finalSurgeApp.Drifts()
+	if err != nil {
+		report.List = err.Error()
+		return report
+	}
+	report.List = "ok"
+	report.Workouts = len(workouts.Data)
+	report.Verdict = verdictCompatible
+	for _, d := range report.Drifts {
+		if d.Kind != app.DriftUnknownField {
+			report.Verdict = verdictIncompatible
+			break
+		}
+		report.Verdict = verdictWarnings
+	}
+	return report
+}
+
+var doctorDays int
+var doctorJSON bool
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "check that final surge still responds the way this tool expects",
+	Long:  "authenticate and list the last few days of workouts in strict mode, and report every unknown field, missing field and type change in the responses",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		// the drifts are in the report, so don't log them too
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		var report doctorReport
+		creds, err := credentials.Resolve(credentials.Credentials{Email: email, Password: password}, config, credentialsPath)
+		if err != nil {
+			report = doctorReport{Verdict: verdictBroken, Auth: err.Error(), Drifts: []app.Drift{}}
+		} else {
+			finalSurgeApp := app.NewApp(creds.Email, creds.Password)
+			finalSurgeApp.Strict = true
+			report = diagnose(context.TODO(), finalSurgeApp, doctorDays)
+		}
+
+		reportBytes, err := json.Marshal(report)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			err := utils.WriteOutput(outputPath, reportBytes)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+		if doctorJSON {
+			fmt.Println(string(reportBytes))
+			return
+		}
+		printDoctor(report)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().IntVar(&doctorDays, "days", 14, "the number of days of workouts to check")
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "output json instead of text")
+}
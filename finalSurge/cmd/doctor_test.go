@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+)
+
+func TestDiagnose_typeChange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Write([]byte(`{"success": true, "data": {"token": "token", "user_key": "user"}}`))
+		default:
+			// a number that became a string, which the list can't be decoded with
+			w.Write([]byte(`{"server_time": "2024-05-01T00:00:00Z", "success": true, "data": [{"key": "a", "workout_completion": "100%"}]}`))
+		}
+	}))
+	defer server.Close()
+	a := app.NewApp("email", "password")
+	a.BaseUrl = server.URL
+	a.Strict = true
+
+	report := diagnose(context.Background(), a, 14)
+	if report.Verdict != verdictBroken || report.Auth != "ok" || report.List == "ok" {
+		t.Errorf("diagnose() = %+v, want the list to have failed", report)
+	}
+	found := false
+	for _, d := range report.Drifts {
+		if d.Kind == app.DriftTypeChanged && d.Path == "data[].workout_completion" && d.Got == "string" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("diagnose() drifts = %v, want the type change that broke the list", report.Drifts)
+	}
+}
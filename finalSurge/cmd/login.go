@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/credentials"
+	"github.com/spf13/cobra"
+)
+
+var saveCredentials bool
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "authenticate and cache the token for later commands",
+	Long:  fmt.Sprintf("authenticate and cache the token for later commands. with --save, the credentials are also saved to an encrypted file (the passphrase comes from %s)", credentials.PassphraseEnv),
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		if saveCredentials && os.Getenv(credentials.PassphraseEnv) == "" {
+			fmt.Println(credentials.NoPassphraseError.Error())
+			return
+		}
+		_, auth, err := freshAuth(context.TODO())
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if saveCredentials {
+			creds, err := credentials.Resolve(credentials.Credentials{Email: email, Password: password}, config, credentialsPath)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			err = credentials.Save(credentialsPath, creds, os.Getenv(credentials.PassphraseEnv))
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			fmt.Printf("saved credentials to %s\n", credentialsPath)
+		}
+		fmt.Printf("logged in as %s %s (%s). token cached in %s\n", auth.Data.FirstName, auth.Data.LastName, auth.Data.Email, tokenPath)
+	},
+}
+
+var forgetCredentials bool
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "remove the cached token",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := credentials.RemoveToken(tokenPath)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if forgetCredentials {
+			err := os.Remove(credentialsPath)
+			if err != nil && !os.IsNotExist(err) {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+		fmt.Println("logged out")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(loginCmd)
+	RootCmd.AddCommand(logoutCmd)
+
+	loginCmd.Flags().BoolVar(&saveCredentials, "save", false, fmt.Sprintf("also save the credentials to the encrypted credentials file (needs %s)", credentials.PassphraseEnv))
+	logoutCmd.Flags().BoolVar(&forgetCredentials, "forget", false, "also remove the saved credentials file")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/jcocozza/cassidy-connector/finalSurge/credentials"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +16,13 @@ var outputPath string
 
 var email string
 var password string
+var athlete string
+var strict bool
+
+var configPath string
+var credentialsPath string
+var tokenPath string
+var config credentials.Config
 
 var RootCmd = &cobra.Command{
 	Use: "cassidy-final-surge",
@@ -24,9 +32,41 @@ var RootCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {},
 }
 
+// read the config, and default the credentials and token files to the home directory
+func initConfig() {
+	if configPath == "" {
+		path, err := credentials.HomePath(credentials.DefaultConfig)
+		cobra.CheckErr(err)
+		configPath = path
+	}
+	var err error
+	config, err = credentials.ReadConfig(configPath)
+	cobra.CheckErr(err)
+	if credentialsPath == "" {
+		credentialsPath = config.CredentialsPath
+	}
+	if credentialsPath == "" {
+		credentialsPath, err = credentials.HomePath(credentials.DefaultCredentialsFile)
+		cobra.CheckErr(err)
+	}
+	if tokenPath == "" {
+		tokenPath = config.TokenPath
+	}
+	if tokenPath == "" {
+		tokenPath, err = credentials.HomePath(credentials.DefaultTokenFile)
+		cobra.CheckErr(err)
+	}
+}
+
 func init() {
-	RootCmd.PersistentFlags().StringVar(&email, "email", "", "your final surge email")
-	RootCmd.PersistentFlags().StringVar(&password, "password", "", "your final surge password")
+	cobra.OnInitialize(initConfig)
+	RootCmd.PersistentFlags().StringVar(&configPath, "config", "", fmt.Sprintf("the config file. (default is $HOME/%s)", credentials.DefaultConfig))
+	RootCmd.PersistentFlags().StringVar(&credentialsPath, "credentials-path", "", fmt.Sprintf("the encrypted credentials file written by login --save. (default is $HOME/%s)", credentials.DefaultCredentialsFile))
+	RootCmd.PersistentFlags().StringVar(&tokenPath, "token-path", "", fmt.Sprintf("where the token is cached between commands. (default is $HOME/%s)", credentials.DefaultTokenFile))
+	RootCmd.PersistentFlags().StringVar(&email, "email", "", fmt.Sprintf("your final surge email (or set %s)", credentials.EmailEnv))
+	RootCmd.PersistentFlags().StringVar(&password, "password", "", fmt.Sprintf("your final surge password. prefer %s or login --save, flags end up in your shell history", credentials.PasswordEnv))
+	RootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "warn (on stderr) about any difference between final surge's responses and what this tool expects. see the doctor command")
+	RootCmd.PersistentFlags().StringVar(&athlete, "athlete", "", "for coaches: read the calendar of this athlete (user key, email or name) instead of your own. see the athletes command")
 
 	RootCmd.PersistentFlags().StringVarP(&outputPath, "path", "f", "", "the path to save successful output to. (will not write errors at this time)")
 }
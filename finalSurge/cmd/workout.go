@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+// everything final surge has on a single workout. parts the workout does not have are left out
+type workoutDetail struct {
+	Workout    app.Workout                    `json:"workout"`
+	Intervals  *app.WorkoutIntervalsResponse  `json:"intervals,omitempty"`
+	Structured *app.StructuredWorkoutResponse `json:"structured_workout,omitempty"`
+	Map        *app.WorkoutMapResponse        `json:"map,omitempty"`
+}
+
+var downloadPath string
+var getWorkout = &cobra.Command{
+	Use:   "workout [key]",
+	Short: "get a workout in full: intervals, structured workout and map",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.TODO()
+		var detail workoutDetail
+		err := withLogin(ctx, func(finalSurgeApp *app.App, auth *app.AuthResponse) error {
+			token := auth.Data.Token
+			userKey, err := athleteKey(ctx, finalSurgeApp, auth)
+			if err != nil {
+				return err
+			}
+
+			workout, err := finalSurgeApp.GetWorkout(ctx, token, userKey, args[0])
+			if err != nil {
+				return err
+			}
+			detail = workoutDetail{Workout: workout.Data}
+			if workout.Data.HasIntervals {
+				detail.Intervals, err = finalSurgeApp.GetWorkoutIntervals(ctx, token, userKey, args[0])
+				if err != nil {
+					return err
+				}
+			}
+			if workout.Data.HasStructuredWorkout {
+				detail.Structured, err = finalSurgeApp.GetStructuredWorkout(ctx, token, userKey, args[0])
+				if err != nil {
+					return err
+				}
+			}
+			if workout.Data.HasMap {
+				detail.Map, err = finalSurgeApp.GetWorkoutMap(ctx, token, userKey, args[0])
+				if err != nil {
+					return err
+				}
+			}
+
+			if downloadPath != "" {
+				path := downloadPath
+				// a directory gets the file under its workout key
+				if info, err := os.Stat(path); err == nil && info.IsDir() {
+					ext := workout.Data.DownloadFileExtension
+					if ext != "" && !strings.HasPrefix(ext, ".") {
+						ext = "." + ext
+					}
+					path = filepath.Join(path, workout.Data.Key+ext)
+				}
+				file, err := os.Create(path)
+				if err != nil {
+					return err
+				}
+				err = finalSurgeApp.DownloadWorkoutFile(ctx, token, userKey, workout.Data, file)
+				file.Close()
+				if err != nil {
+					os.Remove(path)
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		detailBytes, err := json.Marshal(detail)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			err := utils.WriteOutput(outputPath, detailBytes)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+		fmt.Println(string(detailBytes))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(getWorkout)
+
+	getWorkout.Flags().StringVar(&downloadPath, "download", "", "the path to save the original device file to. a directory gets <key><extension> (e.g. abc123.fit)")
+}
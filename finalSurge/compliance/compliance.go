@@ -0,0 +1,304 @@
+// Package compliance compares what a coach planned on the final surge calendar with what was done.
+//
+// Every activity of every workout is an entry: planned values are paired with actuals, and the entry is flagged
+// as completed, missed, shortened or extended (and off pace, if the pace was outside the planned band). Entries are rolled up by week.
+package compliance
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+)
+
+// The status of an entry
+type Status string
+
+const (
+	// done, within the planned range
+	StatusCompleted Status = "completed"
+	// planned, in the past and not done
+	StatusMissed Status = "missed"
+	// done, but less than planned
+	StatusShortened Status = "shortened"
+	// done, but more than planned
+	StatusExtended Status = "extended"
+	// done, with nothing planned
+	StatusUnplanned Status = "unplanned"
+	// planned, and not due yet
+	StatusUpcoming Status = "upcoming"
+	// nothing planned and nothing done (e.g. a rest day or a note). not counted in the week's totals
+	StatusRest Status = "rest"
+)
+
+// The pace of an entry compared with the planned band
+type PaceStatus string
+
+const (
+	PaceInBand PaceStatus = "in band"
+	PaceFast   PaceStatus = "fast"
+	PaceSlow   PaceStatus = "slow"
+)
+
+// defaults for `Options`
+const (
+	DefaultShortened = 0.8
+	DefaultExtended  = 1.2
+)
+
+type Options struct {
+	// an entry done at less than this fraction of the plan is shortened
+	Shortened float64
+	// an entry done at more than this fraction of the plan is extended
+	Extended float64
+	// seconds (per unit of pace) that the pace can be outside the band
+	PaceTolerance float64
+	// planned entries before this day that were not done are missed. after it, they are upcoming
+	Today time.Time
+}
+
+// An Entry pairs a planned activity with what was done
+type Entry struct {
+	WorkoutKey   string    `json:"workout_key"`
+	Name         string    `json:"name"`
+	Date         time.Time `json:"date"`
+	ActivityType string    `json:"activity_type"`
+	// seconds
+	PlannedDuration float64 `json:"planned_duration"`
+	Duration        float64 `json:"duration"`
+	// normalized by final surge, so planned and actual amounts can be compared whatever their units
+	PlannedAmount float64 `json:"planned_amount"`
+	Amount        float64 `json:"amount"`
+	// actual / planned, by amount if there is one, otherwise by duration. 0 if they can't be compared
+	Ratio  float64 `json:"ratio"`
+	Status Status  `json:"status"`
+	// seconds per unit of `PaceType`. the band is empty if no pace was planned
+	PaceLow    float64    `json:"pace_low,omitempty"`
+	PaceHigh   float64    `json:"pace_high,omitempty"`
+	Pace       float64    `json:"pace,omitempty"`
+	PaceType   string     `json:"pace_type,omitempty"`
+	PaceStatus PaceStatus `json:"pace_status,omitempty"`
+	// the completion percentage final surge reports for the workout
+	Completion int `json:"completion"`
+}
+
+// A Week of entries, starting on monday
+type Week struct {
+	Start     time.Time `json:"start"`
+	Planned   int       `json:"planned"`
+	Completed int       `json:"completed"`
+	Missed    int       `json:"missed"`
+	Shortened int       `json:"shortened"`
+	Extended  int       `json:"extended"`
+	Unplanned int       `json:"unplanned"`
+	Upcoming  int       `json:"upcoming"`
+	OffPace   int       `json:"off_pace"`
+	// seconds
+	PlannedDuration float64 `json:"planned_duration"`
+	Duration        float64 `json:"duration"`
+	// the percentage of due planned entries that were completed (or extended)
+	Compliance float64 `json:"compliance"`
+}
+
+type Report struct {
+	Entries []Entry `json:"entries"`
+	Weeks   []Week  `json:"weeks"`
+}
+
+// parse a pace: either a number of seconds, or "m:ss" (or "h:mm:ss"). returns 0 if it can't be parsed
+func parsePace(v interface{}) float64 {
+	switch p := v.(type) {
+	case float64:
+		return p
+	case string:
+		p = strings.TrimSpace(p)
+		if p == "" {
+			return 0
+		}
+		if !strings.Contains(p, ":") {
+			f, _ := strconv.ParseFloat(p, 64)
+			return f
+		}
+		seconds := 0.0
+		for _, part := range strings.Split(p, ":") {
+			n, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return 0
+			}
+			seconds = seconds*60 + n
+		}
+		return seconds
+	}
+	return 0
+}
+
+// the day of a workout. final surge gives the athlete's local date
+func workoutDay(date string) (time.Time, error) {
+	d, err := time.Parse("2006-01-02", date[:min(len(date), 10)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid workout date %q: %w", date, err)
+	}
+	return d, nil
+}
+
+// the monday of the week of `d`
+func weekStart(d time.Time) time.Time {
+	offset := (int(d.Weekday()) + 6) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// Compare the planned and actual values of every activity of every workout
+func Compare(workouts *app.WorkoutListResponse, opts Options) (Report, error) {
+	if opts.Shortened == 0 {
+		opts.Shortened = DefaultShortened
+	}
+	if opts.Extended == 0 {
+		opts.Extended = DefaultExtended
+	}
+	today := opts.Today
+	if today.IsZero() {
+		today = time.Now()
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	report := Report{Entries: []Entry{}, Weeks: []Week{}}
+	for _, w := range workouts.Data {
+		day, err := workoutDay(w.WorkoutDate)
+		if err != nil {
+			return Report{}, err
+		}
+		for _, a := range w.Activities {
+			entry := Entry{
+				WorkoutKey:      w.Key,
+				Name:            w.Name,
+				Date:            day,
+				ActivityType:    a.ActivityTypeName,
+				PlannedDuration: a.PlannedDuration,
+				Duration:        a.Duration,
+				PlannedAmount:   a.PlannedAmountNormalized,
+				Amount:          a.AmountNormalized,
+				Completion:      w.WorkoutCompletion,
+			}
+			// not every response has the normalized amounts. the raw ones are comparable if the units match
+			if entry.PlannedAmount == 0 {
+				entry.PlannedAmount, entry.Amount = a.PlannedAmount, 0
+				if a.PlannedAmountType == a.AmountType {
+					entry.Amount = a.Amount
+				}
+			}
+			entry.Status = status(&entry, today, opts)
+
+			low, high := parsePace(a.PlannedPaceLow), parsePace(a.PlannedPaceHigh)
+			pace := parsePace(a.PaceDisplay)
+			if pace == 0 {
+				pace = a.Pace
+			}
+			if low > 0 || high > 0 {
+				// which end of the band is "low" depends on whether it means slow or a low number. sort them
+				if high == 0 {
+					high = low
+				}
+				if low == 0 {
+					low = high
+				}
+				entry.PaceLow, entry.PaceHigh = min(low, high), max(low, high)
+				entry.PaceType = a.PaceType
+			}
+			if pace > 0 && entry.PaceLow > 0 {
+				entry.Pace = pace
+				switch {
+				case pace < entry.PaceLow-opts.PaceTolerance:
+					entry.PaceStatus = PaceFast
+				case pace > entry.PaceHigh+opts.PaceTolerance:
+					entry.PaceStatus = PaceSlow
+				default:
+					entry.PaceStatus = PaceInBand
+				}
+			}
+			report.Entries = append(report.Entries, entry)
+		}
+	}
+	sort.SliceStable(report.Entries, func(i, j int) bool { return report.Entries[i].Date.Before(report.Entries[j].Date) })
+	report.Weeks = weeks(report.Entries)
+	return report, nil
+}
+
+// the status of an entry. sets its ratio
+func status(e *Entry, today time.Time, opts Options) Status {
+	planned := e.PlannedDuration > 0 || e.PlannedAmount > 0
+	done := e.Duration > 0 || e.Amount > 0
+	switch {
+	case !planned && done:
+		return StatusUnplanned
+	case !planned:
+		return StatusRest
+	case !done && e.Date.Before(today):
+		return StatusMissed
+	case !done:
+		return StatusUpcoming
+	}
+	switch {
+	case e.PlannedAmount > 0 && e.Amount > 0:
+		e.Ratio = e.Amount / e.PlannedAmount
+	case e.PlannedDuration > 0 && e.Duration > 0:
+		e.Ratio = e.Duration / e.PlannedDuration
+	default:
+		// done, but in a way that can't be compared with the plan (e.g. a planned distance, done without one)
+		return StatusCompleted
+	}
+	e.Ratio = float64(int(e.Ratio*1000+0.5)) / 1000
+	switch {
+	case e.Ratio < opts.Shortened:
+		return StatusShortened
+	case e.Ratio > opts.Extended:
+		return StatusExtended
+	}
+	return StatusCompleted
+}
+
+// roll entries up by week. entries are in date order
+func weeks(entries []Entry) []Week {
+	weeks := []Week{}
+	for _, e := range entries {
+		start := weekStart(e.Date)
+		if len(weeks) == 0 || !weeks[len(weeks)-1].Start.Equal(start) {
+			weeks = append(weeks, Week{Start: start})
+		}
+		w := &weeks[len(weeks)-1]
+		if e.Status == StatusRest {
+			continue
+		}
+		if e.Status != StatusUnplanned {
+			w.Planned++
+			w.PlannedDuration += e.PlannedDuration
+		}
+		w.Duration += e.Duration
+		switch e.Status {
+		case StatusCompleted:
+			w.Completed++
+		case StatusMissed:
+			w.Missed++
+		case StatusShortened:
+			w.Shortened++
+		case StatusExtended:
+			w.Extended++
+		case StatusUnplanned:
+			w.Unplanned++
+		case StatusUpcoming:
+			w.Upcoming++
+		}
+		if e.PaceStatus == PaceFast || e.PaceStatus == PaceSlow {
+			w.OffPace++
+		}
+	}
+	for i := range weeks {
+		w := &weeks[i]
+		if due := w.Planned - w.Upcoming; due > 0 {
+			w.Compliance = float64(int(float64(w.Completed+w.Extended)/float64(due)*1000+0.5)) / 10
+		}
+	}
+	return weeks
+}
@@ -0,0 +1,95 @@
+package compliance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+)
+
+// a week of planned (and mostly done) runs with a rest day, and a monday of the next week
+const workouts = `{"data": [
+	{"key": "done", "name": "easy", "workout_date": "2024-05-06T00:00:00", "Activities": [
+		{"activity_type_name": "Run", "planned_duration": 3600, "duration": 3500, "planned_pace_low": "5:30", "planned_pace_high": "5:00", "pace_display": "5:10"}]},
+	{"key": "short", "name": "long run", "workout_date": "2024-05-07T00:00:00", "Activities": [
+		{"activity_type_name": "Run", "planned_amount": 20, "planned_amount_type": "km", "planned_amount_normalized": 20000, "amount": 12, "amount_type": "km", "amount_normalized": 12000, "duration": 4000}]},
+	{"key": "long", "name": "recovery", "workout_date": "2024-05-08T00:00:00", "Activities": [
+		{"activity_type_name": "Run", "planned_duration": 1800, "duration": 2700, "planned_pace_low": 330, "planned_pace_high": 360, "pace": 290}]},
+	{"key": "missed", "name": "tempo", "workout_date": "2024-05-09T00:00:00", "Activities": [
+		{"activity_type_name": "Run", "planned_amount": 10, "planned_amount_type": "km"}]},
+	{"key": "extra", "name": "shakeout", "workout_date": "2024-05-10T00:00:00", "Activities": [
+		{"activity_type_name": "Run", "duration": 1200, "amount": 3, "amount_type": "km"}]},
+	{"key": "rest", "name": "rest day", "workout_date": "2024-05-11T00:00:00", "Activities": [
+		{"activity_type_name": "Rest"}]},
+	{"key": "future", "name": "intervals", "workout_date": "2024-05-13T00:00:00", "Activities": [
+		{"activity_type_name": "Run", "planned_duration": 3600}]}
+]}`
+
+func TestCompare(t *testing.T) {
+	var list app.WorkoutListResponse
+	if err := json.Unmarshal([]byte(workouts), &list); err != nil {
+		t.Fatal(err)
+	}
+	report, err := Compare(&list, Options{Today: time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	want := []struct {
+		key   string
+		s     Status
+		ratio float64
+		pace  PaceStatus
+	}{
+		{"done", StatusCompleted, 0.972, PaceInBand},
+		{"short", StatusShortened, 0.6, ""},
+		{"long", StatusExtended, 1.5, PaceFast},
+		{"missed", StatusMissed, 0, ""},
+		{"extra", StatusUnplanned, 0, ""},
+		{"rest", StatusRest, 0, ""},
+		{"future", StatusUpcoming, 0, ""},
+	}
+	if len(report.Entries) != len(want) {
+		t.Fatalf("Compare() = %d entries, want %d", len(report.Entries), len(want))
+	}
+	for i, w := range want {
+		e := report.Entries[i]
+		if e.WorkoutKey != w.key || e.Status != w.s || e.Ratio != w.ratio || e.PaceStatus != w.pace {
+			t.Errorf("entry %d = %s %s %v %q, want %+v", i, e.WorkoutKey, e.Status, e.Ratio, e.PaceStatus, w)
+		}
+	}
+	if e := report.Entries[0]; e.PaceLow != 300 || e.PaceHigh != 330 || e.Pace != 310 {
+		t.Errorf("pace band = %v-%v, pace %v", e.PaceLow, e.PaceHigh, e.Pace)
+	}
+
+	if len(report.Weeks) != 2 {
+		t.Fatalf("Compare() = %d weeks, want 2", len(report.Weeks))
+	}
+	week := report.Weeks[0]
+	if !week.Start.Equal(time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)) || week.Planned != 4 || week.Unplanned != 1 || week.Missed != 1 || week.OffPace != 1 || week.Compliance != 50 {
+		t.Errorf("week 1 = %+v", week)
+	}
+	if next := report.Weeks[1]; next.Upcoming != 1 || next.Compliance != 0 {
+		t.Errorf("week 2 = %+v", next)
+	}
+}
+
+func TestParsePace(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want float64
+	}{
+		{"5:30", 330},
+		{"1:02:03", 3723},
+		{"275", 275},
+		{275.5, 275.5},
+		{"", 0},
+		{"fast", 0},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		if got := parsePace(tt.in); got != tt.want {
+			t.Errorf("parsePace(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
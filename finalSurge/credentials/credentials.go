@@ -0,0 +1,233 @@
+// Package credentials finds the final surge login of the user, and caches the token it gets.
+//
+// Credentials come from (in order): flags, the FINAL_SURGE_EMAIL and FINAL_SURGE_PASSWORD environment variables, the config file,
+// or an encrypted credentials file. The credentials file is encrypted with a passphrase (from FINAL_SURGE_PASSPHRASE) so it works the same on every os.
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// environment variables
+const (
+	EmailEnv      = "FINAL_SURGE_EMAIL"
+	PasswordEnv   = "FINAL_SURGE_PASSWORD"
+	PassphraseEnv = "FINAL_SURGE_PASSPHRASE"
+)
+
+// default files, in the home directory
+const (
+	DefaultConfig          = ".cassidy-connector-final-surge.json"
+	DefaultCredentialsFile = ".cassidy-connector-final-surge-credentials.json"
+	DefaultTokenFile       = ".cassidy-connector-final-surge-token.json"
+)
+
+// the number of pbkdf2 iterations used to turn the passphrase into a key
+const keyIterations = 200_000
+
+// if no email and password can be found anywhere, will throw this error
+var NoCredentialsError = errors.New("No final surge credentials. pass --email and --password, set FINAL_SURGE_EMAIL and FINAL_SURGE_PASSWORD, or run login --save")
+
+// if the credentials file needs a passphrase and there is none, will throw this error
+var NoPassphraseError = errors.New("The credentials file is encrypted. set FINAL_SURGE_PASSPHRASE")
+
+// if the credentials file can't be decrypted (usually a wrong passphrase), will throw this error
+var DecryptError = errors.New("Failed to decrypt the credentials file (wrong passphrase?)")
+
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (c Credentials) complete() bool {
+	return c.Email != "" && c.Password != ""
+}
+
+// The config file. Every field is optional.
+type Config struct {
+	Email string `json:"email"`
+	// storing the password here is possible, but the credentials file is safer
+	Password        string `json:"password"`
+	CredentialsPath string `json:"credentials_path"`
+	TokenPath       string `json:"token_path"`
+}
+
+// Read a config file. A missing file is an empty config.
+func ReadConfig(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// The path of a file in the home directory
+func HomePath(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, name), nil
+}
+
+// The email of the account the user asked for: the first one in `flags`, the environment and `config`.
+// Empty if only the credentials file has it.
+func Email(flags Credentials, config Config) string {
+	for _, email := range []string{flags.Email, os.Getenv(EmailEnv), config.Email} {
+		if email != "" {
+			return email
+		}
+	}
+	return ""
+}
+
+// Find the credentials of the user. Each source fills in what the ones before it are missing:
+// `flags`, then the environment, then `config`, then the credentials file at `credentialsPath` (if it exists).
+func Resolve(flags Credentials, config Config, credentialsPath string) (Credentials, error) {
+	creds := flags
+	fill := func(c Credentials) {
+		if creds.Email == "" {
+			creds.Email = c.Email
+		}
+		if creds.Password == "" {
+			creds.Password = c.Password
+		}
+	}
+	fill(Credentials{Email: os.Getenv(EmailEnv), Password: os.Getenv(PasswordEnv)})
+	fill(Credentials{Email: config.Email, Password: config.Password})
+	if !creds.complete() && credentialsPath != "" {
+		if _, err := os.Stat(credentialsPath); err == nil {
+			stored, err := Load(credentialsPath, os.Getenv(PassphraseEnv))
+			if err != nil {
+				return Credentials{}, err
+			}
+			fill(stored)
+		}
+	}
+	if !creds.complete() {
+		return Credentials{}, NoCredentialsError
+	}
+	return creds, nil
+}
+
+// pbkdf2 with hmac-sha256 (RFC 8018)
+func deriveKey(passphrase string, salt []byte, iterations, size int) []byte {
+	prf := hmac.New(sha256.New, []byte(passphrase))
+	key := []byte{}
+	for block := uint32(1); len(key) < size; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:size]
+}
+
+// the credentials file: the credentials as json, sealed with aes-gcm
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func gcm(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(passphrase, salt, iterations, 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Save credentials to an encrypted file (only readable by the user)
+func Save(path string, creds Credentials, passphrase string) error {
+	if passphrase == "" {
+		return NoPassphraseError
+	}
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	f := encryptedFile{Version: 1, Iterations: keyIterations, Salt: make([]byte, 16)}
+	_, err = rand.Read(f.Salt)
+	if err != nil {
+		return err
+	}
+	aead, err := gcm(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	_, err = rand.Read(f.Nonce)
+	if err != nil {
+		return err
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, plaintext, nil)
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Load credentials from an encrypted file
+func Load(path string, passphrase string) (Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var f encryptedFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("invalid credentials file %s: %w", path, err)
+	}
+	if passphrase == "" {
+		return Credentials{}, NoPassphraseError
+	}
+	aead, err := gcm(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return Credentials{}, DecryptError
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return Credentials{}, DecryptError
+	}
+	var creds Credentials
+	err = json.Unmarshal(plaintext, &creds)
+	if err != nil {
+		return Credentials{}, DecryptError
+	}
+	return creds, nil
+}
@@ -0,0 +1,155 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+)
+
+func TestDeriveKey(t *testing.T) {
+	// pbkdf2-hmac-sha256 test vectors
+	tests := []struct {
+		iterations int
+		want       string
+	}{
+		{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(deriveKey("password", []byte("salt"), tt.iterations, 32)); got != tt.want {
+			t.Errorf("deriveKey(%d iterations) = %s, want %s", tt.iterations, got, tt.want)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	creds := Credentials{Email: "coach@example.com", Password: "hunter2"}
+	if err := Save(path, creds, ""); err != NoPassphraseError {
+		t.Errorf("Save() without a passphrase error = %v, want %v", err, NoPassphraseError)
+	}
+	if err := Save(path, creds, "correct horse"); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(path, "correct horse")
+	if err != nil || got != creds {
+		t.Errorf("Load() = %+v, %v", got, err)
+	}
+	if _, err := Load(path, "battery staple"); err != DecryptError {
+		t.Errorf("Load() with the wrong passphrase error = %v, want %v", err, DecryptError)
+	}
+	if _, err := Load(path, ""); err != NoPassphraseError {
+		t.Errorf("Load() without a passphrase error = %v, want %v", err, NoPassphraseError)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	stored := filepath.Join(dir, "credentials.json")
+	if err := Save(stored, Credentials{Email: "file@example.com", Password: "from file"}, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		flags      Credentials
+		env        Credentials
+		config     Config
+		passphrase string
+		want       Credentials
+		err        error
+	}{
+		{"flags win", Credentials{"flag@example.com", "from flag"}, Credentials{"env@example.com", "from env"}, Config{}, "secret", Credentials{"flag@example.com", "from flag"}, nil},
+		{"env", Credentials{}, Credentials{"env@example.com", "from env"}, Config{Email: "config@example.com"}, "", Credentials{"env@example.com", "from env"}, nil},
+		{"config email, file password", Credentials{}, Credentials{}, Config{Email: "config@example.com"}, "secret", Credentials{"config@example.com", "from file"}, nil},
+		{"file", Credentials{}, Credentials{}, Config{}, "secret", Credentials{"file@example.com", "from file"}, nil},
+		{"file without a passphrase", Credentials{}, Credentials{}, Config{}, "", Credentials{}, NoPassphraseError},
+		{"nothing", Credentials{Email: "flag@example.com"}, Credentials{}, Config{CredentialsPath: "unused"}, "", Credentials{}, NoCredentialsError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EmailEnv, tt.env.Email)
+			t.Setenv(PasswordEnv, tt.env.Password)
+			t.Setenv(PassphraseEnv, tt.passphrase)
+			path := stored
+			if tt.name == "nothing" {
+				path = filepath.Join(dir, "missing.json")
+			}
+			got, err := Resolve(tt.flags, tt.config, path)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("Resolve() = %+v, %v, want %+v, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	auth := &app.AuthResponse{ServerTime: now}
+	auth.Data.Token = "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1","exp":1714568400}`)) + ".signature"
+	auth.Data.UserKey = "user-1"
+	if got := NewToken(auth); !got.Expiry.Equal(time.Unix(1714568400, 0)) {
+		t.Errorf("NewToken(jwt) expiry = %v, want the exp claim", got.Expiry)
+	}
+	auth.Data.Token = "opaque"
+	token := NewToken(auth)
+	if !token.Expiry.Equal(now.Add(DefaultTokenLifetime)) {
+		t.Errorf("NewToken(opaque) expiry = %v, want %v", token.Expiry, now.Add(DefaultTokenLifetime))
+	}
+
+	path := filepath.Join(t.TempDir(), "token.json")
+	if _, err := LoadToken(path, "", now); err != NoTokenError {
+		t.Errorf("LoadToken() with no cache error = %v, want %v", err, NoTokenError)
+	}
+	token.Email = "Coach@example.com"
+	if err := SaveToken(path, token); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadToken(path, "coach@example.com", now.Add(time.Hour))
+	if err != nil || got.Token != "opaque" || got.AuthResponse().Data.UserKey != "user-1" {
+		t.Errorf("LoadToken() = %+v, %v", got, err)
+	}
+	// the only cached token does when no account is named
+	if got, err := LoadToken(path, "", now.Add(time.Hour)); err != nil || got.Token != "opaque" {
+		t.Errorf("LoadToken() with no email = %+v, %v", got, err)
+	}
+	// within a minute of expiring counts as expired
+	if _, err := LoadToken(path, "coach@example.com", now.Add(DefaultTokenLifetime-30*time.Second)); err != NoTokenError {
+		t.Errorf("LoadToken() of an expired token error = %v, want %v", err, NoTokenError)
+	}
+
+	// the cache is per account
+	if _, err := LoadToken(path, "athlete@example.com", now); err != NoTokenError {
+		t.Errorf("LoadToken() of another account error = %v, want %v", err, NoTokenError)
+	}
+	other := token
+	other.Token, other.Email = "other", "athlete@example.com"
+	if err := SaveToken(path, other); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadToken(path, "athlete@example.com", now); err != nil || got.Token != "other" {
+		t.Errorf("LoadToken() of the second account = %+v, %v", got, err)
+	}
+	if _, err := LoadToken(path, "", now); err != NoTokenError {
+		t.Errorf("LoadToken() with no email and two accounts error = %v, want %v", err, NoTokenError)
+	}
+	if err := ForgetToken(path, "athlete@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadToken(path, "athlete@example.com", now); err != NoTokenError {
+		t.Errorf("LoadToken() of a forgotten account error = %v, want %v", err, NoTokenError)
+	}
+	if got, err := LoadToken(path, "coach@example.com", now); err != nil || got.Token != "opaque" {
+		t.Errorf("LoadToken() after forgetting another account = %+v, %v", got, err)
+	}
+	if err := RemoveToken(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveToken(path); err != nil {
+		t.Errorf("RemoveToken() twice error = %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+)
+
+// final surge does not say when its tokens expire. unless the token says otherwise (see `NewToken`), assume they last this long
+const DefaultTokenLifetime = 12 * time.Hour
+
+// a token is treated as expired this long before it actually expires, so it does not expire mid command
+const expiryLeeway = time.Minute
+
+// if there is no cached token, or it has expired, will throw this error
+var NoTokenError = errors.New("No valid cached token")
+
+// A Token is a cached `app.AuthResponse`
+type Token struct {
+	Token     string    `json:"token"`
+	UserKey   string    `json:"user_key"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Expiry    time.Time `json:"expiry"`
+}
+
+// the expiry of a jwt, if the token is one
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// Cache an auth response. If the token is a jwt with an expiry, that is when it expires. Otherwise it expires `DefaultTokenLifetime` after the server time.
+func NewToken(auth *app.AuthResponse) Token {
+	t := Token{
+		Token:     auth.Data.Token,
+		UserKey:   auth.Data.UserKey,
+		FirstName: auth.Data.FirstName,
+		LastName:  auth.Data.LastName,
+		Email:     auth.Data.Email,
+	}
+	if exp, ok := jwtExpiry(t.Token); ok {
+		t.Expiry = exp
+		return t
+	}
+	issued := auth.ServerTime
+	if issued.IsZero() {
+		issued = time.Now()
+	}
+	t.Expiry = issued.Add(DefaultTokenLifetime)
+	return t
+}
+
+// Whether the token can still be used at `now`
+func (t Token) Valid(now time.Time) bool {
+	return t.Token != "" && now.Add(expiryLeeway).Before(t.Expiry)
+}
+
+// The token as an auth response, for the methods of `app.App`
+func (t Token) AuthResponse() *app.AuthResponse {
+	auth := &app.AuthResponse{Success: true}
+	auth.Data.Token = t.Token
+	auth.Data.UserKey = t.UserKey
+	auth.Data.FirstName = t.FirstName
+	auth.Data.LastName = t.LastName
+	auth.Data.Email = t.Email
+	return auth
+}
+
+// the cache holds a token per account, by lowercased email
+type tokenCache map[string]Token
+
+func tokenKey(email string) string {
+	return strings.ToLower(email)
+}
+
+// read the whole cache. a missing or unreadable cache is empty
+func readTokenCache(path string) (tokenCache, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokenCache{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache := tokenCache{}
+	if json.Unmarshal(data, &cache) != nil {
+		return tokenCache{}, nil
+	}
+	return cache, nil
+}
+
+// Write a token to the cache (only readable by the user), under its email. The tokens of other accounts are kept.
+func SaveToken(path string, t Token) error {
+	cache, err := readTokenCache(path)
+	if err != nil {
+		return err
+	}
+	cache[tokenKey(t.Email)] = t
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Read the cached token of the account with `email`. With no email, the only cached token is used (if there is exactly one).
+// Returns a `NoTokenError` if there is none, or it has expired.
+func LoadToken(path string, email string, now time.Time) (Token, error) {
+	cache, err := readTokenCache(path)
+	if err != nil {
+		return Token{}, err
+	}
+	var t Token
+	if email != "" {
+		t = cache[tokenKey(email)]
+	} else if len(cache) == 1 {
+		for _, only := range cache {
+			t = only
+		}
+	}
+	if !t.Valid(now) {
+		return Token{}, NoTokenError
+	}
+	return t, nil
+}
+
+// Remove the cached token of the account with `email`, keeping the others
+func ForgetToken(path string, email string) error {
+	cache, err := readTokenCache(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := cache[tokenKey(email)]; !ok {
+		return nil
+	}
+	delete(cache, tokenKey(email))
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Remove the cached tokens of every account. A missing cache is not an error.
+func RemoveToken(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
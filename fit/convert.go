@@ -0,0 +1,96 @@
+package fit
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/streamset"
+)
+
+// Convert the records into stream points
+func (f *File) Points() []streamset.Point {
+	points := make([]streamset.Point, 0, len(f.Records))
+	for _, r := range f.Records {
+		p := streamset.Point{Time: r.Timestamp}
+		if r.PositionLat != nil && r.PositionLong != nil {
+			p.LatLng = swagger.LatLng{float32(*r.PositionLat), float32(*r.PositionLong)}
+		}
+		if r.Altitude != nil {
+			v := float32(*r.Altitude)
+			p.Altitude = &v
+		}
+		if r.Distance != nil {
+			v := float32(*r.Distance)
+			p.Distance = &v
+		}
+		if r.Speed != nil {
+			v := float32(*r.Speed)
+			p.Speed = &v
+		}
+		if r.HeartRate != nil {
+			v := int32(*r.HeartRate)
+			p.Heartrate = &v
+		}
+		if r.Cadence != nil {
+			v := int32(*r.Cadence)
+			p.Cadence = &v
+		}
+		if r.Power != nil {
+			v := int32(*r.Power)
+			p.Watts = &v
+		}
+		if r.Temperature != nil {
+			v := int32(*r.Temperature)
+			p.Temp = &v
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// Convert the records into the streams that strava's `GetActivityStreams` returns
+func (f *File) StreamSet() *swagger.StreamSet {
+	return streamset.Build(f.Points())
+}
+
+// Convert the laps into strava's lap model.
+//
+// The start and end index of each lap point into the records (and so into the streams from `StreamSet`).
+func (f *File) StravaLaps() []swagger.Lap {
+	laps := make([]swagger.Lap, 0, len(f.Laps))
+	for i, l := range f.Laps {
+		lap := swagger.Lap{
+			Name:               fmt.Sprintf("Lap %d", i+1),
+			LapIndex:           int32(i + 1),
+			StartDate:          l.StartTime,
+			ElapsedTime:        int32(math.Round(l.TotalElapsedTime)),
+			MovingTime:         int32(math.Round(l.TotalTimerTime)),
+			Distance:           float32(l.TotalDistance),
+			AverageSpeed:       float32(l.AvgSpeed),
+			MaxSpeed:           float32(l.MaxSpeed),
+			AverageCadence:     float32(l.AvgCadence),
+			TotalElevationGain: float32(l.TotalAscent),
+			StartIndex:         -1,
+			EndIndex:           -1,
+		}
+		for j, r := range f.Records {
+			if r.Timestamp.Before(l.StartTime) {
+				continue
+			}
+			if !l.Timestamp.IsZero() && r.Timestamp.After(l.Timestamp) {
+				break
+			}
+			if lap.StartIndex == -1 {
+				lap.StartIndex = int32(j)
+			}
+			lap.EndIndex = int32(j)
+		}
+		if lap.StartIndex == -1 {
+			// no records in the lap
+			lap.StartIndex, lap.EndIndex = 0, 0
+		}
+		laps = append(laps, lap)
+	}
+	return laps
+}
@@ -0,0 +1,406 @@
+// Package fit is a pure go decoder for FIT (Flexible and Interoperable Data Transfer) activity files.
+//
+// FIT is the binary format that garmin (and most other devices) record activities in.
+// Only the messages that are useful for activities are decoded: file id, records, laps, sessions and device info.
+// Developer fields on records are decoded using the field descriptions in the file.
+//
+// See the FIT protocol (https://developer.garmin.com/fit/protocol/) for details of the format.
+package fit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// if the file is not a valid fit file, will throw this error (wrapped with the reason)
+var FormatError = errors.New("Invalid FIT file")
+
+// if a crc in the file does not match its contents, will throw this error
+var CRCError = errors.New("FIT file CRC mismatch")
+
+// fit timestamps are seconds since 1989-12-31 00:00:00 UTC
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// global message numbers
+const (
+	mesgFileID           = 0
+	mesgSession          = 18
+	mesgLap              = 19
+	mesgRecord           = 20
+	mesgDeviceInfo       = 23
+	mesgFieldDescription = 206
+)
+
+// the field number of the timestamp in every message that has one
+const fieldTimestamp = 253
+
+// base types (the low 5 bits of the base type byte)
+const (
+	baseEnum    = 0x00
+	baseSint8   = 0x01
+	baseUint8   = 0x02
+	baseSint16  = 0x03
+	baseUint16  = 0x04
+	baseSint32  = 0x05
+	baseUint32  = 0x06
+	baseString  = 0x07
+	baseFloat32 = 0x08
+	baseFloat64 = 0x09
+	baseUint8z  = 0x0A
+	baseUint16z = 0x0B
+	baseUint32z = 0x0C
+	baseByte    = 0x0D
+	baseSint64  = 0x0E
+	baseUint64  = 0x0F
+	baseUint64z = 0x10
+)
+
+var crcTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// the fit crc-16 of `data`, continuing from `crc`
+func crc16(crc uint16, data []byte) uint16 {
+	for _, b := range data {
+		tmp := crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[b&0xF]
+		tmp = crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[(b>>4)&0xF]
+	}
+	return crc
+}
+
+type fieldDef struct {
+	num      uint8
+	size     uint8
+	baseType uint8
+}
+
+type devFieldDef struct {
+	num      uint8
+	size     uint8
+	devIndex uint8
+}
+
+type definition struct {
+	global    uint16
+	bigEndian bool
+	fields    []fieldDef
+	devFields []devFieldDef
+}
+
+type field struct {
+	baseType  uint8
+	bigEndian bool
+	data      []byte
+}
+
+type devValue struct {
+	devIndex uint8
+	num      uint8
+	data     []byte
+}
+
+// a decoded data message
+type message struct {
+	global    uint16
+	bigEndian bool
+	fields    map[uint8]field
+	dev       []devValue
+}
+
+// the size in bytes of a single value of a base type
+func baseSize(baseType uint8) int {
+	switch baseType & 0x1F {
+	case baseSint16, baseUint16, baseUint16z:
+		return 2
+	case baseSint32, baseUint32, baseUint32z, baseFloat32:
+		return 4
+	case baseSint64, baseUint64, baseUint64z, baseFloat64:
+		return 8
+	default:
+		return 1
+	}
+}
+
+// decode the first value of a numeric field. returns false if the value is the invalid value of its type
+//
+// arrays only have their first element decoded
+func numeric(baseType uint8, bigEndian bool, data []byte) (float64, bool) {
+	size := baseSize(baseType)
+	if len(data) < size {
+		return 0, false
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	switch baseType & 0x1F {
+	case baseEnum, baseUint8, baseByte:
+		return float64(data[0]), data[0] != 0xFF
+	case baseUint8z:
+		return float64(data[0]), data[0] != 0
+	case baseSint8:
+		return float64(int8(data[0])), data[0] != 0x7F
+	case baseSint16:
+		v := order.Uint16(data)
+		return float64(int16(v)), v != 0x7FFF
+	case baseUint16:
+		v := order.Uint16(data)
+		return float64(v), v != 0xFFFF
+	case baseUint16z:
+		v := order.Uint16(data)
+		return float64(v), v != 0
+	case baseSint32:
+		v := order.Uint32(data)
+		return float64(int32(v)), v != 0x7FFFFFFF
+	case baseUint32:
+		v := order.Uint32(data)
+		return float64(v), v != 0xFFFFFFFF
+	case baseUint32z:
+		v := order.Uint32(data)
+		return float64(v), v != 0
+	case baseFloat32:
+		v := order.Uint32(data)
+		return float64(math.Float32frombits(v)), v != 0xFFFFFFFF
+	case baseFloat64:
+		v := order.Uint64(data)
+		return math.Float64frombits(v), v != 0xFFFFFFFFFFFFFFFF
+	case baseSint64:
+		v := order.Uint64(data)
+		return float64(int64(v)), v != 0x7FFFFFFFFFFFFFFF
+	case baseUint64:
+		v := order.Uint64(data)
+		return float64(v), v != 0xFFFFFFFFFFFFFFFF
+	case baseUint64z:
+		v := order.Uint64(data)
+		return float64(v), v != 0
+	}
+	return 0, false
+}
+
+// the numeric value of a field. returns false if the message does not have the field or it is invalid
+func (m *message) num(n uint8) (float64, bool) {
+	f, ok := m.fields[n]
+	if !ok || f.baseType&0x1F == baseString {
+		return 0, false
+	}
+	return numeric(f.baseType, f.bigEndian, f.data)
+}
+
+// the value of a field with the fit scale and offset applied: value / scale - offset
+func (m *message) scaled(n uint8, scale float64, offset float64) (float64, bool) {
+	v, ok := m.num(n)
+	if !ok {
+		return 0, false
+	}
+	return v/scale - offset, true
+}
+
+// the string value of a field. strings are null terminated
+func (m *message) str(n uint8) string {
+	f, ok := m.fields[n]
+	if !ok {
+		return ""
+	}
+	for i, b := range f.data {
+		if b == 0 {
+			return string(f.data[:i])
+		}
+	}
+	return string(f.data)
+}
+
+// the time value of a field
+func (m *message) time(n uint8) (time.Time, bool) {
+	v, ok := m.num(n)
+	if !ok {
+		return time.Time{}, false
+	}
+	return fitEpoch.Add(time.Duration(v) * time.Second), true
+}
+
+// a decoder for a single fit file (fit files can be chained one after the other)
+type decoder struct {
+	data []byte
+	pos  int
+	defs [16]*definition
+	// the last timestamp seen, for compressed timestamp headers
+	lastTimestamp uint32
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if d.pos+n > len(d.data) {
+		return nil, fmt.Errorf("%w: unexpected end of data", FormatError)
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) readDefinition(local uint8, hasDevFields bool) error {
+	head, err := d.read(5)
+	if err != nil {
+		return err
+	}
+	def := &definition{bigEndian: head[1] == 1}
+	if def.bigEndian {
+		def.global = binary.BigEndian.Uint16(head[2:4])
+	} else {
+		def.global = binary.LittleEndian.Uint16(head[2:4])
+	}
+	fields, err := d.read(int(head[4]) * 3)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fieldDef{num: fields[i], size: fields[i+1], baseType: fields[i+2]})
+	}
+	if hasDevFields {
+		count, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		devFields, err := d.read(int(count[0]) * 3)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(devFields); i += 3 {
+			def.devFields = append(def.devFields, devFieldDef{num: devFields[i], size: devFields[i+1], devIndex: devFields[i+2]})
+		}
+	}
+	d.defs[local] = def
+	return nil
+}
+
+func (d *decoder) readData(local uint8) (*message, error) {
+	def := d.defs[local]
+	if def == nil {
+		return nil, fmt.Errorf("%w: data message for undefined local message type %d", FormatError, local)
+	}
+	msg := &message{global: def.global, bigEndian: def.bigEndian, fields: map[uint8]field{}}
+	for _, fd := range def.fields {
+		b, err := d.read(int(fd.size))
+		if err != nil {
+			return nil, err
+		}
+		msg.fields[fd.num] = field{baseType: fd.baseType, bigEndian: def.bigEndian, data: b}
+	}
+	for _, dfd := range def.devFields {
+		b, err := d.read(int(dfd.size))
+		if err != nil {
+			return nil, err
+		}
+		msg.dev = append(msg.dev, devValue{devIndex: dfd.devIndex, num: dfd.num, data: b})
+	}
+	return msg, nil
+}
+
+// decode the messages of a single fit file starting at d.pos. returns the messages in order
+func (d *decoder) decodeFile() ([]*message, error) {
+	start := d.pos
+	header, err := d.read(12)
+	if err != nil {
+		return nil, err
+	}
+	headerSize := int(header[0])
+	if headerSize < 12 || string(header[8:12]) != ".FIT" {
+		return nil, fmt.Errorf("%w: bad header", FormatError)
+	}
+	if headerSize > 12 {
+		rest, err := d.read(headerSize - 12)
+		if err != nil {
+			return nil, err
+		}
+		// a header crc of 0 means it was not computed
+		if headerSize >= 14 {
+			headerCRC := binary.LittleEndian.Uint16(rest[0:2])
+			if headerCRC != 0 && headerCRC != crc16(0, header) {
+				return nil, fmt.Errorf("%w: header", CRCError)
+			}
+		}
+	}
+	dataSize := int(binary.LittleEndian.Uint32(header[4:8]))
+	end := d.pos + dataSize
+	if end+2 > len(d.data) {
+		return nil, fmt.Errorf("%w: file is shorter than its header says", FormatError)
+	}
+	fileCRC := binary.LittleEndian.Uint16(d.data[end : end+2])
+	if fileCRC != crc16(0, d.data[start:end]) {
+		return nil, CRCError
+	}
+	d.defs = [16]*definition{}
+	d.lastTimestamp = 0
+	messages := []*message{}
+	for d.pos < end {
+		h := d.data[d.pos]
+		d.pos++
+		switch {
+		case h&0x80 != 0:
+			// compressed timestamp header: the time is an offset from the last timestamp
+			local := (h >> 5) & 0x03
+			offset := uint32(h & 0x1F)
+			ts := (d.lastTimestamp &^ 0x1F) + offset
+			if offset < d.lastTimestamp&0x1F {
+				ts += 0x20
+			}
+			d.lastTimestamp = ts
+			msg, err := d.readData(local)
+			if err != nil {
+				return nil, err
+			}
+			tsBytes := make([]byte, 4)
+			binary.LittleEndian.PutUint32(tsBytes, ts)
+			msg.fields[fieldTimestamp] = field{baseType: baseUint32, data: tsBytes}
+			messages = append(messages, msg)
+		case h&0x40 != 0:
+			err := d.readDefinition(h&0x0F, h&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			msg, err := d.readData(h & 0x0F)
+			if err != nil {
+				return nil, err
+			}
+			if ts, ok := msg.num(fieldTimestamp); ok {
+				d.lastTimestamp = uint32(ts)
+			}
+			messages = append(messages, msg)
+		}
+	}
+	if d.pos != end {
+		return nil, fmt.Errorf("%w: last message runs past the end of the data", FormatError)
+	}
+	// skip the file crc
+	d.pos += 2
+	return messages, nil
+}
+
+// Decode a fit file. Chained fit files (several files one after the other) are decoded into a single `File`.
+func Decode(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &decoder{data: data}
+	f := &File{}
+	for d.pos < len(d.data) {
+		messages, err := d.decodeFile()
+		if err != nil {
+			return nil, err
+		}
+		f.add(messages)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty file", FormatError)
+	}
+	return f, nil
+}
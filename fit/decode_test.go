@@ -0,0 +1,178 @@
+package fit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func readTestFile(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecode_Run(t *testing.T) {
+	f, err := Decode(bytes.NewReader(readTestFile(t, "run.fit")))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if f.FileID.Type != 4 || f.FileID.Manufacturer != 1 || f.FileID.SerialNumber != 123456 {
+		t.Errorf("FileID = %+v", f.FileID)
+	}
+	if len(f.Records) != 10 {
+		t.Fatalf("Decode() = %d records, want 10", len(f.Records))
+	}
+	// the last 5 records use compressed timestamps, which roll over the 5 low bits
+	for i, r := range f.Records {
+		want := f.Records[0].Timestamp.Add(time.Duration(i) * time.Second)
+		if !r.Timestamp.Equal(want) {
+			t.Errorf("record %d timestamp = %v, want %v", i, r.Timestamp, want)
+		}
+	}
+	r := f.Records[1]
+	if *r.Altitude != 1601 || *r.Distance != 10 || *r.Speed != 3.5 || *r.Power != 251 || *r.HeartRate != 141 {
+		t.Errorf("record 1 = %+v", r)
+	}
+	if lat := *r.PositionLat; lat < 40.00009 || lat > 40.00011 {
+		t.Errorf("record 1 latitude = %v, want ~40.0001", lat)
+	}
+	if f.Records[2].HeartRate != nil {
+		t.Errorf("record 2 heart rate = %v, want nil (invalid value)", *f.Records[2].HeartRate)
+	}
+	if f.Records[7].DeveloperFields["form_power"] != 67 {
+		t.Errorf("record 7 developer fields = %v, want form_power 67", f.Records[7].DeveloperFields)
+	}
+	if len(f.Laps) != 2 || f.Laps[1].TotalDistance != 50 || f.Laps[1].MaxSpeed != 3.6 || f.Laps[1].TotalTimerTime != 4 {
+		t.Errorf("Laps = %+v", f.Laps)
+	}
+	// the session is defined big endian
+	if len(f.Sessions) != 1 || f.Sessions[0].Sport != SportRunning || f.Sessions[0].TotalDistance != 90 || f.Sessions[0].TotalCalories != 120 {
+		t.Errorf("Sessions = %+v", f.Sessions)
+	}
+	if len(f.Devices) != 1 || f.Devices[0].ProductName != "FR 945" || f.Devices[0].SoftwareVersion != 12.5 {
+		t.Errorf("Devices = %+v", f.Devices)
+	}
+}
+
+// a real recording (see testdata/README.md), rather than one written by generate.go
+func TestDecode_Fenix2(t *testing.T) {
+	f, err := Decode(bytes.NewReader(readTestFile(t, "fenix2-run.fit")))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if f.FileID.Type != 4 || f.FileID.Manufacturer != 1 || f.FileID.Product != 1967 || f.FileID.SerialNumber != 3882015183 {
+		t.Errorf("FileID = %+v", f.FileID)
+	}
+	if len(f.Records) != 2809 {
+		t.Fatalf("Decode() = %d records, want 2809", len(f.Records))
+	}
+	r := f.Records[0]
+	if !r.Timestamp.Equal(time.Date(2015, 8, 15, 14, 45, 8, 0, time.UTC)) || *r.Altitude != 55 || *r.HeartRate != 69 || *r.Cadence != 56 || *r.Speed != 5.89 || *r.Temperature != 21 || r.Power != nil {
+		t.Errorf("record 0 = %+v", r)
+	}
+	if lat, long := *r.PositionLat, *r.PositionLong; lat < 58.95918 || lat > 58.95919 || long < 5.72883 || long > 5.72884 {
+		t.Errorf("record 0 position = %v, %v, want ~58.95918, 5.72884", lat, long)
+	}
+	if last := f.Records[2808]; *last.Distance != 9007.07 || *last.HeartRate != 117 {
+		t.Errorf("last record = %+v", last)
+	}
+	if len(f.Laps) != 4 || f.Laps[1].TotalDistance != 2987.88 || f.Laps[1].TotalElapsedTime != 836 || f.Laps[3].TotalTimerTime != 965.98 || f.Laps[3].MaxCadence != 96 {
+		t.Errorf("Laps = %+v", f.Laps)
+	}
+	// the watch did not record the averages of the session; they are invalid in the file
+	s := f.Sessions
+	if len(s) != 1 || s[0].Sport != SportRunning || s[0].TotalDistance != 9008.22 || s[0].TotalElapsedTime != 2832 || s[0].TotalCalories != 516 ||
+		s[0].AvgCadence != 81 || s[0].TotalAscent != 64 || s[0].TotalDescent != 61 || s[0].AvgHeartRate != 0 || s[0].AvgSpeed != 0 {
+		t.Errorf("Sessions = %+v", s)
+	}
+	streams := f.StreamSet()
+	if n := len(streams.Time.Data); n != 2809 || streams.Time.Data[n-1] != 2833 || len(streams.Latlng.Data) != 2809 {
+		t.Errorf("StreamSet() time = %d samples ending at %d, latlng = %d samples", n, streams.Time.Data[n-1], len(streams.Latlng.Data))
+	}
+}
+
+func TestDecode_Indoor(t *testing.T) {
+	f, err := Decode(bytes.NewReader(readTestFile(t, "indoor.fit")))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(f.Records) != 3 || f.Records[0].PositionLat != nil || f.Records[1].Power != nil || *f.Records[2].Temperature != -2 {
+		t.Errorf("Records = %+v", f.Records)
+	}
+	if len(f.Sessions) != 1 || f.Sessions[0].Sport != SportCycling || f.Sessions[0].AvgPower != 205 {
+		t.Errorf("Sessions = %+v", f.Sessions)
+	}
+}
+
+func TestDecode_Chained(t *testing.T) {
+	data := readTestFile(t, "indoor.fit")
+	f, err := Decode(bytes.NewReader(append(append([]byte{}, data...), data...)))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(f.Records) != 6 || len(f.Sessions) != 2 {
+		t.Errorf("Decode() = %d records and %d sessions, want 6 and 2", len(f.Records), len(f.Sessions))
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	data := readTestFile(t, "run.fit")
+	corrupt := append([]byte{}, data...)
+	corrupt[100] ^= 0xFF
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"empty", []byte{}, FormatError},
+		{"not fit", []byte("this is not a fit file at all"), FormatError},
+		{"truncated", data[:len(data)-10], FormatError},
+		{"corrupt", corrupt, CRCError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFile_StreamSet(t *testing.T) {
+	f, err := Decode(bytes.NewReader(readTestFile(t, "run.fit")))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	streams := f.StreamSet()
+	if len(streams.Time.Data) != 10 || streams.Time.Data[9] != 9 {
+		t.Errorf("time stream = %v", streams.Time.Data)
+	}
+	if streams.Distance.Data[9] != 90 || streams.Watts.Data[0] != 250 || streams.VelocitySmooth.Data[0] != 3.5 || streams.Latlng == nil {
+		t.Errorf("StreamSet() = %+v", streams)
+	}
+	// the dropout becomes 0
+	if streams.Heartrate.Data[2] != 0 {
+		t.Errorf("heart rate stream = %v", streams.Heartrate.Data)
+	}
+	if streams.Temp != nil {
+		t.Errorf("temperature stream should not be included when it was never recorded")
+	}
+
+	laps := f.StravaLaps()
+	if len(laps) != 2 {
+		t.Fatalf("StravaLaps() = %d laps, want 2", len(laps))
+	}
+	if laps[0].StartIndex != 0 || laps[0].EndIndex != 4 || laps[1].StartIndex != 5 || laps[1].EndIndex != 9 {
+		t.Errorf("StravaLaps() indexes = [%d,%d] [%d,%d]", laps[0].StartIndex, laps[0].EndIndex, laps[1].StartIndex, laps[1].EndIndex)
+	}
+	if laps[1].LapIndex != 2 || laps[1].ElapsedTime != 5 || laps[1].MovingTime != 4 || laps[1].Distance != 50 {
+		t.Errorf("StravaLaps() lap 2 = %+v", laps[1])
+	}
+}
@@ -0,0 +1,301 @@
+package fit
+
+import (
+	"time"
+)
+
+// Sport values of `Session.Sport` (the common ones)
+const (
+	SportGeneric  uint8 = 0
+	SportRunning  uint8 = 1
+	SportCycling  uint8 = 2
+	SportSwimming uint8 = 5
+	SportWalking  uint8 = 11
+	SportHiking   uint8 = 17
+	SportRowing   uint8 = 15
+)
+
+// A File is everything decoded from a fit file
+type File struct {
+	FileID            FileID
+	Records           []Record
+	Laps              []Lap
+	Sessions          []Session
+	Devices           []DeviceInfo
+	FieldDescriptions []FieldDescription
+}
+
+// FileID identifies the file and the device that created it
+type FileID struct {
+	// 4 is an activity
+	Type         uint8
+	Manufacturer uint16
+	Product      uint16
+	SerialNumber uint32
+	TimeCreated  time.Time
+}
+
+// A Record is a single sample of an activity. Values that were not recorded are nil.
+type Record struct {
+	Timestamp time.Time
+	// degrees
+	PositionLat  *float64
+	PositionLong *float64
+	// meters. the enhanced altitude is used when present
+	Altitude *float64
+	// beats per minute
+	HeartRate *uint8
+	// rotations (or strides) per minute
+	Cadence *uint8
+	// cumulative, in meters
+	Distance *float64
+	// meters per second. the enhanced speed is used when present
+	Speed *float64
+	// watts
+	Power *uint16
+	// celsius
+	Temperature *int8
+	// numeric developer fields (e.g. from a connect iq app), by field name
+	DeveloperFields map[string]float64
+}
+
+// A Lap is a summary of part of an activity. Values that were not recorded are 0.
+type Lap struct {
+	MessageIndex uint16
+	StartTime    time.Time
+	// the end of the lap
+	Timestamp time.Time
+	// seconds
+	TotalElapsedTime float64
+	// seconds, excluding pauses
+	TotalTimerTime float64
+	// meters
+	TotalDistance float64
+	// meters per second
+	AvgSpeed     float64
+	MaxSpeed     float64
+	AvgHeartRate uint8
+	MaxHeartRate uint8
+	AvgCadence   uint8
+	MaxCadence   uint8
+	AvgPower     uint16
+	MaxPower     uint16
+	// meters
+	TotalAscent  uint16
+	TotalDescent uint16
+}
+
+// A Session is a summary of a whole activity (or one sport of a multisport activity)
+type Session struct {
+	Lap
+	Sport         uint8
+	SubSport      uint8
+	TotalCalories uint16
+}
+
+// DeviceInfo describes a device (or sensor) that was used to record the activity
+type DeviceInfo struct {
+	Timestamp       time.Time
+	DeviceIndex     uint8
+	Manufacturer    uint16
+	Product         uint16
+	SerialNumber    uint32
+	SoftwareVersion float64
+	ProductName     string
+}
+
+// A FieldDescription describes a developer field
+type FieldDescription struct {
+	DeveloperDataIndex    uint8
+	FieldDefinitionNumber uint8
+	BaseType              uint8
+	FieldName             string
+	Units                 string
+}
+
+func ptrFloat(m *message, n uint8, scale float64, offset float64) *float64 {
+	v, ok := m.scaled(n, scale, offset)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// the value of a position field (in semicircles) in degrees
+func ptrDegrees(m *message, n uint8) *float64 {
+	v, ok := m.num(n)
+	if !ok {
+		return nil
+	}
+	deg := v * 180 / (1 << 31)
+	return &deg
+}
+
+func ptrUint8(m *message, n uint8) *uint8 {
+	v, ok := m.num(n)
+	if !ok {
+		return nil
+	}
+	u := uint8(v)
+	return &u
+}
+
+func ptrUint16(m *message, n uint8) *uint16 {
+	v, ok := m.num(n)
+	if !ok {
+		return nil
+	}
+	u := uint16(v)
+	return &u
+}
+
+func ptrInt8(m *message, n uint8) *int8 {
+	v, ok := m.num(n)
+	if !ok {
+		return nil
+	}
+	i := int8(v)
+	return &i
+}
+
+// the value of a field, or 0 if it is invalid
+func orZero(m *message, n uint8, scale float64) float64 {
+	v, _ := m.scaled(n, scale, 0)
+	return v
+}
+
+func orZeroTime(m *message, n uint8) time.Time {
+	t, _ := m.time(n)
+	return t
+}
+
+func decodeRecord(m *message, descriptions map[uint16]FieldDescription) Record {
+	r := Record{
+		Timestamp:    orZeroTime(m, fieldTimestamp),
+		PositionLat:  ptrDegrees(m, 0),
+		PositionLong: ptrDegrees(m, 1),
+		Altitude:     ptrFloat(m, 2, 5, 500),
+		HeartRate:    ptrUint8(m, 3),
+		Cadence:      ptrUint8(m, 4),
+		Distance:     ptrFloat(m, 5, 100, 0),
+		Speed:        ptrFloat(m, 6, 1000, 0),
+		Power:        ptrUint16(m, 7),
+		Temperature:  ptrInt8(m, 13),
+	}
+	if alt := ptrFloat(m, 78, 5, 500); alt != nil {
+		r.Altitude = alt
+	}
+	if speed := ptrFloat(m, 73, 1000, 0); speed != nil {
+		r.Speed = speed
+	}
+	for _, dv := range m.dev {
+		desc, ok := descriptions[uint16(dv.devIndex)<<8|uint16(dv.num)]
+		if !ok {
+			continue
+		}
+		v, ok := numeric(desc.BaseType, m.bigEndian, dv.data)
+		if !ok || desc.BaseType&0x1F == baseString {
+			continue
+		}
+		if r.DeveloperFields == nil {
+			r.DeveloperFields = map[string]float64{}
+		}
+		r.DeveloperFields[desc.FieldName] = v
+	}
+	return r
+}
+
+func decodeLap(m *message) Lap {
+	return Lap{
+		MessageIndex:     uint16(orZero(m, 254, 1)),
+		StartTime:        orZeroTime(m, 2),
+		Timestamp:        orZeroTime(m, fieldTimestamp),
+		TotalElapsedTime: orZero(m, 7, 1000),
+		TotalTimerTime:   orZero(m, 8, 1000),
+		TotalDistance:    orZero(m, 9, 100),
+		AvgSpeed:         orZero(m, 13, 1000),
+		MaxSpeed:         orZero(m, 14, 1000),
+		AvgHeartRate:     uint8(orZero(m, 15, 1)),
+		MaxHeartRate:     uint8(orZero(m, 16, 1)),
+		AvgCadence:       uint8(orZero(m, 17, 1)),
+		MaxCadence:       uint8(orZero(m, 18, 1)),
+		AvgPower:         uint16(orZero(m, 19, 1)),
+		MaxPower:         uint16(orZero(m, 20, 1)),
+		TotalAscent:      uint16(orZero(m, 21, 1)),
+		TotalDescent:     uint16(orZero(m, 22, 1)),
+	}
+}
+
+// sessions have the same fields as laps, but with different numbers
+func decodeSession(m *message) Session {
+	return Session{
+		Lap: Lap{
+			MessageIndex:     uint16(orZero(m, 254, 1)),
+			StartTime:        orZeroTime(m, 2),
+			Timestamp:        orZeroTime(m, fieldTimestamp),
+			TotalElapsedTime: orZero(m, 7, 1000),
+			TotalTimerTime:   orZero(m, 8, 1000),
+			TotalDistance:    orZero(m, 9, 100),
+			AvgSpeed:         orZero(m, 14, 1000),
+			MaxSpeed:         orZero(m, 15, 1000),
+			AvgHeartRate:     uint8(orZero(m, 16, 1)),
+			MaxHeartRate:     uint8(orZero(m, 17, 1)),
+			AvgCadence:       uint8(orZero(m, 18, 1)),
+			MaxCadence:       uint8(orZero(m, 19, 1)),
+			AvgPower:         uint16(orZero(m, 20, 1)),
+			MaxPower:         uint16(orZero(m, 21, 1)),
+			TotalAscent:      uint16(orZero(m, 22, 1)),
+			TotalDescent:     uint16(orZero(m, 23, 1)),
+		},
+		Sport:         uint8(orZero(m, 5, 1)),
+		SubSport:      uint8(orZero(m, 6, 1)),
+		TotalCalories: uint16(orZero(m, 11, 1)),
+	}
+}
+
+// add decoded messages to the file, in order
+func (f *File) add(messages []*message) {
+	// developer field descriptions by developer data index and field number
+	descriptions := map[uint16]FieldDescription{}
+	for _, fd := range f.FieldDescriptions {
+		descriptions[uint16(fd.DeveloperDataIndex)<<8|uint16(fd.FieldDefinitionNumber)] = fd
+	}
+	for _, m := range messages {
+		switch m.global {
+		case mesgFileID:
+			f.FileID = FileID{
+				Type:         uint8(orZero(m, 0, 1)),
+				Manufacturer: uint16(orZero(m, 1, 1)),
+				Product:      uint16(orZero(m, 2, 1)),
+				SerialNumber: uint32(orZero(m, 3, 1)),
+				TimeCreated:  orZeroTime(m, 4),
+			}
+		case mesgRecord:
+			f.Records = append(f.Records, decodeRecord(m, descriptions))
+		case mesgLap:
+			f.Laps = append(f.Laps, decodeLap(m))
+		case mesgSession:
+			f.Sessions = append(f.Sessions, decodeSession(m))
+		case mesgDeviceInfo:
+			f.Devices = append(f.Devices, DeviceInfo{
+				Timestamp:       orZeroTime(m, fieldTimestamp),
+				DeviceIndex:     uint8(orZero(m, 0, 1)),
+				Manufacturer:    uint16(orZero(m, 2, 1)),
+				SerialNumber:    uint32(orZero(m, 3, 1)),
+				Product:         uint16(orZero(m, 4, 1)),
+				SoftwareVersion: orZero(m, 5, 100),
+				ProductName:     m.str(27),
+			})
+		case mesgFieldDescription:
+			fd := FieldDescription{
+				DeveloperDataIndex:    uint8(orZero(m, 0, 1)),
+				FieldDefinitionNumber: uint8(orZero(m, 1, 1)),
+				BaseType:              uint8(orZero(m, 2, 1)),
+				FieldName:             m.str(3),
+				Units:                 m.str(8),
+			}
+			f.FieldDescriptions = append(f.FieldDescriptions, fd)
+			descriptions[uint16(fd.DeveloperDataIndex)<<8|uint16(fd.FieldDefinitionNumber)] = fd
+		}
+	}
+}
@@ -0,0 +1,225 @@
+//go:build ignore
+
+// Generates the sample fit files in this directory.
+//
+//	go run testdata/generate.go
+//
+// The files are small on purpose. Each one exercises a part of the protocol that the decoder has to handle:
+//   - run.fit: positions, developer fields, compressed timestamps (including the 5 bit rollover),
+//     a big endian definition, redefined local message types and a 14 byte header with a crc.
+//   - indoor.fit: no positions or distance, invalid values, and a 12 byte header.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"time"
+)
+
+var crcTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+func crc16(crc uint16, data []byte) uint16 {
+	for _, b := range data {
+		tmp := crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[b&0xF]
+		tmp = crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[(b>>4)&0xF]
+	}
+	return crc
+}
+
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+type encoder struct {
+	buf       bytes.Buffer
+	bigEndian map[byte]bool
+}
+
+func (e *encoder) order(local byte) binary.ByteOrder {
+	if e.bigEndian[local] {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// fields and devFields are {number, size, base type / developer data index}
+func (e *encoder) define(local byte, global uint16, bigEndian bool, fields [][3]byte, devFields [][3]byte) {
+	header := 0x40 | local
+	if len(devFields) > 0 {
+		header |= 0x20
+	}
+	e.buf.WriteByte(header)
+	e.buf.WriteByte(0)
+	arch := byte(0)
+	if bigEndian {
+		arch = 1
+	}
+	e.buf.WriteByte(arch)
+	g := make([]byte, 2)
+	if bigEndian {
+		binary.BigEndian.PutUint16(g, global)
+	} else {
+		binary.LittleEndian.PutUint16(g, global)
+	}
+	e.buf.Write(g)
+	e.buf.WriteByte(byte(len(fields)))
+	for _, f := range fields {
+		e.buf.Write(f[:])
+	}
+	if len(devFields) > 0 {
+		e.buf.WriteByte(byte(len(devFields)))
+		for _, f := range devFields {
+			e.buf.Write(f[:])
+		}
+	}
+	if e.bigEndian == nil {
+		e.bigEndian = map[byte]bool{}
+	}
+	e.bigEndian[local] = bigEndian
+}
+
+// values are uint8, int8, uint16, int16, uint32, int32 or string (null padded to its field size, given as a [n]byte)
+func (e *encoder) values(local byte, values ...interface{}) {
+	for _, v := range values {
+		switch v := v.(type) {
+		case []byte:
+			e.buf.Write(v)
+		default:
+			binary.Write(&e.buf, e.order(local), v)
+		}
+	}
+}
+
+func (e *encoder) data(local byte, values ...interface{}) {
+	e.buf.WriteByte(local)
+	e.values(local, values...)
+}
+
+func (e *encoder) compressed(local byte, timestamp uint32, values ...interface{}) {
+	e.buf.WriteByte(0x80 | local<<5 | byte(timestamp&0x1F))
+	e.values(local, values...)
+}
+
+func (e *encoder) file(headerSize int) []byte {
+	header := make([]byte, headerSize)
+	header[0] = byte(headerSize)
+	header[1] = 0x20
+	binary.LittleEndian.PutUint16(header[2:4], 2132)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(e.buf.Len()))
+	copy(header[8:12], ".FIT")
+	if headerSize == 14 {
+		binary.LittleEndian.PutUint16(header[12:14], crc16(0, header[:12]))
+	}
+	out := append(header, e.buf.Bytes()...)
+	crc := make([]byte, 2)
+	binary.LittleEndian.PutUint16(crc, crc16(0, out))
+	return append(out, crc...)
+}
+
+func str(s string, size int) []byte {
+	b := make([]byte, size)
+	copy(b, s)
+	return b
+}
+
+func semicircles(deg float64) int32 {
+	return int32(deg * (1 << 31) / 180)
+}
+
+func ts(t time.Time) uint32 {
+	return uint32(t.Sub(fitEpoch).Seconds())
+}
+
+func run() []byte {
+	e := &encoder{}
+	// the low 5 bits of the first timestamp are 26, so the compressed timestamps roll over
+	start := fitEpoch.Add(time.Duration(1_000_000_026) * time.Second)
+	t0 := ts(start)
+
+	e.define(0, 0, false, [][3]byte{{0, 1, 0x00}, {1, 2, 0x84}, {2, 2, 0x84}, {3, 4, 0x8C}, {4, 4, 0x86}}, nil)
+	e.data(0, uint8(4), uint16(1), uint16(3121), uint32(123456), t0)
+
+	e.define(1, 23, false, [][3]byte{{253, 4, 0x86}, {0, 1, 0x02}, {2, 2, 0x84}, {3, 4, 0x8C}, {4, 2, 0x84}, {5, 2, 0x84}, {27, 10, 0x07}}, nil)
+	e.data(1, t0, uint8(0), uint16(1), uint32(123456), uint16(3121), uint16(1250), str("FR 945", 10))
+
+	e.define(2, 207, false, [][3]byte{{3, 1, 0x02}}, nil)
+	e.data(2, uint8(0))
+	e.define(2, 206, false, [][3]byte{{0, 1, 0x02}, {1, 1, 0x02}, {2, 1, 0x02}, {3, 12, 0x07}, {8, 6, 0x07}}, nil)
+	e.data(2, uint8(0), uint8(0), uint8(0x84), str("form_power", 12), str("watts", 6))
+
+	recordFields := [][3]byte{{0, 4, 0x85}, {1, 4, 0x85}, {78, 4, 0x86}, {3, 1, 0x02}, {4, 1, 0x02}, {5, 4, 0x86}, {73, 4, 0x86}, {7, 2, 0x84}}
+	devFields := [][3]byte{{0, 2, 0}}
+	// normal records have a timestamp field
+	e.define(3, 20, false, append([][3]byte{{253, 4, 0x86}}, recordFields...), devFields)
+	// compressed timestamp records do not
+	e.define(1, 20, false, recordFields, devFields)
+
+	record := func(i int) []interface{} {
+		hr := uint8(140 + i)
+		if i == 2 {
+			// a dropout
+			hr = 0xFF
+		}
+		return []interface{}{
+			semicircles(40 + float64(i)*0.0001),
+			semicircles(-105),
+			uint32((1600 + float64(i) + 500) * 5),
+			hr,
+			uint8(85),
+			uint32(i * 1000),
+			uint32(3500),
+			uint16(250 + i),
+			uint16(60 + i),
+		}
+	}
+	lapDef := [][3]byte{{254, 2, 0x84}, {253, 4, 0x86}, {2, 4, 0x86}, {7, 4, 0x86}, {8, 4, 0x86}, {9, 4, 0x86}, {13, 2, 0x84}, {14, 2, 0x84}, {15, 1, 0x02}, {16, 1, 0x02}, {17, 1, 0x02}, {21, 2, 0x84}}
+	for i := 0; i < 10; i++ {
+		t := t0 + uint32(i)
+		if i < 5 {
+			e.data(3, append([]interface{}{t}, record(i)...)...)
+		} else {
+			e.compressed(1, t, record(i)...)
+		}
+		if i == 4 || i == 9 {
+			lap := uint16(i / 5)
+			lapStart := t0 + uint32(lap)*5
+			e.define(2, 19, false, lapDef, nil)
+			e.data(2, lap, t, lapStart, uint32(5000), uint32(4000), uint32(5000), uint16(3500), uint16(3600), uint8(142), uint8(149), uint8(85), uint16(3))
+		}
+	}
+
+	// the session is big endian
+	e.define(2, 18, true, [][3]byte{{253, 4, 0x86}, {2, 4, 0x86}, {5, 1, 0x00}, {6, 1, 0x00}, {7, 4, 0x86}, {8, 4, 0x86}, {9, 4, 0x86}, {11, 2, 0x84}, {14, 2, 0x84}, {16, 1, 0x02}}, nil)
+	e.data(2, t0+9, t0, uint8(1), uint8(0), uint32(10000), uint32(9000), uint32(9000), uint16(120), uint16(3500), uint8(145))
+	return e.file(14)
+}
+
+func indoor() []byte {
+	e := &encoder{}
+	start := fitEpoch.Add(time.Duration(1_000_100_000) * time.Second)
+	t0 := ts(start)
+	e.define(0, 0, false, [][3]byte{{0, 1, 0x00}, {1, 2, 0x84}, {4, 4, 0x86}}, nil)
+	e.data(0, uint8(4), uint16(255), t0)
+	e.define(0, 20, false, [][3]byte{{253, 4, 0x86}, {3, 1, 0x02}, {7, 2, 0x84}, {13, 1, 0x01}}, nil)
+	for i := 0; i < 3; i++ {
+		power := uint16(200 + i*10)
+		if i == 1 {
+			power = 0xFFFF
+		}
+		e.data(0, t0+uint32(i), uint8(120+i), power, int8(-2))
+	}
+	e.define(1, 18, false, [][3]byte{{253, 4, 0x86}, {2, 4, 0x86}, {5, 1, 0x00}, {7, 4, 0x86}, {20, 2, 0x84}}, nil)
+	e.data(1, t0+2, t0, uint8(2), uint32(2000), uint16(205))
+	return e.file(12)
+}
+
+func main() {
+	os.WriteFile("testdata/run.fit", run(), 0644)
+	os.WriteFile("testdata/indoor.fit", indoor(), 0644)
+}
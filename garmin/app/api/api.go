@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/garmin/client"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/ratelimit"
+	"golang.org/x/oauth2"
+)
+
+// if the garmin api returns 404 not found, will throw this error
+var NotFoundError = errors.New("Object not found")
+
+// if the rate limiter throws an error
+var RateLimitError = errors.New("Rate Limit Error. (this likely means context expired while waiting for rate limits to be reset)")
+
+const (
+	// garmin does not publish limits for the connect api, so these are conservative
+	ReadLimitMinute         = 100.0
+	ReadLimitMinuteDuration = time.Duration(time.Minute)
+	ReadLimitDaily          = 5000.0
+	ReadLimitDailyDuration  = time.Duration(24 * time.Hour)
+)
+
+// the most activities garmin will list at once
+const maxListLimit = 100
+
+// The GarminAPI struct is the primary means of interacting with the garmin api.
+//
+// It wraps the lower level `client` with token refreshing and rate limiting, and maps garmin activities into the strava models
+// (so it can be used anywhere the strava api can, e.g. the `sync` package).
+//
+// # Whenever possible, this will return the NotFoundError when the underlying garmin api returns a 404
+//
+// Make sure that every context has a timeout, otherwise the program will block until the rate limits refreshes.
+type GarminAPI struct {
+	baseURL       string
+	logger        *slog.Logger
+	oauth         *oauth2.Config
+	limiterMinute *ratelimit.FixedWindow
+	limiterDaily  *ratelimit.FixedWindow
+}
+
+func NewGarminAPI(baseURL string, cfg *oauth2.Config, logger *slog.Logger) *GarminAPI {
+	return &GarminAPI{
+		baseURL:       baseURL,
+		logger:        logger,
+		oauth:         cfg,
+		limiterMinute: ratelimit.NewFixedWindow(ReadLimitMinuteDuration, ReadLimitMinute),
+		limiterDaily:  ratelimit.NewFixedWindow(ReadLimitDailyDuration, ReadLimitDaily),
+	}
+}
+
+// check to see if the limits have been surpassed
+//
+// if you have exceeded the rate limit, will sleep until the next time interval
+//
+// ** should be called before every api call **
+func (api *GarminAPI) checkRateLimits(ctx context.Context) error {
+	err := api.limiterDaily.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed daily rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	err = api.limiterMinute.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed minute rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	return nil
+}
+
+// Return the number of requests remaining in the minute and daily windows respectively
+func (api *GarminAPI) RemainingRequests() (int, int) {
+	return api.limiterMinute.RequestsRemaining(), api.limiterDaily.RequestsRemaining()
+}
+
+// a client that authenticates with the token (refreshing it when it expires)
+func (api *GarminAPI) client(ctx context.Context, token *oauth2.Token) *client.Client {
+	return client.New(api.baseURL, oauth2.NewClient(ctx, api.oauth.TokenSource(ctx, token)))
+}
+
+// map the client's errors onto this package's
+func (api *GarminAPI) wrap(ctx context.Context, err error) error {
+	if errors.Is(err, client.NotFoundError) {
+		api.logger.DebugContext(ctx, "object not found")
+		return NotFoundError
+	}
+	api.logger.ErrorContext(ctx, "request failed", slog.String("error", err.Error()))
+	return err
+}
+
+// List garmin activities as garmin returns them (newest first). `start` is the number of activities to skip.
+//
+// `startDate` and `endDate` are optional and only the day is used (both days are included).
+func (api *GarminAPI) ListActivities(ctx context.Context, token *oauth2.Token, start, limit int, startDate, endDate *time.Time) ([]client.Activity, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "listing activities", slog.Int("start", start), slog.Int("limit", limit))
+	activities, err := api.client(ctx, token).ListActivities(ctx, start, limit, startDate, endDate)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return activities, nil
+}
+
+// list every activity around the days of `after` and `before` (either can be nil)
+//
+// garmin filters on the athlete's local day, which can be a day either side of the utc day, so the range is widened by a day
+func (api *GarminAPI) listRange(ctx context.Context, token *oauth2.Token, before, after *time.Time) ([]client.Activity, error) {
+	var startDate, endDate *time.Time
+	if after != nil {
+		d := after.AddDate(0, 0, -1)
+		startDate = &d
+	}
+	if before != nil {
+		d := before.AddDate(0, 0, 1)
+		endDate = &d
+	}
+	all := []client.Activity{}
+	for start := 0; ; start += maxListLimit {
+		activities, err := api.ListActivities(ctx, token, start, maxListLimit, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, activities...)
+		if len(activities) < maxListLimit {
+			return all, nil
+		}
+	}
+}
+
+// Get a page of activities, like strava's `GetActivitiesPage`.
+//
+// Activities are newest first, except when `after` is set, in which case they are oldest first (as strava does).
+//
+// Garmin only filters by day, so when `before` or `after` is set the whole range is listed and filtered here.
+// Without them, a page costs a single request.
+func (api *GarminAPI) GetActivitiesPage(ctx context.Context, token *oauth2.Token, page int, perPage int, before, after *time.Time) ([]swagger.SummaryActivity, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage <= 0 {
+		return []swagger.SummaryActivity{}, nil
+	}
+	if before == nil && after == nil {
+		activities, err := api.ListActivities(ctx, token, (page-1)*perPage, perPage, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]swagger.SummaryActivity, len(activities))
+		for i, a := range activities {
+			summaries[i] = toSummary(a)
+		}
+		return summaries, nil
+	}
+	activities, err := api.listRange(ctx, token, before, after)
+	if err != nil {
+		return nil, err
+	}
+	filtered := []swagger.SummaryActivity{}
+	for _, a := range activities {
+		start := a.Start()
+		if before != nil && !start.Before(*before) {
+			continue
+		}
+		if after != nil && !start.After(*after) {
+			continue
+		}
+		filtered = append(filtered, toSummary(a))
+	}
+	slices.SortStableFunc(filtered, func(a, b swagger.SummaryActivity) int {
+		if after != nil {
+			return a.StartDate.Compare(b.StartDate)
+		}
+		return b.StartDate.Compare(a.StartDate)
+	})
+	start := (page - 1) * perPage
+	if start >= len(filtered) {
+		return []swagger.SummaryActivity{}, nil
+	}
+	return filtered[start:min(start+perPage, len(filtered))], nil
+}
+
+// Get a single garmin activity as garmin returns it
+func (api *GarminAPI) GetGarminActivity(ctx context.Context, token *oauth2.Token, activityID int64) (*client.ActivityDetail, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting activity", slog.Int64("activity id", activityID))
+	activity, err := api.client(ctx, token).GetActivity(ctx, activityID)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return activity, nil
+}
+
+// Get a single activity, like strava's `GetActivity`. `includeAllEfforts` is ignored (garmin has no segment efforts).
+func (api *GarminAPI) GetActivity(ctx context.Context, token *oauth2.Token, activityID int, includeAllEfforts bool) (*swagger.DetailedActivity, error) {
+	activity, err := api.GetGarminActivity(ctx, token, int64(activityID))
+	if err != nil {
+		return nil, err
+	}
+	return toDetailed(*activity), nil
+}
+
+// Download the file the device recorded an activity in. Returns the name of the file (e.g. 1234.fit).
+func (api *GarminAPI) DownloadOriginal(ctx context.Context, token *oauth2.Token, activityID int, w io.Writer) (string, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return "", err
+	}
+	api.logger.DebugContext(ctx, "downloading activity file", slog.Int("activity id", activityID))
+	name, err := api.client(ctx, token).DownloadOriginal(ctx, int64(activityID), w)
+	if err != nil {
+		return "", api.wrap(ctx, err)
+	}
+	return name, nil
+}
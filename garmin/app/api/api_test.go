@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/fakeoauth"
+	"github.com/jcocozza/cassidy-connector/garmin/fake"
+	stravaApi "github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	stravaSync "github.com/jcocozza/cassidy-connector/strava/sync"
+	"golang.org/x/oauth2"
+)
+
+var _ stravaSync.StravaAPI = (*GarminAPI)(nil)
+
+// start a demo server and go through the oauth flow
+func setup(t *testing.T) (*fake.Server, *GarminAPI, *oauth2.Token) {
+	t.Helper()
+	srv := fake.NewDemoServer("id", "secret")
+	tc := fakeoauth.NewTestConfig(t, srv, oauth2.Endpoint{AuthURL: "/oauth2/authorize", TokenURL: "/oauth2/token"})
+	return srv, NewGarminAPI(tc.URL, tc.Config, slog.New(slog.NewTextHandler(io.Discard, nil))), tc.Token
+}
+
+func TestOAuth(t *testing.T) {
+	srv := fake.NewDemoServer("id", "secret")
+	tc := fakeoauth.NewTestConfig(t, srv, oauth2.Endpoint{AuthURL: "/oauth2/authorize", TokenURL: "/oauth2/token"})
+	api := NewGarminAPI(tc.URL, tc.Config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	// an authorization code can only be used once
+	_, err := api.oauth.Exchange(ctx, "not a code")
+	if err == nil {
+		t.Errorf("Exchange(unknown code) error = nil, want an error")
+	}
+	tc.CheckRefresh(t, func(token *oauth2.Token) error {
+		_, err := api.GetActivitiesPage(ctx, token, 1, 1, nil, nil)
+		return err
+	})
+}
+
+func ids(activities []swagger.SummaryActivity) []int64 {
+	out := []int64{}
+	for _, a := range activities {
+		out = append(out, a.Id)
+	}
+	return out
+}
+
+func TestGetActivitiesPage(t *testing.T) {
+	_, api, token := setup(t)
+	ctx := context.Background()
+	day := func(s string) *time.Time {
+		d, _ := time.Parse(time.RFC3339, s)
+		return &d
+	}
+	tests := []struct {
+		name          string
+		page, perPage int
+		before, after *time.Time
+		want          []int64
+	}{
+		{"first page", 1, 2, nil, nil, []int64{14000000003, 14000000002}},
+		{"second page", 2, 2, nil, nil, []int64{14000000001}},
+		{"past the end", 3, 2, nil, nil, []int64{}},
+		// the strength session is on the 5th locally but the 6th in utc
+		{"after, oldest first", 1, 10, nil, day("2021-09-06T00:00:00Z"), []int64{14000000001, 14000000002, 14000000003}},
+		{"after, paged", 2, 2, nil, day("2021-09-06T00:00:00Z"), []int64{14000000003}},
+		{"before", 1, 10, day("2021-09-06T15:00:00Z"), nil, []int64{14000000001}},
+		{"between", 1, 10, day("2021-09-08T00:00:00Z"), day("2021-09-06T01:00:00Z"), []int64{14000000002}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activities, err := api.GetActivitiesPage(ctx, token, tt.page, tt.perPage, tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("GetActivitiesPage() error = %v", err)
+			}
+			got := ids(activities)
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetActivitiesPage() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("GetActivitiesPage() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetActivity(t *testing.T) {
+	_, api, token := setup(t)
+	ctx := context.Background()
+	ride, err := api.GetActivity(ctx, token, 14000000002, false)
+	if err != nil {
+		t.Fatalf("GetActivity() error = %v", err)
+	}
+	start, _ := time.Parse(time.RFC3339, "2021-09-06T15:00:00Z")
+	if ride.Name != "Morning Ride" || *ride.SportType != swagger.RIDE_SportType || !ride.StartDate.Equal(start) {
+		t.Errorf("GetActivity() = %q %v %v, want Morning Ride, Ride, %v", ride.Name, *ride.SportType, ride.StartDate, start)
+	}
+	if ride.Distance != 40000 || ride.MovingTime != 5300 || ride.ElapsedTime != 5700 || ride.TotalElevationGain != 420 {
+		t.Errorf("GetActivity() totals = %v %v %v %v", ride.Distance, ride.MovingTime, ride.ElapsedTime, ride.TotalElevationGain)
+	}
+	if ride.AverageWatts != 180 || ride.WeightedAverageWatts != 195 || ride.DeviceName != "Edge 530" || ride.Description != "easy spin" {
+		t.Errorf("GetActivity() = %+v", ride)
+	}
+	run, err := api.GetActivity(ctx, token, int(fake.DemoRunID), false)
+	if err != nil {
+		t.Fatalf("GetActivity() error = %v", err)
+	}
+	if run.Map_ == nil || run.Map_.Polyline == "" || run.StartLatlng == nil || run.GearId != "b1c2d3e4" {
+		t.Errorf("GetActivity() map = %+v, start = %v, gear = %q", run.Map_, run.StartLatlng, run.GearId)
+	}
+	_, err = api.GetActivity(ctx, token, 1, false)
+	if !errors.Is(err, NotFoundError) {
+		t.Errorf("GetActivity(unknown) error = %v, want NotFoundError", err)
+	}
+}
+
+func TestDownloadAndStreams(t *testing.T) {
+	_, api, token := setup(t)
+	ctx := context.Background()
+	var buf bytes.Buffer
+	name, err := api.DownloadOriginal(ctx, token, int(fake.DemoRunID), &buf)
+	if err != nil {
+		t.Fatalf("DownloadOriginal() error = %v", err)
+	}
+	if name != "14000000003.fit" || buf.Len() == 0 {
+		t.Errorf("DownloadOriginal() = %q (%d bytes)", name, buf.Len())
+	}
+	// the strength session has no file
+	_, err = api.DownloadOriginal(ctx, token, 14000000001, io.Discard)
+	if !errors.Is(err, NotFoundError) {
+		t.Errorf("DownloadOriginal(no file) error = %v, want NotFoundError", err)
+	}
+	streams, err := api.GetActivityStreams(ctx, token, int(fake.DemoRunID), []stravaApi.StreamType{stravaApi.Time, stravaApi.Heartrate})
+	if err != nil {
+		t.Fatalf("GetActivityStreams() error = %v", err)
+	}
+	if streams.Time == nil || len(streams.Time.Data) != 10 || streams.Heartrate == nil || streams.Distance != nil {
+		t.Errorf("GetActivityStreams() = %+v, want only 10 times and heartrate", streams)
+	}
+}
+
+func TestSync(t *testing.T) {
+	_, api, token := setup(t)
+	sink := stravaSync.MemorySink{}
+	syncer := stravaSync.NewSyncer(api, stravaSync.NewFileCheckpointStore(t.TempDir()), sink, nil)
+	report, err := syncer.Sync(context.Background(), token, 0)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Created != 3 || len(sink) != 3 {
+		t.Errorf("Sync() = %+v, want 3 created", report)
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/jcocozza/cassidy-connector/garmin/client"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// map a garmin activity type key onto a strava sport type
+func sportType(typeKey string) swagger.SportType {
+	switch typeKey {
+	case "running", "street_running", "track_running", "treadmill_running", "indoor_running":
+		return swagger.RUN_SportType
+	case "trail_running":
+		return swagger.TRAIL_RUN_SportType
+	case "virtual_run":
+		return swagger.VIRTUAL_RUN_SportType
+	case "cycling", "road_biking", "indoor_cycling", "track_cycling", "cyclocross":
+		return swagger.RIDE_SportType
+	case "gravel_cycling":
+		return swagger.GRAVEL_RIDE_SportType
+	case "mountain_biking":
+		return swagger.MOUNTAIN_BIKE_RIDE_SportType
+	case "virtual_ride":
+		return swagger.VIRTUAL_RIDE_SportType
+	case "e_bike_fitness", "e_bike_mountain":
+		return swagger.E_BIKE_RIDE_SportType
+	case "lap_swimming", "open_water_swimming", "swimming":
+		return swagger.SWIM_SportType
+	case "walking", "casual_walking", "speed_walking":
+		return swagger.WALK_SportType
+	case "hiking":
+		return swagger.HIKE_SportType
+	case "rowing", "indoor_rowing":
+		return swagger.ROWING_SportType
+	case "strength_training":
+		return swagger.WEIGHT_TRAINING_SportType
+	case "yoga":
+		return swagger.YOGA_SportType
+	case "pilates":
+		return swagger.PILATES_SportType
+	case "elliptical":
+		return swagger.ELLIPTICAL_SportType
+	case "cross_country_skiing_ws", "skate_skiing_ws":
+		return swagger.NORDIC_SKI_SportType
+	case "resort_skiing_snowboarding_ws":
+		return swagger.ALPINE_SKI_SportType
+	}
+	return swagger.WORKOUT_SportType
+}
+
+func latlng(lat, lng *float64) *swagger.LatLng {
+	if lat == nil || lng == nil {
+		return nil
+	}
+	return &swagger.LatLng{float32(*lat), float32(*lng)}
+}
+
+// map a listed garmin activity onto a strava summary
+func toSummary(a client.Activity) swagger.SummaryActivity {
+	sport := sportType(a.ActivityType.TypeKey)
+	at := swagger.ActivityType(sport)
+	elapsed := a.ElapsedDuration
+	if elapsed == 0 {
+		elapsed = a.Duration
+	}
+	moving := a.MovingDuration
+	if moving == 0 {
+		moving = a.Duration
+	}
+	return swagger.SummaryActivity{
+		Id:                   a.ActivityID,
+		ExternalId:           strconv.FormatInt(a.ActivityID, 10),
+		Name:                 a.ActivityName,
+		Distance:             float32(a.Distance),
+		MovingTime:           int32(math.Round(moving)),
+		ElapsedTime:          int32(math.Round(elapsed)),
+		TotalElevationGain:   float32(a.ElevationGain),
+		ElevHigh:             float32(a.MaxElevation),
+		ElevLow:              float32(a.MinElevation),
+		Type_:                &at,
+		SportType:            &sport,
+		StartDate:            a.Start(),
+		StartDateLocal:       a.StartLocal(),
+		Timezone:             a.TimeZone,
+		StartLatlng:          latlng(a.StartLatitude, a.StartLongitude),
+		EndLatlng:            latlng(a.EndLatitude, a.EndLongitude),
+		Trainer:              a.Indoor(),
+		Manual:               a.Manual,
+		AverageSpeed:         float32(a.AverageSpeed),
+		MaxSpeed:             float32(a.MaxSpeed),
+		AverageWatts:         float32(a.AveragePower),
+		DeviceWatts:          a.AveragePower > 0,
+		MaxWatts:             int32(math.Round(a.MaxPower)),
+		WeightedAverageWatts: int32(math.Round(a.NormPower)),
+		Kilojoules:           float32(a.AveragePower * moving / 1000),
+	}
+}
+
+// map a single garmin activity onto a strava detailed activity
+func toDetailed(a client.ActivityDetail) *swagger.DetailedActivity {
+	// a detailed activity has every field of a summary
+	data, _ := json.Marshal(toSummary(a.Activity))
+	detailed := &swagger.DetailedActivity{}
+	json.Unmarshal(data, detailed)
+	detailed.Description = a.Description
+	detailed.Calories = float32(a.Calories)
+	detailed.DeviceName = a.DeviceName
+	if len(a.GearUUIDs) > 0 {
+		detailed.GearId = a.GearUUIDs[0]
+	}
+	if a.Polyline != "" {
+		detailed.Map_ = &swagger.PolylineMap{Id: strconv.FormatInt(a.ActivityID, 10), Polyline: a.Polyline}
+	}
+	return detailed
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+
+	stravaApi "github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/bulkexport"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"golang.org/x/oauth2"
+)
+
+// Get the streams of an activity, like strava's `GetActivityStreams`.
+//
+// Garmin has no stream endpoint, so the original file is downloaded and decoded (see `bulkexport.DecodeStreams`).
+// Only the streams in `keys` that the file has are returned.
+func (api *GarminAPI) GetActivityStreams(ctx context.Context, token *oauth2.Token, activityID int, keys []stravaApi.StreamType) (*swagger.StreamSet, error) {
+	var buf bytes.Buffer
+	name, err := api.DownloadOriginal(ctx, token, activityID, &buf)
+	if err != nil {
+		return nil, err
+	}
+	streams, err := bulkexport.DecodeStreams(name, &buf)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed to decode activity file", slog.String("file", name), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return stravaApi.FilterStreams(streams, keys), nil
+}
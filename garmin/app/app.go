@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"github.com/jcocozza/cassidy-connector/garmin/app/api"
+	"github.com/jcocozza/cassidy-connector/garmin/client"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authorizePath = "/oauth2/authorize"
+	tokenPath     = "/oauth2/token"
+)
+
+// An app is a way of interacting with a garmin connect style api.
+//
+// Everything (oauth included) lives under `BaseURL`. Point it at the fake server (see the `fake` package) to develop without a garmin developer account.
+type App struct {
+	logger       *slog.Logger
+	ClientId     string
+	ClientSecret string
+	RedirectURL  string
+	BaseURL      string
+	// OAuthConfig handles OAuth and creates the HTTPClient that is used to make requests
+	OAuthConfig *oauth2.Config
+	// This is where the data methods are called from.
+	Api *api.GarminAPI
+}
+
+func NewApp(clientId string, clientSecret string, redirectURL string, baseURL string, logger *slog.Logger) *App {
+	oauthCfg := &oauth2.Config{
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  baseURL + authorizePath,
+			TokenURL: baseURL + tokenPath,
+		},
+	}
+	if logger == nil {
+		logger = NoopLogger()
+	}
+	logger = logger.WithGroup("cassidy-garmin")
+	return &App{
+		logger:       logger,
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		BaseURL:      baseURL,
+		OAuthConfig:  oauthCfg,
+		Api:          api.NewGarminAPI(baseURL, oauthCfg, logger.WithGroup("api")),
+	}
+}
+
+// Return the approval url. `state` is sent back with the authorization code
+func (a *App) ApprovalUrl(state string) string {
+	return a.OAuthConfig.AuthCodeURL(state)
+}
+
+// This is for the FIRST TIME getting the access token.
+//
+// A user will grant permission to the app then will be redirected to the application's RedirectURL with an authorization code.
+// This code is used to get the user's access token.
+//
+// You are responsible for persisting user tokens
+func (a *App) GetAccessTokenFromAuthorizationCode(ctx context.Context, code string) (*oauth2.Token, error) {
+	a.logger.InfoContext(ctx, "getting access token from authorization code")
+	token, err := a.OAuthConfig.Exchange(ctx, code)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "token exchange failed", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return token, nil
+}
+
+// Load an oauth2 token from a .json file
+func (a *App) ReadTokenFromFile(tokenFilePath string) (*oauth2.Token, error) {
+	tokenData, err := os.ReadFile(tokenFilePath)
+	if err != nil {
+		return nil, err
+	}
+	var token oauth2.Token
+	err = json.Unmarshal(tokenData, &token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// The lower level client, authenticated with `token` (which is refreshed when it expires).
+//
+// None of the rate limiting or mapping of `Api` is done.
+func (a *App) Client(ctx context.Context, token *oauth2.Token) *client.Client {
+	return client.New(a.BaseURL, a.OAuthConfig.Client(ctx, token))
+}
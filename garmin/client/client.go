@@ -0,0 +1,126 @@
+// Package client is the lower level implementation of the garmin connect api: one method per endpoint, with no rate limiting or token handling.
+//
+// The http client passed to `New` is responsible for authentication (e.g. one from `oauth2.Config.Client`).
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	activitiesPath = "/activitylist-service/activities/search/activities"
+	activityPath   = "/activity-service/activity/%d"
+	downloadPath   = "/download-service/files/activity/%d"
+)
+
+// garmin dates (for filtering the activity list)
+const dateLayout = "2006-01-02"
+
+// if garmin returns 404 not found, will throw this error
+var NotFoundError = errors.New("Object not found")
+
+// if garmin returns any other non 200 status, will throw this error (wrapped with the status)
+var StatusError = errors.New("Unexpected status")
+
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{BaseURL: baseURL, HTTPClient: httpClient}
+}
+
+// send a GET and return the response. the caller closes the body
+func (c *Client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, NotFoundError
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("%w: %s", StatusError, resp.Status)
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	resp, err := c.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// List activities, newest first. `start` is the number of activities to skip.
+// `startDate` and `endDate` (both days included) are optional.
+func (c *Client) ListActivities(ctx context.Context, start, limit int, startDate, endDate *time.Time) ([]Activity, error) {
+	query := url.Values{}
+	query.Set("start", strconv.Itoa(start))
+	query.Set("limit", strconv.Itoa(limit))
+	if startDate != nil {
+		query.Set("startDate", startDate.Format(dateLayout))
+	}
+	if endDate != nil {
+		query.Set("endDate", endDate.Format(dateLayout))
+	}
+	activities := []Activity{}
+	err := c.getJSON(ctx, activitiesPath, query, &activities)
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// Get a single activity
+func (c *Client) GetActivity(ctx context.Context, activityID int64) (*ActivityDetail, error) {
+	var activity ActivityDetail
+	err := c.getJSON(ctx, fmt.Sprintf(activityPath, activityID), nil, &activity)
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// Download the file the device recorded an activity in (usually fit). Returns the file name garmin gives it.
+func (c *Client) DownloadOriginal(ctx context.Context, activityID int64, w io.Writer) (string, error) {
+	resp, err := c.get(ctx, fmt.Sprintf(downloadPath, activityID), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	name := fmt.Sprintf("%d.fit", activityID)
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		name = params["filename"]
+	}
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to download activity file: %w", err)
+	}
+	return name, nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"strings"
+	"time"
+)
+
+// garmin times are "2006-01-02 15:04:05", in gmt or local time depending on the field
+const TimeLayout = "2006-01-02 15:04:05"
+
+type ActivityType struct {
+	TypeID int `json:"typeId"`
+	// e.g. "running", "road_biking" or "lap_swimming"
+	TypeKey      string `json:"typeKey"`
+	ParentTypeID int    `json:"parentTypeId"`
+	IsHidden     bool   `json:"isHidden"`
+	Restricted   bool   `json:"restricted"`
+	Trimmable    bool   `json:"trimmable"`
+}
+
+// An Activity as it is listed. Distances are in meters, durations in seconds and speeds in meters per second.
+type Activity struct {
+	ActivityID      int64        `json:"activityId"`
+	ActivityName    string       `json:"activityName"`
+	Description     string       `json:"description"`
+	StartTimeLocal  string       `json:"startTimeLocal"`
+	StartTimeGMT    string       `json:"startTimeGMT"`
+	ActivityType    ActivityType `json:"activityType"`
+	Distance        float64      `json:"distance"`
+	Duration        float64      `json:"duration"`
+	ElapsedDuration float64      `json:"elapsedDuration"`
+	MovingDuration  float64      `json:"movingDuration"`
+	ElevationGain   float64      `json:"elevationGain"`
+	ElevationLoss   float64      `json:"elevationLoss"`
+	MinElevation    float64      `json:"minElevation"`
+	MaxElevation    float64      `json:"maxElevation"`
+	AverageSpeed    float64      `json:"averageSpeed"`
+	MaxSpeed        float64      `json:"maxSpeed"`
+	AverageHR       float64      `json:"averageHR"`
+	MaxHR           float64      `json:"maxHR"`
+	AveragePower    float64      `json:"avgPower"`
+	MaxPower        float64      `json:"maxPower"`
+	NormPower       float64      `json:"normPower"`
+	Calories        float64      `json:"calories"`
+	StartLatitude   *float64     `json:"startLatitude"`
+	StartLongitude  *float64     `json:"startLongitude"`
+	EndLatitude     *float64     `json:"endLatitude"`
+	EndLongitude    *float64     `json:"endLongitude"`
+	DeviceID        int64        `json:"deviceId"`
+	Manual          bool         `json:"manualActivity"`
+	HasPolyline     bool         `json:"hasPolyline"`
+	// e.g. "America/Los_Angeles"
+	TimeZone string `json:"timeZoneId"`
+}
+
+// The start of the activity in utc. zero if garmin sent something that is not a time
+func (a Activity) Start() time.Time {
+	t, _ := time.Parse(TimeLayout, a.StartTimeGMT)
+	return t
+}
+
+// The start of the activity in the athlete's local time, as if it were utc (like strava's `start_date_local`)
+func (a Activity) StartLocal() time.Time {
+	t, _ := time.Parse(TimeLayout, a.StartTimeLocal)
+	return t
+}
+
+// Whether the activity was on a trainer or treadmill (garmin calls these indoor)
+func (a Activity) Indoor() bool {
+	key := a.ActivityType.TypeKey
+	return strings.HasPrefix(key, "indoor_") || strings.HasPrefix(key, "virtual_") || key == "treadmill_running"
+}
+
+// A single activity, with a few more details than the list has
+type ActivityDetail struct {
+	Activity
+	// the athlete's device, as garmin names it (e.g. "Forerunner 945")
+	DeviceName string `json:"deviceName"`
+	// the gear used, if any
+	GearUUIDs []string `json:"gearUUIDs"`
+	// the route, as a google encoded polyline
+	Polyline string `json:"polyline"`
+}
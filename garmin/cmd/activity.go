@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+var getActivity = &cobra.Command{
+	Use:   "activity [activity id]",
+	Short: "Get an activity by activity id. Expects an activity id.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		garminApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activityId, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activity, err := garminApp.Api.GetActivity(context.TODO(), tkn, activityId, false)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activityJsonBytes, err := json.Marshal(activity)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			utils.WriteOutput(outputPath, activityJsonBytes)
+		}
+		fmt.Println(string(activityJsonBytes))
+	},
+}
+
+var downloadDir string
+var downloadActivity = &cobra.Command{
+	Use:   "download [activity id]",
+	Short: "Download the original file of an activity (usually fit).",
+	Long:  "Download the file the device recorded an activity in. It is saved in --dir under the name garmin gives it (e.g. 1234.fit).",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		garminApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activityId, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		// the name is only known once the download starts, so write to a temporary file first
+		tmp, err := os.CreateTemp(downloadDir, ".download-*")
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		defer os.Remove(tmp.Name())
+		name, err := garminApp.Api.DownloadOriginal(context.TODO(), tkn, activityId, tmp)
+		tmp.Close()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		// temporary files are private, downloads should not be
+		os.Chmod(tmp.Name(), 0644)
+		path := filepath.Join(downloadDir, filepath.Base(name))
+		err = os.Rename(tmp.Name(), path)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(path)
+	},
+}
+
+func init() {
+	downloadActivity.Flags().StringVar(&downloadDir, "dir", ".", "the directory to save the file in")
+	tokenCmdGroup.AddCommand(getActivity)
+	tokenCmdGroup.AddCommand(downloadActivity)
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/jcocozza/cassidy-connector/garmin/app"
+	"golang.org/x/oauth2"
+)
+
+// Create the app based on the passed flag settings
+func createApp() (*app.App, *oauth2.Token, error) {
+	garminApp := app.NewApp(clientId, clientSecret, redirectURL, baseURL, nil)
+	if tokenPath == "" {
+		return garminApp, nil, nil
+	}
+	tkn, err := garminApp.ReadTokenFromFile(tokenPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return garminApp, tkn, nil
+}
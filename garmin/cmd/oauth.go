@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/jcocozza/cassidy-connector/garmin/fake"
+	"github.com/jcocozza/cassidy-connector/oauthcli"
+)
+
+// approval-url, initial-access and fake-server
+func init() {
+	oauthcli.AddOAuthCommands(RootCmd, oauthcli.Provider{
+		CreateApp: func() (oauthcli.App, error) {
+			garminApp, _, err := createApp()
+			if err != nil {
+				return nil, err
+			}
+			return garminApp, nil
+		},
+		OutputPath: &outputPath,
+	})
+	oauthcli.AddFakeServerCommand(RootCmd, oauthcli.FakeServer{
+		Platform:     "garmin",
+		API:          "the garmin api",
+		Demo:         "a few demo activities",
+		URLFlags:     "--base-url",
+		DefaultAddr:  defaultFakeAddr,
+		ClientID:     &clientId,
+		ClientSecret: &clientSecret,
+		NewDemoServer: func(clientID string, clientSecret string) http.Handler {
+			return fake.NewDemoServer(clientID, clientSecret)
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	version string = "0.0.1"
+	// where `fake-server` listens by default
+	defaultFakeAddr string = "localhost:8087"
+)
+
+// global app flag variables
+var tokenPath string
+var clientId string
+var clientSecret string
+var redirectURL string
+var baseURL string
+var outputPath string
+
+var RootCmd = &cobra.Command{
+	Use:     "cassidy-garmin",
+	Version: version,
+	Short:   "cassidy-garmin is a cli tool to interact with a Garmin Connect style API",
+	Long: `cassidy-garmin is a cli tool to interact with a Garmin Connect style API
+
+By default it talks to the local fake server (see 'cassidy-garmin fake-server'), so everything can be tried without a garmin developer account.`,
+	Run: func(cmd *cobra.Command, args []string) {},
+}
+
+var tokenCmdGroup = &cobra.Command{
+	Use:   "api",
+	Short: "all subcommands here require a token for authentication",
+	Run:   func(cmd *cobra.Command, args []string) {},
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&clientId, "client-id", "", "the client id of your garmin application")
+	RootCmd.PersistentFlags().StringVar(&clientSecret, "client-secret", "", "the client secret of your garmin application")
+	RootCmd.PersistentFlags().StringVar(&redirectURL, "redirect-url", "http://localhost/exchange_token", "the redirect url of your garmin application")
+	RootCmd.PersistentFlags().StringVar(&baseURL, "base-url", "http://"+defaultFakeAddr, "the base url of the api (oauth included)")
+	RootCmd.PersistentFlags().StringVarP(&outputPath, "path", "f", "", "the path to save successful output to. (will not write errors at this time)")
+	RootCmd.MarkFlagsRequiredTogether("client-id", "client-secret")
+	tokenCmdGroup.PersistentFlags().StringVar(&tokenPath, "token-path", "", "the path to a .json file that contains an OAuth2 token. This json must conform to the `oauth2.Token` struct found here: https://pkg.go.dev/golang.org/x/oauth2#Token.")
+	tokenCmdGroup.MarkPersistentFlagRequired("token-path")
+	RootCmd.AddCommand(tokenCmdGroup)
+}
+
+func Execute() {
+	if err := RootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,93 @@
+package fake
+
+import "github.com/jcocozza/cassidy-connector/garmin/client"
+
+// the demo activity that has a file (a short fit recording)
+const DemoRunID int64 = 14000000003
+
+func ptr(f float64) *float64 {
+	return &f
+}
+
+// A run (with a fit file), a ride and a strength session, newest first
+func DemoActivities() []client.ActivityDetail {
+	return []client.ActivityDetail{
+		{
+			Activity: client.Activity{
+				ActivityID:      DemoRunID,
+				ActivityName:    "Evening Run",
+				StartTimeLocal:  "2021-09-07 18:47:06",
+				StartTimeGMT:    "2021-09-08 01:47:06",
+				ActivityType:    client.ActivityType{TypeID: 1, TypeKey: "running", ParentTypeID: 17},
+				Distance:        90,
+				Duration:        10,
+				ElapsedDuration: 10,
+				MovingDuration:  9,
+				AverageSpeed:    3.5,
+				MaxSpeed:        3.6,
+				AverageHR:       145,
+				MaxHR:           150,
+				Calories:        120,
+				StartLatitude:   ptr(37.7749),
+				StartLongitude:  ptr(-122.4194),
+				EndLatitude:     ptr(37.7757),
+				EndLongitude:    ptr(-122.4194),
+				DeviceID:        3400000001,
+				HasPolyline:     true,
+				TimeZone:        "America/Los_Angeles",
+			},
+			DeviceName: "Forerunner 945",
+			GearUUIDs:  []string{"b1c2d3e4"},
+			Polyline:   "_ulcFjkbjV_@?",
+		},
+		{
+			Activity: client.Activity{
+				ActivityID:      14000000002,
+				ActivityName:    "Morning Ride",
+				Description:     "easy spin",
+				StartTimeLocal:  "2021-09-06 08:00:00",
+				StartTimeGMT:    "2021-09-06 15:00:00",
+				ActivityType:    client.ActivityType{TypeID: 10, TypeKey: "road_biking", ParentTypeID: 2},
+				Distance:        40000,
+				Duration:        5400,
+				ElapsedDuration: 5700,
+				MovingDuration:  5300,
+				ElevationGain:   420,
+				ElevationLoss:   418,
+				MinElevation:    12,
+				MaxElevation:    310,
+				AverageSpeed:    7.55,
+				MaxSpeed:        15.2,
+				AverageHR:       128,
+				MaxHR:           161,
+				AveragePower:    180,
+				MaxPower:        612,
+				NormPower:       195,
+				Calories:        950,
+				StartLatitude:   ptr(37.8044),
+				StartLongitude:  ptr(-122.2712),
+				EndLatitude:     ptr(37.8044),
+				EndLongitude:    ptr(-122.2712),
+				DeviceID:        3400000002,
+				TimeZone:        "America/Los_Angeles",
+			},
+			DeviceName: "Edge 530",
+		},
+		{
+			Activity: client.Activity{
+				ActivityID:      14000000001,
+				ActivityName:    "Strength",
+				StartTimeLocal:  "2021-09-05 17:30:00",
+				StartTimeGMT:    "2021-09-06 00:30:00",
+				ActivityType:    client.ActivityType{TypeID: 13, TypeKey: "strength_training", ParentTypeID: 29},
+				Duration:        2700,
+				ElapsedDuration: 2700,
+				AverageHR:       110,
+				MaxHR:           142,
+				Calories:        250,
+				Manual:          true,
+				TimeZone:        "America/Los_Angeles",
+			},
+		},
+	}
+}
@@ -0,0 +1,173 @@
+// Package fake is a local stand-in for the garmin connect api, so the garmin package can be developed and tested without network (or a garmin developer account).
+//
+// It implements the same oauth flow (see `fakeoauth`) and endpoints as `client`: activity listing, activity detail and original file download.
+//
+// A `Server` is an `http.Handler`, so it can be used with `httptest.NewServer` or served as is (see `cassidy-garmin fake-server`).
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/jcocozza/cassidy-connector/fakefiles"
+	"github.com/jcocozza/cassidy-connector/fakeoauth"
+	"github.com/jcocozza/cassidy-connector/garmin/client"
+)
+
+// An activity file, as it is downloaded
+type File struct {
+	// e.g. 1234.fit
+	Name string
+	Data []byte
+}
+
+// A Server pretends to be garmin connect. Tokens are handled by the embedded oauth server (e.g. `ExpireTokens`).
+type Server struct {
+	*fakeoauth.Server
+
+	mux *http.ServeMux
+	mu  sync.Mutex
+	// newest first
+	activities []client.ActivityDetail
+	files      map[int64]File
+}
+
+// Create a server with no activities. Only `clientID` and `clientSecret` can get tokens.
+func NewServer(clientID string, clientSecret string) *Server {
+	s := &Server{
+		Server: fakeoauth.New(clientID, clientSecret),
+		mux:    http.NewServeMux(),
+		files:  map[int64]File{},
+	}
+	s.mux.HandleFunc("GET /oauth2/authorize", s.Authorize)
+	s.mux.HandleFunc("POST /oauth2/token", s.Token)
+	s.mux.HandleFunc("GET /activitylist-service/activities/search/activities", s.Authenticated(s.listActivities))
+	s.mux.HandleFunc("GET /activity-service/activity/{id}", s.Authenticated(s.getActivity))
+	s.mux.HandleFunc("GET /download-service/files/activity/{id}", s.Authenticated(s.download))
+	return s
+}
+
+// Create a server with a few demo activities (see `DemoActivities`)
+func NewDemoServer(clientID string, clientSecret string) *Server {
+	s := NewServer(clientID, clientSecret)
+	for _, a := range DemoActivities() {
+		var file *File
+		if a.ActivityID == DemoRunID {
+			file = &File{Name: fmt.Sprintf("%d.fit", a.ActivityID), Data: fakefiles.RunFit}
+		}
+		s.AddActivity(a, file)
+	}
+	return s
+}
+
+// Add an activity. `file` is optional; activities without one cannot be downloaded.
+func (s *Server) AddActivity(activity client.ActivityDetail, file *File) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.activities = append(s.activities, activity)
+	slices.SortStableFunc(s.activities, func(a, b client.ActivityDetail) int {
+		return b.Start().Compare(a.Start())
+	})
+	if file != nil {
+		s.files[activity.ActivityID] = *file
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func intParam(q url.Values, key string, def int) (int, error) {
+	v := q.Get(key)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// activities filtered by their local day, newest first
+func (s *Server) listActivities(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	start, err := intParam(q, "start", 0)
+	if err != nil || start < 0 {
+		http.Error(w, "invalid start", http.StatusBadRequest)
+		return
+	}
+	limit, err := intParam(q, "limit", 20)
+	if err != nil || limit < 0 || limit > 100 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	startDate, endDate := q.Get("startDate"), q.Get("endDate")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	activities := []client.Activity{}
+	for _, a := range s.activities {
+		// "2006-01-02 15:04:05" sorts like the day it starts with
+		day := a.StartTimeLocal[:min(len(a.StartTimeLocal), 10)]
+		if (startDate != "" && day < startDate) || (endDate != "" && day > endDate) {
+			continue
+		}
+		activities = append(activities, a.Activity)
+	}
+	if start >= len(activities) {
+		activities = []client.Activity{}
+	} else {
+		activities = activities[start:min(start+limit, len(activities))]
+	}
+	writeJSON(w, http.StatusOK, activities)
+}
+
+// the activity the path refers to, or nil
+func (s *Server) find(r *http.Request) *client.ActivityDetail {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return nil
+	}
+	for i := range s.activities {
+		if s.activities[i].ActivityID == id {
+			return &s.activities[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) getActivity(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	activity := s.find(r)
+	if activity == nil {
+		http.Error(w, "activity not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, activity)
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	activity := s.find(r)
+	if activity == nil {
+		http.Error(w, "activity not found", http.StatusNotFound)
+		return
+	}
+	file, ok := s.files[activity.ActivityID]
+	if !ok {
+		http.Error(w, "activity has no file", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	w.Write(file.Data)
+}
@@ -0,0 +1,147 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// the example from google's polyline documentation
+const googleExample = "_p~iF~ps|U_ulLnnqC_mqNvxq`@"
+
+var googlePoints = []Point{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}
+
+func closeTo(a, b Point, within float64) bool {
+	return math.Abs(a.Lat-b.Lat) <= within && math.Abs(a.Lng-b.Lng) <= within
+}
+
+func TestDecode(t *testing.T) {
+	points, err := Decode(googleExample)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(points) != len(googlePoints) {
+		t.Fatalf("Decode() = %v, want %v", points, googlePoints)
+	}
+	for i := range points {
+		if !closeTo(points[i], googlePoints[i], 1e-9) {
+			t.Errorf("point %d = %v, want %v", i, points[i], googlePoints[i])
+		}
+	}
+	if points, err := Decode(""); err != nil || len(points) != 0 {
+		t.Errorf("Decode(\"\") = %v, %v, want no points", points, err)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []string{
+		// cut off in the middle of a value
+		"_p~iF~ps|U_ulLnnqC_mqNvxq",
+		// only a latitude
+		"_p~iF",
+		// a character outside of the encoding
+		"_p~iF~ps|U ",
+	}
+	for _, encoded := range tests {
+		_, err := Decode(encoded)
+		if !errors.Is(err, InvalidPolylineError) {
+			t.Errorf("Decode(%q) error = %v, want InvalidPolylineError", encoded, err)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	if got := Encode(googlePoints); got != googleExample {
+		t.Errorf("Encode() = %q, want %q", got, googleExample)
+	}
+	// precision 6 keeps 6 decimal places, even for longitudes past ±128
+	points := []Point{{-33.856784, 151.215297}, {-33.857001, 151.214998}}
+	decoded, err := DecodePrecision(EncodePrecision(points, 6), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range points {
+		if !closeTo(decoded[i], points[i], 1e-7) {
+			t.Errorf("point %d = %v, want %v", i, decoded[i], points[i])
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	// a straight line north with a 1m wobble, then a turn east
+	points := []Point{{40, -105}, {40.001, -105.00001}, {40.002, -105}, {40.003, -105}, {40.003, -104.999}}
+	simplified := Simplify(points, 5)
+	want := []Point{points[0], points[3], points[4]}
+	if len(simplified) != len(want) {
+		t.Fatalf("Simplify() = %v, want %v", simplified, want)
+	}
+	for i := range want {
+		if simplified[i] != want[i] {
+			t.Errorf("Simplify() = %v, want %v", simplified, want)
+		}
+	}
+	if got := Simplify(points, 0.1); len(got) != len(points) {
+		t.Errorf("Simplify(0.1) = %v, want every point", got)
+	}
+}
+
+func TestActivitiesFeatureCollection(t *testing.T) {
+	run := swagger.RUN_SportType
+	activities := []swagger.SummaryActivity{
+		{Id: 1, Name: "Morning Run", SportType: &run, Distance: 5000, Map_: &swagger.PolylineMap{SummaryPolyline: googleExample}},
+		// indoor activities have no map
+		{Id: 2, Name: "Treadmill", Map_: &swagger.PolylineMap{}},
+	}
+	collection, err := ActivitiesFeatureCollection(activities, 0)
+	if err != nil {
+		t.Fatalf("ActivitiesFeatureCollection() error = %v", err)
+	}
+	data, err := json.Marshal(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Type     string
+		Features []struct {
+			Type     string
+			ID       int
+			Geometry struct {
+				Type        string
+				Coordinates [][]float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "FeatureCollection" || len(got.Features) != 1 {
+		t.Fatalf("collection = %s", data)
+	}
+	f := got.Features[0]
+	if f.Type != "Feature" || f.ID != 1 || f.Geometry.Type != "LineString" || len(f.Geometry.Coordinates) != 3 {
+		t.Errorf("feature = %+v", f)
+	}
+	// geojson is [lng, lat]
+	if c := f.Geometry.Coordinates[0]; c[0] != -120.2 || c[1] != 38.5 {
+		t.Errorf("first coordinate = %v, want [-120.2 38.5]", c)
+	}
+	if f.Properties["name"] != "Morning Run" || f.Properties["sport_type"] != "Run" || f.Properties["distance"] != 5000.0 {
+		t.Errorf("properties = %v", f.Properties)
+	}
+}
+
+func TestStreamFeature(t *testing.T) {
+	stream := &swagger.LatLngStream{Data: []swagger.LatLng{{40, -105}}}
+	f := StreamFeature(stream, nil)
+	if f.Geometry == nil || f.Geometry.Type != "Point" || f.Properties == nil {
+		t.Errorf("StreamFeature() = %+v", f)
+	}
+	if f := StreamFeature(nil, nil); f.Geometry != nil {
+		t.Errorf("StreamFeature(nil) geometry = %+v, want nil", f.Geometry)
+	}
+}
@@ -0,0 +1,130 @@
+package geo
+
+import (
+	"fmt"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// A Geometry is a GeoJSON geometry. Only the types that an activity can have are produced: "LineString" and "Point".
+type Geometry struct {
+	Type string `json:"type"`
+	// [lng, lat] for a point, a list of them for a line string
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// A Feature is a GeoJSON feature
+type Feature struct {
+	Type string      `json:"type"`
+	ID   interface{} `json:"id,omitempty"`
+	// nil if there are no positions
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// A FeatureCollection is a GeoJSON feature collection
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+func NewFeatureCollection(features ...Feature) *FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return &FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// The geometry of a list of points. A single point is a "Point", more than one is a "LineString". Returns nil if there are no points.
+//
+// GeoJSON positions are [longitude, latitude], the opposite of strava.
+func NewGeometry(points []Point) *Geometry {
+	switch len(points) {
+	case 0:
+		return nil
+	case 1:
+		return &Geometry{Type: "Point", Coordinates: []float64{points[0].Lng, points[0].Lat}}
+	}
+	coordinates := make([][]float64, 0, len(points))
+	for _, p := range points {
+		coordinates = append(coordinates, []float64{p.Lng, p.Lat})
+	}
+	return &Geometry{Type: "LineString", Coordinates: coordinates}
+}
+
+func NewFeature(points []Point, properties map[string]interface{}) Feature {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return Feature{Type: "Feature", Geometry: NewGeometry(points), Properties: properties}
+}
+
+// A feature of the positions of a stream
+func StreamFeature(stream *swagger.LatLngStream, properties map[string]interface{}) Feature {
+	if stream == nil {
+		return NewFeature(nil, properties)
+	}
+	return NewFeature(FromLatLng(stream.Data), properties)
+}
+
+// Decode a map (of an activity, route or segment). The full polyline is used if it is there, otherwise the summary polyline.
+//
+// Returns no points if the map is nil or empty.
+func DecodeMap(m *swagger.PolylineMap) ([]Point, error) {
+	if m == nil {
+		return []Point{}, nil
+	}
+	encoded := m.Polyline
+	if encoded == "" {
+		encoded = m.SummaryPolyline
+	}
+	return Decode(encoded)
+}
+
+// The properties of an activity that are useful on a map
+func ActivityProperties(activity swagger.SummaryActivity) map[string]interface{} {
+	properties := map[string]interface{}{
+		"id":                   activity.Id,
+		"name":                 activity.Name,
+		"start_date":           activity.StartDate,
+		"distance":             activity.Distance,
+		"moving_time":          activity.MovingTime,
+		"elapsed_time":         activity.ElapsedTime,
+		"total_elevation_gain": activity.TotalElevationGain,
+	}
+	if activity.SportType != nil {
+		properties["sport_type"] = *activity.SportType
+	}
+	return properties
+}
+
+// A feature of an activity, shaped by its map
+func ActivityFeature(activity swagger.SummaryActivity) (Feature, error) {
+	points, err := DecodeMap(activity.Map_)
+	if err != nil {
+		return Feature{}, fmt.Errorf("activity %d: %w", activity.Id, err)
+	}
+	feature := NewFeature(points, ActivityProperties(activity))
+	feature.ID = activity.Id
+	return feature, nil
+}
+
+// A collection of activities, ready to be drawn on a map. Activities without a map (e.g. indoor or manual activities) are left out.
+//
+// If `tolerance` is more than 0, each line is simplified (see `Simplify`) to within that many meters.
+func ActivitiesFeatureCollection(activities []swagger.SummaryActivity, tolerance float64) (*FeatureCollection, error) {
+	collection := NewFeatureCollection()
+	for _, activity := range activities {
+		points, err := DecodeMap(activity.Map_)
+		if err != nil {
+			return nil, fmt.Errorf("activity %d: %w", activity.Id, err)
+		}
+		if len(points) == 0 {
+			continue
+		}
+		feature := NewFeature(Simplify(points, tolerance), ActivityProperties(activity))
+		feature.ID = activity.Id
+		collection.Features = append(collection.Features, feature)
+	}
+	return collection, nil
+}
@@ -0,0 +1,127 @@
+// Package geo works with the positions of activities, routes and segments.
+//
+// Strava returns the shape of an activity as an encoded polyline (see `swagger.PolylineMap`) and its positions as a `swagger.LatLngStream`.
+// This package decodes and encodes polylines, converts either of them into GeoJSON and simplifies them for display.
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// the precision strava (and google) use: 5 decimal places
+const DefaultPrecision = 5
+
+// if an encoded polyline is malformed, will throw this error
+var InvalidPolylineError = errors.New("Invalid encoded polyline")
+
+// A Point is a position in degrees.
+//
+// `swagger.LatLng` is a pair of float32s, which cannot hold 6 decimal places for longitudes past ±128, so positions are kept as float64s here.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Convert strava positions into points. Positions that are not a pair are skipped.
+func FromLatLng(latlngs []swagger.LatLng) []Point {
+	points := make([]Point, 0, len(latlngs))
+	for _, ll := range latlngs {
+		if len(ll) < 2 {
+			continue
+		}
+		points = append(points, Point{Lat: float64(ll[0]), Lng: float64(ll[1])})
+	}
+	return points
+}
+
+// Convert points into strava positions
+func ToLatLng(points []Point) []swagger.LatLng {
+	latlngs := make([]swagger.LatLng, 0, len(points))
+	for _, p := range points {
+		latlngs = append(latlngs, swagger.LatLng{float32(p.Lat), float32(p.Lng)})
+	}
+	return latlngs
+}
+
+// Decode a polyline encoded at the default precision
+func Decode(encoded string) ([]Point, error) {
+	return DecodePrecision(encoded, DefaultPrecision)
+}
+
+// Decode a polyline encoded with `precision` decimal places (e.g. 6 for OSRM and valhalla polylines)
+func DecodePrecision(encoded string, precision int) ([]Point, error) {
+	factor := math.Pow10(precision)
+	points := []Point{}
+	var lat, lng int64
+	for i := 0; i < len(encoded); {
+		var deltas [2]int64
+		for j := range deltas {
+			var result int64
+			var shift uint
+			for {
+				if i >= len(encoded) {
+					return nil, fmt.Errorf("%w: ends in the middle of a value", InvalidPolylineError)
+				}
+				b := int64(encoded[i]) - 63
+				i++
+				if b < 0 || b > 63 {
+					return nil, fmt.Errorf("%w: invalid character %q at %d", InvalidPolylineError, encoded[i-1], i-1)
+				}
+				if shift > 60 {
+					return nil, fmt.Errorf("%w: value is too long", InvalidPolylineError)
+				}
+				result |= (b & 0x1F) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[j] = ^(result >> 1)
+			} else {
+				deltas[j] = result >> 1
+			}
+		}
+		lat += deltas[0]
+		lng += deltas[1]
+		points = append(points, Point{Lat: float64(lat) / factor, Lng: float64(lng) / factor})
+	}
+	return points, nil
+}
+
+// Encode points as a polyline at the default precision
+func Encode(points []Point) string {
+	return EncodePrecision(points, DefaultPrecision)
+}
+
+// Encode points as a polyline with `precision` decimal places
+func EncodePrecision(points []Point, precision int) string {
+	factor := math.Pow10(precision)
+	var sb strings.Builder
+	var lastLat, lastLng int64
+	for _, p := range points {
+		lat := int64(math.Round(p.Lat * factor))
+		lng := int64(math.Round(p.Lng * factor))
+		encodeValue(&sb, lat-lastLat)
+		encodeValue(&sb, lng-lastLng)
+		lastLat, lastLng = lat, lng
+	}
+	return sb.String()
+}
+
+func encodeValue(sb *strings.Builder, v int64) {
+	u := v << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		sb.WriteByte(byte((0x20 | (u & 0x1F)) + 63))
+		u >>= 5
+	}
+	sb.WriteByte(byte(u + 63))
+}
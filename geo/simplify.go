@@ -0,0 +1,60 @@
+package geo
+
+import (
+	"math"
+
+	"github.com/jcocozza/cassidy-connector/streamset"
+)
+
+// the distance in meters from p to the segment a-b, on a local flat projection around a
+func segmentDistance(p, a, b Point) float64 {
+	cos := math.Cos(a.Lat * math.Pi / 180)
+	toXY := func(q Point) (float64, float64) {
+		return (q.Lng - a.Lng) * math.Pi / 180 * cos * streamset.EarthRadiusMeters, (q.Lat - a.Lat) * math.Pi / 180 * streamset.EarthRadiusMeters
+	}
+	px, py := toXY(p)
+	bx, by := toXY(b)
+	length := bx*bx + by*by
+	if length == 0 {
+		return math.Hypot(px, py)
+	}
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/length))
+	return math.Hypot(px-t*bx, py-t*by)
+}
+
+// Simplify a line with the Douglas-Peucker algorithm.
+//
+// Points are dropped as long as the simplified line stays within `tolerance` meters of the original. The first and last points are always kept.
+func Simplify(points []Point, tolerance float64) []Point {
+	if len(points) < 3 || tolerance <= 0 {
+		return points
+	}
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+	// an explicit stack, since gps tracks can be long enough to make recursion deep
+	stack := [][2]int{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		span := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		first, last := span[0], span[1]
+		farthest, maxDistance := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			d := segmentDistance(points[i], points[first], points[last])
+			if d > maxDistance {
+				farthest, maxDistance = i, d
+			}
+		}
+		if farthest == -1 {
+			continue
+		}
+		keep[farthest] = true
+		stack = append(stack, [2]int{first, farthest}, [2]int{farthest, last})
+	}
+	simplified := []Point{}
+	for i, p := range points {
+		if keep[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	return simplified
+}
@@ -5,8 +5,11 @@ go 1.23.2
 require (
 	github.com/jcocozza/ratelimit v0.0.0-20241007201339-f86eef901041
 	github.com/spf13/cobra v1.8.0
+	go.etcd.io/bbolt v1.3.11
 )
 
+require golang.org/x/sys v0.4.0 // indirect
+
 require (
 	github.com/antihax/optional v1.0.0
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -0,0 +1,198 @@
+// Package gpx decodes GPX 1.0 and 1.1 files.
+//
+// Heart rate, cadence and temperature are read from the garmin TrackPointExtension (v1 and v2).
+// XML namespaces are ignored, so the extension is found whatever prefix the file gives it.
+package gpx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/streamset"
+)
+
+// A File is a decoded gpx file
+type File struct {
+	// 1.0 or 1.1
+	Version string
+	// the program or device that created the file
+	Creator string
+	// the name of the first track (or the file's metadata name if the track has none)
+	Name string
+	// the file's creation time (may be zero)
+	Time   time.Time
+	Tracks []Track
+}
+
+// A Track is an ordered list of segments. A new segment starts whenever the device lost its fix or was paused.
+type Track struct {
+	Name string
+	// the activity type. this is free text (e.g. "running", "cycling", "9")
+	Type     string
+	Segments [][]TrackPoint
+}
+
+// A TrackPoint is a single sample. Values that were not recorded are nil.
+type TrackPoint struct {
+	Time      time.Time
+	Lat       float64
+	Lon       float64
+	Elevation *float64
+	// beats per minute
+	HeartRate *int
+	// rotations (or steps) per minute
+	Cadence *int
+	// celsius
+	Temperature *float64
+	// meters per second (gpx 1.0 <speed> or the v2 extension)
+	Speed *float64
+	// watts (a <power> extension, written by some apps)
+	Power *int
+}
+
+// the xml layout. 1.0 and 1.1 share everything except where metadata and extensions live
+type gpxXML struct {
+	Version  string `xml:"version,attr"`
+	Creator  string `xml:"creator,attr"`
+	Name     string `xml:"name"`          // 1.0
+	Time     string `xml:"time"`          // 1.0
+	MetaName string `xml:"metadata>name"` // 1.1
+	MetaTime string `xml:"metadata>time"` // 1.1
+	Tracks   []struct {
+		Name     string `xml:"name"`
+		Type     string `xml:"type"`
+		Segments []struct {
+			Points []pointXML `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type trackPointExtensionXML struct {
+	HR    *int     `xml:"hr"`
+	Cad   *int     `xml:"cad"`
+	Atemp *float64 `xml:"atemp"`
+	Wtemp *float64 `xml:"wtemp"`
+	Speed *float64 `xml:"speed"`
+}
+
+type pointXML struct {
+	Lat       float64  `xml:"lat,attr"`
+	Lon       float64  `xml:"lon,attr"`
+	Elevation *float64 `xml:"ele"`
+	Time      string   `xml:"time"`
+	// gpx 1.0 allows speed directly on the point
+	Speed      *float64 `xml:"speed"`
+	Extensions struct {
+		Power               *int                   `xml:"power"`
+		TrackPointExtension trackPointExtensionXML `xml:"TrackPointExtension"`
+	} `xml:"extensions"`
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339, strings.TrimSpace(s))
+}
+
+// Decode a gpx file. Track points without a valid time are skipped, since they cannot be placed in a stream.
+func Decode(r io.Reader) (*File, error) {
+	var x gpxXML
+	err := xml.NewDecoder(r).Decode(&x)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gpx: %w", err)
+	}
+	f := &File{Version: x.Version, Creator: x.Creator, Name: x.MetaName}
+	if f.Name == "" {
+		f.Name = x.Name
+	}
+	f.Time, _ = parseTime(x.MetaTime)
+	if f.Time.IsZero() {
+		f.Time, _ = parseTime(x.Time)
+	}
+	for _, trk := range x.Tracks {
+		track := Track{Name: strings.TrimSpace(trk.Name), Type: strings.TrimSpace(trk.Type)}
+		for _, seg := range trk.Segments {
+			points := []TrackPoint{}
+			for _, px := range seg.Points {
+				t, err := parseTime(px.Time)
+				if err != nil {
+					continue
+				}
+				ext := px.Extensions.TrackPointExtension
+				tp := TrackPoint{
+					Time:      t,
+					Lat:       px.Lat,
+					Lon:       px.Lon,
+					Elevation: px.Elevation,
+					HeartRate: ext.HR,
+					Cadence:   ext.Cad,
+					Speed:     px.Speed,
+					Power:     px.Extensions.Power,
+				}
+				if ext.Speed != nil {
+					tp.Speed = ext.Speed
+				}
+				tp.Temperature = ext.Atemp
+				if tp.Temperature == nil {
+					tp.Temperature = ext.Wtemp
+				}
+				points = append(points, tp)
+			}
+			track.Segments = append(track.Segments, points)
+		}
+		f.Tracks = append(f.Tracks, track)
+	}
+	if len(f.Tracks) > 0 && f.Tracks[0].Name != "" {
+		f.Name = f.Tracks[0].Name
+	}
+	return f, nil
+}
+
+// Convert every track point (of every track and segment, in order) into stream points
+func (f *File) Points() []streamset.Point {
+	points := []streamset.Point{}
+	for _, trk := range f.Tracks {
+		for _, seg := range trk.Segments {
+			for _, tp := range seg {
+				p := streamset.Point{
+					Time:   tp.Time,
+					LatLng: swagger.LatLng{float32(tp.Lat), float32(tp.Lon)},
+				}
+				if tp.Elevation != nil {
+					v := float32(*tp.Elevation)
+					p.Altitude = &v
+				}
+				if tp.Speed != nil {
+					v := float32(*tp.Speed)
+					p.Speed = &v
+				}
+				if tp.HeartRate != nil {
+					v := int32(*tp.HeartRate)
+					p.Heartrate = &v
+				}
+				if tp.Cadence != nil {
+					v := int32(*tp.Cadence)
+					p.Cadence = &v
+				}
+				if tp.Power != nil {
+					v := int32(*tp.Power)
+					p.Watts = &v
+				}
+				if tp.Temperature != nil {
+					v := int32(math.Round(*tp.Temperature))
+					p.Temp = &v
+				}
+				points = append(points, p)
+			}
+		}
+	}
+	return points
+}
+
+// Convert the file into the streams that strava's `GetActivityStreams` returns. Distance and moving are derived from the positions.
+func (f *File) StreamSet() *swagger.StreamSet {
+	return streamset.Build(f.Points())
+}
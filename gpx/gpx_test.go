@@ -0,0 +1,100 @@
+package gpx
+
+import (
+	"strings"
+	"testing"
+)
+
+const gpx11 = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="Garmin Connect" xmlns="http://www.topografix.com/GPX/1/1"
+  xmlns:ns3="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+  <metadata><name>file name</name><time>2024-05-01T12:00:00Z</time></metadata>
+  <trk>
+    <name>Morning Run</name>
+    <type>running</type>
+    <trkseg>
+      <trkpt lat="40.0" lon="-105.0"><ele>1600.0</ele><time>2024-05-01T12:00:00Z</time>
+        <extensions><ns3:TrackPointExtension><ns3:atemp>21.6</ns3:atemp><ns3:hr>140</ns3:hr><ns3:cad>85</ns3:cad></ns3:TrackPointExtension></extensions>
+      </trkpt>
+      <trkpt lat="40.0001" lon="-105.0"><ele>1601.0</ele><time>2024-05-01T12:00:05Z</time>
+        <extensions><ns3:TrackPointExtension><ns3:hr>142</ns3:hr></ns3:TrackPointExtension></extensions>
+      </trkpt>
+      <trkpt lat="40.0002" lon="-105.0"><time>not a time</time></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="40.0002" lon="-105.0"><ele>1603.0</ele><time>2024-05-01T12:00:10Z</time></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+const gpx10 = `<?xml version="1.0"?>
+<gpx version="1.0" creator="old device" xmlns="http://www.topografix.com/GPX/1/0">
+  <name>Old Ride</name>
+  <time>2009-05-01T12:00:00Z</time>
+  <trk><trkseg>
+    <trkpt lat="40.0" lon="-105.0"><time>2009-05-01T12:00:00Z</time><speed>7.5</speed></trkpt>
+    <trkpt lat="40.001" lon="-105.0"><time>2009-05-01T12:00:15Z</time><speed>7.4</speed></trkpt>
+  </trkseg></trk>
+</gpx>`
+
+func TestDecode_11(t *testing.T) {
+	f, err := Decode(strings.NewReader(gpx11))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if f.Version != "1.1" || f.Name != "Morning Run" || f.Time.IsZero() {
+		t.Errorf("Decode() = %+v", f)
+	}
+	if len(f.Tracks) != 1 || f.Tracks[0].Type != "running" || len(f.Tracks[0].Segments) != 2 {
+		t.Fatalf("Tracks = %+v", f.Tracks)
+	}
+	seg := f.Tracks[0].Segments[0]
+	if len(seg) != 2 {
+		t.Fatalf("segment 0 has %d points, want 2 (the point without a time is skipped)", len(seg))
+	}
+	p := seg[0]
+	if *p.HeartRate != 140 || *p.Cadence != 85 || *p.Temperature != 21.6 || *p.Elevation != 1600 {
+		t.Errorf("point 0 = %+v", p)
+	}
+	if seg[1].Cadence != nil || seg[1].Temperature != nil {
+		t.Errorf("point 1 = %+v, want no cadence or temperature", seg[1])
+	}
+}
+
+func TestDecode_10(t *testing.T) {
+	f, err := Decode(strings.NewReader(gpx10))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if f.Version != "1.0" || f.Name != "Old Ride" || f.Time.Year() != 2009 {
+		t.Errorf("Decode() = %+v", f)
+	}
+	p := f.Tracks[0].Segments[0][0]
+	if p.Speed == nil || *p.Speed != 7.5 {
+		t.Errorf("point 0 speed = %v, want 7.5", p.Speed)
+	}
+}
+
+func TestStreamSet(t *testing.T) {
+	f, err := Decode(strings.NewReader(gpx11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := f.StreamSet()
+	if set.Time == nil || len(set.Time.Data) != 3 || set.Time.Data[2] != 10 {
+		t.Fatalf("time stream = %+v", set.Time)
+	}
+	// distance and moving are derived from the positions
+	if set.Distance == nil || set.Moving == nil {
+		t.Fatalf("StreamSet() has no distance or moving stream")
+	}
+	if d := set.Distance.Data[2]; d < 21 || d > 23 {
+		t.Errorf("distance = %v, want ~22.2", d)
+	}
+	if set.Heartrate.Data[2] != 0 || set.Temp.Data[0] != 22 {
+		t.Errorf("heartrate = %v, temp = %v", set.Heartrate.Data, set.Temp.Data)
+	}
+	if set.Watts != nil || set.VelocitySmooth != nil {
+		t.Errorf("StreamSet() has streams the file does not")
+	}
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/intervals/client"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/ratelimit"
+	"golang.org/x/oauth2"
+)
+
+// if the intervals.icu api returns 404 not found, will throw this error
+var NotFoundError = errors.New("Object not found")
+
+// if the rate limiter throws an error
+var RateLimitError = errors.New("Rate Limit Error. (this likely means context expired while waiting for rate limits to be reset)")
+
+const (
+	// intervals.icu asks for reasonable use rather than publishing limits, so these are conservative
+	ReadLimitMinute         = 60.0
+	ReadLimitMinuteDuration = time.Duration(time.Minute)
+	ReadLimitDaily          = 5000.0
+	ReadLimitDailyDuration  = time.Duration(24 * time.Hour)
+)
+
+// where paging starts when there is no `after`
+var epoch = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// The IntervalsAPI struct is the primary means of interacting with the intervals.icu api.
+//
+// It wraps the lower level `client` with rate limiting, and maps activities and streams into the strava models
+// (so it can be used anywhere the strava api can, e.g. the `sync` package).
+// All requests are for a single athlete (the one the app was created for).
+//
+// # Whenever possible, this will return the NotFoundError when the underlying api returns a 404
+//
+// Make sure that every context has a timeout, otherwise the program will block until the rate limits refreshes.
+type IntervalsAPI struct {
+	client        *client.Client
+	athleteID     string
+	logger        *slog.Logger
+	limiterMinute *ratelimit.FixedWindow
+	limiterDaily  *ratelimit.FixedWindow
+}
+
+func NewIntervalsAPI(c *client.Client, athleteID string, logger *slog.Logger) *IntervalsAPI {
+	return &IntervalsAPI{
+		client:        c,
+		athleteID:     athleteID,
+		logger:        logger,
+		limiterMinute: ratelimit.NewFixedWindow(ReadLimitMinuteDuration, ReadLimitMinute),
+		limiterDaily:  ratelimit.NewFixedWindow(ReadLimitDailyDuration, ReadLimitDaily),
+	}
+}
+
+// check to see if the limits have been surpassed
+//
+// if you have exceeded the rate limit, will sleep until the next time interval
+//
+// ** should be called before every api call **
+func (api *IntervalsAPI) checkRateLimits(ctx context.Context) error {
+	err := api.limiterDaily.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed daily rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	err = api.limiterMinute.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed minute rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	return nil
+}
+
+// Return the number of requests remaining in the minute and daily windows respectively
+func (api *IntervalsAPI) RemainingRequests() (int, int) {
+	return api.limiterMinute.RequestsRemaining(), api.limiterDaily.RequestsRemaining()
+}
+
+// map the client's errors onto this package's
+func (api *IntervalsAPI) wrap(ctx context.Context, err error) error {
+	if errors.Is(err, client.NotFoundError) {
+		api.logger.DebugContext(ctx, "object not found")
+		return NotFoundError
+	}
+	api.logger.ErrorContext(ctx, "request failed", slog.String("error", err.Error()))
+	return err
+}
+
+// List the activities that start between the days of `oldest` and `newest` (both included) as intervals.icu returns them (newest first)
+func (api *IntervalsAPI) ListActivities(ctx context.Context, oldest, newest time.Time) ([]client.Activity, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "listing activities", slog.Time("oldest", oldest), slog.Time("newest", newest))
+	activities, err := api.client.ListActivities(ctx, api.athleteID, oldest, newest)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return activities, nil
+}
+
+// List the activities that start between the days of `oldest` and `newest` (both included), newest first.
+func (api *IntervalsAPI) GetActivities(ctx context.Context, oldest, newest time.Time) ([]swagger.SummaryActivity, error) {
+	activities, err := api.ListActivities(ctx, oldest, newest)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]swagger.SummaryActivity, len(activities))
+	for i, a := range activities {
+		summaries[i] = toSummary(a)
+	}
+	return summaries, nil
+}
+
+// Get a page of activities, like strava's `GetActivitiesPage`. The token is ignored (the api key is used).
+//
+// Activities are newest first, except when `after` is set, in which case they are oldest first (as strava does).
+//
+// intervals.icu lists by date range rather than by page, so every page lists the whole range (a single request) and slices it.
+// The range is a day wider on each side than `before` and `after`, since intervals.icu filters on the athlete's local day.
+func (api *IntervalsAPI) GetActivitiesPage(ctx context.Context, token *oauth2.Token, page int, perPage int, before, after *time.Time) ([]swagger.SummaryActivity, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage <= 0 {
+		return []swagger.SummaryActivity{}, nil
+	}
+	oldest, newest := epoch, time.Now().AddDate(0, 0, 1)
+	if after != nil {
+		oldest = after.AddDate(0, 0, -1)
+	}
+	if before != nil {
+		newest = before.AddDate(0, 0, 1)
+	}
+	activities, err := api.ListActivities(ctx, oldest, newest)
+	if err != nil {
+		return nil, err
+	}
+	filtered := []swagger.SummaryActivity{}
+	for _, a := range activities {
+		start := a.Start()
+		if before != nil && !start.Before(*before) {
+			continue
+		}
+		if after != nil && !start.After(*after) {
+			continue
+		}
+		filtered = append(filtered, toSummary(a))
+	}
+	slices.SortStableFunc(filtered, func(a, b swagger.SummaryActivity) int {
+		if after != nil {
+			return a.StartDate.Compare(b.StartDate)
+		}
+		return b.StartDate.Compare(a.StartDate)
+	})
+	start := (page - 1) * perPage
+	if start >= len(filtered) {
+		return []swagger.SummaryActivity{}, nil
+	}
+	return filtered[start:min(start+perPage, len(filtered))], nil
+}
+
+// Get a single activity as intervals.icu returns it. `activityID` is the full id (e.g. "i12345")
+func (api *IntervalsAPI) GetIntervalsActivity(ctx context.Context, activityID string) (*client.Activity, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting activity", slog.String("activity id", activityID))
+	activity, err := api.client.GetActivity(ctx, activityID)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return activity, nil
+}
+
+// the ids that a numeric id could have been made from (see `client.Activity.NumericID`)
+//
+// activities recorded on intervals.icu have an "i" prefix. activities from elsewhere can be numeric
+func candidateIDs(activityID int) []string {
+	return []string{fmt.Sprintf("i%d", activityID), fmt.Sprint(activityID)}
+}
+
+// Get a single activity, like strava's `GetActivity`. The token and `includeAllEfforts` are ignored.
+//
+// `activityID` is the numeric id of a summary (e.g. 12345 for "i12345"). This costs a second request for activities that do not have an "i" prefix.
+func (api *IntervalsAPI) GetActivity(ctx context.Context, token *oauth2.Token, activityID int, includeAllEfforts bool) (*swagger.DetailedActivity, error) {
+	var err error
+	for _, id := range candidateIDs(activityID) {
+		var activity *client.Activity
+		activity, err = api.GetIntervalsActivity(ctx, id)
+		if err == nil {
+			return toDetailed(*activity), nil
+		}
+		if !errors.Is(err, NotFoundError) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/intervals/client"
+	stravaApi "github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	stravaSync "github.com/jcocozza/cassidy-connector/strava/sync"
+)
+
+var _ stravaSync.StravaAPI = (*IntervalsAPI)(nil)
+
+func readFixture(t *testing.T, name string, v interface{}) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return data
+}
+
+// a fake intervals.icu that serves the fixtures in testdata for the owner of the api key "key".
+// only i1001 has streams
+func fakeServer(t *testing.T) *IntervalsAPI {
+	var activities []client.Activity
+	readFixture(t, "activities.json", &activities)
+	var wellness []client.Wellness
+	readFixture(t, "wellness.json", &wellness)
+	streams := readFixture(t, "streams.json", nil)
+	// local days, as intervals.icu filters
+	inRange := func(r *http.Request, day string) bool {
+		q := r.URL.Query()
+		return q.Get("oldest") <= day[:10] && day[:10] <= q.Get("newest")
+	}
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		json.NewEncoder(w).Encode(v)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /athlete/0/activities", func(w http.ResponseWriter, r *http.Request) {
+		filtered := []client.Activity{}
+		for _, a := range activities {
+			if inRange(r, a.StartDateLocal) {
+				filtered = append(filtered, a)
+			}
+		}
+		writeJSON(w, filtered)
+	})
+	mux.HandleFunc("GET /activity/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for _, a := range activities {
+			if a.ID == r.PathValue("id") {
+				writeJSON(w, a)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /activity/i1001/streams", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(streams)
+	})
+	mux.HandleFunc("GET /athlete/0/wellness", func(w http.ResponseWriter, r *http.Request) {
+		filtered := []client.Wellness{}
+		for _, d := range wellness {
+			if inRange(r, d.ID) {
+				filtered = append(filtered, d)
+			}
+		}
+		writeJSON(w, filtered)
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, key, ok := r.BasicAuth()
+		if !ok || user != "API_KEY" || key != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return NewIntervalsAPI(client.New(server.URL, "key", nil), client.CurrentAthlete, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func ids(activities []swagger.SummaryActivity) []string {
+	out := []string{}
+	for _, a := range activities {
+		out = append(out, a.ExternalId)
+	}
+	return out
+}
+
+func TestGetActivitiesPage(t *testing.T) {
+	api := fakeServer(t)
+	ctx := context.Background()
+	day := func(s string) *time.Time {
+		d, _ := time.Parse(time.RFC3339, s)
+		return &d
+	}
+	tests := []struct {
+		name          string
+		page, perPage int
+		before, after *time.Time
+		want          []string
+	}{
+		{"first page", 1, 2, nil, nil, []string{"i1003", "9876543210"}},
+		{"second page", 2, 2, nil, nil, []string{"i1001"}},
+		{"past the end", 3, 2, nil, nil, []string{}},
+		// the run is on the 1st locally but the 2nd in utc
+		{"after, oldest first", 1, 10, nil, day("2024-05-02T00:00:00Z"), []string{"i1001", "9876543210", "i1003"}},
+		{"before", 1, 10, day("2024-05-02T12:00:00Z"), nil, []string{"i1001"}},
+		{"between", 1, 10, day("2024-05-03T00:00:00Z"), day("2024-05-02T02:00:00Z"), []string{"9876543210"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activities, err := api.GetActivitiesPage(ctx, nil, tt.page, tt.perPage, tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("GetActivitiesPage() error = %v", err)
+			}
+			got := ids(activities)
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetActivitiesPage() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("GetActivitiesPage() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetActivity(t *testing.T) {
+	api := fakeServer(t)
+	ctx := context.Background()
+	// from strava, so its id has no prefix
+	ride, err := api.GetActivity(ctx, nil, 9876543210, false)
+	if err != nil {
+		t.Fatalf("GetActivity() error = %v", err)
+	}
+	start, _ := time.Parse(time.RFC3339, "2024-05-02T19:00:00Z")
+	if ride.Id != 9876543210 || ride.Name != "Lunch Ride" || *ride.SportType != swagger.RIDE_SportType || !ride.StartDate.Equal(start) {
+		t.Errorf("GetActivity() = %d %q %v %v", ride.Id, ride.Name, *ride.SportType, ride.StartDate)
+	}
+	if ride.Distance != 42000.5 || ride.MovingTime != 5100 || ride.AverageWatts != 190 || ride.WeightedAverageWatts != 206 || ride.Kilojoules != 969 {
+		t.Errorf("GetActivity() totals = %+v", ride)
+	}
+	if ride.GearId != "b123" || !ride.Commute || ride.Description != "coffee loop" || ride.Calories != 1000 {
+		t.Errorf("GetActivity() = %+v", ride)
+	}
+	run, err := api.GetActivity(ctx, nil, 1001, false)
+	if err != nil {
+		t.Fatalf("GetActivity() error = %v", err)
+	}
+	if run.ExternalId != "i1001" || run.StartDateLocal.Day() != 1 || run.StartDate.Day() != 2 {
+		t.Errorf("GetActivity() = %q %v %v", run.ExternalId, run.StartDateLocal, run.StartDate)
+	}
+	_, err = api.GetActivity(ctx, nil, 1, false)
+	if !errors.Is(err, NotFoundError) {
+		t.Errorf("GetActivity(unknown) error = %v, want NotFoundError", err)
+	}
+	api.client.APIKey = "wrong"
+	_, err = api.GetActivity(ctx, nil, 1001, false)
+	if !errors.Is(err, client.UnauthorizedError) {
+		t.Errorf("GetActivity(wrong key) error = %v, want UnauthorizedError", err)
+	}
+}
+
+func TestGetActivityStreams(t *testing.T) {
+	api := fakeServer(t)
+	ctx := context.Background()
+	keys := []stravaApi.StreamType{stravaApi.Time, stravaApi.Distance, stravaApi.Heartrate, stravaApi.Latlng, stravaApi.Moving, stravaApi.GradeSmooth}
+	streams, err := api.GetActivityStreams(ctx, nil, 1001, keys)
+	if err != nil {
+		t.Fatalf("GetActivityStreams() error = %v", err)
+	}
+	if streams.Time == nil || len(streams.Time.Data) != 5 || streams.Time.Data[4] != 4 {
+		t.Fatalf("GetActivityStreams() time = %+v", streams.Time)
+	}
+	// the missing sample is 0, like streams decoded from a file
+	if hr := streams.Heartrate.Data; hr[0] != 140 || hr[1] != 0 || hr[4] != 143 {
+		t.Errorf("GetActivityStreams() heartrate = %v", hr)
+	}
+	if ll := streams.Latlng.Data; len(ll) != 5 || ll[4][0] != 37.7753 || ll[4][1] != -122.4194 {
+		t.Errorf("GetActivityStreams() latlng = %v", ll)
+	}
+	if streams.Moving == nil || !streams.Moving.Data[1] || streams.Distance.Data[4] != 40 {
+		t.Errorf("GetActivityStreams() moving = %+v, distance = %+v", streams.Moving, streams.Distance)
+	}
+	if streams.GradeSmooth == nil || streams.GradeSmooth.Data[1] != 2 {
+		t.Errorf("GetActivityStreams() grade = %+v", streams.GradeSmooth)
+	}
+	if streams.Altitude != nil || streams.Watts != nil {
+		t.Errorf("GetActivityStreams() = %+v, want only the requested streams", streams)
+	}
+	_, err = api.GetActivityStreams(ctx, nil, 1003, keys)
+	if !errors.Is(err, NotFoundError) {
+		t.Errorf("GetActivityStreams(no streams) error = %v, want NotFoundError", err)
+	}
+}
+
+func TestGetWellness(t *testing.T) {
+	api := fakeServer(t)
+	oldest, _ := time.Parse(client.DateLayout, "2024-05-02")
+	wellness, err := api.GetWellness(context.Background(), oldest, oldest.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("GetWellness() error = %v", err)
+	}
+	if len(wellness) != 1 {
+		t.Fatalf("GetWellness() = %+v, want 1 day", wellness)
+	}
+	w := wellness[0]
+	if !w.Day().Equal(oldest) || *w.RestingHR != 50 || w.HRV != nil || *w.SleepSecs != 24300 {
+		t.Errorf("GetWellness() = %+v", w)
+	}
+}
+
+func TestSync(t *testing.T) {
+	api := fakeServer(t)
+	sink := stravaSync.MemorySink{}
+	syncer := stravaSync.NewSyncer(api, stravaSync.NewFileCheckpointStore(t.TempDir()), sink, nil)
+	report, err := syncer.Sync(context.Background(), nil, 0)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Created != 3 || len(sink) != 3 {
+		t.Errorf("Sync() = %+v, want 3 created", report)
+	}
+}
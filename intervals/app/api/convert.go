@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/jcocozza/cassidy-connector/intervals/client"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// intervals.icu uses strava's sport types, so only unknown types need mapping
+func sportType(t string) swagger.SportType {
+	if t == "" || t == "Other" {
+		return swagger.WORKOUT_SportType
+	}
+	return swagger.SportType(t)
+}
+
+// map an intervals.icu activity onto a strava summary
+func toSummary(a client.Activity) swagger.SummaryActivity {
+	sport := sportType(a.Type)
+	at := swagger.ActivityType(sport)
+	id, _ := a.NumericID()
+	summary := swagger.SummaryActivity{
+		Id:                   id,
+		ExternalId:           a.ID,
+		Name:                 a.Name,
+		Distance:             float32(a.Distance),
+		MovingTime:           int32(a.MovingTime),
+		ElapsedTime:          int32(a.ElapsedTime),
+		TotalElevationGain:   float32(a.TotalElevationGain),
+		Type_:                &at,
+		SportType:            &sport,
+		StartDate:            a.Start(),
+		StartDateLocal:       a.StartLocal(),
+		Timezone:             a.Timezone,
+		Trainer:              a.Trainer,
+		Commute:              a.Commute,
+		AverageSpeed:         float32(a.AverageSpeed),
+		MaxSpeed:             float32(a.MaxSpeed),
+		AverageWatts:         float32(a.AverageWatts),
+		WeightedAverageWatts: int32(math.Round(a.WeightedAvgWatts)),
+		Kilojoules:           float32(a.Joules / 1000),
+	}
+	if a.Gear != nil {
+		summary.GearId = a.Gear.ID
+	}
+	return summary
+}
+
+// map an intervals.icu activity onto a strava detailed activity
+func toDetailed(a client.Activity) *swagger.DetailedActivity {
+	// a detailed activity has every field of a summary
+	data, _ := json.Marshal(toSummary(a))
+	detailed := &swagger.DetailedActivity{}
+	json.Unmarshal(data, detailed)
+	detailed.Description = a.Description
+	detailed.Calories = float32(a.Calories)
+	detailed.DeviceName = a.DeviceName
+	return detailed
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/intervals/client"
+	stravaApi "github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/streamset"
+	"golang.org/x/oauth2"
+)
+
+// Get the streams of an activity as intervals.icu returns them. If `types` is empty, every stream is returned.
+func (api *IntervalsAPI) GetIntervalsStreams(ctx context.Context, activityID string, types []string) ([]client.Stream, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting activity streams", slog.String("activity id", activityID), slog.Any("types", types))
+	streams, err := api.client.GetStreams(ctx, activityID, types)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return streams, nil
+}
+
+// Get the streams of an activity, like strava's `GetActivityStreams`. The token is ignored.
+//
+// intervals.icu has no moving stream, so it is derived from the distance (see `streamset.Build`).
+// Only the streams in `keys` that the activity has are returned.
+func (api *IntervalsAPI) GetActivityStreams(ctx context.Context, token *oauth2.Token, activityID int, keys []stravaApi.StreamType) (*swagger.StreamSet, error) {
+	var streams []client.Stream
+	var err error
+	for _, id := range candidateIDs(activityID) {
+		// every stream is needed to line the samples up (and to derive the moving stream)
+		streams, err = api.GetIntervalsStreams(ctx, id, nil)
+		if !errors.Is(err, NotFoundError) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stravaApi.FilterStreams(toStreamSet(streams), keys), nil
+}
+
+func float32At(data []*float64, i int) *float32 {
+	if i >= len(data) || data[i] == nil {
+		return nil
+	}
+	v := float32(*data[i])
+	return &v
+}
+
+func int32At(data []*float64, i int) *int32 {
+	if i >= len(data) || data[i] == nil {
+		return nil
+	}
+	v := int32(*data[i])
+	return &v
+}
+
+// map intervals.icu streams onto strava's. there is nothing without a time stream
+func toStreamSet(streams []client.Stream) *swagger.StreamSet {
+	byType := map[string]client.Stream{}
+	for _, s := range streams {
+		byType[s.Type] = s
+	}
+	t, ok := byType["time"]
+	if !ok {
+		return &swagger.StreamSet{}
+	}
+	points := make([]streamset.Point, 0, len(t.Data))
+	for i, offset := range t.Data {
+		if offset == nil {
+			continue
+		}
+		p := streamset.Point{
+			// only the offsets from the first point matter
+			Time:      time.Unix(int64(*offset), 0),
+			Altitude:  float32At(byType["altitude"].Data, i),
+			Distance:  float32At(byType["distance"].Data, i),
+			Speed:     float32At(byType["velocity_smooth"].Data, i),
+			Heartrate: int32At(byType["heartrate"].Data, i),
+			Cadence:   int32At(byType["cadence"].Data, i),
+			Watts:     int32At(byType["watts"].Data, i),
+			Temp:      int32At(byType["temp"].Data, i),
+		}
+		latlng := byType["latlng"]
+		if lat, lng := float32At(latlng.Data, i), float32At(latlng.Data2, i); lat != nil && lng != nil {
+			p.LatLng = swagger.LatLng{*lat, *lng}
+		}
+		points = append(points, p)
+	}
+	set := streamset.Build(points)
+	if grade, ok := byType["grade_smooth"]; ok && set.Time != nil {
+		data := make([]float32, 0, len(points))
+		for i, offset := range t.Data {
+			if offset == nil {
+				continue
+			}
+			v := float32At(grade.Data, i)
+			if v == nil {
+				data = append(data, 0)
+			} else {
+				data = append(data, *v)
+			}
+		}
+		set.GradeSmooth = &swagger.SmoothGradeStream{OriginalSize: int32(len(data)), Resolution: "high", SeriesType: "time", Data: data}
+	}
+	return set
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/intervals/client"
+)
+
+// Get the wellness records (hrv, resting heart rate, sleep, ...) between the days of `oldest` and `newest` (both included).
+//
+// There is one record per day that has data. Strava has no equivalent, so these are intervals.icu's own records.
+func (api *IntervalsAPI) GetWellness(ctx context.Context, oldest, newest time.Time) ([]client.Wellness, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "listing wellness", slog.Time("oldest", oldest), slog.Time("newest", newest))
+	wellness, err := api.client.ListWellness(ctx, api.athleteID, oldest, newest)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return wellness, nil
+}
+
+// Get the wellness record of a single day
+func (api *IntervalsAPI) GetWellnessDay(ctx context.Context, day time.Time) (*client.Wellness, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting wellness", slog.Time("day", day))
+	wellness, err := api.client.GetWellness(ctx, api.athleteID, day)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return wellness, nil
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"log/slog"
+
+	"github.com/jcocozza/cassidy-connector/intervals/app/api"
+	"github.com/jcocozza/cassidy-connector/intervals/client"
+)
+
+// An app is a way of interacting with the intervals.icu api for a single athlete.
+//
+// There is no oauth: the athlete creates an api key in their intervals.icu settings (under "Developer Settings").
+type App struct {
+	logger *slog.Logger
+	APIKey string
+	// the athlete's id (e.g. "i12345"). "0" is the owner of the api key
+	AthleteID string
+	// The lower level client. No rate limiting is done.
+	Client *client.Client
+	// This is where the data methods are called from.
+	Api *api.IntervalsAPI
+}
+
+// `athleteID` defaults to the owner of the api key, and `baseURL` to intervals.icu
+func NewApp(apiKey string, athleteID string, baseURL string, logger *slog.Logger) *App {
+	if athleteID == "" {
+		athleteID = client.CurrentAthlete
+	}
+	if logger == nil {
+		logger = NoopLogger()
+	}
+	logger = logger.WithGroup("cassidy-intervals")
+	c := client.New(baseURL, apiKey, nil)
+	return &App{
+		logger:    logger,
+		APIKey:    apiKey,
+		AthleteID: athleteID,
+		Client:    c,
+		Api:       api.NewIntervalsAPI(c, athleteID, logger.WithGroup("api")),
+	}
+}
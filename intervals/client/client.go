@@ -0,0 +1,153 @@
+// Package client is the lower level implementation of the intervals.icu api: one method per endpoint, with no rate limiting or mapping.
+//
+// Requests are authenticated with an api key (from the athlete's intervals.icu settings page) using basic auth.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://intervals.icu/api/v1"
+
+// intervals.icu wants this as the basic auth user name, with the api key as the password
+const apiKeyUser = "API_KEY"
+
+// intervals.icu dates (for filtering lists)
+const DateLayout = "2006-01-02"
+
+// the athlete id that refers to the owner of the api key
+const CurrentAthlete = "0"
+
+const (
+	activitiesPath  = "/athlete/%s/activities"
+	activityPath    = "/activity/%s"
+	streamsPath     = "/activity/%s/streams"
+	wellnessPath    = "/athlete/%s/wellness"
+	wellnessDayPath = "/athlete/%s/wellness/%s"
+)
+
+// if intervals.icu returns 404 not found, will throw this error
+var NotFoundError = errors.New("Object not found")
+
+// if intervals.icu rejects the api key (401 or 403), will throw this error
+var UnauthorizedError = errors.New("Unauthorized. (check the api key and athlete id)")
+
+// if intervals.icu returns any other non 200 status, will throw this error (wrapped with the status)
+var StatusError = errors.New("Unexpected status")
+
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+func New(baseURL string, apiKey string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{BaseURL: baseURL, APIKey: apiKey, HTTPClient: httpClient}
+}
+
+// send a GET and decode the json response into `out`
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(apiKeyUser, c.APIKey)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return NotFoundError
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return UnauthorizedError
+	default:
+		return fmt.Errorf("%w: %s", StatusError, resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func dateRange(oldest, newest time.Time) url.Values {
+	query := url.Values{}
+	query.Set("oldest", oldest.Format(DateLayout))
+	query.Set("newest", newest.Format(DateLayout))
+	return query
+}
+
+// List the activities of an athlete that start between the days of `oldest` and `newest` (both included), newest first.
+func (c *Client) ListActivities(ctx context.Context, athleteID string, oldest, newest time.Time) ([]Activity, error) {
+	activities := []Activity{}
+	err := c.getJSON(ctx, fmt.Sprintf(activitiesPath, url.PathEscape(athleteID)), dateRange(oldest, newest), &activities)
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// Get a single activity
+func (c *Client) GetActivity(ctx context.Context, activityID string) (*Activity, error) {
+	var activity Activity
+	err := c.getJSON(ctx, fmt.Sprintf(activityPath, url.PathEscape(activityID)), nil, &activity)
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// Get the streams of an activity. If `types` is empty, every stream the activity has is returned.
+func (c *Client) GetStreams(ctx context.Context, activityID string, types []string) ([]Stream, error) {
+	query := url.Values{}
+	if len(types) > 0 {
+		query.Set("types", strings.Join(types, ","))
+	}
+	streams := []Stream{}
+	err := c.getJSON(ctx, fmt.Sprintf(streamsPath, url.PathEscape(activityID)), query, &streams)
+	if err != nil {
+		return nil, err
+	}
+	return streams, nil
+}
+
+// List the wellness records of an athlete between the days of `oldest` and `newest` (both included), one per day that has data.
+func (c *Client) ListWellness(ctx context.Context, athleteID string, oldest, newest time.Time) ([]Wellness, error) {
+	wellness := []Wellness{}
+	err := c.getJSON(ctx, fmt.Sprintf(wellnessPath, url.PathEscape(athleteID)), dateRange(oldest, newest), &wellness)
+	if err != nil {
+		return nil, err
+	}
+	return wellness, nil
+}
+
+// Get the wellness record of a single day
+func (c *Client) GetWellness(ctx context.Context, athleteID string, day time.Time) (*Wellness, error) {
+	var wellness Wellness
+	err := c.getJSON(ctx, fmt.Sprintf(wellnessDayPath, url.PathEscape(athleteID), day.Format(DateLayout)), nil, &wellness)
+	if err != nil {
+		return nil, err
+	}
+	return &wellness, nil
+}
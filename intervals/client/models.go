@@ -0,0 +1,124 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// intervals.icu local times have no zone, e.g. "2024-05-01T08:00:00"
+const LocalTimeLayout = "2006-01-02T15:04:05"
+
+type Gear struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// An Activity, with the fields that intervals.icu shares with strava (most of the names are the same).
+// Distances are in meters, times in seconds and speeds in meters per second.
+//
+// Fields that intervals.icu computes itself have an `icu_` prefix.
+type Activity struct {
+	// e.g. "i12345"
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	StartDateLocal string `json:"start_date_local"`
+	StartDate      string `json:"start_date"`
+	Timezone       string `json:"timezone"`
+	// e.g. "Run", "Ride" or "VirtualRide" (the same as strava's sport types)
+	Type               string  `json:"type"`
+	Distance           float64 `json:"distance"`
+	MovingTime         int     `json:"moving_time"`
+	ElapsedTime        int     `json:"elapsed_time"`
+	TotalElevationGain float64 `json:"total_elevation_gain"`
+	TotalElevationLoss float64 `json:"total_elevation_loss"`
+	AverageSpeed       float64 `json:"average_speed"`
+	MaxSpeed           float64 `json:"max_speed"`
+	AverageHeartrate   float64 `json:"average_heartrate"`
+	MaxHeartrate       float64 `json:"max_heartrate"`
+	AverageCadence     float64 `json:"average_cadence"`
+	AverageWatts       float64 `json:"icu_average_watts"`
+	WeightedAvgWatts   float64 `json:"icu_weighted_avg_watts"`
+	Joules             float64 `json:"icu_joules"`
+	TrainingLoad       float64 `json:"icu_training_load"`
+	Calories           float64 `json:"calories"`
+	Trainer            bool    `json:"trainer"`
+	Commute            bool    `json:"commute"`
+	DeviceName         string  `json:"device_name"`
+	Gear               *Gear   `json:"gear"`
+	// the id of the activity where it came from (e.g. a strava or garmin id)
+	ExternalID string `json:"external_id"`
+	// where the activity came from, e.g. "STRAVA", "GARMIN_CONNECT" or "UPLOAD"
+	Source string `json:"source"`
+}
+
+// The start of the activity in utc. zero if intervals.icu sent something that is not a time
+func (a Activity) Start() time.Time {
+	t, _ := time.Parse(time.RFC3339, a.StartDate)
+	return t
+}
+
+// The start of the activity in the athlete's local time, as if it were utc (like strava's `start_date_local`)
+func (a Activity) StartLocal() time.Time {
+	t, _ := time.Parse(LocalTimeLayout, strings.TrimSuffix(a.StartDateLocal, "Z"))
+	return t
+}
+
+// The numeric part of the activity id (e.g. "i12345" -> 12345). false if the id is not numeric.
+func (a Activity) NumericID() (int64, bool) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(a.ID, "i"), 10, 64)
+	return id, err == nil
+}
+
+// A Stream is a series of samples, in the order of the "time" stream. Samples the device did not record are nil.
+type Stream struct {
+	// e.g. "time", "watts", "heartrate" or "latlng"
+	Type string     `json:"type"`
+	Name string     `json:"name"`
+	Data []*float64 `json:"data"`
+	// only for "latlng": the longitudes (`Data` has the latitudes)
+	Data2 []*float64 `json:"data2"`
+}
+
+// The wellness record of a single day. Values that were not recorded are nil.
+type Wellness struct {
+	// the day, e.g. "2024-05-01"
+	ID string `json:"id"`
+	// fitness (chronic training load)
+	CTL *float64 `json:"ctl"`
+	// fatigue (acute training load)
+	ATL      *float64 `json:"atl"`
+	RampRate *float64 `json:"rampRate"`
+	// kilograms
+	Weight *float64 `json:"weight"`
+	// beats per minute
+	RestingHR *float64 `json:"restingHR"`
+	// heart rate variability (rMSSD), in milliseconds
+	HRV *float64 `json:"hrv"`
+	// heart rate variability (SDNN), in milliseconds
+	HRVSDNN *float64 `json:"hrvSDNN"`
+	// seconds
+	SleepSecs  *float64 `json:"sleepSecs"`
+	SleepScore *float64 `json:"sleepScore"`
+	// 1 (great) to 4 (poor)
+	SleepQuality  *float64 `json:"sleepQuality"`
+	AvgSleepingHR *float64 `json:"avgSleepingHR"`
+	// 1 to 4 (or 5 for readiness), as entered by the athlete
+	Soreness    *float64 `json:"soreness"`
+	Fatigue     *float64 `json:"fatigue"`
+	Stress      *float64 `json:"stress"`
+	Mood        *float64 `json:"mood"`
+	Motivation  *float64 `json:"motivation"`
+	Readiness   *float64 `json:"readiness"`
+	SpO2        *float64 `json:"spO2"`
+	Respiration *float64 `json:"respiration"`
+	Steps       *float64 `json:"steps"`
+	Comments    string   `json:"comments"`
+}
+
+// The day of the record. zero if the id is not a date
+func (w Wellness) Day() time.Time {
+	t, _ := time.Parse(DateLayout, w.ID)
+	return t
+}
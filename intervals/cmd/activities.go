@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+var getActivities = &cobra.Command{
+	Use:   "activities",
+	Short: "Get the activities between two days, in the same shape as strava's.",
+	Long: `Get the activities between two days (both included), in the same shape as strava's. Newest first.
+
+The intervals.icu id (e.g. i12345) is in external_id.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		intervalsApp, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		o, n, err := parseRange()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activities, err := intervalsApp.Api.GetActivities(context.TODO(), o, n)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activitiesJsonBytes, err := json.Marshal(activities)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			utils.WriteOutput(outputPath, activitiesJsonBytes)
+		}
+		fmt.Println(string(activitiesJsonBytes))
+	},
+}
+
+var getActivity = &cobra.Command{
+	Use:   "activity [activity id]",
+	Short: "Get an activity by activity id (with or without the i prefix).",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		intervalsApp, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activityId, err := parseActivityId(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activity, err := intervalsApp.Api.GetActivity(context.TODO(), nil, activityId, false)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activityJsonBytes, err := json.Marshal(activity)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			utils.WriteOutput(outputPath, activityJsonBytes)
+		}
+		fmt.Println(string(activityJsonBytes))
+	},
+}
+
+func init() {
+	addRangeFlags(getActivities)
+	RootCmd.AddCommand(getActivities)
+	RootCmd.AddCommand(getActivity)
+}
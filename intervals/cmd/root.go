@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/intervals/app"
+	"github.com/jcocozza/cassidy-connector/intervals/client"
+	"github.com/spf13/cobra"
+)
+
+const (
+	version string = "0.0.1"
+	// read when --api-key is not passed
+	apiKeyEnv            string = "INTERVALS_API_KEY"
+	layout               string = "2006-01-02"
+	layoutInterpretation string = "YYYY-MM-DD"
+)
+
+// global app flag variables
+var apiKey string
+var athleteId string
+var baseURL string
+var outputPath string
+
+var RootCmd = &cobra.Command{
+	Use:     "intervals",
+	Version: version,
+	Short:   "a cli tool to interact with the intervals.icu API",
+	Long: fmt.Sprintf(`a cli tool to interact with the intervals.icu API
+
+Requests are authenticated with an api key (intervals.icu settings, under "Developer Settings").
+Pass it with --api-key or set %s.`, apiKeyEnv),
+	Run: func(cmd *cobra.Command, args []string) {},
+}
+
+// Create the app based on the passed flag settings
+func createApp() (*app.App, error) {
+	key := apiKey
+	if key == "" {
+		key = os.Getenv(apiKeyEnv)
+	}
+	if key == "" {
+		return nil, fmt.Errorf("no api key. pass --api-key or set %s", apiKeyEnv)
+	}
+	return app.NewApp(key, athleteId, baseURL, nil), nil
+}
+
+// parse an activity id, with or without the "i" prefix
+func parseActivityId(s string) (int, error) {
+	return strconv.Atoi(strings.TrimPrefix(s, "i"))
+}
+
+// parse a YYYY-MM-DD date, or "today"
+func parseDate(s string) (time.Time, error) {
+	if s == "today" {
+		return time.Now(), nil
+	}
+	return time.Parse(layout, s)
+}
+
+var oldest string
+var newest string
+
+// the --oldest and --newest flags, shared by the list commands
+func addRangeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&oldest, "oldest", "", fmt.Sprintf("the first day to include. Must be of the format: %s", layoutInterpretation))
+	cmd.Flags().StringVar(&newest, "newest", "today", fmt.Sprintf("the last day to include. Must be of the format: %s (or today)", layoutInterpretation))
+	cmd.MarkFlagRequired("oldest")
+}
+
+func parseRange() (time.Time, time.Time, error) {
+	o, err := parseDate(oldest)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	n, err := parseDate(newest)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return o, n, nil
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&apiKey, "api-key", "", fmt.Sprintf("your intervals.icu api key (default is $%s)", apiKeyEnv))
+	RootCmd.PersistentFlags().StringVar(&athleteId, "athlete-id", client.CurrentAthlete, "the athlete to get data for (e.g. i12345). 0 is the owner of the api key")
+	RootCmd.PersistentFlags().StringVar(&baseURL, "base-url", client.DefaultBaseURL, "the base url of the api")
+	RootCmd.PersistentFlags().StringVarP(&outputPath, "path", "f", "", "the path to save successful output to. (will not write errors at this time)")
+}
+
+func Execute() {
+	if err := RootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	stravaApi "github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+var keys []string
+var getStreams = &cobra.Command{
+	Use:   "streams [activity id]",
+	Short: "Get streams for a given activity, in the same shape as strava's",
+	Long: `Get streams for a given activity, in the same shape as strava's. Stream types include:
+time, distance, latlng, altitude, velocity_smooth, heartrate, cadence, watts, temp, moving, and grade_smooth`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		intervalsApp, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activityId, err := parseActivityId(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		l := []stravaApi.StreamType{}
+		for _, key := range keys {
+			l = append(l, stravaApi.StreamType(key))
+		}
+		streams, err := intervalsApp.Api.GetActivityStreams(context.TODO(), nil, activityId, l)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		streamJsonBytes, err := json.Marshal(streams)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			utils.WriteOutput(outputPath, streamJsonBytes)
+		}
+		fmt.Println(string(streamJsonBytes))
+	},
+}
+
+func init() {
+	getStreams.Flags().StringSliceVarP(&keys, "stream-types", "t", []string{"time", "distance"}, "a comma separated list of the stream types to get.")
+	RootCmd.AddCommand(getStreams)
+}
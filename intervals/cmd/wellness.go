@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jcocozza/cassidy-connector/intervals/client"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+var wellnessJSON bool
+
+// a value for the table. missing values are "-"
+func cell(v *float64, format string) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf(format, *v)
+}
+
+// print the wellness records as a table
+func printWellness(wellness []client.Wellness) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DAY\tRESTING HR\tHRV (ms)\tSLEEP (h)\tSLEEP SCORE\tWEIGHT (kg)\tCTL\tATL")
+	for _, d := range wellness {
+		var sleep *float64
+		if d.SleepSecs != nil {
+			h := *d.SleepSecs / 3600
+			sleep = &h
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.ID,
+			cell(d.RestingHR, "%.0f"),
+			cell(d.HRV, "%.0f"),
+			cell(sleep, "%.1f"),
+			cell(d.SleepScore, "%.0f"),
+			cell(d.Weight, "%.1f"),
+			cell(d.CTL, "%.1f"),
+			cell(d.ATL, "%.1f"),
+		)
+	}
+	w.Flush()
+}
+
+var getWellness = &cobra.Command{
+	Use:   "wellness",
+	Short: "Get the wellness records (resting heart rate, hrv, sleep, ...) between two days.",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		intervalsApp, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		o, n, err := parseRange()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		wellness, err := intervalsApp.Api.GetWellness(context.TODO(), o, n)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		wellnessJsonBytes, err := json.Marshal(wellness)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			utils.WriteOutput(outputPath, wellnessJsonBytes)
+		}
+		if wellnessJSON {
+			fmt.Println(string(wellnessJsonBytes))
+			return
+		}
+		printWellness(wellness)
+	},
+}
+
+func init() {
+	addRangeFlags(getWellness)
+	getWellness.Flags().BoolVar(&wellnessJSON, "json", false, "print json instead of a table")
+	RootCmd.AddCommand(getWellness)
+}
@@ -0,0 +1,351 @@
+// Package localfiles serves a directory of activity files (fit, gpx and tcx, optionally gzipped) as if it were strava.
+//
+// A `Provider` implements the listing part of the strava api (see `sync.StravaAPI`), so local files can be synced, archived
+// and tested against with the same code as real strava activities. Summaries (distance, moving time, elevation, ...) are derived from the streams.
+//
+// Activity ids are a hash of the path of the file relative to the directory, so they are stable as long as the file is not moved.
+// The path itself is the activity's `ExternalId`.
+package localfiles
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/fit"
+	"github.com/jcocozza/cassidy-connector/gpx"
+	"github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/tcx"
+	"golang.org/x/oauth2"
+)
+
+// an activity decoded from a file
+type activity struct {
+	summary swagger.SummaryActivity
+	streams *swagger.StreamSet
+	laps    []swagger.Lap
+}
+
+// a decoded file, along with what it was decoded from so that it can be reused until the file changes
+type entry struct {
+	modTime  time.Time
+	size     int64
+	activity *activity
+	err      error
+	// set when another file has the same activity id. the file is left out of listings
+	collision error
+}
+
+// A Provider serves the activity files in a directory (and its subdirectories).
+//
+// The directory is rescanned on every listing. Files are only decoded again when their size or modification time changes.
+type Provider struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*entry
+}
+
+func New(dir string) *Provider {
+	return &Provider{dir: dir, files: map[string]*entry{}}
+}
+
+// whether a file is an activity file that can be decoded. returns the format (e.g. ".fit")
+func format(name string) (string, bool) {
+	name = strings.TrimSuffix(strings.ToLower(name), ".gz")
+	ext := filepath.Ext(name)
+	switch ext {
+	case ".fit", ".gpx", ".tcx":
+		return ext, true
+	}
+	return "", false
+}
+
+// the activity id of a file.
+//
+// ids are kept to 53 bits so that they are positive and survive json decoders that use floats.
+// collisions are unlikely at that size, and are caught by `Scan`.
+func activityID(rel string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(filepath.ToSlash(rel)))
+	return int64(h.Sum64() & (1<<53 - 1))
+}
+
+// Scan the directory for changes. Returns the files that could not be decoded, along with why.
+//
+// A listing scans the directory itself, so there is no need to call this first. It is useful to report broken files.
+func (p *Provider) Scan(ctx context.Context) (map[string]error, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	seen := map[string]bool{}
+	err := filepath.WalkDir(p.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, ok := format(d.Name()); !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(p.dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		seen[rel] = true
+		e, ok := p.files[rel]
+		if ok && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+			return nil
+		}
+		a, err := decodeFile(path, rel)
+		p.files[rel] = &entry{modTime: info.ModTime(), size: info.Size(), activity: a, err: err}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", p.dir, err)
+	}
+	failed := map[string]error{}
+	for rel, e := range p.files {
+		if !seen[rel] {
+			delete(p.files, rel)
+			continue
+		}
+		if e.err != nil {
+			failed[rel] = e.err
+		}
+	}
+	// when two files have the same id, the first path keeps it so that the result does not depend on the order of the walk
+	rels := make([]string, 0, len(p.files))
+	for rel := range p.files {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	owners := map[int64]string{}
+	for _, rel := range rels {
+		e := p.files[rel]
+		e.collision = nil
+		if e.activity == nil {
+			continue
+		}
+		id := e.activity.summary.Id
+		if owner, ok := owners[id]; ok {
+			e.collision = fmt.Errorf("%s has the same activity id as %s", rel, owner)
+			failed[rel] = e.collision
+			continue
+		}
+		owners[id] = rel
+	}
+	return failed, nil
+}
+
+// every activity that was decoded, newest first
+func (p *Provider) activities(ctx context.Context) ([]*activity, error) {
+	_, err := p.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	activities := []*activity{}
+	for _, e := range p.files {
+		if e.activity != nil && e.collision == nil {
+			activities = append(activities, e.activity)
+		}
+	}
+	sort.Slice(activities, func(i, j int) bool {
+		a, b := activities[i].summary, activities[j].summary
+		if a.StartDate.Equal(b.StartDate) {
+			return a.Id > b.Id
+		}
+		return a.StartDate.After(b.StartDate)
+	})
+	return activities, nil
+}
+
+// find an activity by id. returns `api.NotFoundError` if there is no such activity
+func (p *Provider) find(ctx context.Context, activityID int) (*activity, error) {
+	activities, err := p.activities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range activities {
+		if a.summary.Id == int64(activityID) {
+			return a, nil
+		}
+	}
+	return nil, api.NotFoundError
+}
+
+// Get a page of activities, like strava's `GetActivitiesPage`. The token is ignored.
+//
+// Activities are newest first, except when `after` is set, in which case they are oldest first (as strava does).
+func (p *Provider) GetActivitiesPage(ctx context.Context, token *oauth2.Token, page int, perPage int, before, after *time.Time) ([]swagger.SummaryActivity, error) {
+	activities, err := p.activities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filtered := []swagger.SummaryActivity{}
+	for _, a := range activities {
+		if before != nil && !a.summary.StartDate.Before(*before) {
+			continue
+		}
+		if after != nil && !a.summary.StartDate.After(*after) {
+			continue
+		}
+		filtered = append(filtered, a.summary)
+	}
+	if after != nil {
+		for i, j := 0, len(filtered)-1; i < j; i, j = i+1, j-1 {
+			filtered[i], filtered[j] = filtered[j], filtered[i]
+		}
+	}
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * perPage
+	if perPage <= 0 || start >= len(filtered) {
+		return []swagger.SummaryActivity{}, nil
+	}
+	end := min(start+perPage, len(filtered))
+	return filtered[start:end], nil
+}
+
+// Get a single activity. The token and `includeAllEfforts` are ignored (local files have no segment efforts).
+func (p *Provider) GetActivity(ctx context.Context, token *oauth2.Token, activityID int, includeAllEfforts bool) (*swagger.DetailedActivity, error) {
+	a, err := p.find(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+	// a detailed activity has every field of a summary
+	data, err := json.Marshal(a.summary)
+	if err != nil {
+		return nil, err
+	}
+	detailed := &swagger.DetailedActivity{}
+	err = json.Unmarshal(data, detailed)
+	if err != nil {
+		return nil, err
+	}
+	detailed.Laps = a.laps
+	return detailed, nil
+}
+
+// Get the streams of an activity. Only the streams in `keys` that the file has are returned.
+func (p *Provider) GetActivityStreams(ctx context.Context, token *oauth2.Token, activityID int, keys []api.StreamType) (*swagger.StreamSet, error) {
+	a, err := p.find(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+	return api.FilterStreams(a.streams, keys), nil
+}
+
+// Get the laps of an activity. Gpx files have no laps.
+func (p *Provider) GetActivityLaps(ctx context.Context, token *oauth2.Token, activityID int) ([]swagger.Lap, error) {
+	a, err := p.find(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+	return a.laps, nil
+}
+
+// decode an activity file into an activity
+func decodeFile(path string, rel string) (*activity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", rel, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	ext, _ := format(path)
+	var streams *swagger.StreamSet
+	var laps []swagger.Lap
+	var start time.Time
+	var name string
+	var sport swagger.SportType
+	switch ext {
+	case ".fit":
+		file, err := fit.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		points := file.Points()
+		if len(points) > 0 {
+			start = points[0].Time
+		}
+		streams, laps = file.StreamSet(), file.StravaLaps()
+		sport = swagger.WORKOUT_SportType
+		if len(file.Sessions) > 0 {
+			sport = fitSport(file.Sessions[0].Sport)
+		}
+	case ".gpx":
+		file, err := gpx.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		points := file.Points()
+		if len(points) > 0 {
+			start = points[0].Time
+		}
+		streams, laps = file.StreamSet(), []swagger.Lap{}
+		name = file.Name
+		sport = swagger.WORKOUT_SportType
+		if len(file.Tracks) > 0 {
+			sport = gpxSport(file.Tracks[0].Type)
+		}
+	case ".tcx":
+		file, err := tcx.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		points := file.Points()
+		if len(points) > 0 {
+			start = points[0].Time
+		}
+		streams, laps = file.StreamSet(), file.StravaLaps()
+		sport = swagger.WORKOUT_SportType
+		if len(file.Activities) > 0 {
+			sport = tcxSport(file.Activities[0].Sport)
+		}
+	}
+	if streams.Time == nil {
+		return nil, fmt.Errorf("%s has no samples", rel)
+	}
+	if name == "" {
+		// the file name without its extensions (e.g. "morning run" for "morning run.fit.gz")
+		name = filepath.Base(rel)
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+		if _, ok := format(name); ok {
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+	}
+	summary := summarize(streams, start, sport)
+	summary.Id = activityID(rel)
+	summary.ExternalId = filepath.ToSlash(rel)
+	summary.Name = name
+	return &activity{summary: summary, streams: streams, laps: laps}, nil
+}
@@ -0,0 +1,232 @@
+package localfiles
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	stravaSync "github.com/jcocozza/cassidy-connector/strava/sync"
+)
+
+var _ stravaSync.StravaAPI = (*Provider)(nil)
+
+const testGPX = `<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk><type>hiking</type><trkseg>
+    <trkpt lat="40.0" lon="-105.0"><ele>1600</ele><time>2024-05-02T08:00:00Z</time></trkpt>
+    <trkpt lat="40.001" lon="-105.0"><ele>1610</ele><time>2024-05-02T08:01:00Z</time></trkpt>
+    <trkpt lat="40.001" lon="-105.0"><ele>1605</ele><time>2024-05-02T08:02:00Z</time></trkpt>
+  </trkseg></trk>
+</gpx>`
+
+const testTCX = `<?xml version="1.0"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities><Activity Sport="Running"><Lap StartTime="2024-05-03T08:00:00Z">
+    <TotalTimeSeconds>20</TotalTimeSeconds><DistanceMeters>60</DistanceMeters>
+    <Track>
+      <Trackpoint><Time>2024-05-03T08:00:00Z</Time><DistanceMeters>0</DistanceMeters><HeartRateBpm><Value>120</Value></HeartRateBpm></Trackpoint>
+      <Trackpoint><Time>2024-05-03T08:00:20Z</Time><DistanceMeters>60</DistanceMeters><HeartRateBpm><Value>130</Value></HeartRateBpm></Trackpoint>
+    </Track>
+  </Lap></Activity></Activities>
+</TrainingCenterDatabase>`
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// a directory with a fit, a gzipped gpx, a tcx in a subdirectory, a broken file and a file that is not an activity
+func testDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	run, err := os.ReadFile("../fit/testdata/run.fit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "run.fit"), run)
+	f, err := os.Create(filepath.Join(dir, "hike.gpx.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(testGPX))
+	gz.Close()
+	f.Close()
+	writeFile(t, filepath.Join(dir, "2024", "tempo.tcx"), []byte(testTCX))
+	writeFile(t, filepath.Join(dir, "broken.fit"), []byte("not a fit file"))
+	writeFile(t, filepath.Join(dir, "notes.txt"), []byte("hello"))
+	return dir
+}
+
+func TestGetActivitiesPage(t *testing.T) {
+	p := New(testDir(t))
+	ctx := context.Background()
+	activities, err := p.GetActivitiesPage(ctx, nil, 1, 10, nil, nil)
+	if err != nil {
+		t.Fatalf("GetActivitiesPage() error = %v", err)
+	}
+	if len(activities) != 3 {
+		t.Fatalf("GetActivitiesPage() = %d activities, want 3", len(activities))
+	}
+	// newest first
+	tempo, hike, run := activities[0], activities[1], activities[2]
+	if tempo.ExternalId != "2024/tempo.tcx" || hike.ExternalId != "hike.gpx.gz" || run.ExternalId != "run.fit" {
+		t.Fatalf("order = %s, %s, %s", tempo.ExternalId, hike.ExternalId, run.ExternalId)
+	}
+	if tempo.Name != "tempo" || *tempo.SportType != swagger.RUN_SportType || tempo.Distance != 60 || tempo.ElapsedTime != 20 || !tempo.Trainer {
+		t.Errorf("tempo = %+v", tempo)
+	}
+	if hike.Name != "hike" || *hike.SportType != swagger.HIKE_SportType || hike.TotalElevationGain != 10 || hike.ElevHigh != 1610 || hike.ElevLow != 1600 {
+		t.Errorf("hike = %+v", hike)
+	}
+	// the hike stops moving for the last minute
+	if hike.ElapsedTime != 120 || hike.MovingTime != 60 || hike.Distance < 110 || hike.Distance > 112 {
+		t.Errorf("hike elapsed = %d, moving = %d, distance = %v", hike.ElapsedTime, hike.MovingTime, hike.Distance)
+	}
+	if *run.SportType != swagger.RUN_SportType || run.StartLatlng == nil || run.Trainer {
+		t.Errorf("run = %+v", run)
+	}
+	// `after` sorts oldest first
+	after := run.StartDate
+	activities, err = p.GetActivitiesPage(ctx, nil, 1, 1, nil, &after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activities) != 1 || activities[0].Id != hike.Id {
+		t.Errorf("GetActivitiesPage(after) = %+v, want the hike", activities)
+	}
+	before := hike.StartDate
+	activities, err = p.GetActivitiesPage(ctx, nil, 1, 10, &before, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activities) != 1 || activities[0].Id != run.Id {
+		t.Errorf("GetActivitiesPage(before) = %+v, want the run", activities)
+	}
+	activities, err = p.GetActivitiesPage(ctx, nil, 2, 10, nil, nil)
+	if err != nil || len(activities) != 0 {
+		t.Errorf("GetActivitiesPage(page 2) = %v, %v, want nothing", activities, err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := testDir(t)
+	p := New(dir)
+	ctx := context.Background()
+	failed, err := p.Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(failed) != 1 || failed["broken.fit"] == nil {
+		t.Errorf("Scan() failed = %v, want broken.fit", failed)
+	}
+	// ids are stable across providers
+	first, _ := p.GetActivitiesPage(ctx, nil, 1, 10, nil, nil)
+	second, _ := New(dir).GetActivitiesPage(ctx, nil, 1, 10, nil, nil)
+	if first[0].Id != second[0].Id || first[0].Id <= 0 {
+		t.Errorf("ids = %d and %d, want the same positive id", first[0].Id, second[0].Id)
+	}
+	// removed and changed files are picked up
+	err = os.Remove(filepath.Join(dir, "run.fit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "2024", "tempo.tcx")
+	writeFile(t, path, []byte(testGPX))
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	activities, err := p.GetActivitiesPage(ctx, nil, 1, 10, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activities) != 1 || activities[0].ExternalId != "hike.gpx.gz" {
+		t.Errorf("after changes = %+v, want only the hike (tempo.tcx is now a gpx and fails to decode)", activities)
+	}
+}
+
+func TestScan_collision(t *testing.T) {
+	p := New(testDir(t))
+	ctx := context.Background()
+	_, err := p.Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	// pretend the two files hash to the same id
+	p.files["run.fit"].activity.summary.Id = p.files["hike.gpx.gz"].activity.summary.Id
+	failed, err := p.Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if failed["run.fit"] == nil {
+		t.Errorf("Scan() failed = %v, want run.fit to collide with hike.gpx.gz", failed)
+	}
+	activities, err := p.GetActivitiesPage(ctx, nil, 1, 10, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range activities {
+		if a.ExternalId == "run.fit" {
+			t.Errorf("GetActivitiesPage() listed run.fit, want it left out")
+		}
+	}
+	if len(activities) != 2 {
+		t.Errorf("GetActivitiesPage() = %d activities, want 2", len(activities))
+	}
+}
+
+func TestGetActivity(t *testing.T) {
+	p := New(testDir(t))
+	ctx := context.Background()
+	activities, err := p.GetActivitiesPage(ctx, nil, 1, 10, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := activities[2]
+	detailed, err := p.GetActivity(ctx, nil, int(run.Id), false)
+	if err != nil {
+		t.Fatalf("GetActivity() error = %v", err)
+	}
+	if detailed.Id != run.Id || detailed.Distance != run.Distance || len(detailed.Laps) != 2 {
+		t.Errorf("GetActivity() = %+v", detailed)
+	}
+	laps, err := p.GetActivityLaps(ctx, nil, int(run.Id))
+	if err != nil || len(laps) != 2 {
+		t.Errorf("GetActivityLaps() = %v, %v", laps, err)
+	}
+	streams, err := p.GetActivityStreams(ctx, nil, int(run.Id), []api.StreamType{api.Time, api.Heartrate})
+	if err != nil {
+		t.Fatalf("GetActivityStreams() error = %v", err)
+	}
+	if streams.Time == nil || streams.Heartrate == nil || streams.Latlng != nil || streams.Distance != nil {
+		t.Errorf("GetActivityStreams() = %+v, want only time and heartrate", streams)
+	}
+	_, err = p.GetActivity(ctx, nil, 1, false)
+	if !errors.Is(err, api.NotFoundError) {
+		t.Errorf("GetActivity(unknown) error = %v, want NotFoundError", err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	sink := stravaSync.MemorySink{}
+	syncer := stravaSync.NewSyncer(New(testDir(t)), stravaSync.NewFileCheckpointStore(t.TempDir()), sink, nil)
+	report, err := syncer.Sync(context.Background(), nil, 0)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Created != 3 || len(sink) != 3 {
+		t.Errorf("Sync() = %+v, want 3 created", report)
+	}
+}
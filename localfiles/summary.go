@@ -0,0 +1,130 @@
+package localfiles
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/fit"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+func fitSport(sport uint8) swagger.SportType {
+	switch sport {
+	case fit.SportRunning:
+		return swagger.RUN_SportType
+	case fit.SportCycling:
+		return swagger.RIDE_SportType
+	case fit.SportSwimming:
+		return swagger.SWIM_SportType
+	case fit.SportWalking:
+		return swagger.WALK_SportType
+	case fit.SportHiking:
+		return swagger.HIKE_SportType
+	case fit.SportRowing:
+		return swagger.ROWING_SportType
+	}
+	return swagger.WORKOUT_SportType
+}
+
+// tcx only has "Running", "Biking" and "Other"
+func tcxSport(sport string) swagger.SportType {
+	switch strings.ToLower(sport) {
+	case "running":
+		return swagger.RUN_SportType
+	case "biking":
+		return swagger.RIDE_SportType
+	}
+	return swagger.WORKOUT_SportType
+}
+
+// the gpx type is free text, so match on the common words
+func gpxSport(t string) swagger.SportType {
+	t = strings.ToLower(t)
+	switch {
+	case strings.Contains(t, "run"):
+		return swagger.RUN_SportType
+	case strings.Contains(t, "cycl"), strings.Contains(t, "bik"), strings.Contains(t, "ride"):
+		return swagger.RIDE_SportType
+	case strings.Contains(t, "walk"):
+		return swagger.WALK_SportType
+	case strings.Contains(t, "hik"):
+		return swagger.HIKE_SportType
+	case strings.Contains(t, "swim"):
+		return swagger.SWIM_SportType
+	case strings.Contains(t, "row"):
+		return swagger.ROWING_SportType
+	}
+	return swagger.WORKOUT_SportType
+}
+
+// derive a summary from the streams of an activity
+func summarize(streams *swagger.StreamSet, start time.Time, sport swagger.SportType) swagger.SummaryActivity {
+	at := swagger.ActivityType(sport)
+	summary := swagger.SummaryActivity{
+		StartDate:      start.UTC(),
+		StartDateLocal: start,
+		SportType:      &sport,
+		Type_:          &at,
+	}
+	t := streams.Time.Data
+	summary.ElapsedTime = t[len(t)-1] - t[0]
+	if streams.Distance != nil {
+		d := streams.Distance.Data
+		summary.Distance = d[len(d)-1]
+		for i := 1; i < len(d); i++ {
+			dt := float32(t[i] - t[i-1])
+			if dt > 0 {
+				summary.MaxSpeed = max(summary.MaxSpeed, (d[i]-d[i-1])/dt)
+			}
+		}
+	}
+	if streams.Moving != nil {
+		for i := 1; i < len(t); i++ {
+			if streams.Moving.Data[i] {
+				summary.MovingTime += t[i] - t[i-1]
+			}
+		}
+	} else {
+		summary.MovingTime = summary.ElapsedTime
+	}
+	if summary.MovingTime > 0 {
+		summary.AverageSpeed = summary.Distance / float32(summary.MovingTime)
+	}
+	if streams.VelocitySmooth != nil {
+		summary.MaxSpeed = 0
+		for _, v := range streams.VelocitySmooth.Data {
+			summary.MaxSpeed = max(summary.MaxSpeed, v)
+		}
+	}
+	if streams.Altitude != nil {
+		a := streams.Altitude.Data
+		summary.ElevHigh, summary.ElevLow = a[0], a[0]
+		for i, v := range a {
+			summary.ElevHigh = max(summary.ElevHigh, v)
+			summary.ElevLow = min(summary.ElevLow, v)
+			if i > 0 && v > a[i-1] {
+				summary.TotalElevationGain += v - a[i-1]
+			}
+		}
+	}
+	if streams.Latlng != nil {
+		l := streams.Latlng.Data
+		startLatLng, endLatLng := l[0], l[len(l)-1]
+		summary.StartLatlng, summary.EndLatlng = &startLatLng, &endLatLng
+	} else {
+		// no positions means it was recorded indoors
+		summary.Trainer = true
+	}
+	if streams.Watts != nil {
+		var total float64
+		for _, w := range streams.Watts.Data {
+			total += float64(w)
+			summary.MaxWatts = max(summary.MaxWatts, w)
+		}
+		summary.AverageWatts = float32(total / float64(len(streams.Watts.Data)))
+		summary.Kilojoules = float32(math.Round(float64(summary.AverageWatts) * float64(summary.MovingTime) / 1000))
+		summary.DeviceWatts = true
+	}
+	return summary
+}
@@ -0,0 +1,146 @@
+// Package oauthcli has the commands that the clis of the oauth providers (e.g. cassidy-garmin) share:
+// approval-url and initial-access for the oauth flow, and fake-server for the provider's local stand-in.
+package oauthcli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// The part of a provider's app that the oauth commands use
+type App interface {
+	ApprovalUrl(state string) string
+	GetAccessTokenFromAuthorizationCode(ctx context.Context, code string) (*oauth2.Token, error)
+}
+
+// What the oauth commands need from a provider's cli
+type Provider struct {
+	// create the app from the flags of the cli
+	CreateApp func() (App, error)
+	// optional; how initial-access writes the token (default `json.Marshal`)
+	MarshalToken func(token *oauth2.Token) ([]byte, error)
+	// optional; appended to the help of initial-access (e.g. how long the token lasts)
+	InitialAccessHelp string
+	// the path to also write successful output to (the -f flag of the cli)
+	OutputPath *string
+}
+
+// print the output, and write it to the output path if there is one
+func (p Provider) output(data []byte) {
+	if p.OutputPath != nil && *p.OutputPath != "" {
+		utils.WriteOutput(*p.OutputPath, data)
+	}
+	fmt.Println(string(data))
+}
+
+// Add approval-url and initial-access to `root`
+func AddOAuthCommands(root *cobra.Command, p Provider) {
+	marshal := p.MarshalToken
+	if marshal == nil {
+		marshal = func(token *oauth2.Token) ([]byte, error) { return json.Marshal(token) }
+	}
+	long := "Used for getting the user's access token for the first time. You are responsible for persisting the returned token so that it can be used later."
+	if p.InitialAccessHelp != "" {
+		long += " " + p.InitialAccessHelp
+	}
+
+	// Used to get the first access token.
+	initialAccess := &cobra.Command{
+		Use:   "initial-access [authorization code]",
+		Short: "For getting the user's access token for the first time.",
+		Long:  long,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			a, err := p.CreateApp()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			token, err := a.GetAccessTokenFromAuthorizationCode(context.TODO(), args[0])
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			jsonBytes, err := marshal(token)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			p.output(jsonBytes)
+		},
+	}
+
+	var state string
+	// Used to get the approval url for the user to grant access
+	approvalUrl := &cobra.Command{
+		Use:   "approval-url",
+		Short: "Generate the approval url for the user to grant access.",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			a, err := p.CreateApp()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			p.output([]byte(a.ApprovalUrl(state)))
+		},
+	}
+	approvalUrl.Flags().StringVar(&state, "state", "cassidy", "sent back with the authorization code")
+
+	root.AddCommand(initialAccess)
+	root.AddCommand(approvalUrl)
+}
+
+// What fake-server needs to know about a provider's local stand-in
+type FakeServer struct {
+	// e.g. "garmin"
+	Platform string
+	// what the stand-in is for, e.g. "the garmin api"
+	API string
+	// what the demo server has, e.g. "a few demo activities"
+	Demo string
+	// the flags that point the other commands at the server, e.g. "--base-url"
+	URLFlags string
+	// where the server listens by default
+	DefaultAddr string
+	// the --client-id and --client-secret flags of the cli
+	ClientID     *string
+	ClientSecret *string
+	// create the demo server
+	NewDemoServer func(clientID string, clientSecret string) http.Handler
+}
+
+// Add fake-server to `root`
+func AddFakeServerCommand(root *cobra.Command, f FakeServer) {
+	var addr string
+	launchFakeServer := &cobra.Command{
+		Use:   "fake-server",
+		Short: fmt.Sprintf("Run a local stand-in for %s, with %s.", f.API, f.Demo),
+		Long: fmt.Sprintf(`Run a local stand-in for %s, with %s.
+
+Only --client-id and --client-secret can get tokens. Every authorization is approved straight away, so opening the approval url redirects to --redirect-url with a code.
+The other commands talk to this server by default (see %s).`, f.API, f.Demo, f.URLFlags),
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			if *f.ClientID == "" || *f.ClientSecret == "" {
+				fmt.Println("--client-id and --client-secret are required")
+				return
+			}
+			srv := f.NewDemoServer(*f.ClientID, *f.ClientSecret)
+			fmt.Printf("fake %s server listening on http://%s\n", f.Platform, addr)
+			err := http.ListenAndServe(addr, srv)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		},
+	}
+	launchFakeServer.Flags().StringVar(&addr, "addr", f.DefaultAddr, "the address to listen on")
+	root.AddCommand(launchFakeServer)
+}
@@ -0,0 +1,135 @@
+// Package plan is the common model of planned (structured) workouts.
+//
+// Every platform that has plans (final surge, trainingpeaks, ...) maps its workouts into a `PlannedWorkout`,
+// so plans can be compared, totalled and exported without knowing where they came from.
+package plan
+
+import (
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// How hard a step is meant to be
+type Intensity string
+
+const (
+	Warmup   Intensity = "warmup"
+	Active   Intensity = "active"
+	Rest     Intensity = "rest"
+	Recovery Intensity = "recovery"
+	Cooldown Intensity = "cooldown"
+	Other    Intensity = "other"
+)
+
+// What ends a step
+type DurationType string
+
+const (
+	// seconds
+	DurationTime DurationType = "time"
+	// meters
+	DurationDistance DurationType = "distance"
+	// until the athlete presses lap
+	DurationOpen DurationType = "open"
+)
+
+// What a step is paced by
+type TargetType string
+
+const (
+	TargetNone TargetType = "none"
+	// seconds per kilometer. note that the faster end of the band is the lower number
+	TargetPace TargetType = "pace"
+	// beats per minute
+	TargetHeartRate TargetType = "heart_rate"
+	// watts
+	TargetPower TargetType = "power"
+	// rotations (or steps) per minute
+	TargetCadence TargetType = "cadence"
+)
+
+// The band a step should be done in
+type Target struct {
+	Type TargetType `json:"type"`
+	Low  float64    `json:"low,omitempty"`
+	High float64    `json:"high,omitempty"`
+	// Low and High are percents of the athlete's threshold (ftp, threshold heart rate or threshold pace) rather than absolute values
+	OfThreshold bool `json:"of_threshold,omitempty"`
+	// Low and High are percents of the athlete's maximum (e.g. max heart rate) rather than absolute values
+	OfMax bool `json:"of_max,omitempty"`
+}
+
+// A Step is a single piece of a workout, or a repeat of other steps
+type Step struct {
+	Name      string       `json:"name,omitempty"`
+	Intensity Intensity    `json:"intensity,omitempty"`
+	Duration  DurationType `json:"duration_type,omitempty"`
+	// seconds or meters, per `Duration`
+	Value  float64 `json:"value,omitempty"`
+	Target Target  `json:"target"`
+	Notes  string  `json:"notes,omitempty"`
+	// the number of times `Steps` are done. 0 for a single step
+	Repeat int    `json:"repeat,omitempty"`
+	Steps  []Step `json:"steps,omitempty"`
+}
+
+// A PlannedWorkout is a workout on an athlete's calendar, from any platform
+type PlannedWorkout struct {
+	// the platform the workout came from, e.g. "trainingpeaks" or "finalsurge"
+	Source string `json:"source"`
+	// the id of the workout on that platform
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Sport       swagger.SportType `json:"sport"`
+	// the day the workout is planned for (midnight, as if it were utc)
+	Date time.Time `json:"date"`
+	// the planned totals, which platforms can have with or without steps. seconds and meters
+	PlannedDuration float64 `json:"planned_duration,omitempty"`
+	PlannedDistance float64 `json:"planned_distance,omitempty"`
+	// training stress score
+	PlannedLoad float64 `json:"planned_load,omitempty"`
+	// empty if the workout is not structured
+	Steps []Step `json:"steps,omitempty"`
+}
+
+func flatten(steps []Step) []Step {
+	flat := []Step{}
+	for _, s := range steps {
+		if s.Repeat == 0 && len(s.Steps) == 0 {
+			flat = append(flat, s)
+			continue
+		}
+		inner := flatten(s.Steps)
+		for i := 0; i < max(s.Repeat, 1); i++ {
+			flat = append(flat, inner...)
+		}
+	}
+	return flat
+}
+
+// The steps in the order they are done, with repeats expanded
+func (w PlannedWorkout) Flatten() []Step {
+	return flatten(w.Steps)
+}
+
+// The total seconds of the steps that end on time and the total meters of the steps that end on distance.
+//
+// Steps that end on the other (or are open) are not estimated, so use the planned totals when a workout mixes them.
+func (w PlannedWorkout) StepTotals() (seconds float64, meters float64) {
+	for _, s := range w.Flatten() {
+		switch s.Duration {
+		case DurationTime:
+			seconds += s.Value
+		case DurationDistance:
+			meters += s.Value
+		}
+	}
+	return seconds, meters
+}
+
+// Whether the workout has steps
+func (w PlannedWorkout) Structured() bool {
+	return len(w.Steps) > 0
+}
@@ -0,0 +1,37 @@
+package plan
+
+import "testing"
+
+func TestFlatten(t *testing.T) {
+	w := PlannedWorkout{Steps: []Step{
+		{Name: "warm up", Intensity: Warmup, Duration: DurationTime, Value: 600},
+		{Repeat: 3, Steps: []Step{
+			{Name: "on", Intensity: Active, Duration: DurationDistance, Value: 400},
+			{Repeat: 2, Steps: []Step{
+				{Name: "float", Intensity: Recovery, Duration: DurationTime, Value: 30},
+			}},
+		}},
+		{Name: "cool down", Intensity: Cooldown, Duration: DurationOpen},
+	}}
+	flat := w.Flatten()
+	names := []string{}
+	for _, s := range flat {
+		names = append(names, s.Name)
+	}
+	want := []string{"warm up", "on", "float", "float", "on", "float", "float", "on", "float", "float", "cool down"}
+	if len(names) != len(want) {
+		t.Fatalf("Flatten() = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("Flatten() = %v, want %v", names, want)
+		}
+	}
+	seconds, meters := w.StepTotals()
+	if seconds != 600+6*30 || meters != 1200 {
+		t.Errorf("StepTotals() = %v, %v, want 780, 1200", seconds, meters)
+	}
+	if !w.Structured() || (PlannedWorkout{}).Structured() {
+		t.Errorf("Structured() is wrong")
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/polar/client"
+	"github.com/jcocozza/ratelimit"
+	"golang.org/x/oauth2"
+)
+
+// if the polar api returns 404 not found, will throw this error
+var NotFoundError = errors.New("Object not found")
+
+// if a user is registered twice, will throw this error
+var AlreadyRegisteredError = errors.New("User is already registered")
+
+// if the rate limiter throws an error
+var RateLimitError = errors.New("Rate Limit Error. (this likely means context expired while waiting for rate limits to be reset)")
+
+const (
+	// polar's limits grow with the number of registered users. these are the limits for a client with no users
+	ReadLimitShortTerm         = 500.0
+	ReadLimitShortTermDuration = time.Duration(15 * time.Minute)
+	ReadLimitDaily             = 5000.0
+	ReadLimitDailyDuration     = time.Duration(24 * time.Hour)
+)
+
+// The PolarAPI struct is the primary means of interacting with the polar accesslink api.
+//
+// It wraps the lower level `client` with rate limiting, and maps polar exercises into the strava models.
+// Polar access tokens do not expire, so there is no refreshing.
+//
+// Unlike strava, polar does not page through history: new exercises are pulled in transactions (see `PullExercises`).
+//
+// # Whenever possible, this will return the NotFoundError when the underlying polar api returns a 404
+//
+// Make sure that every context has a timeout, otherwise the program will block until the rate limits refreshes.
+type PolarAPI struct {
+	baseURL          string
+	logger           *slog.Logger
+	oauth            *oauth2.Config
+	limiterShortTerm *ratelimit.FixedWindow
+	limiterDaily     *ratelimit.FixedWindow
+}
+
+func NewPolarAPI(baseURL string, cfg *oauth2.Config, logger *slog.Logger) *PolarAPI {
+	return &PolarAPI{
+		baseURL:          baseURL,
+		logger:           logger,
+		oauth:            cfg,
+		limiterShortTerm: ratelimit.NewFixedWindow(ReadLimitShortTermDuration, ReadLimitShortTerm),
+		limiterDaily:     ratelimit.NewFixedWindow(ReadLimitDailyDuration, ReadLimitDaily),
+	}
+}
+
+// check to see if the limits have been surpassed
+//
+// if you have exceeded the rate limit, will sleep until the next time interval
+//
+// ** should be called before every api call **
+func (api *PolarAPI) checkRateLimits(ctx context.Context) error {
+	err := api.limiterDaily.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed daily rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	err = api.limiterShortTerm.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed 15 minute rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	return nil
+}
+
+// Return the number of requests remaining in the 15 minute and daily windows respectively
+func (api *PolarAPI) RemainingRequests() (int, int) {
+	return api.limiterShortTerm.RequestsRemaining(), api.limiterDaily.RequestsRemaining()
+}
+
+// a client that authenticates with the token
+func (api *PolarAPI) client(ctx context.Context, token *oauth2.Token) *client.Client {
+	return client.New(api.baseURL, api.oauth.Client(ctx, token))
+}
+
+// map the client's errors onto this package's
+func (api *PolarAPI) wrap(ctx context.Context, err error) error {
+	if errors.Is(err, client.NotFoundError) {
+		api.logger.DebugContext(ctx, "object not found")
+		return NotFoundError
+	}
+	api.logger.ErrorContext(ctx, "request failed", slog.String("error", err.Error()))
+	return err
+}
+
+// Register the user the token is for. This must be done once (after the first token exchange) before any of their data can be read.
+//
+// `memberID` is any id you want to know the user by. Registering a user twice returns an `AlreadyRegisteredError`, which is safe to ignore.
+func (api *PolarAPI) RegisterUser(ctx context.Context, token *oauth2.Token, memberID string) (*client.User, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.InfoContext(ctx, "registering user", slog.String("member id", memberID))
+	user, err := api.client(ctx, token).RegisterUser(ctx, memberID)
+	if errors.Is(err, client.ConflictError) {
+		api.logger.DebugContext(ctx, "user is already registered")
+		return nil, AlreadyRegisteredError
+	}
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return user, nil
+}
+
+// Get a registered user
+func (api *PolarAPI) GetUser(ctx context.Context, token *oauth2.Token, userID int64) (*client.User, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting user", slog.Int64("user id", userID))
+	user, err := api.client(ctx, token).GetUser(ctx, userID)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return user, nil
+}
+
+// Deregister a user. Their token no longer works afterwards
+func (api *PolarAPI) DeleteUser(ctx context.Context, token *oauth2.Token, userID int64) error {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return err
+	}
+	api.logger.InfoContext(ctx, "deleting user", slog.Int64("user id", userID))
+	err = api.client(ctx, token).DeleteUser(ctx, userID)
+	if err != nil {
+		return api.wrap(ctx, err)
+	}
+	return nil
+}
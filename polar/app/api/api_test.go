@@ -0,0 +1,318 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/fakeoauth"
+	"github.com/jcocozza/cassidy-connector/polar/client"
+	"github.com/jcocozza/cassidy-connector/polar/fake"
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"golang.org/x/oauth2"
+)
+
+// start a demo server and go through the oauth flow. the user is not registered
+func setup(t *testing.T) (*fake.Server, *PolarAPI, *oauth2.Token) {
+	t.Helper()
+	srv := fake.NewDemoServer("id", "secret")
+	tc := fakeoauth.NewTestConfig(t, srv, oauth2.Endpoint{AuthURL: "/oauth2/authorization", TokenURL: "/v2/oauth2/token", AuthStyle: oauth2.AuthStyleInHeader})
+	token := tc.Token
+	if id, _ := token.Extra("x_user_id").(float64); int64(id) != fake.UserID {
+		t.Fatalf("token x_user_id = %v, want %d", token.Extra("x_user_id"), fake.UserID)
+	}
+	if !token.Expiry.IsZero() || token.RefreshToken != "" {
+		t.Errorf("token = %+v, want one that never expires", token)
+	}
+	return srv, NewPolarAPI(tc.URL, tc.Config, slog.New(slog.NewTextHandler(io.Discard, nil))), token
+}
+
+// start a demo server with the user registered
+func setupRegistered(t *testing.T) (*fake.Server, *PolarAPI, *oauth2.Token) {
+	t.Helper()
+	srv, api, token := setup(t)
+	_, err := api.RegisterUser(context.Background(), token, "member-1")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	return srv, api, token
+}
+
+func TestRegisterUser(t *testing.T) {
+	srv, api, token := setup(t)
+	ctx := context.Background()
+	// nothing can be read before the user is registered
+	_, err := api.BeginTransaction(ctx, token, fake.UserID)
+	if !errors.Is(err, NotFoundError) {
+		t.Errorf("BeginTransaction(unregistered) error = %v, want NotFoundError", err)
+	}
+	user, err := api.RegisterUser(ctx, token, "member-1")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	if user.PolarUserID != fake.UserID || user.MemberID != "member-1" || !srv.Registered() {
+		t.Errorf("RegisterUser() = %+v", user)
+	}
+	_, err = api.RegisterUser(ctx, token, "member-1")
+	if !errors.Is(err, AlreadyRegisteredError) {
+		t.Errorf("RegisterUser(again) error = %v, want AlreadyRegisteredError", err)
+	}
+	user, err = api.GetUser(ctx, token, fake.UserID)
+	if err != nil || user.MemberID != "member-1" {
+		t.Errorf("GetUser() = %+v, %v", user, err)
+	}
+	err = api.DeleteUser(ctx, token, fake.UserID)
+	if err != nil || srv.Registered() {
+		t.Errorf("DeleteUser() error = %v, registered = %v", err, srv.Registered())
+	}
+	_, err = api.GetUser(ctx, token, fake.UserID)
+	if !errors.Is(err, NotFoundError) {
+		t.Errorf("GetUser(deleted) error = %v, want NotFoundError", err)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	srv, api, token := setupRegistered(t)
+	ctx := context.Background()
+	tx, err := api.BeginTransaction(ctx, token, fake.UserID)
+	if err != nil || tx == nil {
+		t.Fatalf("BeginTransaction() = %v, %v", tx, err)
+	}
+	ids, err := tx.Exercises(ctx)
+	if err != nil || len(ids) != 2 || ids[0] != fake.DemoRunID || ids[1] != fake.DemoStrengthID {
+		t.Fatalf("Exercises() = %v, %v", ids, err)
+	}
+	run, err := tx.Exercise(ctx, fake.DemoRunID)
+	if err != nil {
+		t.Fatalf("Exercise() error = %v", err)
+	}
+	if run.TransactionID != tx.ID || run.Elapsed() != 10*time.Second {
+		t.Errorf("Exercise() = %+v", run)
+	}
+	var buf bytes.Buffer
+	err = tx.Download(ctx, fake.DemoRunID, client.GPX, &buf)
+	if err != nil || buf.Len() == 0 {
+		t.Errorf("Download(gpx) = %d bytes, %v", buf.Len(), err)
+	}
+	// the strength session has no files
+	err = tx.Download(ctx, fake.DemoStrengthID, client.FIT, io.Discard)
+	if !errors.Is(err, NotFoundError) {
+		t.Errorf("Download(no file) error = %v, want NotFoundError", err)
+	}
+	// a new transaction drops the one that is open (as if it had expired)
+	next, err := api.BeginTransaction(ctx, token, fake.UserID)
+	if err != nil || next == nil || next.ID == tx.ID {
+		t.Fatalf("BeginTransaction(again) = %v, %v", next, err)
+	}
+	err = tx.Commit(ctx)
+	if !errors.Is(err, NotFoundError) {
+		t.Errorf("Commit(dropped) error = %v, want NotFoundError", err)
+	}
+	if len(srv.Pending()) != 2 {
+		t.Errorf("Pending() = %v, want both exercises", srv.Pending())
+	}
+	err = next.Commit(ctx)
+	if err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if len(srv.Pending()) != 0 {
+		t.Errorf("Pending() = %v, want none", srv.Pending())
+	}
+	_, err = next.Exercises(ctx)
+	if !errors.Is(err, CommittedError) {
+		t.Errorf("Exercises(committed) error = %v, want CommittedError", err)
+	}
+	none, err := api.BeginTransaction(ctx, token, fake.UserID)
+	if err != nil || none != nil {
+		t.Errorf("BeginTransaction(no new exercises) = %v, %v, want nil, nil", none, err)
+	}
+}
+
+func TestPullExercises(t *testing.T) {
+	srv, api, token := setupRegistered(t)
+	ctx := context.Background()
+	persisted := map[int64]PulledExercise{}
+	calls := 0
+	failing := func(ctx context.Context, pulled PulledExercise) error {
+		calls++
+		if pulled.Exercise.ID == fake.DemoStrengthID {
+			return errors.New("disk full")
+		}
+		persisted[pulled.Exercise.ID] = pulled
+		return nil
+	}
+	opts := &PullOpts{Formats: []client.Format{client.GPX}, Streams: true}
+	result, err := api.PullExercises(ctx, token, fake.UserID, opts, failing)
+	if err == nil || result.Committed || len(result.Exercises) != 1 {
+		t.Fatalf("PullExercises(failing persist) = %+v, %v, want an error and no commit", result, err)
+	}
+	// nothing was committed, so both exercises are offered again
+	if len(srv.Pending()) != 2 {
+		t.Fatalf("Pending() = %v, want both exercises", srv.Pending())
+	}
+	run := persisted[fake.DemoRunID]
+	if len(run.Files[client.FIT]) == 0 || len(run.Files[client.GPX]) == 0 || run.Streams == nil || len(run.Streams.Time.Data) != 10 {
+		t.Errorf("pulled run files = %d fit %d gpx bytes, streams = %+v", len(run.Files[client.FIT]), len(run.Files[client.GPX]), run.Streams)
+	}
+	start, _ := time.Parse(time.RFC3339, "2021-09-08T01:47:06Z")
+	if !run.Summary.StartDate.Equal(start) || *run.Summary.SportType != swagger.RUN_SportType || run.Summary.ElapsedTime != 10 || run.Summary.Distance != 90 {
+		t.Errorf("pulled run summary = %+v", run.Summary)
+	}
+	if run.Detailed.DeviceName != "Polar Vantage V2" || run.Detailed.Calories != 2 {
+		t.Errorf("pulled run detail = %+v", run.Detailed)
+	}
+
+	succeeding := func(ctx context.Context, pulled PulledExercise) error {
+		calls++
+		persisted[pulled.Exercise.ID] = pulled
+		return nil
+	}
+	result, err = api.PullExercises(ctx, token, fake.UserID, nil, succeeding)
+	if err != nil || !result.Committed || len(result.Exercises) != 2 {
+		t.Fatalf("PullExercises() = %+v, %v", result, err)
+	}
+	// the run was persisted again, since the first transaction was not committed
+	if calls != 4 || len(srv.Pending()) != 0 {
+		t.Errorf("persist calls = %d, pending = %v, want 4 and none", calls, srv.Pending())
+	}
+	strength := persisted[fake.DemoStrengthID]
+	if *strength.Summary.SportType != swagger.WEIGHT_TRAINING_SportType || strength.Summary.MovingTime != 2730 || len(strength.Files) != 0 || strength.Streams != nil {
+		t.Errorf("pulled strength = %+v", strength)
+	}
+	result, err = api.PullExercises(ctx, token, fake.UserID, nil, succeeding)
+	if err != nil || result.TransactionID != 0 || len(result.Exercises) != 0 {
+		t.Errorf("PullExercises(nothing new) = %+v, %v", result, err)
+	}
+}
+
+func TestPullExercises_DecodeError(t *testing.T) {
+	srv, api, token := setupRegistered(t)
+	broken := fake.DemoExercises()[0]
+	broken.ID = 7300000003
+	err := srv.AddExercise(broken, map[client.Format][]byte{client.FIT: []byte("not a fit file")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	persisted := map[int64]PulledExercise{}
+	persist := func(ctx context.Context, pulled PulledExercise) error {
+		persisted[pulled.Exercise.ID] = pulled
+		return nil
+	}
+	result, err := api.PullExercises(context.Background(), token, fake.UserID, &PullOpts{Streams: true}, persist)
+	// the broken file does not hold up the queue
+	if err != nil || !result.Committed || len(result.Exercises) != 3 || len(srv.Pending()) != 0 {
+		t.Fatalf("PullExercises() = %+v, %v, pending %v", result, err, srv.Pending())
+	}
+	got := persisted[broken.ID]
+	if got.DecodeErr == nil || got.Streams != nil || string(got.Files[client.FIT]) != "not a fit file" {
+		t.Errorf("pulled broken exercise = %+v", got)
+	}
+	if run := persisted[fake.DemoRunID]; run.DecodeErr != nil || run.Streams == nil {
+		t.Errorf("pulled run = %v streams, decode error %v", run.Streams, run.DecodeErr)
+	}
+}
+
+func TestPuller(t *testing.T) {
+	srv, api, token := setupRegistered(t)
+	ctx := context.Background()
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	persisted := map[int64]bool{}
+	persist := func(ctx context.Context, pulled PulledExercise) error {
+		entered <- struct{}{}
+		<-release
+		mu.Lock()
+		persisted[pulled.Exercise.ID] = true
+		mu.Unlock()
+		return nil
+	}
+	pulls := 0
+	var errs []error
+	done := func(result *PullResult, err error) {
+		mu.Lock()
+		pulls++
+		if err != nil {
+			errs = append(errs, err)
+		}
+		mu.Unlock()
+	}
+	p := api.NewPuller(token, fake.UserID, nil, persist, done)
+
+	first := make(chan struct{})
+	go func() {
+		p.Trigger(ctx)
+		close(first)
+	}()
+	<-entered
+	// two more events arrive while the first pull is persisting. they must not begin a transaction of their own
+	newer := fake.DemoExercises()[0]
+	newer.ID = 7300000003
+	if err := srv.AddExercise(newer, nil); err != nil {
+		t.Fatal(err)
+	}
+	p.Trigger(ctx)
+	p.Trigger(ctx)
+	close(release)
+	<-first
+
+	if len(errs) != 0 || pulls != 2 {
+		t.Errorf("pulls = %d, errors = %v, want 2 pulls without errors", pulls, errs)
+	}
+	if len(persisted) != 3 || len(srv.Pending()) != 0 {
+		t.Errorf("persisted = %v, pending = %v, want every exercise persisted and committed", persisted, srv.Pending())
+	}
+}
+
+func TestSinkPersister(t *testing.T) {
+	_, api, token := setupRegistered(t)
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	result, err := api.PullExercises(context.Background(), token, fake.UserID, &PullOpts{Streams: true}, SinkPersister(db.SourceSink(store.SourcePolar)))
+	if err != nil || !result.Committed {
+		t.Fatalf("PullExercises() = %+v, %v", result, err)
+	}
+	// kept apart from strava's activities
+	activities, err := db.QueryActivities(store.ActivityQuery{Source: store.SourcePolar})
+	if err != nil || len(activities) != 2 || activities[0].AthleteID != fmt.Sprint(fake.UserID) {
+		t.Errorf("QueryActivities(polar) = %+v, %v", activities, err)
+	}
+	if strava, _ := db.QueryActivities(store.ActivityQuery{Source: store.SourceStrava}); len(strava) != 0 {
+		t.Errorf("QueryActivities(strava) = %+v, want none", strava)
+	}
+	if _, err := db.Latest(store.SourcePolar, store.KindStreams, fmt.Sprint(fake.DemoRunID)); err != nil {
+		t.Errorf("Latest(run streams) error = %v", err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{"PT10S", 10 * time.Second, false},
+		{"PT2H44M", 2*time.Hour + 44*time.Minute, false},
+		{"PT1H2M3.5S", time.Hour + 2*time.Minute + 3500*time.Millisecond, false},
+		{"PT", 0, true},
+		{"1H", 0, true},
+		{"PTxS", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := client.ParseDuration(tt.in)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v (error %v)", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
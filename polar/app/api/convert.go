@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/jcocozza/cassidy-connector/polar/client"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// map a polar sport (or detailed sport) onto a strava sport type. false if it is not known
+func sportType(sport string) (swagger.SportType, bool) {
+	switch sport {
+	case "RUNNING", "ROAD_RUNNING", "TREADMILL_RUNNING", "TRACK_AND_FIELD_RUNNING":
+		return swagger.RUN_SportType, true
+	case "TRAIL_RUNNING":
+		return swagger.TRAIL_RUN_SportType, true
+	case "CYCLING", "ROAD_BIKING", "INDOOR_CYCLING":
+		return swagger.RIDE_SportType, true
+	case "MOUNTAIN_BIKING":
+		return swagger.MOUNTAIN_BIKE_RIDE_SportType, true
+	case "GRAVEL_CYCLING":
+		return swagger.GRAVEL_RIDE_SportType, true
+	case "SWIMMING", "POOL_SWIMMING", "OPEN_WATER_SWIMMING":
+		return swagger.SWIM_SportType, true
+	case "WALKING", "NORDIC_WALKING":
+		return swagger.WALK_SportType, true
+	case "HIKING":
+		return swagger.HIKE_SportType, true
+	case "ROWING", "INDOOR_ROWING":
+		return swagger.ROWING_SportType, true
+	case "STRENGTH_TRAINING", "CIRCUIT_TRAINING":
+		return swagger.WEIGHT_TRAINING_SportType, true
+	case "YOGA":
+		return swagger.YOGA_SportType, true
+	case "PILATES":
+		return swagger.PILATES_SportType, true
+	case "CROSS-COUNTRY_SKIING", "CROSS_COUNTRY_SKIING":
+		return swagger.NORDIC_SKI_SportType, true
+	case "DOWNHILL_SKIING":
+		return swagger.ALPINE_SKI_SportType, true
+	}
+	return "", false
+}
+
+// the sport of an exercise: the detailed sport if it is known, otherwise the sport
+func exerciseSport(e client.Exercise) swagger.SportType {
+	if sport, ok := sportType(e.DetailedSportInfo); ok {
+		return sport
+	}
+	if sport, ok := sportType(e.Sport); ok {
+		return sport
+	}
+	return swagger.WORKOUT_SportType
+}
+
+// map a polar exercise onto a strava summary
+func toSummary(e client.Exercise) swagger.SummaryActivity {
+	sport := exerciseSport(e)
+	at := swagger.ActivityType(sport)
+	seconds := int32(math.Round(e.Elapsed().Seconds()))
+	summary := swagger.SummaryActivity{
+		Id:             e.ID,
+		ExternalId:     strconv.FormatInt(e.ID, 10),
+		Name:           string(sport),
+		Distance:       float32(e.Distance),
+		MovingTime:     seconds,
+		ElapsedTime:    seconds,
+		Type_:          &at,
+		SportType:      &sport,
+		StartDate:      e.Start(),
+		StartDateLocal: e.StartLocal(),
+	}
+	if seconds > 0 {
+		summary.AverageSpeed = float32(e.Distance / float64(seconds))
+	}
+	return summary
+}
+
+// map a polar exercise onto a strava detailed activity
+func toDetailed(e client.Exercise) *swagger.DetailedActivity {
+	// a detailed activity has every field of a summary
+	data, _ := json.Marshal(toSummary(e))
+	detailed := &swagger.DetailedActivity{}
+	json.Unmarshal(data, detailed)
+	detailed.Calories = float32(e.Calories)
+	detailed.DeviceName = e.Device
+	return detailed
+}
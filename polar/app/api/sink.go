@@ -0,0 +1,27 @@
+package api
+
+import (
+	"context"
+
+	"github.com/jcocozza/cassidy-connector/strava/bulkexport"
+	stravaSync "github.com/jcocozza/cassidy-connector/strava/sync"
+)
+
+// Persist pulled exercises into a sink of activities in strava's shape, keyed by the polar user id. For use with `PullExercises`.
+//
+// In the local archive, that is `db.SourceSink(store.SourcePolar)`, so polar's exercises are kept apart from strava's activities.
+//
+// If the sink is also a `bulkexport.StreamSink`, the streams are written too (when they were pulled).
+func SinkPersister(sink stravaSync.Sink) func(context.Context, PulledExercise) error {
+	streamSink, withStreams := sink.(bulkexport.StreamSink)
+	return func(ctx context.Context, pulled PulledExercise) error {
+		err := sink.Put(ctx, int(pulled.UserID), pulled.Summary)
+		if err != nil {
+			return err
+		}
+		if withStreams && pulled.Streams != nil {
+			return streamSink.PutStreams(ctx, int(pulled.UserID), int(pulled.Exercise.ID), pulled.Streams)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/jcocozza/cassidy-connector/polar/client"
+	"github.com/jcocozza/cassidy-connector/strava/bulkexport"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"golang.org/x/oauth2"
+)
+
+// if a transaction is used after it was committed, will throw this error
+var CommittedError = errors.New("Transaction is already committed")
+
+// A Transaction is an open exercise transaction: a snapshot of the exercises a user uploaded since the last commit.
+//
+// Nothing is marked as read until `Commit`. A transaction that is not committed expires (after 10 minutes),
+// and its exercises are offered again in the next transaction.
+type Transaction struct {
+	api       *PolarAPI
+	client    *client.Client
+	UserID    int64
+	ID        int64
+	committed bool
+}
+
+// Start a transaction over the user's new exercises. Returns nil (and no error) if there are none.
+func (api *PolarAPI) BeginTransaction(ctx context.Context, token *oauth2.Token, userID int64) (*Transaction, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := api.client(ctx, token)
+	tx, err := c.CreateExerciseTransaction(ctx, userID)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	if tx == nil {
+		api.logger.DebugContext(ctx, "no new exercises", slog.Int64("user id", userID))
+		return nil, nil
+	}
+	api.logger.DebugContext(ctx, "began transaction", slog.Int64("user id", userID), slog.Int64("transaction id", tx.TransactionID))
+	return &Transaction{api: api, client: c, UserID: userID, ID: tx.TransactionID}, nil
+}
+
+// check the rate limits, and that the transaction is still open
+func (tx *Transaction) check(ctx context.Context) error {
+	if tx.committed {
+		return CommittedError
+	}
+	return tx.api.checkRateLimits(ctx)
+}
+
+// The ids of the exercises in the transaction
+func (tx *Transaction) Exercises(ctx context.Context) ([]int64, error) {
+	err := tx.check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := tx.client.ListExercises(ctx, tx.UserID, tx.ID)
+	if err != nil {
+		return nil, tx.api.wrap(ctx, err)
+	}
+	return ids, nil
+}
+
+// The summary of an exercise, as polar returns it
+func (tx *Transaction) Exercise(ctx context.Context, exerciseID int64) (*client.Exercise, error) {
+	err := tx.check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exercise, err := tx.client.GetExercise(ctx, tx.UserID, tx.ID, exerciseID)
+	if err != nil {
+		return nil, tx.api.wrap(ctx, err)
+	}
+	return exercise, nil
+}
+
+// Download an exercise as a fit, gpx or tcx file. Exercises without a route have no gpx, which returns a `NotFoundError`.
+func (tx *Transaction) Download(ctx context.Context, exerciseID int64, format client.Format, w io.Writer) error {
+	err := tx.check(ctx)
+	if err != nil {
+		return err
+	}
+	err = tx.client.DownloadExercise(ctx, tx.UserID, tx.ID, exerciseID, format, w)
+	if err != nil {
+		return tx.api.wrap(ctx, err)
+	}
+	return nil
+}
+
+// Commit the transaction, so its exercises are not in any later transaction.
+//
+// Only commit once everything you need from the transaction has been persisted.
+func (tx *Transaction) Commit(ctx context.Context) error {
+	err := tx.check(ctx)
+	if err != nil {
+		return err
+	}
+	err = tx.client.CommitTransaction(ctx, tx.UserID, tx.ID)
+	if err != nil {
+		return tx.api.wrap(ctx, err)
+	}
+	tx.committed = true
+	tx.api.logger.DebugContext(ctx, "committed transaction", slog.Int64("user id", tx.UserID), slog.Int64("transaction id", tx.ID))
+	return nil
+}
+
+// PullOpts control what `PullExercises` gets for each exercise
+type PullOpts struct {
+	// the files to download. formats an exercise does not have (e.g. gpx without a route) are skipped
+	Formats []client.Format
+	// decode the streams from the fit file (it is downloaded even if it is not in `Formats`)
+	Streams bool
+}
+
+// A PulledExercise is everything that was fetched for a single exercise
+type PulledExercise struct {
+	UserID   int64
+	Exercise client.Exercise
+	Summary  swagger.SummaryActivity
+	Detailed *swagger.DetailedActivity
+	// the downloaded files, by format
+	Files map[client.Format][]byte
+	// only if `PullOpts.Streams` is set
+	Streams *swagger.StreamSet
+	// why the fit file could not be decoded into `Streams`, if it could not. the files are still there
+	DecodeErr error
+}
+
+// PullResult is returned by `PullExercises`
+type PullResult struct {
+	// 0 if there were no new exercises
+	TransactionID int64
+	// the exercises that were persisted
+	Exercises []int64
+	Committed bool
+}
+
+// fetch everything requested for a single exercise
+func (tx *Transaction) pull(ctx context.Context, exerciseID int64, opts *PullOpts) (*PulledExercise, error) {
+	exercise, err := tx.Exercise(ctx, exerciseID)
+	if err != nil {
+		return nil, err
+	}
+	pulled := &PulledExercise{
+		UserID:   tx.UserID,
+		Exercise: *exercise,
+		Summary:  toSummary(*exercise),
+		Detailed: toDetailed(*exercise),
+		Files:    map[client.Format][]byte{},
+	}
+	formats := opts.Formats
+	if opts.Streams && !slices.Contains(formats, client.FIT) {
+		formats = append([]client.Format{client.FIT}, formats...)
+	}
+	for _, format := range formats {
+		var buf bytes.Buffer
+		err := tx.Download(ctx, exerciseID, format, &buf)
+		if errors.Is(err, NotFoundError) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pulled.Files[format] = buf.Bytes()
+	}
+	if data, ok := pulled.Files[client.FIT]; ok && opts.Streams {
+		// a file that can't be decoded would never decode, so it must not hold up the exercises behind it: the exercise is persisted without streams
+		pulled.Streams, pulled.DecodeErr = bulkexport.DecodeStreams(fmt.Sprintf("%d.fit", exerciseID), bytes.NewReader(data))
+		if pulled.DecodeErr != nil {
+			tx.api.logger.WarnContext(ctx, "failed to decode exercise file, pulling it without streams", slog.Int64("exercise id", exerciseID), slog.String("error", pulled.DecodeErr.Error()))
+			pulled.Streams = nil
+		}
+	}
+	return pulled, nil
+}
+
+// Pull the user's new exercises, and commit the transaction once every one of them has been persisted.
+//
+// `persist` is called once per exercise, in the order polar lists them. If it (or fetching an exercise) fails, the transaction
+// is left uncommitted and the error is returned: polar offers the same exercises again in the next transaction, so nothing is lost.
+// This also means an exercise can be persisted more than once (if a later one fails), so `persist` should be idempotent.
+//
+// Returns a result with no transaction id if there were no new exercises.
+func (api *PolarAPI) PullExercises(ctx context.Context, token *oauth2.Token, userID int64, opts *PullOpts, persist func(context.Context, PulledExercise) error) (*PullResult, error) {
+	if opts == nil {
+		opts = &PullOpts{}
+	}
+	result := &PullResult{Exercises: []int64{}}
+	tx, err := api.BeginTransaction(ctx, token, userID)
+	if err != nil || tx == nil {
+		return result, err
+	}
+	result.TransactionID = tx.ID
+	ids, err := tx.Exercises(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, id := range ids {
+		pulled, err := tx.pull(ctx, id, opts)
+		if err != nil {
+			return result, fmt.Errorf("failed to pull exercise %d: %w", id, err)
+		}
+		err = persist(ctx, *pulled)
+		if err != nil {
+			api.logger.ErrorContext(ctx, "failed to persist exercise, leaving the transaction open", slog.Int64("exercise id", id), slog.String("error", err.Error()))
+			return result, fmt.Errorf("failed to persist exercise %d: %w", id, err)
+		}
+		result.Exercises = append(result.Exercises, id)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Committed = true
+	api.logger.InfoContext(ctx, "pulled exercises", slog.Int64("user id", userID), slog.Int("exercises", len(result.Exercises)))
+	return result, nil
+}
+
+// A Puller pulls the new exercises of a user whenever it is triggered (e.g. by an EXERCISE webhook event), one pull at a time.
+//
+// Every pull begins a transaction, and polar drops a user's open transaction when another one begins, so pulls must not overlap.
+// Triggers that arrive while a pull is running are collapsed into a single pull after it, which gets every exercise they were for.
+type Puller struct {
+	api     *PolarAPI
+	token   *oauth2.Token
+	userID  int64
+	opts    *PullOpts
+	persist func(context.Context, PulledExercise) error
+	// called with the outcome of every pull
+	done func(*PullResult, error)
+
+	mu      sync.Mutex
+	running bool
+	pending bool
+}
+
+// Create a puller for the user. See `PullExercises` for `opts` and `persist`. `done` is optional.
+func (api *PolarAPI) NewPuller(token *oauth2.Token, userID int64, opts *PullOpts, persist func(context.Context, PulledExercise) error, done func(*PullResult, error)) *Puller {
+	if done == nil {
+		done = func(*PullResult, error) {}
+	}
+	return &Puller{api: api, token: token, userID: userID, opts: opts, persist: persist, done: done}
+}
+
+// Pull the user's new exercises. If a pull is already running, this returns straight away and another pull runs once it is done.
+func (p *Puller) Trigger(ctx context.Context) {
+	p.mu.Lock()
+	if p.running {
+		p.pending = true
+		p.mu.Unlock()
+		return
+	}
+	p.running = true
+	p.mu.Unlock()
+	for {
+		p.done(p.api.PullExercises(ctx, p.token, p.userID, p.opts, p.persist))
+		p.mu.Lock()
+		if !p.pending {
+			p.running = false
+			p.mu.Unlock()
+			return
+		}
+		p.pending = false
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/jcocozza/cassidy-connector/polar/app/api"
+	"github.com/jcocozza/cassidy-connector/polar/client"
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultAuthURL  = "https://flow.polar.com/oauth2/authorization"
+	defaultTokenURL = "https://polarremote.com/v2/oauth2/token"
+	authorizePath   = "/oauth2/authorization"
+	tokenPath       = "/v2/oauth2/token"
+	// polar sends the id of the user a token is for along with the token
+	userIDField = "x_user_id"
+)
+
+// if a token does not carry the id of its user, will throw this error
+var NoUserIDError = errors.New("Token has no user id")
+
+// An app is a way of interacting with the polar accesslink api.
+//
+// Polar serves the authorization page, the token endpoint and the api from different hosts.
+// When `OAuthURL` is set, both oauth endpoints are under it instead (e.g. for the fake server, see the `fake` package).
+type App struct {
+	logger       *slog.Logger
+	ClientId     string
+	ClientSecret string
+	RedirectURL  string
+	BaseURL      string
+	OAuthURL     string
+	// OAuthConfig handles OAuth and creates the HTTPClient that is used to make requests
+	OAuthConfig *oauth2.Config
+	// optional; the key webhook events are signed with (see `CreateWebhook`). events with a bad signature are rejected
+	WebhookSignatureKey string
+	// optional; a user defined function that tells the app how to handle webhook events (see `WebhookHandler`)
+	//
+	// *IMPORTANT* this will be called asynchronously with a go func
+	// polar wants a response quickly so all events need to be handled asynchronously
+	//
+	// A basic WebhookEventHandler pulls the new exercises of the user:
+	//
+	//	func polarEventHandler(pe app.PolarEvent) {
+	//		if pe.Event == app.EventExercise {
+	//			polarApp.Api.PullExercises(ctx, tokens[pe.UserID], pe.UserID, nil, persist)
+	//		}
+	//	}
+	WebhookEventHandler func(PolarEvent)
+	// This is where the data methods are called from.
+	Api *api.PolarAPI
+}
+
+// Empty urls default to polar's own
+func NewApp(clientId string, clientSecret string, redirectURL string, baseURL string, oauthURL string, logger *slog.Logger) *App {
+	if baseURL == "" {
+		baseURL = client.DefaultBaseURL
+	}
+	endpoint := oauth2.Endpoint{AuthURL: defaultAuthURL, TokenURL: defaultTokenURL, AuthStyle: oauth2.AuthStyleInHeader}
+	if oauthURL != "" {
+		endpoint.AuthURL = oauthURL + authorizePath
+		endpoint.TokenURL = oauthURL + tokenPath
+	}
+	oauthCfg := &oauth2.Config{
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Endpoint:     endpoint,
+	}
+	if logger == nil {
+		logger = NoopLogger()
+	}
+	logger = logger.WithGroup("cassidy-polar")
+	return &App{
+		logger:       logger,
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		BaseURL:      baseURL,
+		OAuthURL:     oauthURL,
+		OAuthConfig:  oauthCfg,
+		Api:          api.NewPolarAPI(baseURL, oauthCfg, logger.WithGroup("api")),
+	}
+}
+
+// Return the approval url. `state` is sent back with the authorization code
+func (a *App) ApprovalUrl(state string) string {
+	return a.OAuthConfig.AuthCodeURL(state)
+}
+
+// This is for the FIRST TIME getting the access token.
+//
+// A user will grant permission to the app then will be redirected to the application's RedirectURL with an authorization code.
+// This code is used to get the user's access token. The id of the user is sent with it (see `UserID`).
+// Before any data can be read, the user must be registered (see `api.RegisterUser`).
+//
+// Polar tokens do not expire. You are responsible for persisting user tokens (see `MarshalToken`)
+func (a *App) GetAccessTokenFromAuthorizationCode(ctx context.Context, code string) (*oauth2.Token, error) {
+	a.logger.InfoContext(ctx, "getting access token from authorization code")
+	token, err := a.OAuthConfig.Exchange(ctx, code)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "token exchange failed", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return token, nil
+}
+
+// The id of the user a token is for
+func UserID(token *oauth2.Token) (int64, error) {
+	switch id := token.Extra(userIDField).(type) {
+	case float64:
+		return int64(id), nil
+	case int64:
+		return id, nil
+	}
+	return 0, NoUserIDError
+}
+
+// a token as it is saved: the oauth2 token along with the user id (which `oauth2.Token` does not marshal)
+type savedToken struct {
+	*oauth2.Token
+	UserID int64 `json:"x_user_id,omitempty"`
+}
+
+// Marshal a token along with its user id, so that `ReadTokenFromFile` can get both back
+func MarshalToken(token *oauth2.Token) ([]byte, error) {
+	userID, _ := UserID(token)
+	return json.Marshal(savedToken{Token: token, UserID: userID})
+}
+
+// Load an oauth2 token from a .json file (see `MarshalToken`). If the file has a user id, `UserID` works on the token.
+func (a *App) ReadTokenFromFile(tokenFilePath string) (*oauth2.Token, error) {
+	tokenData, err := os.ReadFile(tokenFilePath)
+	if err != nil {
+		return nil, err
+	}
+	saved := savedToken{Token: &oauth2.Token{}}
+	err = json.Unmarshal(tokenData, &saved)
+	if err != nil {
+		return nil, err
+	}
+	if saved.UserID == 0 {
+		return saved.Token, nil
+	}
+	return saved.Token.WithExtra(map[string]interface{}{userIDField: saved.UserID}), nil
+}
+
+// The lower level client, authenticated with `token`.
+//
+// None of the rate limiting or mapping of `Api` is done.
+func (a *App) Client(ctx context.Context, token *oauth2.Token) *client.Client {
+	return client.New(a.BaseURL, a.OAuthConfig.Client(ctx, token))
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/fakeoauth"
+	"github.com/jcocozza/cassidy-connector/polar/client"
+	"github.com/jcocozza/cassidy-connector/polar/fake"
+	"golang.org/x/oauth2"
+)
+
+// go through the oauth flow of the app
+func authorize(t *testing.T, polarApp *App) *oauth2.Token {
+	t.Helper()
+	token, err := polarApp.GetAccessTokenFromAuthorizationCode(context.Background(), fakeoauth.ApprovalCode(t, polarApp.ApprovalUrl("xyz")))
+	if err != nil {
+		t.Fatalf("GetAccessTokenFromAuthorizationCode() error = %v", err)
+	}
+	return token
+}
+
+func TestToken(t *testing.T) {
+	ts := httptest.NewServer(fake.NewServer("id", "secret"))
+	defer ts.Close()
+	polarApp := NewApp("id", "secret", "http://localhost/exchange_token", ts.URL, ts.URL, nil)
+	token := authorize(t, polarApp)
+	userID, err := UserID(token)
+	if err != nil || userID != fake.UserID {
+		t.Fatalf("UserID() = %d, %v, want %d", userID, err, fake.UserID)
+	}
+	data, err := MarshalToken(token)
+	if err != nil {
+		t.Fatalf("MarshalToken() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "token.json")
+	os.WriteFile(path, data, 0600)
+	read, err := polarApp.ReadTokenFromFile(path)
+	if err != nil {
+		t.Fatalf("ReadTokenFromFile() error = %v", err)
+	}
+	userID, err = UserID(read)
+	if err != nil || userID != fake.UserID || read.AccessToken != token.AccessToken {
+		t.Errorf("ReadTokenFromFile() = %+v, user id %d, %v", read, userID, err)
+	}
+	_, err = UserID(&oauth2.Token{AccessToken: "x"})
+	if err != NoUserIDError {
+		t.Errorf("UserID(no id) error = %v, want NoUserIDError", err)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	srv := fake.NewServer("id", "secret")
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	polarApp := NewApp("id", "secret", "http://localhost/exchange_token", ts.URL, ts.URL, nil)
+	events := make(chan PolarEvent, 1)
+	polarApp.WebhookEventHandler = func(pe PolarEvent) { events <- pe }
+	receiver := httptest.NewServer(http.HandlerFunc(polarApp.WebhookHandler))
+	defer receiver.Close()
+	ctx := context.Background()
+
+	webhook, err := polarApp.CreateWebhook(ctx, receiver.URL, []string{EventExercise})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	if webhook.SignatureSecretKey == "" || polarApp.WebhookSignatureKey != webhook.SignatureSecretKey {
+		t.Errorf("CreateWebhook() key = %q, app key = %q", webhook.SignatureSecretKey, polarApp.WebhookSignatureKey)
+	}
+	webhooks, err := polarApp.GetWebhooks(ctx)
+	if err != nil || len(webhooks) != 1 || webhooks[0].SignatureSecretKey != "" {
+		t.Errorf("GetWebhooks() = %+v, %v", webhooks, err)
+	}
+	// the ping is answered but not handed on
+	select {
+	case pe := <-events:
+		t.Errorf("handler got %+v, want nothing", pe)
+	default:
+	}
+
+	// events are only sent for registered users
+	token := authorize(t, polarApp)
+	_, err = polarApp.Api.RegisterUser(ctx, token, "member-1")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	err = srv.AddExercise(client.Exercise{ID: 42, StartTime: "2024-05-01T07:00:00", Duration: "PT30M", Sport: "RUNNING"}, nil)
+	if err != nil {
+		t.Fatalf("AddExercise() error = %v", err)
+	}
+	select {
+	case pe := <-events:
+		if pe.Event != EventExercise || pe.UserID != fake.UserID || pe.EntityID != "42" {
+			t.Errorf("handler got %+v", pe)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("handler was not called")
+	}
+
+	// events with a bad signature are rejected
+	body := []byte(`{"event":"EXERCISE","user_id":1,"entity_id":"1"}`)
+	req, _ := http.NewRequest(http.MethodPost, receiver.URL, bytes.NewReader(body))
+	req.Header.Set(SignatureHeader, Sign("not the key", body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("bad signature = %v, %v, want 401", resp.Status, err)
+	}
+
+	err = polarApp.DeleteWebhook(ctx, webhook.ID)
+	if err != nil {
+		t.Errorf("DeleteWebhook() error = %v", err)
+	}
+	webhooks, err = polarApp.GetWebhooks(ctx)
+	if err != nil || len(webhooks) != 0 {
+		t.Errorf("GetWebhooks(deleted) = %+v, %v", webhooks, err)
+	}
+}
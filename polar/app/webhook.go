@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/jcocozza/cassidy-connector/polar/client"
+)
+
+const (
+	EventExercise            string = "EXERCISE"
+	EventSleep               string = "SLEEP"
+	EventContinuousHeartRate string = "CONTINUOUS_HEART_RATE"
+	EventActivitySummary     string = "ACTIVITY_SUMMARY"
+	// sent when a webhook is created, to check that the url answers
+	EventPing string = "PING"
+
+	// the header polar signs events in
+	SignatureHeader string = "Polar-Webhook-Signature"
+)
+
+// A PolarEvent is an event that is sent from the webhook
+type PolarEvent struct {
+	// e.g. "EXERCISE" or "PING"
+	Event string `json:"event"`
+	// the polar user id the event is for (not set for "PING")
+	UserID int64 `json:"user_id"`
+	// the id of the new object
+	EntityID string `json:"entity_id"`
+	// when the event occured
+	Timestamp string `json:"timestamp"`
+	// where the new object can be read
+	URL string `json:"url"`
+}
+
+// The signature of a webhook body: hex encoded hmac-sha256 with the webhook's signature key
+func Sign(key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Receives webhook events. Mount it at the url the webhook was created with.
+//
+// Events are checked against `WebhookSignatureKey` (when it is set), answered straight away, then handed to `WebhookEventHandler` asynchronously.
+// Pings are answered but not handed on.
+func (a *App) WebhookHandler(w http.ResponseWriter, r *http.Request) {
+	a.logger.Debug("webhook handler called")
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		a.logger.Error("unable to read post content")
+		http.Error(w, fmt.Sprintf("error reading post content: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	pe := PolarEvent{}
+	err = json.Unmarshal(body, &pe)
+	if err != nil {
+		a.logger.Error("unable to unmarshal polar event")
+		http.Error(w, fmt.Sprintf("error unmarshalling event: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	// the ping is sent before the webhook (and so the key) exists
+	if pe.Event != EventPing && a.WebhookSignatureKey != "" {
+		if !hmac.Equal([]byte(r.Header.Get(SignatureHeader)), []byte(Sign(a.WebhookSignatureKey, body))) {
+			a.logger.Warn("webhook signatures do not match")
+			http.Error(w, "signatures do not match", http.StatusUnauthorized)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	if pe.Event == EventPing {
+		a.logger.Info("webhook pinged")
+		return
+	}
+	if a.WebhookEventHandler != nil {
+		a.logger.Debug("running webhook event handler", slog.String("event", pe.Event), slog.Int64("user id", pe.UserID))
+		go func() {
+			a.WebhookEventHandler(pe)
+		}()
+	} else {
+		a.logger.Warn("no webhook event handler defined. doing nothing")
+	}
+}
+
+// the client for the webhook endpoints, which authenticate with the client id and secret
+func (a *App) webhookClient() *client.Client {
+	return client.New(a.BaseURL, nil)
+}
+
+// Create the webhook of the app (an app has at most one), for `events` (e.g. `EventExercise`).
+//
+// Polar pings `url` before creating the webhook, so `WebhookHandler` must already be served there.
+// The signature key of the created webhook is set as `WebhookSignatureKey`. It is only ever returned here, so persist it.
+func (a *App) CreateWebhook(ctx context.Context, url string, events []string) (*client.Webhook, error) {
+	a.logger.InfoContext(ctx, "creating webhook", slog.String("url", url))
+	webhook, err := a.webhookClient().CreateWebhook(ctx, a.ClientId, a.ClientSecret, events, url)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "unable to create webhook", slog.String("error", err.Error()))
+		return nil, err
+	}
+	a.WebhookSignatureKey = webhook.SignatureSecretKey
+	return webhook, nil
+}
+
+// View the webhooks of the app
+func (a *App) GetWebhooks(ctx context.Context) ([]client.Webhook, error) {
+	webhooks, err := a.webhookClient().GetWebhooks(ctx, a.ClientId, a.ClientSecret)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "unable to get webhooks", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// Delete a webhook of the app
+func (a *App) DeleteWebhook(ctx context.Context, webhookID string) error {
+	a.logger.InfoContext(ctx, "deleting webhook", slog.String("webhook id", webhookID))
+	err := a.webhookClient().DeleteWebhook(ctx, a.ClientId, a.ClientSecret, webhookID)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "unable to delete webhook", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+// Package client is the lower level implementation of the polar accesslink api (v3): one method per endpoint, with no rate limiting or token handling.
+//
+// The http client passed to `New` is responsible for authenticating user requests (e.g. one from `oauth2.Config.Client`).
+// The webhook endpoints authenticate with the client id and secret instead, which are passed to those methods.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const DefaultBaseURL = "https://www.polaraccesslink.com"
+
+const (
+	usersPath        = "/v3/users"
+	userPath         = "/v3/users/%d"
+	transactionsPath = "/v3/users/%d/exercise-transactions"
+	transactionPath  = "/v3/users/%d/exercise-transactions/%d"
+	exercisePath     = "/v3/users/%d/exercise-transactions/%d/exercises/%d"
+	webhooksPath     = "/v3/webhooks"
+	webhookPath      = "/v3/webhooks/%s"
+)
+
+// The formats an exercise can be downloaded in
+type Format string
+
+const (
+	FIT Format = "fit"
+	GPX Format = "gpx"
+	TCX Format = "tcx"
+)
+
+// if polar returns 404 not found, will throw this error
+var NotFoundError = errors.New("Object not found")
+
+// if polar returns 409 conflict (e.g. registering a user twice), will throw this error
+var ConflictError = errors.New("Conflict")
+
+// if polar returns 401 unauthorized or 403 forbidden (e.g. the user has not accepted the consents), will throw this error
+var UnauthorizedError = errors.New("Unauthorized")
+
+// if polar returns any other unexpected status, will throw this error (wrapped with the status)
+var StatusError = errors.New("Unexpected status")
+
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func New(baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{BaseURL: baseURL, HTTPClient: httpClient}
+}
+
+// the basic auth that the webhook endpoints use
+type credentials struct {
+	id     string
+	secret string
+}
+
+// send a request and return the response if its status is one of `ok`. the caller closes the body
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, creds *credentials, ok ...int) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if creds != nil {
+		req.SetBasicAuth(creds.id, creds.secret)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	for _, status := range ok {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, NotFoundError
+	case http.StatusConflict:
+		return nil, ConflictError
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, UnauthorizedError
+	}
+	return nil, fmt.Errorf("%w: %s", StatusError, resp.Status)
+}
+
+func decode(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	err := json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Register the user the token is for with the client. `memberID` is any id the client wants to know the user by.
+//
+// Registering a user that is already registered returns a `ConflictError`.
+func (c *Client) RegisterUser(ctx context.Context, memberID string) (*User, error) {
+	resp, err := c.do(ctx, "POST", usersPath, map[string]string{"member-id": memberID}, nil, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	var user User
+	err = decode(resp, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Get a registered user
+func (c *Client) GetUser(ctx context.Context, userID int64) (*User, error) {
+	resp, err := c.do(ctx, "GET", fmt.Sprintf(userPath, userID), nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	var user User
+	err = decode(resp, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Deregister a user. The client no longer has access to their data
+func (c *Client) DeleteUser(ctx context.Context, userID int64) error {
+	resp, err := c.do(ctx, "DELETE", fmt.Sprintf(userPath, userID), nil, nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Start a transaction over the exercises the user has uploaded since the last committed transaction.
+//
+// Returns nil (and no error) if there are no new exercises.
+func (c *Client) CreateExerciseTransaction(ctx context.Context, userID int64) (*Transaction, error) {
+	resp, err := c.do(ctx, "POST", fmt.Sprintf(transactionsPath, userID), nil, nil, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return nil, nil
+	}
+	var tx Transaction
+	err = decode(resp, &tx)
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// the id at the end of a resource url
+func idFromURL(resource string) (int64, error) {
+	i := strings.LastIndex(resource, "/")
+	return strconv.ParseInt(resource[i+1:], 10, 64)
+}
+
+// List the ids of the exercises in a transaction
+func (c *Client) ListExercises(ctx context.Context, userID int64, transactionID int64) ([]int64, error) {
+	resp, err := c.do(ctx, "GET", fmt.Sprintf(transactionPath, userID, transactionID), nil, nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int64{}
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return ids, nil
+	}
+	var list struct {
+		Exercises []string `json:"exercises"`
+	}
+	err = decode(resp, &list)
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range list.Exercises {
+		id, err := idFromURL(resource)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exercise url %q: %w", resource, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Get the summary of an exercise in a transaction
+func (c *Client) GetExercise(ctx context.Context, userID int64, transactionID int64, exerciseID int64) (*Exercise, error) {
+	resp, err := c.do(ctx, "GET", fmt.Sprintf(exercisePath, userID, transactionID, exerciseID), nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	var exercise Exercise
+	err = decode(resp, &exercise)
+	if err != nil {
+		return nil, err
+	}
+	return &exercise, nil
+}
+
+// Download an exercise in a transaction as a fit, gpx or tcx file.
+//
+// Exercises without a route have no gpx (polar answers 204 no content), which returns a `NotFoundError`.
+func (c *Client) DownloadExercise(ctx context.Context, userID int64, transactionID int64, exerciseID int64, format Format, w io.Writer) error {
+	resp, err := c.do(ctx, "GET", fmt.Sprintf(exercisePath, userID, transactionID, exerciseID)+"/"+string(format), nil, nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return NotFoundError
+	}
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download exercise: %w", err)
+	}
+	return nil
+}
+
+// Commit a transaction. Its exercises will not be in any later transaction.
+func (c *Client) CommitTransaction(ctx context.Context, userID int64, transactionID int64) error {
+	resp, err := c.do(ctx, "PUT", fmt.Sprintf(transactionPath, userID, transactionID), nil, nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Create the client's webhook. Polar sends a PING event to `url` first, and fails the request if it is not answered with 200.
+//
+// The returned webhook has the key that events are signed with; it is only ever returned here.
+func (c *Client) CreateWebhook(ctx context.Context, clientID string, clientSecret string, events []string, url string) (*Webhook, error) {
+	body := map[string]interface{}{"events": events, "url": url}
+	resp, err := c.do(ctx, "POST", webhooksPath, body, &credentials{clientID, clientSecret}, http.StatusCreated, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	var created struct {
+		Data Webhook `json:"data"`
+	}
+	err = decode(resp, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created.Data, nil
+}
+
+// Get the client's webhooks (a client has at most one)
+func (c *Client) GetWebhooks(ctx context.Context, clientID string, clientSecret string) ([]Webhook, error) {
+	resp, err := c.do(ctx, "GET", webhooksPath, nil, &credentials{clientID, clientSecret}, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Data []Webhook `json:"data"`
+	}
+	err = decode(resp, &list)
+	if err != nil {
+		return nil, err
+	}
+	if list.Data == nil {
+		list.Data = []Webhook{}
+	}
+	return list.Data, nil
+}
+
+// Delete a webhook
+func (c *Client) DeleteWebhook(ctx context.Context, clientID string, clientSecret string, webhookID string) error {
+	resp, err := c.do(ctx, "DELETE", fmt.Sprintf(webhookPath, webhookID), nil, &credentials{clientID, clientSecret}, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
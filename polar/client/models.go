@@ -0,0 +1,123 @@
+package client
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// polar start times are the local time of the exercise with no zone (the offset is separate)
+const LocalTimeLayout = "2006-01-02T15:04:05"
+
+// if a duration is not an iso 8601 duration, will throw this error
+var InvalidDurationError = errors.New("Invalid duration")
+
+// A registered user
+type User struct {
+	PolarUserID      int64  `json:"polar-user-id"`
+	MemberID         string `json:"member-id"`
+	RegistrationDate string `json:"registration-date"`
+	FirstName        string `json:"first-name,omitempty"`
+	LastName         string `json:"last-name,omitempty"`
+	Birthdate        string `json:"birthdate,omitempty"`
+	// "MALE" or "FEMALE"
+	Gender string `json:"gender,omitempty"`
+	// kilograms
+	Weight float64 `json:"weight,omitempty"`
+	// centimeters
+	Height float64 `json:"height,omitempty"`
+}
+
+// An open exercise transaction
+type Transaction struct {
+	TransactionID int64  `json:"transaction-id"`
+	ResourceURI   string `json:"resource-uri"`
+}
+
+type HeartRate struct {
+	Average float64 `json:"average"`
+	Maximum float64 `json:"maximum"`
+}
+
+// The summary of an exercise
+type Exercise struct {
+	ID            int64  `json:"id"`
+	UploadTime    string `json:"upload-time"`
+	PolarUser     string `json:"polar-user"`
+	TransactionID int64  `json:"transaction-id"`
+	Device        string `json:"device"`
+	DeviceID      string `json:"device-id"`
+	// local time, see `StartTimeUTCOffset`
+	StartTime string `json:"start-time"`
+	// minutes
+	StartTimeUTCOffset int `json:"start-time-utc-offset"`
+	// iso 8601, e.g. "PT1H2M3.5S"
+	Duration string  `json:"duration"`
+	Calories float64 `json:"calories"`
+	// meters
+	Distance  float64    `json:"distance"`
+	HeartRate *HeartRate `json:"heart-rate,omitempty"`
+	// training load (polar's cardio load)
+	TrainingLoad float64 `json:"training-load"`
+	// e.g. "RUNNING", "CYCLING" or "OTHER"
+	Sport    string `json:"sport"`
+	HasRoute bool   `json:"has-route"`
+	// a finer grained sport, e.g. "TRAIL_RUNNING" or "ROAD_BIKING"
+	DetailedSportInfo string `json:"detailed-sport-info"`
+}
+
+// When the exercise started, in utc
+func (e Exercise) Start() time.Time {
+	return e.StartLocal().Add(-time.Duration(e.StartTimeUTCOffset) * time.Minute)
+}
+
+// When the exercise started, in the athlete's local time (as if it were utc)
+func (e Exercise) StartLocal() time.Time {
+	t, _ := time.Parse(LocalTimeLayout, e.StartTime[:min(len(e.StartTime), len(LocalTimeLayout))])
+	return t
+}
+
+// The duration of the exercise. 0 if it is not a valid iso 8601 duration
+func (e Exercise) Elapsed() time.Duration {
+	d, _ := ParseDuration(e.Duration)
+	return d
+}
+
+// Parse an iso 8601 duration of hours, minutes and seconds (e.g. "PT1H2M3.5S"), as polar sends them
+func ParseDuration(s string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(s, "PT")
+	if !ok || rest == "" {
+		return 0, InvalidDurationError
+	}
+	var d time.Duration
+	for rest != "" {
+		i := strings.IndexAny(rest, "HMS")
+		if i <= 0 {
+			return 0, InvalidDurationError
+		}
+		v, err := strconv.ParseFloat(rest[:i], 64)
+		if err != nil {
+			return 0, InvalidDurationError
+		}
+		switch rest[i] {
+		case 'H':
+			d += time.Duration(v * float64(time.Hour))
+		case 'M':
+			d += time.Duration(v * float64(time.Minute))
+		case 'S':
+			d += time.Duration(v * float64(time.Second))
+		}
+		rest = rest[i+1:]
+	}
+	return d, nil
+}
+
+// The client's webhook
+type Webhook struct {
+	ID     string   `json:"id"`
+	Events []string `json:"events"`
+	URL    string   `json:"url"`
+	// the key events are signed with. only set when the webhook is created
+	SignatureSecretKey string `json:"signature_secret_key,omitempty"`
+}
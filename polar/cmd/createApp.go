@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/jcocozza/cassidy-connector/polar/app"
+	"golang.org/x/oauth2"
+)
+
+// Create the app based on the passed flag settings
+func createApp() (*app.App, *oauth2.Token, error) {
+	polarApp := app.NewApp(clientId, clientSecret, redirectURL, baseURL, oauthURL, nil)
+	if tokenPath == "" {
+		return polarApp, nil, nil
+	}
+	tkn, err := polarApp.ReadTokenFromFile(tokenPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return polarApp, tkn, nil
+}
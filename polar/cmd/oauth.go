@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/jcocozza/cassidy-connector/oauthcli"
+	"github.com/jcocozza/cassidy-connector/polar/app"
+	"github.com/jcocozza/cassidy-connector/polar/fake"
+)
+
+// approval-url, initial-access and fake-server
+func init() {
+	oauthcli.AddOAuthCommands(RootCmd, oauthcli.Provider{
+		CreateApp: func() (oauthcli.App, error) {
+			polarApp, _, err := createApp()
+			if err != nil {
+				return nil, err
+			}
+			return polarApp, nil
+		},
+		MarshalToken:      app.MarshalToken,
+		InitialAccessHelp: "The token never expires, and the polar user id is saved with it. Register the user (api register) before reading any data.",
+		OutputPath:        &outputPath,
+	})
+	oauthcli.AddFakeServerCommand(RootCmd, oauthcli.FakeServer{
+		Platform:     "polar",
+		API:          "the polar accesslink api",
+		Demo:         "a few demo exercises",
+		URLFlags:     "--base-url and --oauth-url",
+		DefaultAddr:  defaultFakeAddr,
+		ClientID:     &clientId,
+		ClientSecret: &clientSecret,
+		NewDemoServer: func(clientID string, clientSecret string) http.Handler {
+			return fake.NewDemoServer(clientID, clientSecret)
+		},
+	})
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jcocozza/cassidy-connector/polar/app/api"
+	"github.com/jcocozza/cassidy-connector/polar/client"
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/spf13/cobra"
+)
+
+// write a file, so that it is either fully written or not there at all
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	err := os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// write the exercise (as a strava detailed activity) and its files to `dir`
+func persistToDir(dir string) func(context.Context, api.PulledExercise) error {
+	return func(ctx context.Context, pulled api.PulledExercise) error {
+		for format, data := range pulled.Files {
+			err := writeFile(filepath.Join(dir, fmt.Sprintf("%d.%s", pulled.Exercise.ID, format)), data)
+			if err != nil {
+				return err
+			}
+		}
+		activityJsonBytes, err := json.Marshal(pulled.Detailed)
+		if err != nil {
+			return err
+		}
+		// written last, so that an exercise with a json file has all of its files
+		err = writeFile(filepath.Join(dir, fmt.Sprintf("%d.json", pulled.Exercise.ID)), activityJsonBytes)
+		if err != nil {
+			return err
+		}
+		if pulled.DecodeErr != nil {
+			fmt.Printf("saved exercise %d (without streams: %s)\n", pulled.Exercise.ID, pulled.DecodeErr.Error())
+			return nil
+		}
+		fmt.Printf("saved exercise %d\n", pulled.Exercise.ID)
+		return nil
+	}
+}
+
+var pullDir string
+var pullFormats []string
+var pullDB string
+var pullExercises = &cobra.Command{
+	Use:   "pull",
+	Short: "Pull the user's new exercises into a directory.",
+	Long: `Pull the user's new exercises into a directory.
+
+Each exercise is saved as <id>.json (in the same shape as a strava activity) along with its files (<id>.fit, ...).
+With --db, the exercises (and their streams) are also written into the local archive, under the polar source.
+The transaction is only committed once every exercise has been saved. If saving fails, nothing is committed and the same exercises are pulled next time.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		polarApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		id, err := userID(tkn)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		err = os.MkdirAll(pullDir, 0755)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		opts := &api.PullOpts{}
+		for _, f := range pullFormats {
+			format := client.Format(f)
+			if format != client.FIT && format != client.GPX && format != client.TCX {
+				fmt.Printf("unknown format %q. use fit, gpx or tcx\n", f)
+				return
+			}
+			opts.Formats = append(opts.Formats, format)
+		}
+		persist := persistToDir(pullDir)
+		if pullDB != "" {
+			db, err := store.Open(pullDB)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			defer db.Close()
+			opts.Streams = true
+			toDir, toDB := persist, api.SinkPersister(db.SourceSink(store.SourcePolar))
+			persist = func(ctx context.Context, pulled api.PulledExercise) error {
+				err := toDB(ctx, pulled)
+				if err != nil {
+					return err
+				}
+				return toDir(ctx, pulled)
+			}
+		}
+		result, err := polarApp.Api.PullExercises(context.TODO(), tkn, id, opts, persist)
+		if errors.Is(err, api.NotFoundError) {
+			fmt.Println("user not found. they must be registered first (see api register)")
+			return
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if result.TransactionID == 0 {
+			fmt.Println("no new exercises")
+			return
+		}
+		fmt.Printf("pulled %d exercises (transaction %d committed)\n", len(result.Exercises), result.TransactionID)
+	},
+}
+
+func init() {
+	pullExercises.Flags().StringVar(&pullDir, "dir", ".", "the directory to save exercises to")
+	pullExercises.Flags().StringSliceVar(&pullFormats, "formats", []string{"fit"}, "the files to save for each exercise (fit, gpx and/or tcx)")
+	pullExercises.Flags().StringVar(&pullDB, "db", "", "the path to a local archive to also write the exercises into")
+	tokenCmdGroup.AddCommand(pullExercises)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	version string = "0.0.1"
+	// where `fake-server` listens by default
+	defaultFakeAddr string = "localhost:8089"
+)
+
+// global app flag variables
+var tokenPath string
+var clientId string
+var clientSecret string
+var redirectURL string
+var baseURL string
+var oauthURL string
+var outputPath string
+
+var RootCmd = &cobra.Command{
+	Use:     "cassidy-polar",
+	Version: version,
+	Short:   "cassidy-polar is a cli tool to interact with the Polar AccessLink API",
+	Long: `cassidy-polar is a cli tool to interact with the Polar AccessLink API
+
+By default it talks to the local fake server (see 'cassidy-polar fake-server'), so everything can be tried without a polar client.`,
+	Run: func(cmd *cobra.Command, args []string) {},
+}
+
+var tokenCmdGroup = &cobra.Command{
+	Use:   "api",
+	Short: "all subcommands here require a token for authentication",
+	Run:   func(cmd *cobra.Command, args []string) {},
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&clientId, "client-id", "", "the client id of your polar client")
+	RootCmd.PersistentFlags().StringVar(&clientSecret, "client-secret", "", "the client secret of your polar client")
+	RootCmd.PersistentFlags().StringVar(&redirectURL, "redirect-url", "http://localhost/exchange_token", "the redirect url of your polar client")
+	RootCmd.PersistentFlags().StringVar(&baseURL, "base-url", "http://"+defaultFakeAddr, "the base url of the api (polar's is https://www.polaraccesslink.com)")
+	RootCmd.PersistentFlags().StringVar(&oauthURL, "oauth-url", "http://"+defaultFakeAddr, "the base url of both oauth endpoints. set it to an empty string to use polar's own")
+	RootCmd.PersistentFlags().StringVarP(&outputPath, "path", "f", "", "the path to save successful output to. (will not write errors at this time)")
+	RootCmd.MarkFlagsRequiredTogether("client-id", "client-secret")
+	tokenCmdGroup.PersistentFlags().StringVar(&tokenPath, "token-path", "", "the path to a .json file that contains an OAuth2 token. This is the json that initial-access prints, which has the polar user id along with the token.")
+	tokenCmdGroup.MarkPersistentFlagRequired("token-path")
+	RootCmd.AddCommand(tokenCmdGroup)
+}
+
+func Execute() {
+	if err := RootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jcocozza/cassidy-connector/polar/app"
+	"github.com/jcocozza/cassidy-connector/polar/app/api"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// print (and save, if --path is set) anything as json
+func printJSON(v interface{}) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if outputPath != "" {
+		utils.WriteOutput(outputPath, jsonBytes)
+	}
+	fmt.Println(string(jsonBytes))
+}
+
+// the user id saved with the token
+func userID(tkn *oauth2.Token) (int64, error) {
+	id, err := app.UserID(tkn)
+	if err != nil {
+		return 0, fmt.Errorf("%w. use a token saved by initial-access", err)
+	}
+	return id, nil
+}
+
+var memberID string
+var registerUser = &cobra.Command{
+	Use:   "register",
+	Short: "Register the user of the token. This must be done once before any of their data can be read.",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		polarApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		user, err := polarApp.Api.RegisterUser(context.TODO(), tkn, memberID)
+		if errors.Is(err, api.AlreadyRegisteredError) {
+			fmt.Println("user is already registered")
+			return
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		printJSON(user)
+	},
+}
+
+var getUser = &cobra.Command{
+	Use:   "user",
+	Short: "Get the registered user of the token.",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		polarApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		id, err := userID(tkn)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		user, err := polarApp.Api.GetUser(context.TODO(), tkn, id)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		printJSON(user)
+	},
+}
+
+var deregisterUser = &cobra.Command{
+	Use:   "deregister",
+	Short: "Deregister the user of the token. The token no longer works afterwards.",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		polarApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		id, err := userID(tkn)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		err = polarApp.Api.DeleteUser(context.TODO(), tkn, id)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Printf("deregistered user %d\n", id)
+	},
+}
+
+func init() {
+	registerUser.Flags().StringVar(&memberID, "member-id", "", "the id you want to know the user by")
+	registerUser.MarkFlagRequired("member-id")
+	tokenCmdGroup.AddCommand(registerUser)
+	tokenCmdGroup.AddCommand(getUser)
+	tokenCmdGroup.AddCommand(deregisterUser)
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/jcocozza/cassidy-connector/polar/app"
+	"github.com/jcocozza/cassidy-connector/polar/app/api"
+	"github.com/spf13/cobra"
+)
+
+var webhookCmdGroup = &cobra.Command{
+	Use:   "webhook",
+	Short: "commands here are used for interacting with the polar webhook",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Nothing to see here
+	},
+}
+
+var webhookURL string
+var webhookEvents []string
+var createWebhook = &cobra.Command{
+	Use:   "create",
+	Short: "create the webhook for your client. polar pings the url first, so launch-server must already be running there",
+	Long: `create the webhook for your client. polar pings the url first, so launch-server must already be running there
+
+The signature key of the webhook is printed. It is only ever returned here, so keep it (launch-server --signature-key checks events with it).`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		polarApp, _, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		webhook, err := polarApp.CreateWebhook(context.TODO(), webhookURL, webhookEvents)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		printJSON(webhook)
+	},
+}
+
+var webhookAddr string
+var signatureKey string
+var webhookDir string
+var launchWebhookServer = &cobra.Command{
+	Use:   "launch-server",
+	Short: "launch the server that receives webhook events.",
+	Long: `launch the server that receives webhook events. Every event is printed.
+
+Use --dir (with --token-path) to pull the new exercises of the token's user into a directory whenever an EXERCISE event for them arrives (see api pull).`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		polarApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		polarApp.WebhookSignatureKey = signatureKey
+		var puller *api.Puller
+		var id int64
+		if webhookDir != "" {
+			if tkn == nil {
+				fmt.Println("a token is required to use --dir. use --token-path")
+				return
+			}
+			id, err = userID(tkn)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			err = os.MkdirAll(webhookDir, 0755)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			// events that arrive during a pull are collapsed into one more pull, so their transactions don't compete
+			puller = polarApp.Api.NewPuller(tkn, id, nil, persistToDir(webhookDir), func(result *api.PullResult, err error) {
+				if err != nil {
+					fmt.Printf("failed to pull exercises: %s\n", err.Error())
+					return
+				}
+				fmt.Printf("pulled %d exercises\n", len(result.Exercises))
+			})
+		}
+		polarApp.WebhookEventHandler = func(pe app.PolarEvent) {
+			fmt.Printf("event: %s user: %d entity: %s\n", pe.Event, pe.UserID, pe.EntityID)
+			if puller != nil && pe.Event == app.EventExercise && pe.UserID == id {
+				puller.Trigger(context.TODO())
+			}
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", polarApp.WebhookHandler)
+		mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("alive")) })
+		fmt.Printf("server is running on %s\n", webhookAddr)
+		err = http.ListenAndServe(webhookAddr, mux)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+	},
+}
+
+var viewWebhook = &cobra.Command{
+	Use:   "view",
+	Short: "view the webhook of your client",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		polarApp, _, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		webhooks, err := polarApp.GetWebhooks(context.TODO())
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		printJSON(webhooks)
+	},
+}
+
+var deleteWebhook = &cobra.Command{
+	Use:   "delete [webhook id]",
+	Short: "delete the webhook of your client",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		polarApp, _, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		err = polarApp.DeleteWebhook(context.TODO(), args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Printf("deleted webhook %s\n", args[0])
+	},
+}
+
+func init() {
+	createWebhook.Flags().StringVar(&webhookURL, "url", "", "the url polar sends events to")
+	createWebhook.Flags().StringSliceVar(&webhookEvents, "events", []string{app.EventExercise}, "the events to subscribe to")
+	createWebhook.MarkFlagRequired("url")
+	launchWebhookServer.Flags().StringVar(&webhookAddr, "addr", "localhost:8090", "the address to listen on")
+	launchWebhookServer.Flags().StringVar(&signatureKey, "signature-key", "", "the signature key of the webhook. events are not checked if it is empty")
+	launchWebhookServer.Flags().StringVar(&tokenPath, "token-path", "", "the path to a token saved by initial-access. only needed with --dir")
+	launchWebhookServer.Flags().StringVar(&webhookDir, "dir", "", "pull new exercises into this directory as they arrive")
+	webhookCmdGroup.AddCommand(createWebhook)
+	webhookCmdGroup.AddCommand(launchWebhookServer)
+	webhookCmdGroup.AddCommand(viewWebhook)
+	webhookCmdGroup.AddCommand(deleteWebhook)
+	RootCmd.AddCommand(webhookCmdGroup)
+}
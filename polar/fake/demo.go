@@ -0,0 +1,46 @@
+package fake
+
+import "github.com/jcocozza/cassidy-connector/polar/client"
+
+const (
+	// the demo exercise that has files (a short fit recording and a gpx route)
+	DemoRunID int64 = 7300000001
+	// a strength session, with no files
+	DemoStrengthID int64 = 7300000002
+)
+
+// A run (with a fit and gpx file) and a strength session, in upload order
+func DemoExercises() []client.Exercise {
+	return []client.Exercise{
+		{
+			ID:                 DemoRunID,
+			UploadTime:         "2021-09-08T02:00:00.000Z",
+			Device:             "Polar Vantage V2",
+			DeviceID:           "A1B2C3D4",
+			StartTime:          "2021-09-07T18:47:06",
+			StartTimeUTCOffset: -420,
+			Duration:           "PT10S",
+			Calories:           2,
+			Distance:           90,
+			HeartRate:          &client.HeartRate{Average: 145, Maximum: 150},
+			TrainingLoad:       0.4,
+			Sport:              "RUNNING",
+			HasRoute:           true,
+			DetailedSportInfo:  "RUNNING",
+		},
+		{
+			ID:                 DemoStrengthID,
+			UploadTime:         "2021-09-09T07:30:00.000Z",
+			Device:             "Polar Vantage V2",
+			DeviceID:           "A1B2C3D4",
+			StartTime:          "2021-09-09T06:30:00",
+			StartTimeUTCOffset: -420,
+			Duration:           "PT45M30S",
+			Calories:           310,
+			HeartRate:          &client.HeartRate{Average: 112, Maximum: 141},
+			TrainingLoad:       28.5,
+			Sport:              "OTHER",
+			DetailedSportInfo:  "STRENGTH_TRAINING",
+		},
+	}
+}
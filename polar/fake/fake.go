@@ -0,0 +1,187 @@
+// Package fake is a local stand-in for the polar accesslink api, so the polar package can be developed and tested without network (or a polar client).
+//
+// It implements the same oauth flow (see `fakeoauth`) and endpoints as `client`: user registration, the exercise transaction lifecycle and webhooks.
+// A single user (`UserID`) can authorize. Exercises added with `AddExercise` are offered in transactions until a transaction that has them is committed.
+//
+// A `Server` is an `http.Handler`, so it can be used with `httptest.NewServer` or served as is (see `cassidy-polar fake-server`).
+// Both the oauth and api endpoints are served, so the same url is used for both.
+package fake
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/fakefiles"
+	"github.com/jcocozza/cassidy-connector/fakeoauth"
+	"github.com/jcocozza/cassidy-connector/polar/client"
+)
+
+// the polar user that every token is for
+const UserID int64 = 52000001
+
+type exercise struct {
+	summary   client.Exercise
+	files     map[client.Format][]byte
+	committed bool
+}
+
+// an open transaction: the exercises that were new when it was created
+type transaction struct {
+	id        int64
+	exercises []int64
+}
+
+// A Server pretends to be polar accesslink. Tokens are handled by the embedded oauth server.
+type Server struct {
+	*fakeoauth.Server
+
+	mux *http.ServeMux
+	// used to send webhook events
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	registered bool
+	memberID   string
+	exercises  []*exercise
+	open       *transaction
+	nextTxID   int64
+	webhook    *client.Webhook
+}
+
+// Create a server with no exercises. Only `clientID` and `clientSecret` can get tokens, which never expire.
+func NewServer(clientID string, clientSecret string) *Server {
+	oauth := fakeoauth.New(clientID, clientSecret)
+	oauth.TokenLifetime = 0
+	oauth.TokenExtra = map[string]interface{}{"x_user_id": UserID}
+	s := &Server{
+		Server:     oauth,
+		mux:        http.NewServeMux(),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		nextTxID:   9100,
+	}
+	s.mux.HandleFunc("GET /oauth2/authorization", s.Authorize)
+	s.mux.HandleFunc("POST /v2/oauth2/token", s.Token)
+	s.mux.HandleFunc("POST /v3/users", s.Authenticated(s.register))
+	s.mux.HandleFunc("GET /v3/users/{user}", s.Authenticated(s.user(s.getUser)))
+	s.mux.HandleFunc("DELETE /v3/users/{user}", s.Authenticated(s.user(s.deleteUser)))
+	s.mux.HandleFunc("POST /v3/users/{user}/exercise-transactions", s.Authenticated(s.user(s.createTransaction)))
+	s.mux.HandleFunc("GET /v3/users/{user}/exercise-transactions/{tx}", s.Authenticated(s.user(s.transaction(s.listExercises))))
+	s.mux.HandleFunc("PUT /v3/users/{user}/exercise-transactions/{tx}", s.Authenticated(s.user(s.transaction(s.commit))))
+	s.mux.HandleFunc("GET /v3/users/{user}/exercise-transactions/{tx}/exercises/{id}", s.Authenticated(s.user(s.transaction(s.getExercise))))
+	s.mux.HandleFunc("GET /v3/users/{user}/exercise-transactions/{tx}/exercises/{id}/{format}", s.Authenticated(s.user(s.transaction(s.download))))
+	s.mux.HandleFunc("POST /v3/webhooks", s.clientAuthenticated(s.createWebhook))
+	s.mux.HandleFunc("GET /v3/webhooks", s.clientAuthenticated(s.getWebhooks))
+	s.mux.HandleFunc("DELETE /v3/webhooks/{id}", s.clientAuthenticated(s.deleteWebhook))
+	return s
+}
+
+// Create a server with a few demo exercises (see `DemoExercises`)
+func NewDemoServer(clientID string, clientSecret string) *Server {
+	s := NewServer(clientID, clientSecret)
+	for _, e := range DemoExercises() {
+		var files map[client.Format][]byte
+		if e.ID == DemoRunID {
+			files = map[client.Format][]byte{client.FIT: fakefiles.RunFit, client.GPX: fakefiles.RunGpx}
+		}
+		s.AddExercise(e, files)
+	}
+	return s
+}
+
+// Add a new exercise, with its files by format (optional). If there is a webhook, an EXERCISE event is sent to it (once the user is registered).
+//
+// Returns the error of sending the event, if any.
+func (s *Server) AddExercise(summary client.Exercise, files map[client.Format][]byte) error {
+	s.mu.Lock()
+	if files == nil {
+		files = map[client.Format][]byte{}
+	}
+	s.exercises = append(s.exercises, &exercise{summary: summary, files: files})
+	webhook, registered := s.webhook, s.registered
+	s.mu.Unlock()
+	if webhook == nil || !registered {
+		return nil
+	}
+	return s.send(*webhook, event{
+		Event:     "EXERCISE",
+		UserID:    UserID,
+		EntityID:  strconv.FormatInt(summary.ID, 10),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		URL:       fmt.Sprintf("/v3/exercises/%d", summary.ID),
+	})
+}
+
+// The ids of the exercises that have not been committed yet
+func (s *Server) Pending() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := []int64{}
+	for _, e := range s.exercises {
+		if !e.committed {
+			ids = append(ids, e.summary.ID)
+		}
+	}
+	return ids
+}
+
+// Whether the user is registered
+func (s *Server) Registered() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.registered
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// a webhook event, as polar sends it
+type event struct {
+	Event     string `json:"event"`
+	UserID    int64  `json:"user_id,omitempty"`
+	EntityID  string `json:"entity_id,omitempty"`
+	Timestamp string `json:"timestamp"`
+	URL       string `json:"url,omitempty"`
+}
+
+// send a signed event to a webhook. fails if it is not answered with 200
+func (s *Server) send(webhook client.Webhook, e event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Polar-Webhook-Event", e.Event)
+	if webhook.SignatureSecretKey != "" {
+		mac := hmac.New(sha256.New, []byte(webhook.SignatureSecretKey))
+		mac.Write(body)
+		req.Header.Set("Polar-Webhook-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
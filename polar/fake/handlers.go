@@ -0,0 +1,234 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/fakeoauth"
+	"github.com/jcocozza/cassidy-connector/polar/client"
+)
+
+// check that the path is for the registered user. `next` is called with the lock held
+func (s *Server) user(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		id, err := strconv.ParseInt(r.PathValue("user"), 10, 64)
+		if err != nil || id != UserID || !s.registered {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// check that the path is for the open transaction
+func (s *Server) transaction(next func(http.ResponseWriter, *http.Request, *transaction)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("tx"), 10, 64)
+		if err != nil || s.open == nil || s.open.id != id {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		next(w, r, s.open)
+	}
+}
+
+// webhooks authenticate with the client id and secret
+func (s *Server) clientAuthenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != s.ClientID || secret != s.ClientSecret {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// the user as it is returned
+func (s *Server) userInfo() client.User {
+	return client.User{
+		PolarUserID:      UserID,
+		MemberID:         s.memberID,
+		RegistrationDate: "2024-05-01T08:00:00.000Z",
+		FirstName:        "Demo",
+		LastName:         "Athlete",
+		Gender:           "FEMALE",
+		Weight:           58,
+		Height:           168,
+	}
+}
+
+func (s *Server) register(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		MemberID string `json:"member-id"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.MemberID == "" {
+		http.Error(w, "member-id is required", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.registered {
+		http.Error(w, "user is already registered", http.StatusConflict)
+		return
+	}
+	s.registered = true
+	s.memberID = body.MemberID
+	writeJSON(w, http.StatusOK, s.userInfo())
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.userInfo())
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	s.registered = false
+	s.memberID = ""
+	s.open = nil
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// start a transaction over the exercises that have not been committed. any open transaction is dropped (as if it had expired)
+func (s *Server) createTransaction(w http.ResponseWriter, r *http.Request) {
+	s.open = nil
+	ids := []int64{}
+	for _, e := range s.exercises {
+		if !e.committed {
+			ids = append(ids, e.summary.ID)
+		}
+	}
+	if len(ids) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	s.nextTxID++
+	s.open = &transaction{id: s.nextTxID, exercises: ids}
+	writeJSON(w, http.StatusCreated, client.Transaction{
+		TransactionID: s.open.id,
+		ResourceURI:   fmt.Sprintf("http://%s/v3/users/%d/exercise-transactions/%d", r.Host, UserID, s.open.id),
+	})
+}
+
+func (s *Server) listExercises(w http.ResponseWriter, r *http.Request, tx *transaction) {
+	urls := []string{}
+	for _, id := range tx.exercises {
+		urls = append(urls, fmt.Sprintf("http://%s/v3/users/%d/exercise-transactions/%d/exercises/%d", r.Host, UserID, tx.id, id))
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"exercises": urls})
+}
+
+// the exercise in the transaction that the path refers to, or nil
+func (s *Server) find(r *http.Request, tx *transaction) *exercise {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || !slices.Contains(tx.exercises, id) {
+		return nil
+	}
+	for _, e := range s.exercises {
+		if e.summary.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *Server) getExercise(w http.ResponseWriter, r *http.Request, tx *transaction) {
+	e := s.find(r, tx)
+	if e == nil {
+		http.Error(w, "exercise not found", http.StatusNotFound)
+		return
+	}
+	summary := e.summary
+	summary.TransactionID = tx.id
+	summary.PolarUser = fmt.Sprintf("http://%s/v3/users/%d", r.Host, UserID)
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// like polar, a format the exercise does not have is answered with 204 no content
+func (s *Server) download(w http.ResponseWriter, r *http.Request, tx *transaction) {
+	e := s.find(r, tx)
+	if e == nil {
+		http.Error(w, "exercise not found", http.StatusNotFound)
+		return
+	}
+	format := client.Format(r.PathValue("format"))
+	if format != client.FIT && format != client.GPX && format != client.TCX {
+		http.Error(w, "unknown format", http.StatusNotFound)
+		return
+	}
+	data, ok := e.files[format]
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+func (s *Server) commit(w http.ResponseWriter, r *http.Request, tx *transaction) {
+	for _, e := range s.exercises {
+		if slices.Contains(tx.exercises, e.summary.ID) {
+			e.committed = true
+		}
+	}
+	s.open = nil
+	w.WriteHeader(http.StatusOK)
+}
+
+// like polar, the url is pinged before the webhook is created, and the webhook is only created if the ping is answered with 200
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Events []string `json:"events"`
+		URL    string   `json:"url"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.URL == "" || len(body.Events) == 0 {
+		http.Error(w, "events and url are required", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	exists := s.webhook != nil
+	s.mu.Unlock()
+	if exists {
+		http.Error(w, "webhook already exists", http.StatusConflict)
+		return
+	}
+	err = s.send(client.Webhook{URL: body.URL}, event{Event: "PING", Timestamp: time.Now().UTC().Format(time.RFC3339)})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("webhook ping failed: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	webhook := client.Webhook{ID: fakeoauth.RandomString()[:8], Events: body.Events, URL: body.URL, SignatureSecretKey: fakeoauth.RandomString()}
+	s.mu.Lock()
+	s.webhook = &webhook
+	s.mu.Unlock()
+	writeJSON(w, http.StatusCreated, map[string]client.Webhook{"data": webhook})
+}
+
+func (s *Server) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhooks := []client.Webhook{}
+	if s.webhook != nil {
+		// the key is only returned when the webhook is created
+		webhooks = append(webhooks, client.Webhook{ID: s.webhook.ID, Events: s.webhook.Events, URL: s.webhook.URL})
+	}
+	writeJSON(w, http.StatusOK, map[string][]client.Webhook{"data": webhooks})
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.webhook == nil || s.webhook.ID != r.PathValue("id") {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+	s.webhook = nil
+	w.WriteHeader(http.StatusNoContent)
+}
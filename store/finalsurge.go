@@ -0,0 +1,42 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/finalSurge/app"
+)
+
+// final surge only gives the workout date
+const finalSurgeDateLayout = "2006-01-02T15:04:05"
+
+// Put every workout in a final surge workout list
+func (s *Store) PutFinalSurgeWorkouts(workouts *app.WorkoutListResponse) error {
+	for _, workout := range workouts.Data {
+		data, err := json.Marshal(workout)
+		if err != nil {
+			return err
+		}
+		rec := Record{
+			Source:    SourceFinalSurge,
+			Kind:      KindActivity,
+			ID:        workout.Key,
+			AthleteID: workout.UserKey,
+			Data:      data,
+		}
+		start, err := time.Parse(finalSurgeDateLayout, workout.WorkoutDate)
+		if err != nil {
+			start, _ = time.Parse("2006-01-02", workout.WorkoutDate)
+		}
+		rec.StartDate = start
+		if len(workout.Activities) > 0 {
+			rec.SportType = workout.Activities[0].ActivityTypeName
+			rec.GearID = workout.Activities[0].Equipment.EquipmentKey
+		}
+		err = s.Put(rec)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
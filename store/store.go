@@ -0,0 +1,304 @@
+// Package store is a local archive of activity data from any platform.
+//
+// Everything is kept in a single bolt database file (pure go, no server).
+// Each put is a new version of a record, keyed by the time it was fetched, so nothing is overwritten.
+// Activities are indexed by start date so that they can be queried by date range, sport, gear and source platform.
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// if a record does not exist, will throw this error
+var NotFoundError = errors.New("Record not found")
+
+// Source is the platform that a record came from
+type Source string
+
+const (
+	SourceStrava     Source = "strava"
+	SourceFinalSurge Source = "finalsurge"
+	// activity files from a local directory (see the `localfiles` package)
+	SourceLocal Source = "local"
+	// exercises pulled from polar accesslink (see `SinkPersister` in `polar/app/api`)
+	SourcePolar Source = "polar"
+)
+
+// Kind is the type of data that a record holds
+type Kind string
+
+const (
+	KindAthlete          Kind = "athlete"
+	KindActivity         Kind = "activity"          // a summary activity
+	KindDetailedActivity Kind = "detailed_activity" // a detailed activity
+	KindStreams          Kind = "streams"
+	KindLaps             Kind = "laps"
+	KindGear             Kind = "gear"
+)
+
+var (
+	// source/kind/id/fetched at -> record
+	recordsBucket = []byte("records")
+	// start date/source/kind/id -> nothing
+	startIndexBucket = []byte("activities_by_start")
+)
+
+// the separator used in keys
+const sep = "\x00"
+
+// A Record is a single version of a piece of data
+type Record struct {
+	Source Source `json:"source"`
+	Kind   Kind   `json:"kind"`
+	// the id of the object on the source platform
+	ID string `json:"id"`
+	// the id of the athlete that the object belongs to (if any)
+	AthleteID string `json:"athlete_id,omitempty"`
+	// when this version was fetched
+	FetchedAt time.Time `json:"fetched_at"`
+	// for activities; used for date range queries
+	StartDate time.Time `json:"start_date,omitempty"`
+	// for activities; the sport (e.g. "Run")
+	SportType string `json:"sport_type,omitempty"`
+	// for activities; the gear used
+	GearID string `json:"gear_id,omitempty"`
+	// the object itself as returned by the platform
+	Data json.RawMessage `json:"data"`
+}
+
+// Decode the record's data into v
+func (r *Record) Decode(v interface{}) error {
+	return json.Unmarshal(r.Data, v)
+}
+
+// A Store is a local archive of records
+type Store struct {
+	db *bolt.DB
+}
+
+// Open (or create) the store at `path`
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{recordsBucket, startIndexBucket} {
+			_, err := tx.CreateBucketIfNotExists(b)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// sortable byte representation of a time
+func timeKey(t time.Time) []byte {
+	b := make([]byte, 8)
+	// shift so that times before 1970 still sort correctly
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano())^(1<<63))
+	return b
+}
+
+// the key prefix shared by every version of an object
+func objectPrefix(source Source, kind Kind, id string) []byte {
+	return []byte(strings.Join([]string{string(source), string(kind), id}, sep) + sep)
+}
+
+func indexKey(startDate time.Time, source Source, kind Kind, id string) []byte {
+	return append(timeKey(startDate), objectPrefix(source, kind, id)...)
+}
+
+// Put a new version of a record.
+//
+// If `FetchedAt` is not set, it is set to now.
+func (s *Store) Put(rec Record) error {
+	if rec.Source == "" || rec.Kind == "" || rec.ID == "" {
+		return fmt.Errorf("record must have a source, kind and id")
+	}
+	if rec.FetchedAt.IsZero() {
+		rec.FetchedAt = time.Now()
+	}
+	value, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		prefix := objectPrefix(rec.Source, rec.Kind, rec.ID)
+		// the start date can change between versions, so drop the old index entry
+		prev, err := latest(tx, prefix)
+		if err == nil && !prev.StartDate.IsZero() {
+			err = tx.Bucket(startIndexBucket).Delete(indexKey(prev.StartDate, prev.Source, prev.Kind, prev.ID))
+			if err != nil {
+				return err
+			}
+		}
+		key := append(append([]byte{}, prefix...), timeKey(rec.FetchedAt)...)
+		err = tx.Bucket(recordsBucket).Put(key, value)
+		if err != nil {
+			return err
+		}
+		if !rec.StartDate.IsZero() {
+			return tx.Bucket(startIndexBucket).Put(indexKey(rec.StartDate, rec.Source, rec.Kind, rec.ID), []byte{})
+		}
+		return nil
+	})
+}
+
+// the latest version of the object with `prefix`
+func latest(tx *bolt.Tx, prefix []byte) (*Record, error) {
+	c := tx.Bucket(recordsBucket).Cursor()
+	// seek past the last possible version, then step back
+	k, v := c.Seek(append(append([]byte{}, prefix...), bytes.Repeat([]byte{0xff}, 8)...))
+	if k == nil {
+		k, v = c.Last()
+	} else {
+		k, v = c.Prev()
+	}
+	if k == nil || !bytes.HasPrefix(k, prefix) {
+		return nil, NotFoundError
+	}
+	var rec Record
+	err := json.Unmarshal(v, &rec)
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Get the latest version of an object
+func (s *Store) Latest(source Source, kind Kind, id string) (*Record, error) {
+	var rec *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		rec, err = latest(tx, objectPrefix(source, kind, id))
+		return err
+	})
+	return rec, err
+}
+
+// Get every version of an object, oldest first
+func (s *Store) Versions(source Source, kind Kind, id string) ([]Record, error) {
+	records := []Record{}
+	prefix := objectPrefix(source, kind, id)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(recordsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var rec Record
+			err := json.Unmarshal(v, &rec)
+			if err != nil {
+				return err
+			}
+			records = append(records, rec)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Delete every version of an object. Deleting an object that does not exist is not an error.
+func (s *Store) Delete(source Source, kind Kind, id string) error {
+	prefix := objectPrefix(source, kind, id)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		prev, err := latest(tx, prefix)
+		if errors.Is(err, NotFoundError) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !prev.StartDate.IsZero() {
+			err = tx.Bucket(startIndexBucket).Delete(indexKey(prev.StartDate, prev.Source, prev.Kind, prev.ID))
+			if err != nil {
+				return err
+			}
+		}
+		c := tx.Bucket(recordsBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+			err := c.Delete()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// An ActivityQuery filters activities. Empty fields are ignored.
+type ActivityQuery struct {
+	// inclusive
+	From time.Time
+	// exclusive
+	To        time.Time
+	SportType string
+	GearID    string
+	Source    Source
+	// the kind of activity record to return. (default KindActivity)
+	Kind Kind
+}
+
+// Get the latest version of every activity that matches the query, ordered by start date
+func (s *Store) QueryActivities(q ActivityQuery) ([]Record, error) {
+	kind := q.Kind
+	if kind == "" {
+		kind = KindActivity
+	}
+	records := []Record{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(startIndexBucket).Cursor()
+		var k []byte
+		if q.From.IsZero() {
+			k, _ = c.First()
+		} else {
+			k, _ = c.Seek(timeKey(q.From))
+		}
+		var end []byte
+		if !q.To.IsZero() {
+			end = timeKey(q.To)
+		}
+		for ; k != nil; k, _ = c.Next() {
+			if end != nil && bytes.Compare(k[:8], end) >= 0 {
+				break
+			}
+			prefix := k[8:]
+			parts := strings.SplitN(string(prefix), sep, 3)
+			if len(parts) != 3 || Kind(parts[1]) != kind {
+				continue
+			}
+			if q.Source != "" && Source(parts[0]) != q.Source {
+				continue
+			}
+			rec, err := latest(tx, prefix)
+			if err != nil {
+				return err
+			}
+			if q.SportType != "" && !strings.EqualFold(rec.SportType, q.SportType) {
+				continue
+			}
+			if q.GearID != "" && rec.GearID != q.GearID {
+				continue
+			}
+			records = append(records, *rec)
+		}
+		return nil
+	})
+	return records, err
+}
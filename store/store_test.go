@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	stravaSync "github.com/jcocozza/cassidy-connector/strava/sync"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+var _ stravaSync.Sink = (*StravaSink)(nil)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStore_Versions(t *testing.T) {
+	s := openTestStore(t)
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"first", "second"} {
+		a := swagger.SummaryActivity{Id: 1, Name: name, StartDate: first}
+		rec, _ := stravaRecord(KindActivity, a.Id, 1, a)
+		rec.StartDate = a.StartDate
+		rec.FetchedAt = first.Add(time.Duration(i) * time.Hour)
+		if err := s.Put(rec); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+	versions, err := s.Versions(SourceStrava, KindActivity, "1")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("Versions() = %d, %v, want 2 versions", len(versions), err)
+	}
+	latest, err := s.Latest(SourceStrava, KindActivity, "1")
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	var a swagger.SummaryActivity
+	latest.Decode(&a)
+	if a.Name != "second" {
+		t.Errorf("Latest() = %v, want second", a.Name)
+	}
+	// another object whose id starts with the same digit should not be mixed in
+	if _, err := s.Latest(SourceStrava, KindActivity, "10"); err != NotFoundError {
+		t.Errorf("Latest() error = %v, want NotFoundError", err)
+	}
+}
+
+func TestStore_QueryActivities(t *testing.T) {
+	s := openTestStore(t)
+	run := swagger.RUN_SportType
+	ride := swagger.RIDE_SportType
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 8, 0, 0, 0, time.UTC) }
+	activities := []swagger.SummaryActivity{
+		{Id: 1, SportType: &run, StartDate: day(1), GearId: "g1"},
+		{Id: 2, SportType: &ride, StartDate: day(2), GearId: "b1"},
+		{Id: 3, SportType: &run, StartDate: day(3), GearId: "g2"},
+		{Id: 4, SportType: &run, StartDate: day(4), GearId: "g1"},
+	}
+	for _, a := range activities {
+		if err := s.PutStravaSummaryActivity(1, a); err != nil {
+			t.Fatalf("PutStravaSummaryActivity() error = %v", err)
+		}
+	}
+	tests := []struct {
+		name  string
+		query ActivityQuery
+		want  []string
+	}{
+		{"everything", ActivityQuery{}, []string{"1", "2", "3", "4"}},
+		{"date range", ActivityQuery{From: day(2), To: day(4)}, []string{"2", "3"}},
+		{"sport", ActivityQuery{SportType: "run"}, []string{"1", "3", "4"}},
+		{"gear", ActivityQuery{GearID: "g1"}, []string{"1", "4"}},
+		{"other source", ActivityQuery{Source: SourceFinalSurge}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.QueryActivities(tt.query)
+			if err != nil {
+				t.Fatalf("QueryActivities() error = %v", err)
+			}
+			ids := []string{}
+			for _, r := range got {
+				ids = append(ids, r.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("QueryActivities() = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Errorf("QueryActivities() = %v, want %v", ids, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestStravaSink(t *testing.T) {
+	s := openTestStore(t)
+	sink := s.StravaSink()
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := sink.Put(ctx, 1, swagger.SummaryActivity{Id: 7, StartDate: start}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	// moving the start date should move the index entry
+	if err := sink.Put(ctx, 1, swagger.SummaryActivity{Id: 7, StartDate: start.AddDate(0, 1, 0)}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	got, _ := s.QueryActivities(ActivityQuery{})
+	if len(got) != 1 {
+		t.Fatalf("QueryActivities() = %d records, want 1", len(got))
+	}
+	if err := sink.Delete(ctx, 1, 7); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	got, _ = s.QueryActivities(ActivityQuery{})
+	if len(got) != 0 {
+		t.Errorf("QueryActivities() = %d records after delete, want 0", len(got))
+	}
+	if _, err := s.Latest(SourceStrava, KindActivity, "7"); err != NotFoundError {
+		t.Errorf("Latest() error = %v after delete, want NotFoundError", err)
+	}
+}
+
+func TestSourceSink(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// the same id from two sources are different activities
+	s.StravaSink().Put(ctx, 1, swagger.SummaryActivity{Id: 7, StartDate: start})
+	local := s.SourceSink(SourceLocal)
+	if err := local.Put(ctx, 0, swagger.SummaryActivity{Id: 7, StartDate: start}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	got, _ := s.QueryActivities(ActivityQuery{Source: SourceLocal})
+	if len(got) != 1 || got[0].AthleteID != "" {
+		t.Fatalf("QueryActivities(local) = %+v, want 1 record without an athlete", got)
+	}
+	if err := local.Delete(ctx, 0, 7); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Latest(SourceStrava, KindActivity, "7"); err != nil {
+		t.Errorf("Latest(strava) error = %v after deleting the local activity, want it kept", err)
+	}
+}
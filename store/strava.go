@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// build a record for a strava object
+func stravaRecord(kind Kind, id int64, athleteID int, v interface{}) (Record, error) {
+	return sourceRecord(SourceStrava, kind, id, athleteID, v)
+}
+
+// build a record for an object in strava's shape from any source
+func sourceRecord(source Source, kind Kind, id int64, athleteID int, v interface{}) (Record, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Record{}, err
+	}
+	rec := Record{Source: source, Kind: kind, ID: fmt.Sprint(id), Data: data}
+	if athleteID != 0 {
+		rec.AthleteID = fmt.Sprint(athleteID)
+	}
+	return rec, nil
+}
+
+// the sport of a strava activity. prefers sport type over the deprecated type
+func stravaSport(sportType *swagger.SportType, activityType *swagger.ActivityType) string {
+	if sportType != nil {
+		return string(*sportType)
+	}
+	if activityType != nil {
+		return string(*activityType)
+	}
+	return ""
+}
+
+func (s *Store) PutStravaAthlete(athlete *swagger.DetailedAthlete) error {
+	rec, err := stravaRecord(KindAthlete, athlete.Id, int(athlete.Id), athlete)
+	if err != nil {
+		return err
+	}
+	return s.Put(rec)
+}
+
+func (s *Store) PutStravaSummaryActivity(athleteID int, activity swagger.SummaryActivity) error {
+	return s.putSummaryActivity(SourceStrava, athleteID, activity)
+}
+
+func (s *Store) putSummaryActivity(source Source, athleteID int, activity swagger.SummaryActivity) error {
+	rec, err := sourceRecord(source, KindActivity, activity.Id, athleteID, activity)
+	if err != nil {
+		return err
+	}
+	rec.StartDate = activity.StartDate
+	rec.SportType = stravaSport(activity.SportType, activity.Type_)
+	rec.GearID = activity.GearId
+	return s.Put(rec)
+}
+
+func (s *Store) PutStravaDetailedActivity(athleteID int, activity *swagger.DetailedActivity) error {
+	rec, err := stravaRecord(KindDetailedActivity, activity.Id, athleteID, activity)
+	if err != nil {
+		return err
+	}
+	rec.StartDate = activity.StartDate
+	rec.SportType = stravaSport(activity.SportType, activity.Type_)
+	rec.GearID = activity.GearId
+	return s.Put(rec)
+}
+
+// streams are keyed by the activity they belong to
+func (s *Store) PutStravaStreams(athleteID int, activityID int, streams *swagger.StreamSet) error {
+	return s.putStreams(SourceStrava, athleteID, activityID, streams)
+}
+
+func (s *Store) putStreams(source Source, athleteID int, activityID int, streams *swagger.StreamSet) error {
+	rec, err := sourceRecord(source, KindStreams, int64(activityID), athleteID, streams)
+	if err != nil {
+		return err
+	}
+	return s.Put(rec)
+}
+
+// laps are keyed by the activity they belong to
+func (s *Store) PutStravaLaps(athleteID int, activityID int, laps []swagger.Lap) error {
+	rec, err := stravaRecord(KindLaps, int64(activityID), athleteID, laps)
+	if err != nil {
+		return err
+	}
+	return s.Put(rec)
+}
+
+func (s *Store) PutStravaGear(athleteID int, gear *swagger.DetailedGear) error {
+	data, err := json.Marshal(gear)
+	if err != nil {
+		return err
+	}
+	rec := Record{Source: SourceStrava, Kind: KindGear, ID: gear.Id, AthleteID: fmt.Sprint(athleteID), Data: data}
+	return s.Put(rec)
+}
+
+// StravaSink writes synced activities into the store.
+//
+// It satisfies the `Sink` interface of the `strava/sync` package, so it can be used for both syncs and webhook events.
+type StravaSink struct {
+	store  *Store
+	source Source
+}
+
+// A sink for activities from strava
+func (s *Store) StravaSink() *StravaSink {
+	return s.SourceSink(SourceStrava)
+}
+
+// A sink for activities in strava's shape that come from another source (e.g. `SourceLocal`), so they are kept apart from strava's
+func (s *Store) SourceSink(source Source) *StravaSink {
+	return &StravaSink{store: s, source: source}
+}
+
+func (ss *StravaSink) Put(ctx context.Context, athleteID int, activity swagger.SummaryActivity) error {
+	return ss.store.putSummaryActivity(ss.source, athleteID, activity)
+}
+
+func (ss *StravaSink) PutStreams(ctx context.Context, athleteID int, activityID int, streams *swagger.StreamSet) error {
+	return ss.store.putStreams(ss.source, athleteID, activityID, streams)
+}
+
+// Delete the activity along with its detail, streams and laps
+func (ss *StravaSink) Delete(ctx context.Context, athleteID int, activityID int) error {
+	id := fmt.Sprint(activityID)
+	for _, kind := range []Kind{KindActivity, KindDetailedActivity, KindStreams, KindLaps} {
+		err := ss.store.Delete(ss.source, kind, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
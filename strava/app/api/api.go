@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/antihax/optional"
@@ -45,6 +46,14 @@ const (
 	// The strava API limits to 3000 READ requests per day
 	ReadLimitDaily          = 3000.0
 	ReadLimitDailyDuration  = time.Duration(24*time.Hour)
+	// The strava API limits to 600 requests per 15 minutes overall.
+	// Anything that is not used by reads is left for WRITE requests.
+	WriteLimit15Min         = 300.0
+	WriteLimit15MinDuration = time.Duration(15*time.Minute)
+	// The strava API limits to 6000 requests per day overall.
+	// Anything that is not used by reads is left for WRITE requests.
+	WriteLimitDaily         = 3000.0
+	WriteLimitDailyDuration = time.Duration(24*time.Hour)
 )
 
 // This contains the user's short-lived access token which is used to access data.
@@ -85,15 +94,25 @@ type StravaAPI struct {
 	oauth        *oauth2.Config
 	limiter15min *ratelimit.FixedWindow
 	limiterDaily *ratelimit.FixedWindow
+	// writes are limited separately from reads
+	writeLimiter15min *ratelimit.FixedWindow
+	writeLimiterDaily *ratelimit.FixedWindow
+	// optional; see `CachingClient`
+	cache *ResponseCache
+	// the daily requests that are set aside for bulk fetches (see `reserveDaily`)
+	reserveMu     sync.Mutex
+	reservedDaily int
 }
 
 func NewStravaAPI(stravaClient *swagger.APIClient, cfg *oauth2.Config, logger *slog.Logger) *StravaAPI {
 	return &StravaAPI{
-		stravaClient: stravaClient,
-		logger:       logger,
-		oauth:        cfg,
-		limiter15min: ratelimit.NewFixedWindow(ReadLimit15MinDuration, ReadLimit15Min),
-		limiterDaily: ratelimit.NewFixedWindow(ReadLimitDailyDuration, ReadLimitDaily),
+		stravaClient:      stravaClient,
+		logger:            logger,
+		oauth:             cfg,
+		limiter15min:      ratelimit.NewFixedWindow(ReadLimit15MinDuration, ReadLimit15Min),
+		limiterDaily:      ratelimit.NewFixedWindow(ReadLimitDailyDuration, ReadLimitDaily),
+		writeLimiter15min: ratelimit.NewFixedWindow(WriteLimit15MinDuration, WriteLimit15Min),
+		writeLimiterDaily: ratelimit.NewFixedWindow(WriteLimitDailyDuration, WriteLimitDaily),
 	}
 }
 
@@ -103,12 +122,62 @@ func NewStravaAPI(stravaClient *swagger.APIClient, cfg *oauth2.Config, logger *s
 //
 // ** should be called before every api call **
 func (api *StravaAPI) checkRateLimits(ctx context.Context) error {
-	err := api.limiterDaily.WaitRequest(ctx)
-	if err != nil {
-		api.logger.ErrorContext(ctx, "failed daily rate limits", slog.String("error", err.Error()))
+	// with a cache installed, reads are counted by the caching transport once it knows a request is needed
+	if api.cache != nil {
+		return api.waitForRoom(ctx)
+	}
+	return api.waitRateLimits(ctx)
+}
+
+// wait until there is room in the read rate limits, without using any of it
+func (api *StravaAPI) waitForRoom(ctx context.Context) error {
+	for api.dailyRemaining(ctx) <= 0 {
+		if err := api.sleep(ctx, api.dailyWait()); err != nil {
+			return err
+		}
+	}
+	for api.limiter15min.RequestsRemaining() <= 0 {
+		if err := api.sleep(ctx, api.limiter15min.TimeTillNextWindow()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// how long to wait before checking the daily window again
+//
+// requests set aside for a bulk fetch can be given back before the window resets, so check again soon while there are any
+func (api *StravaAPI) dailyWait() time.Duration {
+	wait := api.limiterDaily.TimeTillNextWindow()
+	api.reserveMu.Lock()
+	defer api.reserveMu.Unlock()
+	if api.reservedDaily > 0 && wait > reservationPoll {
+		return reservationPoll
+	}
+	return wait
+}
+
+// wait for `d`, or until the context is done
+func (api *StravaAPI) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		api.logger.ErrorContext(ctx, "failed waiting for rate limits", slog.String("error", ctx.Err().Error()))
 		return RateLimitError
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// wait for room in the read rate limits
+//
+// requests that are reserved for bulk fetches (see `reserveDaily`) are only used by the calls they were reserved for
+func (api *StravaAPI) waitRateLimits(ctx context.Context) error {
+	for !api.takeDaily(ctx) {
+		if err := api.sleep(ctx, api.dailyWait()); err != nil {
+			return err
+		}
 	}
-	err = api.limiter15min.WaitRequest(ctx)
+	err := api.limiter15min.WaitRequest(ctx)
 	if err != nil {
 		api.logger.ErrorContext(ctx, "failed 15 minute rate limits", slog.String("error", err.Error()))
 		return RateLimitError
@@ -116,6 +185,23 @@ func (api *StravaAPI) checkRateLimits(ctx context.Context) error {
 	return nil
 }
 
+// the same as checkRateLimits, but for WRITE requests
+//
+// ** should be called before every api call that modifies data **
+func (api *StravaAPI) checkWriteRateLimits(ctx context.Context) error {
+	err := api.writeLimiterDaily.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed daily write rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	err = api.writeLimiter15min.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed 15 minute write rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	return nil
+}
+
 // return the remaining requests for the 15 mintue request window and the daily window
 // (in that order)
 func (api *StravaAPI) RemainingRequests() (int, int) {
@@ -127,6 +213,17 @@ func (api *StravaAPI) RemainingRequests() (int, int) {
 	return rr15, rrdaily
 }
 
+// return the remaining write requests for the 15 mintue request window and the daily window
+// (in that order)
+func (api *StravaAPI) RemainingWriteRequests() (int, int) {
+	rrdaily := api.writeLimiterDaily.RequestsRemaining()
+	if rrdaily == 0 {
+		return 0, 0
+	}
+	rr15 := api.writeLimiter15min.RequestsRemaining()
+	return rr15, rrdaily
+}
+
 // return the time till the next 15 minute window and the next daily window
 // (in that order)
 func (api *StravaAPI) TimeTillNextWindows() (time.Duration, time.Duration){
@@ -151,7 +248,7 @@ func (api *StravaAPI) setContext(ctx context.Context, token *oauth2.Token) (cont
 		return nil, err
 	}
 	us := &userSession{tkn: refreshedTkn}
-	newCtx := us.AuthorizationContext(ctx)
+	newCtx := us.AuthorizationContext(withCacheOwner(ctx, token))
 	return newCtx, nil
 }
 
@@ -167,7 +264,7 @@ func (api *StravaAPI) GetAthlete(ctx context.Context, token *oauth2.Token) (*swa
 	}
 	api.logger.DebugContext(ctx, "getting athlete")
 	athlete, resp, err := api.stravaClient.AthletesApi.GetLoggedInAthlete(ctx)
-	if resp.StatusCode == http.StatusNotFound {
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
 		api.logger.DebugContext(ctx, "athlete not found")
 		return nil, NotFoundError
 	}
@@ -201,30 +298,13 @@ func (api *StravaAPI) GetActivities(ctx context.Context, token *oauth2.Token, pe
 		slog.Any("after", after),
 	)
 	var summaryActivitylol [][]swagger.SummaryActivity
-	opts := &swagger.ActivitiesApiGetLoggedInAthleteActivitiesOpts{}
-	if before != nil {
-		beforeOpt := optional.NewInt32(int32(before.Unix()))
-		opts.Before = beforeOpt
-	}
-	if after != nil {
-		afterOpt := optional.NewInt32(int32(after.Unix()))
-		opts.After = afterOpt
-	}
-	perPageOpt := optional.NewInt32(int32(perPage))
-	opts.PerPage = perPageOpt
 	existsMore := true
-	var page int32 = 1 // page enumeration starts at 1
-	for existsMore {   // enumerate until there are no more activities
-		opts.Page = optional.NewInt32(page)
-		err := api.checkRateLimits(ctx)
+	page := 1        // page enumeration starts at 1
+	for existsMore { // enumerate until there are no more activities
+		summary, err := api.getActivitiesPage(ctx, page, perPage, before, after)
 		if err != nil {
 			return nil, err
 		}
-		summary, _, err := api.stravaClient.ActivitiesApi.GetLoggedInAthleteActivities(ctx, opts)
-		if err != nil {
-			api.logger.ErrorContext(ctx, "getting activities failed", slog.Any("page", opts.Page), slog.String("error", err.Error()))
-			return nil, err
-		}
 		//return summary, nil
 		summaryActivitylol = append(summaryActivitylol, summary)
 		page += 1
@@ -235,6 +315,55 @@ func (api *StravaAPI) GetActivities(ctx context.Context, token *oauth2.Token, pe
 	return summaryActivitylol, nil
 }
 
+// Get a single page of activities.
+//
+// This is the same as `GetActivities` except that only one page (starting at 1) is fetched.
+// An empty page means there are no more activities.
+//
+// When `after` is set, strava returns activities in ascending order of start date, otherwise in descending order.
+// This is useful if you want to process (and checkpoint) activities a page at a time.
+func (api *StravaAPI) GetActivitiesPage(ctx context.Context, token *oauth2.Token, page int, perPage int, before, after *time.Time) ([]swagger.SummaryActivity, error) {
+	ctx, err := api.setContext(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting activities page",
+		slog.Int("page", page),
+		slog.Int("per page", perPage),
+		slog.Any("before", before),
+		slog.Any("after", after),
+	)
+	return api.getActivitiesPage(ctx, page, perPage, before, after)
+}
+
+// get a page of activities
+//
+// ctx must already have the authorization context set
+func (api *StravaAPI) getActivitiesPage(ctx context.Context, page int, perPage int, before, after *time.Time) ([]swagger.SummaryActivity, error) {
+	opts := &swagger.ActivitiesApiGetLoggedInAthleteActivitiesOpts{}
+	if before != nil {
+		beforeOpt := optional.NewInt32(int32(before.Unix()))
+		opts.Before = beforeOpt
+	}
+	if after != nil {
+		afterOpt := optional.NewInt32(int32(after.Unix()))
+		opts.After = afterOpt
+	}
+	perPageOpt := optional.NewInt32(int32(perPage))
+	opts.PerPage = perPageOpt
+	opts.Page = optional.NewInt32(int32(page))
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary, _, err := api.stravaClient.ActivitiesApi.GetLoggedInAthleteActivities(ctx, opts)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "getting activities failed", slog.Any("page", opts.Page), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return summary, nil
+}
+
 // Get a single activity by activity ID
 //
 // `activityID` is the id of the activity
@@ -252,7 +381,7 @@ func (api *StravaAPI) GetActivity(ctx context.Context, token *oauth2.Token, acti
 	api.logger.DebugContext(ctx, "getting activity", slog.Int("activity id", activityID), slog.Bool("include all efforts", includeAllEfforts))
 	opts := &swagger.ActivitiesApiGetActivityByIdOpts{IncludeAllEfforts: optional.NewBool(includeAllEfforts)}
 	activity, resp, err := api.stravaClient.ActivitiesApi.GetActivityById(ctx, int64(activityID), opts)
-	if resp.StatusCode == http.StatusNotFound {
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
 		api.logger.DebugContext(ctx, "activity not found")
 		return nil, NotFoundError
 	}
@@ -290,6 +419,51 @@ func validateKeys(keys []StreamType) error {
 	return nil
 }
 
+// Keep only the streams in `keys`.
+//
+// This is for providers that get every stream of an activity at once (e.g. by decoding the activity file), so they can return what `GetActivityStreams` would.
+func FilterStreams(set *swagger.StreamSet, keys []StreamType) *swagger.StreamSet {
+	want := map[StreamType]bool{}
+	for _, k := range keys {
+		want[k] = true
+	}
+	filtered := &swagger.StreamSet{}
+	if want[Time] {
+		filtered.Time = set.Time
+	}
+	if want[Distance] {
+		filtered.Distance = set.Distance
+	}
+	if want[Latlng] {
+		filtered.Latlng = set.Latlng
+	}
+	if want[Altitude] {
+		filtered.Altitude = set.Altitude
+	}
+	if want[VelocitySmooth] {
+		filtered.VelocitySmooth = set.VelocitySmooth
+	}
+	if want[Heartrate] {
+		filtered.Heartrate = set.Heartrate
+	}
+	if want[Cadence] {
+		filtered.Cadence = set.Cadence
+	}
+	if want[Watts] {
+		filtered.Watts = set.Watts
+	}
+	if want[Temp] {
+		filtered.Temp = set.Temp
+	}
+	if want[Moving] {
+		filtered.Moving = set.Moving
+	}
+	if want[GradeSmooth] {
+		filtered.GradeSmooth = set.GradeSmooth
+	}
+	return filtered
+}
+
 // Get the streams for a given activity.
 //
 // `activityID` is the id of the activity
@@ -319,7 +493,7 @@ func (api *StravaAPI) GetActivityStreams(ctx context.Context, token *oauth2.Toke
 		return nil, err
 	}
 	streamSet, resp, err := api.stravaClient.StreamsApi.GetActivityStreams(ctx, int64(activityID), keyList, keyByType)
-	if resp.StatusCode == http.StatusNotFound {
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
 		api.logger.DebugContext(ctx, "streams not found")
 		return nil, NotFoundError
 	}
@@ -329,3 +503,107 @@ func (api *StravaAPI) GetActivityStreams(ctx context.Context, token *oauth2.Toke
 	}
 	return &streamSet, nil
 }
+
+// Get the rolled-up statistics for an athlete.
+//
+// `athleteID` must be the id of the authenticated athlete. Strava will not return stats for anyone else.
+//
+// The returned stats contain the recent (last 4 weeks), year to date and all time totals for rides, runs and swims.
+func (api *StravaAPI) GetAthleteStats(ctx context.Context, token *oauth2.Token, athleteID int) (*swagger.ActivityStats, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = api.setContext(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting athlete stats", slog.Int("athlete id", athleteID))
+	stats, resp, err := api.stravaClient.AthletesApi.GetStats(ctx, int64(athleteID))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		api.logger.DebugContext(ctx, "athlete stats not found")
+		return nil, NotFoundError
+	}
+	if err != nil {
+		api.logger.ErrorContext(ctx, "error getting athlete stats", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Get the heart rate and power zones of the authenticated athlete.
+//
+// Requires the `profile:read_all` scope.
+//
+// The zones can be passed to `ZoneIndex` or `TimeInZones` so that local calculations use the same boundaries as strava.
+func (api *StravaAPI) GetAthleteZones(ctx context.Context, token *oauth2.Token) (*swagger.Zones, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = api.setContext(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting athlete zones")
+	zones, resp, err := api.stravaClient.AthletesApi.GetLoggedInAthleteZones(ctx)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		api.logger.DebugContext(ctx, "athlete zones not found")
+		return nil, NotFoundError
+	}
+	if err != nil {
+		api.logger.ErrorContext(ctx, "error getting athlete zones", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &zones, nil
+}
+
+// Get the laps of an activity
+//
+// `activityID` is the id of the activity
+func (api *StravaAPI) GetActivityLaps(ctx context.Context, token *oauth2.Token, activityID int) ([]swagger.Lap, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = api.setContext(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting activity laps", slog.Int("activity id", activityID))
+	laps, resp, err := api.stravaClient.ActivitiesApi.GetLapsByActivityId(ctx, int64(activityID))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		api.logger.DebugContext(ctx, "laps not found")
+		return nil, NotFoundError
+	}
+	if err != nil {
+		api.logger.ErrorContext(ctx, "error getting laps", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return laps, nil
+}
+
+// Get the heart rate and power zones of an activity. (This is a strava summit feature)
+//
+// `activityID` is the id of the activity
+func (api *StravaAPI) GetActivityZones(ctx context.Context, token *oauth2.Token, activityID int) ([]swagger.ActivityZone, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = api.setContext(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting activity zones", slog.Int("activity id", activityID))
+	zones, resp, err := api.stravaClient.ActivitiesApi.GetZonesByActivityId(ctx, int64(activityID))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		api.logger.DebugContext(ctx, "activity zones not found")
+		return nil, NotFoundError
+	}
+	if err != nil {
+		api.logger.ErrorContext(ctx, "error getting activity zones", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return zones, nil
+}
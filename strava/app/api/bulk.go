@@ -0,0 +1,281 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/ratelimit"
+	"golang.org/x/oauth2"
+)
+
+const defaultFetchWorkers = 4
+
+// how often callers blocked on the daily window check whether a bulk fetch gave back requests
+const reservationPoll = time.Second
+
+// FetchOpts control what `FetchActivitiesDetailed` gets for each activity
+type FetchOpts struct {
+	// the number of activities fetched at once. (default 4)
+	//
+	// all workers share the same rate limits, so more workers only helps while there is room in the 15 minute window
+	Workers int
+	// get the detailed activity
+	Detail bool
+	// include all segment efforts in the detailed activity
+	IncludeAllEfforts bool
+	// the streams to get. no streams are fetched if this is empty
+	Streams []StreamType
+	// get the laps of the activity
+	Laps bool
+	// get the zones of the activity
+	Zones bool
+	// optional; called after each activity is fetched (successfully or not)
+	//
+	// this is called from the worker goroutines, so it must be safe for concurrent use
+	Progress func(FetchProgress)
+}
+
+// the number of api requests needed to fetch a single activity
+func (o *FetchOpts) requestsPerActivity() int {
+	n := 0
+	if o.Detail {
+		n++
+	}
+	if len(o.Streams) > 0 {
+		n++
+	}
+	if o.Laps {
+		n++
+	}
+	if o.Zones {
+		n++
+	}
+	return n
+}
+
+// FetchProgress is reported after each activity is fetched
+type FetchProgress struct {
+	// the activity that was fetched
+	ActivityID int
+	// the number of activities fetched so far (including this one)
+	Completed int
+	// the total number of activities requested
+	Total int
+	// the error for this activity, if any
+	Err error
+}
+
+// An ActivityBundle is everything that was fetched for a single activity
+type ActivityBundle struct {
+	ActivityID int
+	Activity   *swagger.DetailedActivity
+	Streams    *swagger.StreamSet
+	Laps       []swagger.Lap
+	Zones      []swagger.ActivityZone
+	// the first error that occured while fetching the activity.
+	// anything fetched before the error is still set.
+	Err error
+}
+
+// FetchResult is returned by `FetchActivitiesDetailed`
+type FetchResult struct {
+	// one bundle per activity that was attempted, in the order the ids were passed in
+	Bundles []ActivityBundle
+	// the ids that were not attempted because the daily rate limit ran out (or the context was canceled)
+	//
+	// this is a checkpoint: pass it back into `FetchActivitiesDetailed` once the daily window resets
+	Remaining []int
+}
+
+// Return the errors of the failed bundles, keyed by activity id
+func (r *FetchResult) Errors() map[int]error {
+	errs := map[int]error{}
+	for _, b := range r.Bundles {
+		if b.Err != nil {
+			errs[b.ActivityID] = b.Err
+		}
+	}
+	return errs
+}
+
+// requests that are set aside in the daily window for one activity of a bulk fetch (see `reserveDaily`)
+//
+// the requests are only taken from the daily window as they are used. other callers can't use them in the meantime.
+type reservation struct {
+	api *StravaAPI
+	// guarded by api.reserveMu
+	remaining int
+}
+
+type reservationKey struct{}
+
+// Set aside `n` requests in the daily window for the calls made with the returned context.
+//
+// Returns false if the window does not have `n` requests left that are not already set aside.
+// Call `release` once the calls are done to give back the requests that were not used.
+func (api *StravaAPI) reserveDaily(ctx context.Context, n int) (context.Context, *reservation, bool) {
+	r := &reservation{api: api}
+	if n <= 0 {
+		return ctx, r, true
+	}
+	api.reserveMu.Lock()
+	defer api.reserveMu.Unlock()
+	if api.limiterDaily.RequestsRemaining()-api.reservedDaily < n {
+		return ctx, r, false
+	}
+	api.reservedDaily += n
+	r.remaining = n
+	return context.WithValue(ctx, reservationKey{}, r), r, true
+}
+
+// give back the requests of the reservation that were not used
+func (r *reservation) release() {
+	r.api.reserveMu.Lock()
+	defer r.api.reserveMu.Unlock()
+	r.api.reservedDaily -= r.remaining
+	r.remaining = 0
+}
+
+// Take one request from the daily window. false if there is no room.
+//
+// A context with a reservation (see `reserveDaily`) uses one of its requests. Otherwise only the requests that are not set aside can be taken.
+func (api *StravaAPI) takeDaily(ctx context.Context) bool {
+	api.reserveMu.Lock()
+	defer api.reserveMu.Unlock()
+	if r, ok := ctx.Value(reservationKey{}).(*reservation); ok && r.remaining > 0 {
+		if api.limiterDaily.Request() != nil {
+			return false
+		}
+		r.remaining--
+		api.reservedDaily--
+		return true
+	}
+	return api.limiterDaily.Request(ratelimit.WithThrottle(api.limiterDaily.MaxRequests-api.reservedDaily)) == nil
+}
+
+// the number of requests in the daily window that the context can use
+func (api *StravaAPI) dailyRemaining(ctx context.Context) int {
+	api.reserveMu.Lock()
+	defer api.reserveMu.Unlock()
+	if r, ok := ctx.Value(reservationKey{}).(*reservation); ok && r.remaining > 0 {
+		return r.remaining
+	}
+	return api.limiterDaily.RequestsRemaining() - api.reservedDaily
+}
+
+// fetch everything requested for a single activity
+func (api *StravaAPI) fetchBundle(ctx context.Context, token *oauth2.Token, activityID int, opts *FetchOpts) ActivityBundle {
+	bundle := ActivityBundle{ActivityID: activityID}
+	if opts.Detail {
+		bundle.Activity, bundle.Err = api.GetActivity(ctx, token, activityID, opts.IncludeAllEfforts)
+		if bundle.Err != nil {
+			return bundle
+		}
+	}
+	if len(opts.Streams) > 0 {
+		bundle.Streams, bundle.Err = api.GetActivityStreams(ctx, token, activityID, opts.Streams)
+		if bundle.Err != nil {
+			return bundle
+		}
+	}
+	if opts.Laps {
+		bundle.Laps, bundle.Err = api.GetActivityLaps(ctx, token, activityID)
+		if bundle.Err != nil {
+			return bundle
+		}
+	}
+	if opts.Zones {
+		bundle.Zones, bundle.Err = api.GetActivityZones(ctx, token, activityID)
+	}
+	return bundle
+}
+
+// Fetch the detail, streams, laps and/or zones (see `FetchOpts`) for many activities at once.
+//
+// A pool of workers fetches the activities concurrently. The workers share the same rate limits as every other method.
+// Make sure that the context has a timeout, otherwise workers will block until the 15 minute window resets.
+//
+// Before each activity is started, its requests are reserved from the daily window; the ones it did not use are given back once it is done. If there are not enough requests left in the daily window to fetch the activity,
+// no further activities are started and the ids that were not attempted are returned in `FetchResult.Remaining`.
+//
+// Errors for individual activities do not stop the fetch. They are reported in the activity's bundle (see `FetchResult.Errors`).
+func (api *StravaAPI) FetchActivitiesDetailed(ctx context.Context, token *oauth2.Token, activityIDs []int, opts *FetchOpts) (*FetchResult, error) {
+	if opts == nil {
+		opts = &FetchOpts{Detail: true}
+	}
+	err := validateKeys(opts.Streams)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "invalid keys", slog.String("error", err.Error()))
+		return nil, err
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultFetchWorkers
+	}
+	perActivity := opts.requestsPerActivity()
+	api.logger.DebugContext(ctx, "fetching activities", slog.Int("activities", len(activityIDs)), slog.Int("workers", workers), slog.Int("requests per activity", perActivity))
+
+	type job struct {
+		index int
+		id    int
+		// carries the requests reserved for the activity
+		ctx      context.Context
+		reserved *reservation
+	}
+	jobs := make(chan job)
+	bundles := make([]*ActivityBundle, len(activityIDs))
+	var mu sync.Mutex
+	completed := 0
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				bundle := api.fetchBundle(j.ctx, token, j.id, opts)
+				j.reserved.release()
+				mu.Lock()
+				bundles[j.index] = &bundle
+				completed++
+				progress := FetchProgress{ActivityID: j.id, Completed: completed, Total: len(activityIDs), Err: bundle.Err}
+				mu.Unlock()
+				if opts.Progress != nil {
+					opts.Progress(progress)
+				}
+			}
+		}()
+	}
+
+	next := 0
+dispatch:
+	for ; next < len(activityIDs); next++ {
+		// reserve the requests for this activity up front so that workers never block on the daily window
+		jobCtx, reserved, ok := api.reserveDaily(ctx, perActivity)
+		if !ok {
+			api.logger.WarnContext(ctx, "daily rate limit reached, stopping early", slog.Int("remaining activities", len(activityIDs)-next))
+			break
+		}
+		select {
+		case jobs <- job{index: next, id: activityIDs[next], ctx: jobCtx, reserved: reserved}:
+		case <-ctx.Done():
+			reserved.release()
+			api.logger.WarnContext(ctx, "context canceled, stopping early", slog.Int("remaining activities", len(activityIDs)-next))
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := &FetchResult{}
+	for _, b := range bundles {
+		if b != nil {
+			result.Bundles = append(result.Bundles, *b)
+		}
+	}
+	result.Remaining = append([]int{}, activityIDs[next:]...)
+	return result, nil
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStravaAPI_FetchActivitiesDetailed(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		id := strings.Split(strings.TrimPrefix(r.URL.Path, "/activities/"), "/")[0]
+		if id == "404" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Record Not Found"}`))
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/laps") {
+			w.Write([]byte(`[{"id": 1, "lap_index": 1}]`))
+			return
+		}
+		fmt.Fprintf(w, `{"id": %s, "name": "activity %s"}`, id, id)
+	})
+
+	tests := []struct {
+		name          string
+		dailyLimit    int
+		ids           []int
+		wantBundles   int
+		wantErrors    int
+		wantRemaining []int
+	}{
+		{"all fetched", 100, []int{1, 2, 3, 404}, 4, 1, nil},
+		{"daily budget runs out", 5, []int{1, 2, 3, 4}, 2, 0, []int{3, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestStravaAPI(t, handler, tt.dailyLimit)
+			var progressCalls atomic.Int32
+			opts := &FetchOpts{
+				Workers:  2,
+				Detail:   true,
+				Laps:     true,
+				Progress: func(FetchProgress) { progressCalls.Add(1) },
+			}
+			res, err := api.FetchActivitiesDetailed(context.Background(), testToken(), tt.ids, opts)
+			if err != nil {
+				t.Fatalf("FetchActivitiesDetailed() error = %v", err)
+			}
+			if len(res.Bundles) != tt.wantBundles {
+				t.Errorf("FetchActivitiesDetailed() bundles = %d, want %d", len(res.Bundles), tt.wantBundles)
+			}
+			if len(res.Errors()) != tt.wantErrors {
+				t.Errorf("FetchActivitiesDetailed() errors = %v, want %d", res.Errors(), tt.wantErrors)
+			}
+			if fmt.Sprint(res.Remaining) != fmt.Sprint(tt.wantRemaining) && !(len(res.Remaining) == 0 && len(tt.wantRemaining) == 0) {
+				t.Errorf("FetchActivitiesDetailed() remaining = %v, want %v", res.Remaining, tt.wantRemaining)
+			}
+			if int(progressCalls.Load()) != tt.wantBundles {
+				t.Errorf("progress called %d times, want %d", progressCalls.Load(), tt.wantBundles)
+			}
+			for _, b := range res.Bundles {
+				if b.Err == nil && (b.Activity == nil || len(b.Laps) != 1) {
+					t.Errorf("bundle %d is incomplete: %+v", b.ActivityID, b)
+				}
+			}
+		})
+	}
+}
+
+func TestStravaAPI_reserveDaily(t *testing.T) {
+	api := newTestStravaAPI(t, http.NotFoundHandler(), 5)
+	// another caller uses part of the window
+	api.limiterDaily.Request()
+	api.limiterDaily.Request()
+	if _, _, ok := api.reserveDaily(context.Background(), 4); ok {
+		t.Fatalf("reserveDaily(4) succeeded with 3 requests left")
+	}
+	ctx, r, ok := api.reserveDaily(context.Background(), 3)
+	if !ok {
+		t.Fatalf("reserveDaily(3) failed with 3 requests left")
+	}
+	if _, _, ok := api.reserveDaily(context.Background(), 1); ok {
+		t.Errorf("reserveDaily(1) succeeded with every request set aside")
+	}
+	if api.takeDaily(context.Background()) {
+		t.Errorf("takeDaily() without a reservation used a reserved request")
+	}
+	for i := 0; i < 2; i++ {
+		if !api.takeDaily(ctx) {
+			t.Fatalf("takeDaily() = false after %d uses, want 3 reserved", i)
+		}
+	}
+	// the fetch finished with one request unused
+	r.release()
+	if left := api.limiterDaily.RequestsRemaining(); left != 1 {
+		t.Errorf("daily requests remaining = %d, want 1", left)
+	}
+	if !api.takeDaily(context.Background()) {
+		t.Errorf("takeDaily() = false after the unused request was given back")
+	}
+}
+
+func TestStravaAPI_reserveDaily_concurrent(t *testing.T) {
+	api := newTestStravaAPI(t, http.NotFoundHandler(), 10)
+	var wg sync.WaitGroup
+	var reserved atomic.Int64
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, ok := api.reserveDaily(context.Background(), 3); ok {
+				reserved.Add(3)
+			}
+		}()
+	}
+	wg.Wait()
+	if reserved.Load() != 9 {
+		t.Errorf("reserved %d requests, want 9 of 10", reserved.Load())
+	}
+	if left := api.dailyRemaining(context.Background()); left != 1 {
+		t.Errorf("daily requests left = %d, want 1", left)
+	}
+}
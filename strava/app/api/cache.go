@@ -0,0 +1,389 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// CacheResource represents the kinds of strava responses that can be cached
+type CacheResource string
+
+const (
+	CacheAthlete      CacheResource = "athlete"       // the logged in athlete
+	CacheAthleteZones CacheResource = "athlete_zones" // the logged in athlete's zones
+	CacheStats        CacheResource = "stats"         // athlete stats
+	CacheActivities   CacheResource = "activities"    // activity listings
+	CacheActivity     CacheResource = "activity"      // a single activity
+	CacheStreams      CacheResource = "streams"       // activity streams
+	CacheLaps         CacheResource = "laps"          // activity laps
+	CacheZones        CacheResource = "zones"         // activity zones
+	CacheGear         CacheResource = "gear"          // a piece of gear
+)
+
+const defaultCacheMaxEntries = 1000
+
+// Return the default time that each resource is served from the cache before it is revalidated.
+//
+// A ttl of 0 means always revalidate (a 304 still costs no rate limit). A negative ttl means never cache.
+func DefaultCacheTTLs() map[CacheResource]time.Duration {
+	return map[CacheResource]time.Duration{
+		CacheAthlete:      1 * time.Hour,
+		CacheAthleteZones: 24 * time.Hour,
+		CacheStats:        15 * time.Minute,
+		CacheActivities:   0,
+		CacheActivity:     10 * time.Minute,
+		// streams, laps and zones only change if the activity is cropped, which is picked up by webhook invalidation
+		CacheStreams: 24 * time.Hour,
+		CacheLaps:    1 * time.Hour,
+		CacheZones:   1 * time.Hour,
+		CacheGear:    24 * time.Hour,
+	}
+}
+
+// the paths that can be cached. activity paths capture the activity id
+var cacheRoutes = []struct {
+	pattern  *regexp.Regexp
+	resource CacheResource
+}{
+	{regexp.MustCompile(`/athlete$`), CacheAthlete},
+	{regexp.MustCompile(`/athlete/zones$`), CacheAthleteZones},
+	{regexp.MustCompile(`/athlete/activities$`), CacheActivities},
+	{regexp.MustCompile(`/athletes/\d+/stats$`), CacheStats},
+	{regexp.MustCompile(`/activities/(\d+)$`), CacheActivity},
+	{regexp.MustCompile(`/activities/(\d+)/streams$`), CacheStreams},
+	{regexp.MustCompile(`/activities/(\d+)/laps$`), CacheLaps},
+	{regexp.MustCompile(`/activities/(\d+)/zones$`), CacheZones},
+	{regexp.MustCompile(`/gear/[^/]+$`), CacheGear},
+}
+
+// find the resource of a path. returns false if it cannot be cached
+//
+// activityID is 0 if the path does not belong to an activity
+func classifyPath(path string) (CacheResource, int, bool) {
+	for _, route := range cacheRoutes {
+		m := route.pattern.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		activityID := 0
+		if len(m) > 1 {
+			activityID, _ = strconv.Atoi(m[1])
+		}
+		return route.resource, activityID, true
+	}
+	return "", 0, false
+}
+
+type cacheEntry struct {
+	owner      string
+	resource   CacheResource
+	activityID int
+	etag       string
+	header     http.Header
+	body       []byte
+	stored     time.Time
+}
+
+// build a fresh response from a cache entry
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// A ResponseCache stores strava responses keyed by athlete and url.
+//
+// Responses are served from the cache until their resource's ttl runs out.
+// After that, the request is revalidated with the response's ETag. Strava answers with 304 Not Modified if nothing has changed.
+// Neither cache hits nor 304s count against the rate limits.
+//
+// Use `StravaAPI.CachingClient` to install a cache.
+type ResponseCache struct {
+	mu         sync.Mutex
+	ttls       map[CacheResource]time.Duration
+	maxEntries int
+	entries    map[string]*cacheEntry
+	// the athlete id of owners that are not keyed by athlete id
+	athletes map[string]int
+}
+
+// Create a cache. Resources that are missing from `ttls` use the defaults (see `DefaultCacheTTLs`).
+//
+// maxEntries is the number of responses kept. The oldest responses are dropped first. (default 1000)
+func NewResponseCache(ttls map[CacheResource]time.Duration, maxEntries int) *ResponseCache {
+	merged := DefaultCacheTTLs()
+	for resource, ttl := range ttls {
+		merged[resource] = ttl
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &ResponseCache{
+		ttls:       merged,
+		maxEntries: maxEntries,
+		entries:    map[string]*cacheEntry{},
+		athletes:   map[string]int{},
+	}
+}
+
+// The number of responses in the cache
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *ResponseCache) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key]
+}
+
+func (c *ResponseCache) put(key string, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		oldestKey := ""
+		var oldest time.Time
+		for k, e := range c.entries {
+			if oldestKey == "" || e.stored.Before(oldest) {
+				oldestKey, oldest = k, e.stored
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+	c.entries[key] = entry
+	if entry.resource == CacheAthlete {
+		// learn who the owner is so that athlete events can find their entries
+		var athlete struct {
+			Id int `json:"id"`
+		}
+		if json.Unmarshal(entry.body, &athlete) == nil && athlete.Id != 0 {
+			c.athletes[entry.owner] = athlete.Id
+		}
+	}
+}
+
+// mark an entry as fresh again (after a 304).
+//
+// the entry is replaced rather than changed: entries are never changed once they are in the cache, so the one `get` returns can be read without the lock
+func (c *ResponseCache) touch(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		fresh := *e
+		fresh.stored = time.Now()
+		c.entries[key] = &fresh
+	}
+}
+
+func (c *ResponseCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Drop the entries of an activity, along with any listings (which may contain the activity)
+func (c *ResponseCache) InvalidateActivity(activityID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if e.resource == CacheActivities || (activityID != 0 && e.activityID == activityID) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Drop every entry that belongs to an athlete
+func (c *ResponseCache) InvalidateAthlete(athleteID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if e.owner == strconv.Itoa(athleteID) || c.athletes[e.owner] == athleteID {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Drop the entries affected by a webhook event.
+//
+// The arguments are the `ObjectType` and `ObjectID` of an `app.StravaEvent`.
+// For a newly created activity, this only drops the listings.
+func (c *ResponseCache) Invalidate(objectType string, objectID int) {
+	switch objectType {
+	case "activity":
+		c.InvalidateActivity(objectID)
+	case "athlete":
+		c.InvalidateAthlete(objectID)
+	}
+}
+
+type cacheOwnerKey struct{}
+
+// the identity that cache entries of a token are keyed by
+//
+// this is the athlete id when strava included the athlete with the token. otherwise it is a hash of the refresh token,
+// which (unlike the access token) does not change when the token is refreshed.
+func tokenOwner(token *oauth2.Token) string {
+	if athlete, ok := token.Extra("athlete").(map[string]interface{}); ok {
+		if id, ok := athlete["id"].(float64); ok {
+			return strconv.Itoa(int(id))
+		}
+	}
+	secret := token.RefreshToken
+	if secret == "" {
+		secret = token.AccessToken
+	}
+	sum := sha1.Sum([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// attach the cache owner of a token to a context
+func withCacheOwner(ctx context.Context, token *oauth2.Token) context.Context {
+	return context.WithValue(ctx, cacheOwnerKey{}, tokenOwner(token))
+}
+
+// cachingTransport serves GET requests from the cache and does the read rate limiting for them
+type cachingTransport struct {
+	api   *StravaAPI
+	cache *ResponseCache
+	base  http.RoundTripper
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	resource, activityID, cacheable := classifyPath(req.URL.Path)
+	if req.Method != http.MethodGet {
+		// writes are rate limited by the calling method
+		resp, err := t.base.RoundTrip(req)
+		if err == nil && resp.StatusCode < 300 && activityID != 0 {
+			t.cache.InvalidateActivity(activityID)
+		}
+		return resp, err
+	}
+	ttl := t.cache.ttls[resource]
+	owner, _ := ctx.Value(cacheOwnerKey{}).(string)
+	if !cacheable || ttl < 0 || owner == "" {
+		err := t.api.waitRateLimits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return t.base.RoundTrip(req)
+	}
+	key := owner + " " + req.URL.String()
+	entry := t.cache.get(key)
+	if entry != nil && time.Since(entry.stored) < ttl {
+		t.api.logger.DebugContext(ctx, "cache hit", slog.String("resource", string(resource)))
+		return entry.response(req), nil
+	}
+	var resp *http.Response
+	var err error
+	if entry != nil && entry.etag != "" {
+		// a 304 is not counted, but the request still needs room in the limits
+		err = t.api.waitForRoom(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conditional := req.Clone(ctx)
+		conditional.Header.Set("If-None-Match", entry.etag)
+		resp, err = t.base.RoundTrip(conditional)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotModified {
+			t.api.logger.DebugContext(ctx, "cache revalidated", slog.String("resource", string(resource)))
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			t.cache.touch(key)
+			return entry.response(req), nil
+		}
+		// the object changed, so this was a real read after all
+		t.api.countRateLimits(ctx)
+	} else {
+		err = t.api.waitRateLimits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.cache.remove(key)
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	etag := resp.Header.Get("ETag")
+	if ttl > 0 || etag != "" {
+		t.cache.put(key, &cacheEntry{
+			owner:      owner,
+			resource:   resource,
+			activityID: activityID,
+			etag:       etag,
+			header:     resp.Header.Clone(),
+			body:       body,
+			stored:     time.Now(),
+		})
+	}
+	return resp, nil
+}
+
+// Return a client that serves strava GET requests through `cache`, wrapping `client` (http.DefaultClient if nil).
+//
+// Set it as the HTTPClient of the swagger configuration that this api was created with (see `app.App.EnableResponseCache`).
+//
+// Once installed, reads are counted against the rate limits when a request actually goes out, instead of at the start of each method.
+// This is what lets cache hits and 304s skip the rate limits. Every read still waits while a window is used up.
+func (api *StravaAPI) CachingClient(cache *ResponseCache, client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	api.cache = cache
+	return &http.Client{
+		Transport:     &cachingTransport{api: api, cache: cache, base: base},
+		CheckRedirect: client.CheckRedirect,
+		Jar:           client.Jar,
+		Timeout:       client.Timeout,
+	}
+}
+
+// Return the response cache. nil if caching is not enabled.
+func (api *StravaAPI) Cache() *ResponseCache {
+	return api.cache
+}
+
+// count a request that has already been made against the read rate limits
+func (api *StravaAPI) countRateLimits(ctx context.Context) {
+	api.takeDaily(ctx)
+	api.limiter15min.Request()
+}
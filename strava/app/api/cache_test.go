@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/ratelimit"
+	"golang.org/x/oauth2"
+)
+
+// a stravaAPI with a response cache that talks to a fake strava server
+func newCachedTestStravaAPI(t *testing.T, handler http.Handler, ttls map[CacheResource]time.Duration) (*StravaAPI, *ResponseCache) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cfg := swagger.NewConfiguration()
+	cfg.BasePath = srv.URL
+	api := &StravaAPI{
+		stravaClient:      swagger.NewAPIClient(cfg),
+		logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+		oauth:             &oauth2.Config{},
+		limiter15min:      ratelimit.NewFixedWindow(ReadLimit15MinDuration, ReadLimit15Min),
+		limiterDaily:      ratelimit.NewFixedWindow(ReadLimitDailyDuration, ReadLimitDaily),
+		writeLimiter15min: ratelimit.NewFixedWindow(WriteLimit15MinDuration, WriteLimit15Min),
+		writeLimiterDaily: ratelimit.NewFixedWindow(WriteLimitDailyDuration, WriteLimitDaily),
+	}
+	cache := NewResponseCache(ttls, 0)
+	cfg.HTTPClient = api.CachingClient(cache, nil)
+	return api, cache
+}
+
+func TestClassifyPath(t *testing.T) {
+	tests := []struct {
+		path         string
+		wantResource CacheResource
+		wantID       int
+		wantOK       bool
+	}{
+		{"/api/v3/athlete", CacheAthlete, 0, true},
+		{"/api/v3/athlete/activities", CacheActivities, 0, true},
+		{"/api/v3/athletes/12/stats", CacheStats, 0, true},
+		{"/api/v3/activities/42", CacheActivity, 42, true},
+		{"/api/v3/activities/42/streams", CacheStreams, 42, true},
+		{"/api/v3/gear/b123", CacheGear, 0, true},
+		{"/api/v3/uploads/7", "", 0, false},
+	}
+	for _, tt := range tests {
+		resource, id, ok := classifyPath(tt.path)
+		if resource != tt.wantResource || id != tt.wantID || ok != tt.wantOK {
+			t.Errorf("classifyPath(%q) = %v, %v, %v, want %v, %v, %v", tt.path, resource, id, ok, tt.wantResource, tt.wantID, tt.wantOK)
+		}
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	var requests, conditional atomic.Int32
+	var version atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		etag := fmt.Sprintf(`"v%d"`, version.Load())
+		if r.Header.Get("If-None-Match") != "" {
+			conditional.Add(1)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `{"id": 1, "name": "version %d"}`, version.Load())
+	})
+	ctx := context.Background()
+
+	t.Run("fresh responses are served from the cache", func(t *testing.T) {
+		requests.Store(0)
+		api, _ := newCachedTestStravaAPI(t, handler, nil)
+		for i := 0; i < 3; i++ {
+			_, err := api.GetActivity(ctx, testToken(), 1, false)
+			if err != nil {
+				t.Fatalf("GetActivity() error = %v", err)
+			}
+		}
+		if requests.Load() != 1 {
+			t.Errorf("server got %d requests, want 1", requests.Load())
+		}
+		if _, daily := api.RemainingRequests(); daily != ReadLimitDaily-1 {
+			t.Errorf("remaining daily requests = %d, want %d", daily, int(ReadLimitDaily-1))
+		}
+	})
+
+	t.Run("stale responses are revalidated", func(t *testing.T) {
+		requests.Store(0)
+		conditional.Store(0)
+		api, _ := newCachedTestStravaAPI(t, handler, map[CacheResource]time.Duration{CacheActivity: 0})
+		for i := 0; i < 3; i++ {
+			activity, err := api.GetActivity(ctx, testToken(), 1, false)
+			if err != nil {
+				t.Fatalf("GetActivity() error = %v", err)
+			}
+			if activity.Name != fmt.Sprintf("version %d", version.Load()) {
+				t.Errorf("GetActivity() name = %s", activity.Name)
+			}
+		}
+		if conditional.Load() != 2 {
+			t.Errorf("server got %d conditional requests, want 2", conditional.Load())
+		}
+		// only the first request was a real read
+		if _, daily := api.RemainingRequests(); daily != ReadLimitDaily-1 {
+			t.Errorf("remaining daily requests = %d, want %d", daily, int(ReadLimitDaily-1))
+		}
+		version.Add(1)
+		activity, err := api.GetActivity(ctx, testToken(), 1, false)
+		if err != nil {
+			t.Fatalf("GetActivity() error = %v", err)
+		}
+		if activity.Name != fmt.Sprintf("version %d", version.Load()) {
+			t.Errorf("GetActivity() name = %s, want the new version", activity.Name)
+		}
+		if _, daily := api.RemainingRequests(); daily != ReadLimitDaily-2 {
+			t.Errorf("remaining daily requests = %d, want %d", daily, int(ReadLimitDaily-2))
+		}
+	})
+
+	t.Run("concurrent revalidations", func(t *testing.T) {
+		api, _ := newCachedTestStravaAPI(t, handler, map[CacheResource]time.Duration{CacheActivity: 0})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 5; j++ {
+					if _, err := api.GetActivity(ctx, testToken(), 1, false); err != nil {
+						t.Errorf("GetActivity() error = %v", err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("revalidation waits for the limits", func(t *testing.T) {
+		requests.Store(0)
+		api, _ := newCachedTestStravaAPI(t, handler, map[CacheResource]time.Duration{CacheActivity: 0})
+		api.GetActivity(ctx, testToken(), 1, false)
+		// use up the 15 minute window
+		for api.limiter15min.Request() == nil {
+		}
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := api.GetActivity(timeout, testToken(), 1, false)
+		if !errors.Is(err, RateLimitError) {
+			t.Errorf("GetActivity() error = %v, want RateLimitError", err)
+		}
+		if requests.Load() != 1 {
+			t.Errorf("server got %d requests, want 1", requests.Load())
+		}
+		// the transport waits too when it is used without the api methods
+		req, _ := http.NewRequestWithContext(withCacheOwner(timeout, testToken()), http.MethodGet, api.stravaClient.GetConfig().BasePath+"/activities/1", nil)
+		_, err = api.stravaClient.GetConfig().HTTPClient.Do(req)
+		if !errors.Is(err, RateLimitError) {
+			t.Errorf("conditional request error = %v, want RateLimitError", err)
+		}
+		if requests.Load() != 1 {
+			t.Errorf("server got %d requests, want 1", requests.Load())
+		}
+	})
+
+	t.Run("webhook events invalidate", func(t *testing.T) {
+		requests.Store(0)
+		conditional.Store(0)
+		api, cache := newCachedTestStravaAPI(t, handler, nil)
+		api.GetActivity(ctx, testToken(), 1, false)
+		cache.Invalidate("activity", 2)
+		if cache.Len() != 1 {
+			t.Errorf("cache has %d entries after invalidating another activity, want 1", cache.Len())
+		}
+		cache.Invalidate("activity", 1)
+		if cache.Len() != 0 {
+			t.Errorf("cache has %d entries after invalidating the activity, want 0", cache.Len())
+		}
+		api.GetActivity(ctx, testToken(), 1, false)
+		if requests.Load() != 2 || conditional.Load() != 0 {
+			t.Errorf("server got %d requests (%d conditional), want 2 (0 conditional)", requests.Load(), conditional.Load())
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	ScopeRead            = "read"
+	ScopeReadAll         = "read_all"
+	ScopeProfileReadAll  = "profile:read_all"
+	ScopeProfileWrite    = "profile:write"
+	ScopeActivityRead    = "activity:read"
+	ScopeActivityReadAll = "activity:read_all"
+	ScopeActivityWrite   = "activity:write"
+)
+
+// the key that scopes are recorded under in the token's extra data
+const tokenScopeKey = "scope"
+
+// A MissingScopeError is returned when a method requires a scope that the token was not granted.
+type MissingScopeError struct {
+	// the scope that is required
+	Scope string
+	// the scopes the token has
+	Granted []string
+}
+
+func (e *MissingScopeError) Error() string {
+	if e.Granted == nil {
+		return fmt.Sprintf("missing required scope %q (the token's granted scopes are unknown; re-authorize to record them)", e.Scope)
+	}
+	return fmt.Sprintf("missing required scope %q (granted: %s)", e.Scope, strings.Join(e.Granted, ","))
+}
+
+// Record the scopes that were granted for a token.
+//
+// Strava reports the granted scopes in the redirect url (not in the token itself), so they need to be attached to the token.
+// Extra token data is not kept by `json.Marshal`, so use `app.MarshalToken` to persist them.
+func WithScopes(token *oauth2.Token, scopes []string) *oauth2.Token {
+	return token.WithExtra(map[string]interface{}{tokenScopeKey: strings.Join(scopes, ",")})
+}
+
+// Return the scopes recorded for a token. Returns nil if no scopes were recorded.
+func TokenScopes(token *oauth2.Token) []string {
+	if token == nil {
+		return nil
+	}
+	scopeStr, ok := token.Extra(tokenScopeKey).(string)
+	if !ok || scopeStr == "" {
+		return nil
+	}
+	return strings.FieldsFunc(scopeStr, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// check that a scope is in a list of scopes
+//
+// "read_all" style scopes also grant their "read" counterparts
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == scope+"_all" {
+			return true
+		}
+	}
+	return false
+}
+
+// check that the token has been granted `scope`
+//
+// the user may have granted fewer scopes than the app requested, so a token without recorded scopes is rejected
+func (api *StravaAPI) requireScope(token *oauth2.Token, scope string) error {
+	granted := TokenScopes(token)
+	if !hasScope(granted, scope) {
+		return &MissingScopeError{Scope: scope, Granted: granted}
+	}
+	return nil
+}
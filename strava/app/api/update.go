@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/streamset"
+	"golang.org/x/oauth2"
+)
+
+// if an activity patch does not change anything, will throw this error
+var EmptyPatchError = errors.New("Activity patch has no changes")
+
+// An ActivityPatch is the set of changes to make to an activity.
+//
+// Only the fields that are set are sent to strava, so fields can be explicitly set to false.
+// (swagger.UpdatableActivity can't do this because every field is omitempty)
+//
+//	patch := api.NewActivityPatch().Commute(true).GearID("b1234")
+type ActivityPatch struct {
+	fields map[string]interface{}
+}
+
+func NewActivityPatch() *ActivityPatch {
+	return &ActivityPatch{fields: map[string]interface{}{}}
+}
+
+// set the name of the activity
+func (p *ActivityPatch) Name(name string) *ActivityPatch {
+	p.fields["name"] = name
+	return p
+}
+
+// set the sport type of the activity
+func (p *ActivityPatch) SportType(sportType swagger.SportType) *ActivityPatch {
+	p.fields["sport_type"] = sportType
+	return p
+}
+
+// set the gear of the activity. use "none" to clear the gear.
+func (p *ActivityPatch) GearID(gearID string) *ActivityPatch {
+	p.fields["gear_id"] = gearID
+	return p
+}
+
+// mark whether the activity is a commute
+func (p *ActivityPatch) Commute(commute bool) *ActivityPatch {
+	p.fields["commute"] = commute
+	return p
+}
+
+// mark whether the activity was recorded on a trainer
+func (p *ActivityPatch) Trainer(trainer bool) *ActivityPatch {
+	p.fields["trainer"] = trainer
+	return p
+}
+
+// mark whether the activity is muted
+func (p *ActivityPatch) HideFromHome(hide bool) *ActivityPatch {
+	p.fields["hide_from_home"] = hide
+	return p
+}
+
+// set the description of the activity
+func (p *ActivityPatch) Description(description string) *ActivityPatch {
+	p.fields["description"] = description
+	return p
+}
+
+// the request body for the patch
+func (p *ActivityPatch) body() map[string]interface{} {
+	return p.fields
+}
+
+// Update an activity.
+//
+// Requires the `activity:write` scope. This is checked before any request is made.
+// The update counts against the write rate limits.
+//
+// Returns the updated activity.
+func (api *StravaAPI) UpdateActivity(ctx context.Context, token *oauth2.Token, activityID int, patch *ActivityPatch) (*swagger.DetailedActivity, error) {
+	if patch == nil || len(patch.fields) == 0 {
+		return nil, EmptyPatchError
+	}
+	err := api.requireScope(token, ScopeActivityWrite)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "unable to update activity", slog.String("error", err.Error()))
+		return nil, err
+	}
+	err = api.checkWriteRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = api.setContext(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "updating activity", slog.Int("activity id", activityID), slog.Any("patch", patch.body()))
+	activity, err := api.putActivity(ctx, activityID, patch.body())
+	if err != nil {
+		if !errors.Is(err, NotFoundError) {
+			api.logger.ErrorContext(ctx, "error updating activity", slog.String("error", err.Error()))
+		}
+		return nil, err
+	}
+	return activity, nil
+}
+
+// send the update request.
+//
+// The swagger client only accepts a swagger.UpdatableActivity as the body, which would drop any fields set to false,
+// so the request is made directly with the swagger client's configuration.
+func (api *StravaAPI) putActivity(ctx context.Context, activityID int, body map[string]interface{}) (*swagger.DetailedActivity, error) {
+	cfg := api.stravaClient.GetConfig()
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/activities/%d", cfg.BasePath, activityID), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", cfg.UserAgent)
+	if src, ok := ctx.Value(swagger.ContextOAuth2).(oauth2.TokenSource); ok {
+		tkn, err := src.Token()
+		if err != nil {
+			return nil, err
+		}
+		tkn.SetAuthHeader(req)
+	}
+	resp, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		api.logger.DebugContext(ctx, "activity not found")
+		return nil, NotFoundError
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("update activity failed with status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var activity swagger.DetailedActivity
+	err = json.NewDecoder(resp.Body).Decode(&activity)
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// An ActivityFilter decides whether an activity should be included
+type ActivityFilter func(swagger.SummaryActivity) bool
+
+// Include activities that have any of the passed sport types
+func BySportType(sportTypes ...swagger.SportType) ActivityFilter {
+	return func(a swagger.SummaryActivity) bool {
+		if a.SportType == nil {
+			return false
+		}
+		for _, st := range sportTypes {
+			if *a.SportType == st {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Include activities that start within `radius` meters of `start` and end within `radius` meters of `end`.
+//
+// This is a simple way of matching activities along a route (e.g. a commute).
+func ByRoute(start, end swagger.LatLng, radius float64) ActivityFilter {
+	return func(a swagger.SummaryActivity) bool {
+		if a.StartLatlng == nil || a.EndLatlng == nil {
+			return false
+		}
+		return streamset.Haversine(*a.StartLatlng, start) <= radius && streamset.Haversine(*a.EndLatlng, end) <= radius
+	}
+}
+
+// Include activities that match every filter
+func AllOf(filters ...ActivityFilter) ActivityFilter {
+	return func(a swagger.SummaryActivity) bool {
+		for _, f := range filters {
+			if !f(a) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Apply the same patch to every activity between `after` and `before` that matches `filter`.
+//
+// For example, to mark every ride along a route as a commute:
+//
+//	filter := api.AllOf(api.BySportType(swagger.RIDE_SportType), api.ByRoute(home, work, 200))
+//	ids, err := stravaApi.RetagActivities(ctx, token, &before, &after, filter, api.NewActivityPatch().Commute(true))
+//
+// Activities that already match the patch are still updated.
+// Returns the ids of the activities that were updated. If an update fails, the ids updated so far are returned along with the error.
+func (api *StravaAPI) RetagActivities(ctx context.Context, token *oauth2.Token, before, after *time.Time, filter ActivityFilter, patch *ActivityPatch) ([]int, error) {
+	if patch == nil || len(patch.fields) == 0 {
+		return nil, EmptyPatchError
+	}
+	// fail before listing activities if we can't write
+	err := api.requireScope(token, ScopeActivityWrite)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "unable to retag activities", slog.String("error", err.Error()))
+		return nil, err
+	}
+	pages, err := api.GetActivities(ctx, token, 200, before, after)
+	if err != nil {
+		return nil, err
+	}
+	updated := []int{}
+	for _, page := range pages {
+		for _, activity := range page {
+			if filter != nil && !filter(activity) {
+				continue
+			}
+			_, err := api.UpdateActivity(ctx, token, int(activity.Id), patch)
+			if err != nil {
+				return updated, err
+			}
+			updated = append(updated, int(activity.Id))
+		}
+	}
+	api.logger.InfoContext(ctx, "retagged activities", slog.Int("count", len(updated)))
+	return updated, nil
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/ratelimit"
+	"golang.org/x/oauth2"
+)
+
+// a stravaAPI that talks to a fake strava server
+func newTestStravaAPI(t *testing.T, handler http.Handler, dailyLimit int) *StravaAPI {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cfg := swagger.NewConfiguration()
+	cfg.BasePath = srv.URL
+	return &StravaAPI{
+		stravaClient:      swagger.NewAPIClient(cfg),
+		logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+		oauth:             &oauth2.Config{},
+		limiter15min:      ratelimit.NewFixedWindow(ReadLimit15MinDuration, ReadLimit15Min),
+		limiterDaily:      ratelimit.NewFixedWindow(ReadLimitDailyDuration, dailyLimit),
+		writeLimiter15min: ratelimit.NewFixedWindow(WriteLimit15MinDuration, WriteLimit15Min),
+		writeLimiterDaily: ratelimit.NewFixedWindow(WriteLimitDailyDuration, WriteLimitDaily),
+	}
+}
+
+// a token that does not need refreshing
+func testToken() *oauth2.Token {
+	return &oauth2.Token{AccessToken: "test", Expiry: time.Now().Add(time.Hour)}
+}
+
+func TestActivityPatch_body(t *testing.T) {
+	body := NewActivityPatch().Commute(false).Name("commute").body()
+	if len(body) != 2 {
+		t.Fatalf("body() has %d fields, want 2", len(body))
+	}
+	if v, ok := body["commute"]; !ok || v != false {
+		t.Errorf("body()[commute] = %v, want false", v)
+	}
+	if body["name"] != "commute" {
+		t.Errorf("body()[name] = %v, want commute", body["name"])
+	}
+}
+
+func TestStravaAPI_UpdateActivity(t *testing.T) {
+	var method, path, auth string
+	var body map[string]interface{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, auth = r.Method, r.URL.Path, r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 42, "name": "commute", "commute": false}`))
+	})
+	api := newTestStravaAPI(t, handler, ReadLimitDaily)
+	token := WithScopes(testToken(), []string{ScopeActivityWrite})
+
+	activity, err := api.UpdateActivity(context.Background(), token, 42, NewActivityPatch().Commute(false).Name("commute"))
+	if err != nil {
+		t.Fatalf("UpdateActivity() error = %v", err)
+	}
+	if activity.Id != 42 || activity.Name != "commute" {
+		t.Errorf("UpdateActivity() = %+v, want activity 42", activity)
+	}
+	if method != http.MethodPut || path != "/activities/42" {
+		t.Errorf("request = %s %s, want PUT /activities/42", method, path)
+	}
+	if auth != "Bearer test" {
+		t.Errorf("Authorization = %q, want %q", auth, "Bearer test")
+	}
+	if len(body) != 2 || body["commute"] != false || body["name"] != "commute" {
+		t.Errorf("request body = %v, want commute=false and name=commute", body)
+	}
+}
+
+func TestStravaAPI_requireScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      *oauth2.Token
+		configured []string
+		wantErr    bool
+	}{
+		{"recorded write", WithScopes(&oauth2.Token{}, []string{"read", "activity:write"}), nil, false},
+		{"recorded read only", WithScopes(&oauth2.Token{}, []string{"activity:read_all"}), []string{"activity:write"}, true},
+		{"unknown scopes are not trusted", &oauth2.Token{}, []string{"activity:write"}, true},
+		{"nothing granted", WithScopes(&oauth2.Token{}, []string{}), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &StravaAPI{oauth: &oauth2.Config{Scopes: tt.configured}}
+			err := api.requireScope(tt.token, ScopeActivityWrite)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("requireScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var mse *MissingScopeError
+			if err != nil && !errors.As(err, &mse) {
+				t.Errorf("requireScope() error = %v, want MissingScopeError", err)
+			}
+		})
+	}
+}
+
+func TestByRoute(t *testing.T) {
+	home := swagger.LatLng{40.0, -105.0}
+	work := swagger.LatLng{40.05, -105.05}
+	filter := ByRoute(home, work, 200)
+	near := swagger.LatLng{40.001, -105.0}
+	far := swagger.LatLng{41.0, -105.0}
+	ride := swagger.RIDE_SportType
+	tests := []struct {
+		name     string
+		activity swagger.SummaryActivity
+		want     bool
+	}{
+		{"on route", swagger.SummaryActivity{StartLatlng: &near, EndLatlng: &work}, true},
+		{"wrong start", swagger.SummaryActivity{StartLatlng: &far, EndLatlng: &work}, false},
+		{"no gps", swagger.SummaryActivity{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter(tt.activity); got != tt.want {
+				t.Errorf("ByRoute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	rides := AllOf(BySportType(ride), filter)
+	if rides(swagger.SummaryActivity{StartLatlng: &home, EndLatlng: &work}) {
+		t.Errorf("AllOf() matched an activity without a sport type")
+	}
+	if !rides(swagger.SummaryActivity{SportType: &ride, StartLatlng: &home, EndLatlng: &work}) {
+		t.Errorf("AllOf() did not match a ride on the route")
+	}
+}
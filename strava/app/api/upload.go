@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/antihax/optional"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"golang.org/x/oauth2"
+)
+
+// UploadDataType represents the file formats that strava accepts for uploads.
+type UploadDataType string
+
+const (
+	Fit   UploadDataType = "fit"    // fit file
+	FitGz UploadDataType = "fit.gz" // gzipped fit file
+	Tcx   UploadDataType = "tcx"    // tcx file
+	TcxGz UploadDataType = "tcx.gz" // gzipped tcx file
+	Gpx   UploadDataType = "gpx"    // gpx file
+	GpxGz UploadDataType = "gpx.gz" // gzipped gpx file
+)
+
+const (
+	// strava asks that uploads are not polled more than once a second
+	defaultUploadPollInterval    = 1 * time.Second
+	defaultUploadMaxPollInterval = 30 * time.Second
+)
+
+// if strava fails to process an upload, will throw this error (wrapped with the reason)
+var UploadError = errors.New("Upload failed")
+
+// strava reports duplicates as e.g. "activity.fit duplicate of <a href='/activities/1234'>Morning Run</a>"
+var duplicateRegex = regexp.MustCompile(`duplicate of .*?/?activities/(\d+)|duplicate of activity (\d+)`)
+
+// A DuplicateUploadError is returned when strava rejects an upload because the activity already exists.
+type DuplicateUploadError struct {
+	// the id of the activity that already exists
+	ActivityID int
+	// the raw error message from strava
+	Message string
+}
+
+func (e *DuplicateUploadError) Error() string {
+	return fmt.Sprintf("upload is a duplicate of activity %d: %s", e.ActivityID, e.Message)
+}
+
+// UploadOpts are the optional fields that can be set when uploading an activity
+type UploadOpts struct {
+	Name        string
+	Description string
+	Trainer     bool
+	Commute     bool
+	// an identifier of your choosing. strava will return it with the upload status
+	ExternalID string
+	// the time to wait before the first status check. (default 1 second)
+	PollInterval time.Duration
+	// each status check doubles the wait until this is reached. (default 30 seconds)
+	MaxPollInterval time.Duration
+}
+
+// make sure users aren't passing weird data types into `UploadActivity`
+func validateDataType(dataType UploadDataType) error {
+	dataTypes := []UploadDataType{Fit, FitGz, Tcx, TcxGz, Gpx, GpxGz}
+	for _, dt := range dataTypes {
+		if dt == dataType {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a correct upload data type", dataType)
+}
+
+// convert a bool into the string form that the upload endpoint expects
+func boolFormValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// turn a processed upload into either its activity id or an error
+//
+// returns done=false if the upload is still being processed
+func uploadResult(upload swagger.Upload) (int, bool, error) {
+	if upload.Error_ != "" {
+		m := duplicateRegex.FindStringSubmatch(upload.Error_)
+		if m != nil {
+			idStr := m[1]
+			if idStr == "" {
+				idStr = m[2]
+			}
+			id, err := strconv.Atoi(idStr)
+			if err == nil {
+				return 0, true, &DuplicateUploadError{ActivityID: id, Message: upload.Error_}
+			}
+		}
+		return 0, true, fmt.Errorf("%w: %s", UploadError, upload.Error_)
+	}
+	if upload.ActivityId != 0 {
+		return int(upload.ActivityId), true, nil
+	}
+	return 0, false, nil
+}
+
+// Upload an activity file (FIT, TCX or GPX) to strava.
+//
+// Requires the `activity:write` scope. This is checked before any request is made.
+//
+// The upload itself counts against the write rate limits. Strava then processes the file asynchronously,
+// so this will poll the upload status (counting against the read rate limits) with backoff until the upload is processed or errors.
+// Make sure the context has a timeout.
+//
+// Returns the id of the resulting activity.
+// If strava reports the file as a duplicate, a `*DuplicateUploadError` containing the existing activity id is returned.
+//
+// `file` is closed once it has been read.
+func (api *StravaAPI) UploadActivity(ctx context.Context, token *oauth2.Token, file *os.File, dataType UploadDataType, opts *UploadOpts) (int, error) {
+	err := validateDataType(dataType)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "invalid data type", slog.String("error", err.Error()))
+		return 0, err
+	}
+	if opts == nil {
+		opts = &UploadOpts{}
+	}
+	err = api.requireScope(token, ScopeActivityWrite)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "unable to upload activity", slog.String("error", err.Error()))
+		return 0, err
+	}
+	err = api.checkWriteRateLimits(ctx)
+	if err != nil {
+		return 0, err
+	}
+	ctx, err = api.setContext(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+	api.logger.DebugContext(ctx, "uploading activity", slog.String("file", file.Name()), slog.String("data type", string(dataType)))
+	uploadOpts := &swagger.UploadsApiCreateUploadOpts{
+		File:     optional.NewInterface(file),
+		DataType: optional.NewString(string(dataType)),
+		Trainer:  optional.NewString(boolFormValue(opts.Trainer)),
+		Commute:  optional.NewString(boolFormValue(opts.Commute)),
+	}
+	if opts.Name != "" {
+		uploadOpts.Name = optional.NewString(opts.Name)
+	}
+	if opts.Description != "" {
+		uploadOpts.Description = optional.NewString(opts.Description)
+	}
+	if opts.ExternalID != "" {
+		uploadOpts.ExternalId = optional.NewString(opts.ExternalID)
+	}
+	upload, _, err := api.stravaClient.UploadsApi.CreateUpload(ctx, uploadOpts)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "error creating upload", slog.String("error", err.Error()))
+		return 0, err
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultUploadPollInterval
+	}
+	maxInterval := opts.MaxPollInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultUploadMaxPollInterval
+	}
+	for {
+		activityID, done, err := uploadResult(upload)
+		if done {
+			if err != nil {
+				api.logger.WarnContext(ctx, "upload failed", slog.Int64("upload id", upload.Id), slog.String("error", err.Error()))
+			}
+			return activityID, err
+		}
+		api.logger.DebugContext(ctx, "upload still processing", slog.Int64("upload id", upload.Id), slog.String("status", upload.Status), slog.Duration("wait", interval))
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, maxInterval)
+		err = api.checkRateLimits(ctx)
+		if err != nil {
+			return 0, err
+		}
+		var resp *http.Response
+		upload, resp, err = api.stravaClient.UploadsApi.GetUploadById(ctx, upload.Id)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			api.logger.DebugContext(ctx, "upload not found")
+			return 0, NotFoundError
+		}
+		if err != nil {
+			api.logger.ErrorContext(ctx, "error getting upload", slog.String("error", err.Error()))
+			return 0, err
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+func Test_uploadResult(t *testing.T) {
+	tests := []struct {
+		name          string
+		upload        swagger.Upload
+		wantID        int
+		wantDone      bool
+		wantDuplicate int
+		wantErr       bool
+	}{
+		{"processing", swagger.Upload{Status: "Your activity is still being processed."}, 0, false, 0, false},
+		{"ready", swagger.Upload{Status: "Your activity is ready.", ActivityId: 42}, 42, true, 0, false},
+		{"duplicate link", swagger.Upload{Error_: "run.fit duplicate of <a href='/activities/1234'>Morning Run</a>"}, 0, true, 1234, true},
+		{"duplicate plain", swagger.Upload{Error_: "run.fit duplicate of activity 5678"}, 0, true, 5678, true},
+		{"error", swagger.Upload{Error_: "Improperly formatted data."}, 0, true, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, done, err := uploadResult(tt.upload)
+			if id != tt.wantID || done != tt.wantDone {
+				t.Errorf("uploadResult() = %v, %v, want %v, %v", id, done, tt.wantID, tt.wantDone)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("uploadResult() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var dup *DuplicateUploadError
+			isDup := errors.As(err, &dup)
+			if isDup != (tt.wantDuplicate != 0) {
+				t.Errorf("uploadResult() duplicate = %v, want duplicate of %v", isDup, tt.wantDuplicate)
+			}
+			if isDup && dup.ActivityID != tt.wantDuplicate {
+				t.Errorf("uploadResult() duplicate of %v, want %v", dup.ActivityID, tt.wantDuplicate)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import "github.com/jcocozza/cassidy-connector/strava/swagger"
+
+// strava marks the upper bound of the last zone with -1
+const openZoneMax int32 = -1
+
+// Return the index of the zone that `value` falls into, or -1 if it falls into none of them.
+//
+// This follows strava's boundaries: a zone includes its min and excludes its max.
+// The last zone has a max of -1, meaning it has no upper bound.
+func ZoneIndex(zones swagger.ZoneRanges, value float64) int {
+	for i, zone := range zones {
+		if value < float64(zone.Min) {
+			continue
+		}
+		if zone.Max == openZoneMax || value < float64(zone.Max) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Compute the number of seconds spent in each zone.
+//
+// `values` is a heartrate or watts stream and `time` is the matching time stream.
+// Each sample is credited with the time until the next sample. The final sample is not counted.
+//
+// The result is in the same order as `zones`.
+func TimeInZones(zones swagger.ZoneRanges, values []float64, time []int32) swagger.TimedZoneDistribution {
+	dist := make(swagger.TimedZoneDistribution, len(zones))
+	for i, zone := range zones {
+		dist[i] = swagger.TimedZoneRange{Min: zone.Min, Max: zone.Max}
+	}
+	n := min(len(values), len(time))
+	for i := 0; i < n-1; i++ {
+		idx := ZoneIndex(zones, values[i])
+		if idx == -1 {
+			continue
+		}
+		dist[idx].Time += time[i+1] - time[i]
+	}
+	return dist
+}
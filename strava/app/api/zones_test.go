@@ -0,0 +1,46 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+var testZones = swagger.ZoneRanges{
+	{Min: 0, Max: 120},
+	{Min: 120, Max: 150},
+	{Min: 150, Max: -1},
+}
+
+func TestZoneIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		want  int
+	}{
+		{"bottom", 0, 0},
+		{"max is exclusive", 120, 1},
+		{"inside", 130, 1},
+		{"open ended", 250, 2},
+		{"below all zones", -5, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ZoneIndex(testZones, tt.value); got != tt.want {
+				t.Errorf("ZoneIndex() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeInZones(t *testing.T) {
+	values := []float64{100, 110, 130, 160, 170}
+	time := []int32{0, 1, 3, 6, 10}
+	got := TimeInZones(testZones, values, time)
+	want := []int32{3, 3, 4}
+	for i := range want {
+		if got[i].Time != want[i] {
+			t.Errorf("TimeInZones()[%d].Time = %v, want %v", i, got[i].Time, want[i])
+		}
+	}
+}
@@ -95,6 +95,9 @@ type App struct {
 	// A way to get the authorization token from the intial authorization process
 	// Any calls to the stravaRedirectHandler will push the authorization code to the AuthorizationReciver channel.
 	AuthorizationReciever chan string
+	// the scopes granted with each authorization code that came through the redirect handler (strava sends these with the code)
+	grantedScopes map[string]string
+	scopesMu      sync.Mutex
 	// this ensures that the webhook GET request from strava completes before we move forward
 	WebhookReciever chan string
 	// optional; a user defined function that tells the api how to handle new events
@@ -164,9 +167,17 @@ func NewApp(clientId string, clientSecret, redirectURL string, authorizationCall
 		StravaClient:                client,
 		Api:                         api.NewStravaAPI(client, oauthCfg, logger.WithGroup("api")),
 		AuthorizationReciever:       reciever,
+		grantedScopes:               map[string]string{},
 	}
 }
 
+// Serve strava reads through a response cache (see `api.ResponseCache`).
+//
+// Cached responses are invalidated automatically when webhook events arrive for their object.
+func (a *App) EnableResponseCache(cache *api.ResponseCache) {
+	a.SwaggerConfig.HTTPClient = a.Api.CachingClient(cache, a.SwaggerConfig.HTTPClient)
+}
+
 // Return the approval url
 func (a *App) ApprovalUrl() string {
 	scopeStr := strings.Join(a.Scopes, ",")
@@ -179,7 +190,10 @@ func (a *App) ApprovalUrl() string {
 // A user will grant permission to the app then will be redirected to the application's RedirectURL.
 // The RedirectURL will contain an authorization code. This code is used to get the user's access token.
 //
-// You are responsible for persisting user tokens
+// You are responsible for persisting user tokens (see `MarshalToken`)
+//
+// If the code came through the redirect handler, the scopes the user granted are recorded on the token (see `api.TokenScopes`).
+// Otherwise, record them with `api.WithScopes` using the "scope" parameter of the redirect url.
 func (a *App) GetAccessTokenFromAuthorizationCode(ctx context.Context, code string) (*oauth2.Token, error) {
 	a.logger.InfoContext(ctx, "getting access token from authorization code")
 	token, err := a.OAuthConfig.Exchange(ctx, code)
@@ -187,31 +201,52 @@ func (a *App) GetAccessTokenFromAuthorizationCode(ctx context.Context, code stri
 		a.logger.ErrorContext(ctx, "token exchange failed", slog.String("error", err.Error()))
 		return nil, err
 	}
+	a.scopesMu.Lock()
+	granted, ok := a.grantedScopes[code]
+	delete(a.grantedScopes, code)
+	a.scopesMu.Unlock()
+	if ok {
+		token = api.WithScopes(token, strings.Split(granted, ","))
+	}
 	return token, nil
 }
 
-// Turn a json string token into an `oauth2.Token` struct
-func (a *App) ReadTokenString(tokenJsonString string) (*oauth2.Token, error) {
-	var token *oauth2.Token
-	err := json.Unmarshal([]byte(tokenJsonString), &token)
+// a token as it is saved: the oauth2 token along with its granted scopes (which `oauth2.Token` does not marshal)
+type savedToken struct {
+	*oauth2.Token
+	Scope string `json:"scope,omitempty"`
+}
+
+// Marshal a token along with its granted scopes, so that `ReadTokenFromFile` and `ReadTokenString` can get both back
+func MarshalToken(token *oauth2.Token) ([]byte, error) {
+	return json.Marshal(savedToken{Token: token, Scope: strings.Join(api.TokenScopes(token), ",")})
+}
+
+// read a token saved by `MarshalToken`
+func unmarshalToken(data []byte) (*oauth2.Token, error) {
+	saved := savedToken{Token: &oauth2.Token{}}
+	err := json.Unmarshal(data, &saved)
 	if err != nil {
 		return nil, err
 	}
-	return token, nil
+	if saved.Scope == "" {
+		return saved.Token, nil
+	}
+	return api.WithScopes(saved.Token, strings.Split(saved.Scope, ",")), nil
+}
+
+// Turn a json string token into an `oauth2.Token` struct (see `MarshalToken`)
+func (a *App) ReadTokenString(tokenJsonString string) (*oauth2.Token, error) {
+	return unmarshalToken([]byte(tokenJsonString))
 }
 
-// Load an oauth2 token from a .json file
+// Load an oauth2 token from a .json file (see `MarshalToken`)
 func (a *App) ReadTokenFromFile(tokenFilePath string) (*oauth2.Token, error) {
 	tokenData, err := os.ReadFile(tokenFilePath)
 	if err != nil {
 		return nil, err
 	}
-	var token oauth2.Token
-	err = json.Unmarshal(tokenData, &token)
-	if err != nil {
-		return nil, err
-	}
-	return &token, nil
+	return unmarshalToken(tokenData)
 }
 
 // Get the authorization code form the url that results from the redirect.
@@ -224,7 +259,12 @@ func (a *App) stravaRedirectHandler(w http.ResponseWriter, r *http.Request) {
 	code := r.URL.Query().Get("code") // Assuming 'code' is the parameter sent by Strava
 	err := r.URL.Query().Get("error") // if the user denies, the url will send an error "access_denied"
 	if code != "" {
-		a.logger.Debug("sending code to authorization reciever")
+		// written before the code is sent so that it is visible to whoever reads the code
+		granted := r.URL.Query().Get("scope")
+		a.scopesMu.Lock()
+		a.grantedScopes[code] = granted
+		a.scopesMu.Unlock()
+		a.logger.Debug("sending code to authorization reciever", slog.String("granted scopes", granted))
 		a.AuthorizationReciever <- code
 	} else if err != "" {
 		a.logger.Warn("sending error to authorization reciever", slog.String("error", err))
@@ -409,6 +449,10 @@ func (a *App) webhookRedirectHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("error unmarshalling event: %s", err.Error()), http.StatusInternalServerError)
 			return
 		}
+		if cache := a.Api.Cache(); cache != nil {
+			// drop stale responses before the handler has a chance to fetch the object again
+			cache.Invalidate(se.ObjectType, se.ObjectID)
+		}
 		if a.WebhookEventHandler != nil {
 			a.logger.Debug("running webhook event handler")
 			go func() {
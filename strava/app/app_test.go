@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/jcocozza/cassidy-connector/strava/app/api"
+)
+
+func TestApp_tokenScopes(t *testing.T) {
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "access", "refresh_token": "refresh", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer tokenSrv.Close()
+	stravaApp := NewApp("id", "secret", "http://localhost/exchange_token", "", "", "", nil, []string{"read", "activity:write"}, nil)
+	stravaApp.OAuthConfig.Endpoint.TokenURL = tokenSrv.URL
+
+	// the user only granted read access
+	go stravaApp.stravaRedirectHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/exchange_token?code=abc&scope=read,activity:read_all", nil))
+	code := <-stravaApp.AuthorizationReciever
+	token, err := stravaApp.GetAccessTokenFromAuthorizationCode(context.Background(), code)
+	if err != nil {
+		t.Fatalf("GetAccessTokenFromAuthorizationCode() error = %v", err)
+	}
+	want := []string{"read", "activity:read_all"}
+	if got := api.TokenScopes(token); !slices.Equal(got, want) {
+		t.Fatalf("TokenScopes() = %v, want %v", got, want)
+	}
+
+	data, err := MarshalToken(token)
+	if err != nil {
+		t.Fatalf("MarshalToken() error = %v", err)
+	}
+	read, err := stravaApp.ReadTokenString(string(data))
+	if err != nil {
+		t.Fatalf("ReadTokenString() error = %v", err)
+	}
+	if read.AccessToken != "access" || read.RefreshToken != "refresh" {
+		t.Errorf("ReadTokenString() = %+v, want the saved token", read)
+	}
+	if got := api.TokenScopes(read); !slices.Equal(got, want) {
+		t.Errorf("TokenScopes() after reload = %v, want %v", got, want)
+	}
+
+	// a code that did not come through the redirect handler has no recorded scopes
+	token, err = stravaApp.GetAccessTokenFromAuthorizationCode(context.Background(), "other")
+	if err != nil {
+		t.Fatalf("GetAccessTokenFromAuthorizationCode() error = %v", err)
+	}
+	if got := api.TokenScopes(token); got != nil {
+		t.Errorf("TokenScopes() = %v, want none", got)
+	}
+}
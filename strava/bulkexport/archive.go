@@ -0,0 +1,172 @@
+// Package bulkexport reads the account export that strava lets athletes download (Settings > My Account > Download or Delete Your Account).
+//
+// The export is a zip with an `activities.csv` (one row per activity) and the original activity files in `activities/` (.fit, .gpx, .tcx, optionally gzipped).
+// Importing it backfills years of history without using the api at all.
+package bulkexport
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	stravaSync "github.com/jcocozza/cassidy-connector/strava/sync"
+)
+
+// if an activity has no file in the archive, will throw this error
+var NoFileError = errors.New("Activity has no file in the archive")
+
+// An Archive is an opened strava export
+type Archive struct {
+	zr *zip.Reader
+	// closes the underlying file (if opened with Open)
+	closer io.Closer
+	// files by their path in the archive
+	files map[string]*zip.File
+}
+
+// Open the export zip at `path`
+func Open(path string) (*Archive, error) {
+	rc, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	a := newArchive(&rc.Reader)
+	a.closer = rc
+	return a, nil
+}
+
+// Read an export zip from memory or any other `io.ReaderAt`
+func NewArchive(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	return newArchive(zr), nil
+}
+
+func newArchive(zr *zip.Reader) *Archive {
+	a := &Archive{zr: zr, files: map[string]*zip.File{}}
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+	}
+	return a
+}
+
+func (a *Archive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
+
+// find a file in the archive. some exports are nested in a top level folder, so a match on the end of the path is also accepted
+func (a *Archive) find(name string) (*zip.File, bool) {
+	if f, ok := a.files[name]; ok {
+		return f, true
+	}
+	for p, f := range a.files {
+		if strings.HasSuffix(p, "/"+name) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func (a *Archive) open(name string) (io.ReadCloser, error) {
+	f, ok := a.find(name)
+	if !ok {
+		return nil, fmt.Errorf("%s not found in archive", name)
+	}
+	return f.Open()
+}
+
+// Parse the activities in activities.csv
+func (a *Archive) Activities() ([]Activity, error) {
+	rc, err := a.open("activities.csv")
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ParseActivitiesCSV(rc)
+}
+
+// Parse the id of the athlete that the export belongs to from profile.csv
+func (a *Archive) AthleteID() (int, error) {
+	rc, err := a.open("profile.csv")
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	return ParseProfileCSV(rc)
+}
+
+// Decode the file of an activity into streams (see `DecodeStreams`).
+//
+// Returns a `NoFileError` if the activity has no file (e.g. it was created manually).
+func (a *Archive) Streams(activity Activity) (*swagger.StreamSet, error) {
+	if activity.Filename == "" {
+		return nil, NoFileError
+	}
+	rc, err := a.open(activity.Filename)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return DecodeStreams(path.Base(activity.Filename), rc)
+}
+
+// StreamSink is implemented by sinks that can also store streams (e.g. `store.StravaSink`)
+type StreamSink interface {
+	PutStreams(ctx context.Context, athleteID int, activityID int, streams *swagger.StreamSet) error
+}
+
+// ImportReport summarizes what an import did
+type ImportReport struct {
+	Activities int
+	Streams    int
+	// why the file of an activity could not be decoded, by activity id. activities without a file are not included
+	StreamErrors map[int]error
+}
+
+// Write every activity in the archive to `sink`, the same way that a sync would.
+//
+// If the sink is also a `StreamSink`, the activity files are decoded and their streams are written too.
+// A file that cannot be decoded does not stop the import; it is reported in `ImportReport.StreamErrors`.
+func (a *Archive) Import(ctx context.Context, athleteID int, sink stravaSync.Sink) (*ImportReport, error) {
+	activities, err := a.Activities()
+	if err != nil {
+		return nil, err
+	}
+	report := &ImportReport{StreamErrors: map[int]error{}}
+	streamSink, withStreams := sink.(StreamSink)
+	for _, activity := range activities {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		id := int(activity.Summary.Id)
+		err := sink.Put(ctx, athleteID, activity.Summary)
+		if err != nil {
+			return report, fmt.Errorf("failed to write activity %d: %w", id, err)
+		}
+		report.Activities++
+		if !withStreams || activity.Filename == "" {
+			continue
+		}
+		streams, err := a.Streams(activity)
+		if err != nil {
+			report.StreamErrors[id] = err
+			continue
+		}
+		err = streamSink.PutStreams(ctx, athleteID, id, streams)
+		if err != nil {
+			return report, fmt.Errorf("failed to write streams of activity %d: %w", id, err)
+		}
+		report.Streams++
+	}
+	return report, nil
+}
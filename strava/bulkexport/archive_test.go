@@ -0,0 +1,172 @@
+package bulkexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jcocozza/cassidy-connector/fit"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+const testActivitiesCSV = `Activity ID,Activity Date,Activity Name,Activity Type,Activity Description,Elapsed Time,Distance,Commute,Activity Gear,Filename,Elapsed Time,Moving Time,Distance,Max Speed,Average Speed,Elevation Gain
+1,"Jan 2, 2024, 6:30:00 AM",Morning Run,Run,"easy, with ""strides""",1800,5.01,false,Shoes,activities/1.gpx,1800,1750,5010.5,4.2,2.86,25
+2,"Jan 3, 2024, 5:00:00 PM",Trainer Ride,Virtual Ride,,3600,30.00,false,Bike,activities/2.tcx.gz,3600,3600,30000,12.5,8.33,0
+3,"Jan 4, 2024, 7:00:00 AM",Lift,Weight Training,,2700,0,false,,,2700,2700,0,0,0,0
+4,"Jan 5, 2024, 7:00:00 AM",Watch Run,Run,,1200,3.00,false,,activities/4.fit.gz,1200,1200,3000,3,2.5,0
+`
+
+const testGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1" xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+ <trk><trkseg>
+  <trkpt lat="40.0" lon="-105.0"><ele>1600</ele><time>2024-01-02T06:30:00Z</time>
+   <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>120</gpxtpx:hr><gpxtpx:cad>85</gpxtpx:cad></gpxtpx:TrackPointExtension></extensions></trkpt>
+  <trkpt lat="40.001" lon="-105.0"><ele>1601</ele><time>2024-01-02T06:30:30Z</time>
+   <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>130</gpxtpx:hr><gpxtpx:cad>86</gpxtpx:cad></gpxtpx:TrackPointExtension></extensions></trkpt>
+ </trkseg></trk>
+</gpx>`
+
+const testTCX = `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2" xmlns:ns3="http://www.garmin.com/xmlschemas/ActivityExtension/v2">
+ <Activities><Activity Sport="Biking"><Lap StartTime="2024-01-03T17:00:00Z"><Track>
+  <Trackpoint><Time>2024-01-03T17:00:00Z</Time><DistanceMeters>0</DistanceMeters><HeartRateBpm><Value>100</Value></HeartRateBpm><Cadence>80</Cadence><Extensions><ns3:TPX><ns3:Watts>150</ns3:Watts></ns3:TPX></Extensions></Trackpoint>
+  <Trackpoint><Time>2024-01-03T17:00:01Z</Time><DistanceMeters>8.5</DistanceMeters><HeartRateBpm><Value>101</Value></HeartRateBpm><Cadence>81</Cadence><Extensions><ns3:TPX><ns3:Watts>160</ns3:Watts></ns3:TPX></Extensions></Trackpoint>
+ </Track></Lap></Activity></Activities>
+</TrainingCenterDatabase>`
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(s))
+	gz.Close()
+	return buf.Bytes()
+}
+
+// build an export zip in memory
+func testArchive(t *testing.T) *Archive {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string][]byte{
+		"activities.csv":      []byte(testActivitiesCSV),
+		"profile.csv":         []byte("Athlete ID,Email Address\n99,a@b.c\n"),
+		"activities/1.gpx":    []byte(testGPX),
+		"activities/2.tcx.gz": gzipped(t, testTCX),
+		"activities/4.fit.gz": gzipped(t, "not really a fit file"),
+	}
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	zw.Close()
+	a, err := NewArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewArchive() error = %v", err)
+	}
+	return a
+}
+
+func TestParseActivitiesCSV(t *testing.T) {
+	activities, err := ParseActivitiesCSV(bytes.NewReader([]byte(testActivitiesCSV)))
+	if err != nil {
+		t.Fatalf("ParseActivitiesCSV() error = %v", err)
+	}
+	if len(activities) != 4 {
+		t.Fatalf("ParseActivitiesCSV() = %d activities, want 4", len(activities))
+	}
+	run := activities[0]
+	if run.Summary.Id != 1 || run.Summary.Name != "Morning Run" || *run.Summary.SportType != swagger.RUN_SportType {
+		t.Errorf("ParseActivitiesCSV() run = %+v", run.Summary)
+	}
+	// the second distance column is in meters
+	if run.Summary.Distance != 5010.5 || run.Summary.MovingTime != 1750 {
+		t.Errorf("ParseActivitiesCSV() distance = %v, moving time = %v", run.Summary.Distance, run.Summary.MovingTime)
+	}
+	if run.Summary.StartDate.Hour() != 6 || run.Description != `easy, with "strides"` || run.GearName != "Shoes" {
+		t.Errorf("ParseActivitiesCSV() run = %+v", run)
+	}
+	if *activities[1].Summary.SportType != swagger.VIRTUAL_RIDE_SportType {
+		t.Errorf("ParseActivitiesCSV() sport type = %v, want VirtualRide", *activities[1].Summary.SportType)
+	}
+	if !activities[2].Summary.Manual {
+		t.Errorf("ParseActivitiesCSV() activity without a file should be manual")
+	}
+}
+
+func TestArchive_Streams(t *testing.T) {
+	a := testArchive(t)
+	activities, err := a.Activities()
+	if err != nil {
+		t.Fatalf("Activities() error = %v", err)
+	}
+	gpx, err := a.Streams(activities[0])
+	if err != nil {
+		t.Fatalf("Streams() gpx error = %v", err)
+	}
+	if len(gpx.Time.Data) != 2 || gpx.Time.Data[1] != 30 || gpx.Heartrate.Data[1] != 130 || gpx.Cadence == nil || gpx.Altitude == nil {
+		t.Errorf("Streams() gpx = %+v", gpx)
+	}
+	// about 111m between the two points
+	if d := gpx.Distance.Data[1]; d < 110 || d > 112 {
+		t.Errorf("Streams() derived distance = %v, want ~111", d)
+	}
+	tcx, err := a.Streams(activities[1])
+	if err != nil {
+		t.Fatalf("Streams() tcx error = %v", err)
+	}
+	if tcx.Watts.Data[1] != 160 || tcx.Distance.Data[1] != 8.5 || tcx.Latlng != nil {
+		t.Errorf("Streams() tcx = %+v", tcx)
+	}
+	if _, err := a.Streams(activities[2]); !errors.Is(err, NoFileError) {
+		t.Errorf("Streams() manual error = %v, want NoFileError", err)
+	}
+	if _, err := a.Streams(activities[3]); !errors.Is(err, fit.FormatError) {
+		t.Errorf("Streams() fit error = %v, want fit.FormatError", err)
+	}
+	id, err := a.AthleteID()
+	if err != nil || id != 99 {
+		t.Errorf("AthleteID() = %v, %v, want 99", id, err)
+	}
+}
+
+type memorySink struct {
+	activities map[int]swagger.SummaryActivity
+	streams    map[int]*swagger.StreamSet
+}
+
+func (s *memorySink) Put(ctx context.Context, athleteID int, activity swagger.SummaryActivity) error {
+	s.activities[int(activity.Id)] = activity
+	return nil
+}
+
+func (s *memorySink) Delete(ctx context.Context, athleteID int, activityID int) error {
+	delete(s.activities, activityID)
+	return nil
+}
+
+func (s *memorySink) PutStreams(ctx context.Context, athleteID int, activityID int, streams *swagger.StreamSet) error {
+	s.streams[activityID] = streams
+	return nil
+}
+
+func TestArchive_Import(t *testing.T) {
+	a := testArchive(t)
+	sink := &memorySink{activities: map[int]swagger.SummaryActivity{}, streams: map[int]*swagger.StreamSet{}}
+	report, err := a.Import(context.Background(), 99, sink)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if report.Activities != 4 || report.Streams != 2 || len(report.StreamErrors) != 1 {
+		t.Errorf("Import() = %+v", report)
+	}
+	if len(sink.activities) != 4 || len(sink.streams) != 2 {
+		t.Errorf("sink has %d activities and %d streams, want 4 and 2", len(sink.activities), len(sink.streams))
+	}
+}
@@ -0,0 +1,178 @@
+package bulkexport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// the format of "Activity Date" (always in UTC)
+const activityDateLayout = "Jan 2, 2006, 3:04:05 PM"
+
+// An Activity is a single row of activities.csv
+type Activity struct {
+	// the row mapped onto the fields that the api would return
+	Summary swagger.SummaryActivity
+	// the export includes the description, which a summary activity does not
+	Description string
+	// the export only includes the name of the gear, not its id
+	GearName string
+	// the path of the activity file in the archive (e.g. activities/1234.fit.gz). empty for manual activities
+	Filename string
+}
+
+// the columns of a csv, by name
+//
+// strava repeats some columns (e.g. "Distance" in display units, then in meters). the last one wins.
+type columns struct {
+	index map[string]int
+	// the number of times each column occurs
+	count map[string]int
+}
+
+func newColumns(header []string) *columns {
+	c := &columns{index: map[string]int{}, count: map[string]int{}}
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		c.index[name] = i
+		c.count[name]++
+	}
+	return c
+}
+
+func (c *columns) get(row []string, name string) string {
+	i, ok := c.index[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func (c *columns) float(row []string, name string) float32 {
+	f, _ := strconv.ParseFloat(strings.ReplaceAll(c.get(row, name), ",", ""), 32)
+	return float32(f)
+}
+
+func (c *columns) int(row []string, name string) int32 {
+	return int32(c.float(row, name))
+}
+
+func (c *columns) bool(row []string, name string) bool {
+	v := strings.ToLower(c.get(row, name))
+	return v == "true" || v == "1"
+}
+
+// convert the display name of an activity type (e.g. "Virtual Ride") into a sport type (e.g. "VirtualRide")
+func sportType(displayName string) *swagger.SportType {
+	if displayName == "" {
+		return nil
+	}
+	st := swagger.SportType(strings.ReplaceAll(displayName, " ", ""))
+	return &st
+}
+
+// Parse activities.csv.
+//
+// Only the columns that have a counterpart in `swagger.SummaryActivity` are mapped. Distances are in meters and times in seconds,
+// like the api. (Older exports only have the distance in kilometers; it is converted.)
+func ParseActivitiesCSV(r io.Reader) ([]Activity, error) {
+	reader := csv.NewReader(r)
+	// descriptions can contain anything, so don't be strict about quotes or the number of fields
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read activities.csv header: %w", err)
+	}
+	cols := newColumns(header)
+	if _, ok := cols.index["Activity ID"]; !ok {
+		return nil, fmt.Errorf("activities.csv has no Activity ID column")
+	}
+	activities := []Activity{}
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read activities.csv line %d: %w", line, err)
+		}
+		activity, err := parseRow(cols, row)
+		if err != nil {
+			return nil, fmt.Errorf("activities.csv line %d: %w", line, err)
+		}
+		activities = append(activities, activity)
+	}
+	return activities, nil
+}
+
+func parseRow(cols *columns, row []string) (Activity, error) {
+	id, err := strconv.ParseInt(cols.get(row, "Activity ID"), 10, 64)
+	if err != nil {
+		return Activity{}, fmt.Errorf("invalid activity id: %w", err)
+	}
+	start, err := time.Parse(activityDateLayout, cols.get(row, "Activity Date"))
+	if err != nil {
+		return Activity{}, fmt.Errorf("invalid activity date: %w", err)
+	}
+	distance := cols.float(row, "Distance")
+	if cols.count["Distance"] == 1 {
+		distance *= 1000
+	}
+	st := sportType(cols.get(row, "Activity Type"))
+	summary := swagger.SummaryActivity{
+		Id:                 id,
+		Name:               cols.get(row, "Activity Name"),
+		SportType:          st,
+		StartDate:          start,
+		ElapsedTime:        cols.int(row, "Elapsed Time"),
+		MovingTime:         cols.int(row, "Moving Time"),
+		Distance:           distance,
+		MaxSpeed:           cols.float(row, "Max Speed"),
+		AverageSpeed:       cols.float(row, "Average Speed"),
+		TotalElevationGain: cols.float(row, "Elevation Gain"),
+		ElevLow:            cols.float(row, "Elevation Low"),
+		ElevHigh:           cols.float(row, "Elevation High"),
+		AverageWatts:       cols.float(row, "Average Watts"),
+		MaxWatts:           cols.int(row, "Max Watts"),
+		Commute:            cols.bool(row, "Commute"),
+	}
+	if st != nil {
+		// the deprecated type mostly matches the sport type
+		at := swagger.ActivityType(*st)
+		summary.Type_ = &at
+	}
+	filename := cols.get(row, "Filename")
+	summary.Manual = filename == ""
+	return Activity{
+		Summary:     summary,
+		Description: cols.get(row, "Activity Description"),
+		GearName:    cols.get(row, "Activity Gear"),
+		Filename:    filename,
+	}, nil
+}
+
+// Parse the athlete id out of profile.csv
+func ParseProfileCSV(r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read profile.csv header: %w", err)
+	}
+	row, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read profile.csv: %w", err)
+	}
+	id, err := strconv.Atoi(newColumns(header).get(row, "Athlete ID"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid athlete id in profile.csv: %w", err)
+	}
+	return id, nil
+}
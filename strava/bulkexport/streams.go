@@ -0,0 +1,50 @@
+package bulkexport
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/jcocozza/cassidy-connector/fit"
+	"github.com/jcocozza/cassidy-connector/gpx"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/tcx"
+)
+
+// if an activity file is in a format that cannot be decoded (yet), will throw this error
+var UnsupportedFormatError = errors.New("Unsupported activity file format")
+
+// Decode an activity file into streams. The format is taken from the file name (e.g. 1234.gpx or 1234.tcx.gz).
+//
+// FIT, GPX and TCX files are supported. Other formats return an `UnsupportedFormatError`.
+func DecodeStreams(name string, r io.Reader) (*swagger.StreamSet, error) {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", name, err)
+		}
+		defer gz.Close()
+		r = gz
+		name = strings.TrimSuffix(name, ".gz")
+	}
+	var file interface{ StreamSet() *swagger.StreamSet }
+	var err error
+	switch ext := path.Ext(name); ext {
+	case ".gpx":
+		file, err = gpx.Decode(r)
+	case ".tcx":
+		file, err = tcx.Decode(r)
+	case ".fit":
+		file, err = fit.Decode(r)
+	default:
+		return nil, fmt.Errorf("%w: %s", UnsupportedFormatError, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return file.StreamSet(), nil
+}
@@ -2,9 +2,10 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
+	"github.com/jcocozza/cassidy-connector/strava/app"
+	"github.com/jcocozza/cassidy-connector/strava/app/api"
 	"github.com/jcocozza/cassidy-connector/utils"
 	"github.com/spf13/cobra"
 )
@@ -30,7 +31,10 @@ var initialAccess = &cobra.Command{
 			fmt.Println(err1.Error())
 			return
 		}
-		jsonBytes, err := json.Marshal(token)
+		if len(grantedScopes) > 0 {
+			token = api.WithScopes(token, grantedScopes)
+		}
+		jsonBytes, err := app.MarshalToken(token)
 		if err != nil {
 			fmt.Println(err.Error())
 			return
@@ -41,6 +45,9 @@ var initialAccess = &cobra.Command{
 		fmt.Println(string(jsonBytes))
 	},
 }
+// the scopes the user granted, from the "scope" parameter of the redirect url
+var grantedScopes []string
+
 // Used to get the approval url for the user to grant access
 var approvalUrl = &cobra.Command{
 	Use: "approval-url",
@@ -62,6 +69,7 @@ var approvalUrl = &cobra.Command{
 }
 
 func init() {
+	initialAccess.Flags().StringSliceVar(&grantedScopes, "granted-scopes", nil, "the scopes the user granted (the \"scope\" parameter of the redirect url). these are saved with the token and checked before writing to strava")
 	RootCmd.AddCommand(initialAccess)
 	RootCmd.AddCommand(approvalUrl)
 }
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jcocozza/cassidy-connector/geo"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
 	"github.com/jcocozza/cassidy-connector/utils"
 	"github.com/spf13/cobra"
 )
@@ -16,10 +18,16 @@ const layoutInterpretation string = "YYYY-MM-DD"
 var perPage int
 var before string
 var after string
+var asGeoJSON bool
+var simplifyTolerance float64
 var getActivities = &cobra.Command{
 	Use:   "activities",
 	Short: "Get activities.",
-	Args:  cobra.ExactArgs(0),
+	Long: `Get activities.
+
+Use --geojson to get a GeoJSON FeatureCollection of the activities instead, with one LineString per activity.
+Activities without a map (e.g. indoor activities) are left out.`,
+	Args: cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
 		stravaApp, tkn, err := createApp()
 		if err != nil {
@@ -49,7 +57,19 @@ var getActivities = &cobra.Command{
 			fmt.Println(err.Error())
 			return
 		}
-		activitiesJsonBytes, err := json.Marshal(activities)
+		var out interface{} = activities
+		if asGeoJSON {
+			all := []swagger.SummaryActivity{}
+			for _, page := range activities {
+				all = append(all, page...)
+			}
+			out, err = geo.ActivitiesFeatureCollection(all, simplifyTolerance)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+		activitiesJsonBytes, err := json.Marshal(out)
 		if err != nil {
 			fmt.Println(err.Error())
 			return
@@ -65,5 +85,7 @@ func init() {
 	getActivities.Flags().IntVarP(&perPage, "per-page", "n", 30, "The number of activities to get per page. (max 200)")
 	getActivities.Flags().StringVarP(&before, "before", "b", "", fmt.Sprintf("Filter to only include activities before this date. Must be of the format: %s", layoutInterpretation))
 	getActivities.Flags().StringVarP(&after, "after", "a", "", fmt.Sprintf("Filter to only include activities after this date. Must be of the format: %s", layoutInterpretation))
+	getActivities.Flags().BoolVar(&asGeoJSON, "geojson", false, "Output a GeoJSON FeatureCollection of the activities.")
+	getActivities.Flags().Float64Var(&simplifyTolerance, "simplify", 0, "With --geojson, simplify each line to within this many meters. (0 does not simplify)")
 	tokenCmdGroup.AddCommand(getActivities)
 }
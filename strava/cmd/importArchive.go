@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/jcocozza/cassidy-connector/strava/bulkexport"
+	stravaSync "github.com/jcocozza/cassidy-connector/strava/sync"
+	"github.com/spf13/cobra"
+)
+
+var importDir string
+var importDB string
+var importAthleteID int
+var importArchiveCmd = &cobra.Command{
+	Use:   "import-archive [export.zip]",
+	Short: "Import a strava account export without using the api.",
+	Long: fmt.Sprintf(`Import a strava account export (Settings > My Account > Download or Delete Your Account) without using the api.
+
+Activities are written the same way as the sync command: to <dir>/activities/<athlete id>/<activity id>.json (default dir is $HOME/%s),
+or into a local archive with --db. With --db, the activity files are decoded and their streams are stored too.
+
+The athlete id is read from profile.csv in the export, unless --athlete-id is passed.`, defaultSyncDir),
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		archive, err := bulkexport.Open(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		defer archive.Close()
+		athleteID := importAthleteID
+		if athleteID == 0 {
+			athleteID, err = archive.AthleteID()
+			if err != nil {
+				fmt.Println(err.Error())
+				fmt.Println("use --athlete-id to set the athlete id")
+				return
+			}
+		}
+		var sink stravaSync.Sink
+		if importDB != "" {
+			db, err := store.Open(importDB)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			defer db.Close()
+			sink = db.StravaSink()
+		} else {
+			dir := importDir
+			if dir == "" {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					fmt.Println(err.Error())
+					return
+				}
+				dir = filepath.Join(home, defaultSyncDir)
+			}
+			sink = stravaSync.NewJSONDirSink(filepath.Join(dir, "activities"))
+		}
+		report, err := archive.Import(context.TODO(), athleteID, sink)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		for id, err := range report.StreamErrors {
+			fmt.Printf("activity %d: %s\n", id, err.Error())
+		}
+		fmt.Printf("activities: %d, streams: %d\n", report.Activities, report.Streams)
+	},
+}
+
+func init() {
+	importArchiveCmd.Flags().StringVar(&importDir, "dir", "", fmt.Sprintf("the directory to import into. (default is $HOME/%s)", defaultSyncDir))
+	importArchiveCmd.Flags().StringVar(&importDB, "db", "", "the path to a local archive to import into, instead of json files")
+	importArchiveCmd.Flags().IntVar(&importAthleteID, "athlete-id", 0, "the id of the athlete the export belongs to. (default is read from profile.csv)")
+	importArchiveCmd.MarkFlagsMutuallyExclusive("dir", "db")
+	RootCmd.AddCommand(importArchiveCmd)
+}
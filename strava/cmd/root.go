@@ -106,6 +106,8 @@ func initConfig() {
 	}
 	if config.TokenPath != "" {
 		tokenCmdGroup.Flags().Set("token-path", config.TokenPath)
+		syncCmd.Flags().Set("token-path", config.TokenPath)
+		launchWebhookServer.Flags().Set("token-path", config.TokenPath)
 	}
 }
 
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+// write a single row of the stats table
+func writeTotalRow(w *tabwriter.Writer, period, sport string, total *swagger.ActivityTotal) {
+	if total == nil {
+		total = &swagger.ActivityTotal{}
+	}
+	fmt.Fprintf(w, "%s\t%s\t%d\t%.2f\t%d\t%d\t%.1f\n",
+		period,
+		sport,
+		total.Count,
+		total.Distance/1000,
+		total.MovingTime,
+		total.ElapsedTime,
+		total.ElevationGain,
+	)
+}
+
+// print athlete stats as a table
+func printStats(stats *swagger.ActivityStats) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERIOD\tSPORT\tCOUNT\tDISTANCE (km)\tMOVING (s)\tELAPSED (s)\tELEVATION (m)")
+	writeTotalRow(w, "recent", "ride", stats.RecentRideTotals)
+	writeTotalRow(w, "recent", "run", stats.RecentRunTotals)
+	writeTotalRow(w, "recent", "swim", stats.RecentSwimTotals)
+	writeTotalRow(w, "ytd", "ride", stats.YtdRideTotals)
+	writeTotalRow(w, "ytd", "run", stats.YtdRunTotals)
+	writeTotalRow(w, "ytd", "swim", stats.YtdSwimTotals)
+	writeTotalRow(w, "all", "ride", stats.AllRideTotals)
+	writeTotalRow(w, "all", "run", stats.AllRunTotals)
+	writeTotalRow(w, "all", "swim", stats.AllSwimTotals)
+	w.Flush()
+}
+
+var getStats = &cobra.Command{
+	Use:   "stats [athlete id]",
+	Short: "Get the recent, year to date and all time totals for the authenticated athlete.",
+	Long:  "Get the recent, year to date and all time totals for the authenticated athlete. If no athlete id is passed, the authenticated athlete is looked up first (this costs an extra request).",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		stravaApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		var athleteId int
+		if len(args) == 1 {
+			athleteId, err = strconv.Atoi(args[0])
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		} else {
+			athlete, err := stravaApp.Api.GetAthlete(context.TODO(), tkn)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			athleteId = int(athlete.Id)
+		}
+		stats, err := stravaApp.Api.GetAthleteStats(context.TODO(), tkn, athleteId)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			statsJsonBytes, err := json.Marshal(stats)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			utils.WriteOutput(outputPath, statsJsonBytes)
+		}
+		printStats(stats)
+	},
+}
+
+func init() {
+	tokenCmdGroup.AddCommand(getStats)
+}
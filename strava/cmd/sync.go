@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jcocozza/cassidy-connector/localfiles"
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	stravaSync "github.com/jcocozza/cassidy-connector/strava/sync"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+const defaultSyncDir string = ".cassidy-connector-strava-sync"
+
+var syncDir string
+var syncDB string
+var fullScan bool
+var syncFromDir string
+var syncAthleteID int
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync new activities into a local directory. Resumes where the last sync left off.",
+	Long: fmt.Sprintf(`Sync new activities into a local directory. Resumes where the last sync left off.
+
+The checkpoint of each athlete is kept in <dir>/checkpoints and activities are written to <dir>/activities/<athlete id>/<activity id>.json.
+(default dir is $HOME/%s)
+
+Use --db to write activities into a local archive instead (see 'cassidy query'). Checkpoints are still kept in <dir>/checkpoints.
+
+Use --full to scan the entire history. This picks up edits and deletions that were missed.
+
+Use --from-dir to sync a directory of activity files (.fit, .gpx and .tcx, optionally gzipped) instead of strava.
+No token is needed. The activities are stored under --athlete-id, apart from strava's: checkpoints go in <dir>/local/checkpoints,
+activities in <dir>/local/activities, and archived activities have the "local" source.`, defaultSyncDir),
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		var source stravaSync.StravaAPI
+		var tkn *oauth2.Token
+		athlete := &swagger.DetailedAthlete{Id: int64(syncAthleteID)}
+		if syncFromDir != "" {
+			source = localfiles.New(syncFromDir)
+		} else {
+			stravaApp, t, err := createApp()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			if t == nil {
+				fmt.Println("a token is required. use --token or --token-path")
+				return
+			}
+			tkn = t
+			athlete, err = stravaApp.Api.GetAthlete(context.TODO(), tkn)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			source = stravaApp.Api
+		}
+		dir := syncDir
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			dir = filepath.Join(home, defaultSyncDir)
+		}
+		recordSource := store.SourceStrava
+		if syncFromDir != "" {
+			// local files get their own checkpoints and records so their ids never mix with strava's
+			recordSource = store.SourceLocal
+			dir = filepath.Join(dir, "local")
+		}
+		var sink stravaSync.Sink = stravaSync.NewJSONDirSink(filepath.Join(dir, "activities"))
+		if syncDB != "" {
+			db, err := store.Open(syncDB)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			defer db.Close()
+			if syncFromDir == "" {
+				err = db.PutStravaAthlete(athlete)
+				if err != nil {
+					fmt.Println(err.Error())
+					return
+				}
+			}
+			sink = db.SourceSink(recordSource)
+		}
+		syncer := stravaSync.NewSyncer(
+			source,
+			stravaSync.NewFileCheckpointStore(filepath.Join(dir, "checkpoints")),
+			sink,
+			nil,
+		)
+		var report *stravaSync.Report
+		var err error
+		if fullScan {
+			report, err = syncer.FullScan(context.TODO(), tkn, int(athlete.Id))
+		} else {
+			report, err = syncer.Sync(context.TODO(), tkn, int(athlete.Id))
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			// anything synced before the error has been checkpointed
+			fmt.Println("run sync again to resume")
+			return
+		}
+		fmt.Printf("created: %d, updated: %d, deleted: %d\n", report.Created, report.Updated, report.Deleted)
+	},
+}
+
+func init() {
+	syncCmd.Flags().StringVar(&syncDir, "dir", "", fmt.Sprintf("the directory to sync into. (default is $HOME/%s)", defaultSyncDir))
+	syncCmd.Flags().StringVar(&syncDB, "db", "", "the path to a local archive to write activities into, instead of json files")
+	syncCmd.Flags().StringVar(&syncFromDir, "from-dir", "", "a directory of activity files to sync instead of strava")
+	syncCmd.Flags().IntVar(&syncAthleteID, "athlete-id", 0, "the athlete id to store activities under when using --from-dir")
+	syncCmd.Flags().BoolVar(&fullScan, "full", false, "scan the entire history to pick up edits and deletions")
+	// sync lives outside of the api group, so it needs its own token flags. they share the same variables.
+	syncCmd.Flags().StringVar(&tokenPath, "token-path", "", "the path to a .json file that contains an OAuth2 token.")
+	syncCmd.Flags().StringVar(&token, "token", "", "a json token.")
+	syncCmd.MarkFlagsMutuallyExclusive("token-path", "token")
+	RootCmd.AddCommand(syncCmd)
+}
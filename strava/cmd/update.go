@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+// build a patch from the flags that were explicitly set
+func patchFromFlags(cmd *cobra.Command) *api.ActivityPatch {
+	patch := api.NewActivityPatch()
+	flags := cmd.Flags()
+	if flags.Changed("name") {
+		v, _ := flags.GetString("name")
+		patch.Name(v)
+	}
+	if flags.Changed("sport-type") {
+		v, _ := flags.GetString("sport-type")
+		patch.SportType(swagger.SportType(v))
+	}
+	if flags.Changed("gear-id") {
+		v, _ := flags.GetString("gear-id")
+		patch.GearID(v)
+	}
+	if flags.Changed("description") {
+		v, _ := flags.GetString("description")
+		patch.Description(v)
+	}
+	if flags.Changed("commute") {
+		v, _ := flags.GetBool("commute")
+		patch.Commute(v)
+	}
+	if flags.Changed("trainer") {
+		v, _ := flags.GetBool("trainer")
+		patch.Trainer(v)
+	}
+	if flags.Changed("hide-from-home") {
+		v, _ := flags.GetBool("hide-from-home")
+		patch.HideFromHome(v)
+	}
+	return patch
+}
+
+var updateActivity = &cobra.Command{
+	Use:   "update [activity id]",
+	Short: "Update an activity. Only the flags that are passed are changed. Requires the activity:write scope.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		stravaApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activityId, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activity, err := stravaApp.Api.UpdateActivity(context.TODO(), tkn, activityId, patchFromFlags(cmd))
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		activityJsonBytes, err := json.Marshal(activity)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if outputPath != "" {
+			utils.WriteOutput(outputPath, activityJsonBytes)
+		}
+		fmt.Println(string(activityJsonBytes))
+	},
+}
+
+func init() {
+	updateActivity.Flags().String("name", "", "the name of the activity")
+	updateActivity.Flags().String("sport-type", "", "the sport type of the activity (e.g. Ride, Run)")
+	updateActivity.Flags().String("gear-id", "", "the gear of the activity. 'none' clears the gear")
+	updateActivity.Flags().String("description", "", "the description of the activity")
+	updateActivity.Flags().Bool("commute", false, "mark the activity as a commute")
+	updateActivity.Flags().Bool("trainer", false, "mark the activity as done on a trainer")
+	updateActivity.Flags().Bool("hide-from-home", false, "mute the activity")
+	tokenCmdGroup.AddCommand(updateActivity)
+}
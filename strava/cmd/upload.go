@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/app/api"
+	"github.com/spf13/cobra"
+)
+
+// guess the upload data type from a file name e.g. "ride.fit.gz" -> "fit.gz"
+func dataTypeFromFileName(fileName string) string {
+	lower := strings.ToLower(fileName)
+	for _, ext := range []string{"fit.gz", "tcx.gz", "gpx.gz", "fit", "tcx", "gpx"} {
+		if strings.HasSuffix(lower, "."+ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+const uploadTimeout = 5 * time.Minute
+
+var dataType string
+var uploadName string
+var uploadDescription string
+var uploadTrainer bool
+var uploadCommute bool
+var uploadActivity = &cobra.Command{
+	Use:   "upload [file]",
+	Short: "Upload a FIT, TCX or GPX file. Requires the activity:write scope.",
+	Long:  "Upload a FIT, TCX or GPX file and wait for strava to process it. Prints the id of the created activity. Requires the activity:write scope.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		stravaApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		filePath := args[0]
+		if dataType == "" {
+			dataType = dataTypeFromFileName(filePath)
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		opts := &api.UploadOpts{
+			Name:        uploadName,
+			Description: uploadDescription,
+			Trainer:     uploadTrainer,
+			Commute:     uploadCommute,
+		}
+		// strava usually processes uploads in a few seconds, but don't wait forever
+		ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+		defer cancel()
+		activityId, err := stravaApp.Api.UploadActivity(ctx, tkn, file, api.UploadDataType(dataType), opts)
+		var dup *api.DuplicateUploadError
+		if errors.As(err, &dup) {
+			fmt.Printf("duplicate of activity: %d\n", dup.ActivityID)
+			return
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Println(activityId)
+	},
+}
+
+func init() {
+	uploadActivity.Flags().StringVarP(&dataType, "data-type", "t", "", "one of fit, fit.gz, tcx, tcx.gz, gpx, gpx.gz. (default is guessed from the file extension)")
+	uploadActivity.Flags().StringVar(&uploadName, "name", "", "the name of the activity")
+	uploadActivity.Flags().StringVar(&uploadDescription, "description", "", "the description of the activity")
+	uploadActivity.Flags().BoolVar(&uploadTrainer, "trainer", false, "mark the activity as done on a trainer")
+	uploadActivity.Flags().BoolVar(&uploadCommute, "commute", false, "mark the activity as a commute")
+	tokenCmdGroup.AddCommand(uploadActivity)
+}
@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/jcocozza/cassidy-connector/store"
+	"github.com/jcocozza/cassidy-connector/strava/app"
+	stravaSync "github.com/jcocozza/cassidy-connector/strava/sync"
 	"github.com/spf13/cobra"
 )
 
 var keepAlive bool
+var webhookDB string
 
 var webhookCmdGroup = &cobra.Command{
 	Use:   "webhook",
@@ -44,13 +51,47 @@ var createSubscription = &cobra.Command{
 var launchWebhookServer = &cobra.Command{
 	Use:   "launch-server",
 	Short: "launch the server. only do this if you have already created a webhook subscription.",
-	Args:  cobra.ExactArgs(0),
+	Long: `launch the server. only do this if you have already created a webhook subscription.
+
+Use --db (with a token) to apply activity events to a local archive as they arrive.
+The checkpoints are shared with the sync command, so a later sync does not rewrite what the server already applied.
+The archive is locked while the server runs, so sync --db can't use the same archive until the server is stopped.`,
+	Args: cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
-		stravaApp, _, err := createApp()
+		stravaApp, tkn, err := createApp()
 		if err != nil {
 			fmt.Println(err.Error())
 			return
 		}
+		if webhookDB != "" {
+			if tkn == nil {
+				fmt.Println("a token is required to use --db. use --token or --token-path")
+				return
+			}
+			home, err := os.UserHomeDir()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			db, err := store.Open(webhookDB)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			defer db.Close()
+			syncer := stravaSync.NewSyncer(
+				stravaApp.Api,
+				stravaSync.NewFileCheckpointStore(filepath.Join(home, defaultSyncDir, "checkpoints")),
+				db.StravaSink(),
+				nil,
+			)
+			stravaApp.WebhookEventHandler = func(se app.StravaEvent) {
+				err := syncer.HandleEvent(context.TODO(), tkn, se)
+				if err != nil {
+					fmt.Printf("failed to handle event for %s %d: %s\n", se.ObjectType, se.ObjectID, err.Error())
+				}
+			}
+		}
 		server, wg, err := stravaApp.LaunchWebhookServer()
 		if err != nil {
 			fmt.Println(err.Error())
@@ -105,6 +146,11 @@ var deleteSubscription = &cobra.Command{
 func init() {
 	createSubscription.Flags().BoolVar(&keepAlive, "keep-alive", true, "keep the server alive after creation. the server is needed to get events from the webhook")
 	launchWebhookServer.Flags().BoolVar(&keepAlive, "keep-alive", true, "keep the server alive after creation. the server is needed to get events from the webhook")
+	launchWebhookServer.Flags().StringVar(&webhookDB, "db", "", "the path to a local archive to apply activity events to")
+	// like sync, launch-server lives outside of the api group, so it needs its own token flags
+	launchWebhookServer.Flags().StringVar(&tokenPath, "token-path", "", "the path to a .json file that contains an OAuth2 token.")
+	launchWebhookServer.Flags().StringVar(&token, "token", "", "a json token.")
+	launchWebhookServer.MarkFlagsMutuallyExclusive("token-path", "token")
 	webhookCmdGroup.AddCommand(createSubscription)
 	webhookCmdGroup.AddCommand(launchWebhookServer)
 	webhookCmdGroup.AddCommand(viewSubscription)
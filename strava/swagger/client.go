@@ -177,6 +177,11 @@ func (c *APIClient) ChangeBasePath(path string) {
 	c.cfg.BasePath = path
 }
 
+// GetConfig allows modification of underlying config for alternate implementations and testing
+func (c *APIClient) GetConfig() *Configuration {
+	return c.cfg
+}
+
 // prepareRequest build the request
 func (c *APIClient) prepareRequest(
 	ctx context.Context,
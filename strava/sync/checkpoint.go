@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A KnownActivity is what the checkpoint remembers about an activity that has been written to the sink
+type KnownActivity struct {
+	// when the activity was last written to the sink
+	Updated time.Time `json:"updated"`
+	// a fingerprint of the fields that can be edited. used to detect edits during full scans
+	Fingerprint string `json:"fingerprint"`
+}
+
+// A Checkpoint is the sync state of a single athlete
+type Checkpoint struct {
+	AthleteID int `json:"athlete_id"`
+	// the start time of the most recent activity that has been synced.
+	// the next incremental sync fetches activities after this time.
+	LastStart time.Time `json:"last_start"`
+	// the last time a full scan completed
+	LastFullScan time.Time `json:"last_full_scan"`
+	// every activity that has been synced, keyed by activity id
+	Known map[int]KnownActivity `json:"known"`
+}
+
+func NewCheckpoint(athleteID int) *Checkpoint {
+	return &Checkpoint{AthleteID: athleteID, Known: map[int]KnownActivity{}}
+}
+
+// A CheckpointStore persists checkpoints between runs
+type CheckpointStore interface {
+	// Load the checkpoint for an athlete. If there is none, return a new checkpoint.
+	Load(athleteID int) (*Checkpoint, error)
+	Save(checkpoint *Checkpoint) error
+}
+
+// FileCheckpointStore keeps one json file per athlete in a directory
+type FileCheckpointStore struct {
+	Dir string
+}
+
+func NewFileCheckpointStore(dir string) *FileCheckpointStore {
+	return &FileCheckpointStore{Dir: dir}
+}
+
+func (s *FileCheckpointStore) path(athleteID int) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("%d.json", athleteID))
+}
+
+func (s *FileCheckpointStore) Load(athleteID int) (*Checkpoint, error) {
+	data, err := os.ReadFile(s.path(athleteID))
+	if errors.Is(err, os.ErrNotExist) {
+		return NewCheckpoint(athleteID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	err = json.Unmarshal(data, &cp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if cp.Known == nil {
+		cp.Known = map[int]KnownActivity{}
+	}
+	return &cp, nil
+}
+
+// Save the checkpoint. The file is replaced atomically so an interrupted save never corrupts the checkpoint.
+func (s *FileCheckpointStore) Save(checkpoint *Checkpoint) error {
+	err := os.MkdirAll(s.Dir, 0755)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	path := s.path(checkpoint.AthleteID)
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,69 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// A Sink is where synced activities are written to
+type Sink interface {
+	// Create or replace an activity
+	Put(ctx context.Context, athleteID int, activity swagger.SummaryActivity) error
+	// Remove an activity. Removing an activity that does not exist is not an error.
+	Delete(ctx context.Context, athleteID int, activityID int) error
+}
+
+// MemorySink keeps the activities in memory, keyed by activity id (e.g. for tests, or to see what a sync would write).
+//
+// It is not safe for concurrent use. The syncer only writes to a sink for one athlete at a time, so it is safe with a single athlete.
+type MemorySink map[int]swagger.SummaryActivity
+
+func (m MemorySink) Put(ctx context.Context, athleteID int, activity swagger.SummaryActivity) error {
+	m[int(activity.Id)] = activity
+	return nil
+}
+
+func (m MemorySink) Delete(ctx context.Context, athleteID int, activityID int) error {
+	delete(m, activityID)
+	return nil
+}
+
+// JSONDirSink writes each activity to `<Dir>/<athlete id>/<activity id>.json`
+type JSONDirSink struct {
+	Dir string
+}
+
+func NewJSONDirSink(dir string) *JSONDirSink {
+	return &JSONDirSink{Dir: dir}
+}
+
+func (s *JSONDirSink) path(athleteID int, activityID int) string {
+	return filepath.Join(s.Dir, fmt.Sprint(athleteID), fmt.Sprintf("%d.json", activityID))
+}
+
+func (s *JSONDirSink) Put(ctx context.Context, athleteID int, activity swagger.SummaryActivity) error {
+	path := s.path(athleteID, int(activity.Id))
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (s *JSONDirSink) Delete(ctx context.Context, athleteID int, activityID int) error {
+	err := os.Remove(s.path(athleteID, activityID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,305 @@
+// Package sync keeps a local copy of an athlete's strava activities up to date without re-downloading their whole history.
+//
+// An incremental sync only asks strava for activities that started after the last synced activity.
+// Edits and deletions are picked up from webhook events (see `Syncer.HandleEvent`) or from a periodic full scan (see `Syncer.FullScan`).
+//
+// The sync state of each athlete is kept in a `Checkpoint`. It is saved after every page of activities, so an interrupted sync resumes where it left off.
+package sync
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/app"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"golang.org/x/oauth2"
+)
+
+// the strava max
+const perPage = 200
+
+// StravaAPI is the subset of `api.StravaAPI` that the syncer needs
+type StravaAPI interface {
+	GetActivitiesPage(ctx context.Context, token *oauth2.Token, page int, perPage int, before, after *time.Time) ([]swagger.SummaryActivity, error)
+	GetActivity(ctx context.Context, token *oauth2.Token, activityID int, includeAllEfforts bool) (*swagger.DetailedActivity, error)
+}
+
+// A Report summarizes what a sync did
+type Report struct {
+	Created int
+	Updated int
+	Deleted int
+}
+
+// A Syncer syncs activities from strava into a sink
+type Syncer struct {
+	api         StravaAPI
+	checkpoints CheckpointStore
+	sink        Sink
+	logger      *slog.Logger
+
+	mu sync.Mutex
+	// a lock per athlete, held from loading their checkpoint to saving it, so that syncs, scans and events don't overwrite each other's changes
+	athleteLocks map[int]*sync.Mutex
+}
+
+func NewSyncer(api StravaAPI, checkpoints CheckpointStore, sink Sink, logger *slog.Logger) *Syncer {
+	if logger == nil {
+		logger = app.NoopLogger()
+	}
+	return &Syncer{
+		api:         api,
+		checkpoints: checkpoints,
+		sink:        sink,
+		logger:      logger.WithGroup("cassidy-strava-sync"),
+
+		athleteLocks: map[int]*sync.Mutex{},
+	}
+}
+
+// lock the checkpoint of an athlete. returns the unlock
+func (s *Syncer) lock(athleteID int) func() {
+	s.mu.Lock()
+	l, ok := s.athleteLocks[athleteID]
+	if !ok {
+		l = &sync.Mutex{}
+		s.athleteLocks[athleteID] = l
+	}
+	s.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// a fingerprint of the fields of an activity that an athlete can edit
+func fingerprint(activity swagger.SummaryActivity) string {
+	editable := struct {
+		Name         string
+		Type         *swagger.ActivityType
+		SportType    *swagger.SportType
+		GearId       string
+		Commute      bool
+		Trainer      bool
+		Private      bool
+		HideFromHome bool
+		WorkoutType  int32
+		Distance     float32
+		MovingTime   int32
+		ElapsedTime  int32
+	}{
+		activity.Name,
+		activity.Type_,
+		activity.SportType,
+		activity.GearId,
+		activity.Commute,
+		activity.Trainer,
+		activity.Private,
+		activity.HideFromHome,
+		activity.WorkoutType,
+		activity.Distance,
+		activity.MovingTime,
+		activity.ElapsedTime,
+	}
+	data, _ := json.Marshal(editable)
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// write an activity to the sink and record it in the checkpoint
+//
+// activities that are unchanged since they were last written are skipped
+func (s *Syncer) put(ctx context.Context, cp *Checkpoint, activity swagger.SummaryActivity, report *Report) error {
+	fp := fingerprint(activity)
+	known, exists := cp.Known[int(activity.Id)]
+	if exists && known.Fingerprint == fp {
+		return nil
+	}
+	err := s.sink.Put(ctx, cp.AthleteID, activity)
+	if err != nil {
+		return fmt.Errorf("failed to write activity %d: %w", activity.Id, err)
+	}
+	cp.Known[int(activity.Id)] = KnownActivity{Updated: time.Now(), Fingerprint: fp}
+	if exists {
+		report.Updated++
+	} else {
+		report.Created++
+	}
+	return nil
+}
+
+// Sync the activities that started after the last synced activity.
+//
+// The checkpoint is saved after each page, so if the sync is interrupted the next sync resumes from the last saved page.
+func (s *Syncer) Sync(ctx context.Context, token *oauth2.Token, athleteID int) (*Report, error) {
+	defer s.lock(athleteID)()
+	cp, err := s.checkpoints.Load(athleteID)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "starting incremental sync", slog.Int("athlete id", athleteID), slog.Time("after", cp.LastStart))
+	report := &Report{}
+	for {
+		// strava returns activities in ascending order when `after` is set.
+		// the filter moves forward after each page, so the next page is always page 1 of the new filter.
+		after := cp.LastStart
+		if after.IsZero() {
+			// nothing has been synced yet. strava needs a real timestamp to sort in ascending order
+			after = time.Unix(0, 0)
+		}
+		activities, err := s.api.GetActivitiesPage(ctx, token, 1, perPage, nil, &after)
+		if err != nil {
+			return report, err
+		}
+		if len(activities) == 0 {
+			break
+		}
+		lastStart := cp.LastStart
+		for _, activity := range activities {
+			err := s.put(ctx, cp, activity, report)
+			if err != nil {
+				return report, err
+			}
+			if activity.StartDate.After(lastStart) {
+				lastStart = activity.StartDate
+			}
+		}
+		// a page that does not move the filter forward would be fetched again forever
+		advanced := lastStart.After(cp.LastStart)
+		// only move the checkpoint forward once the whole page is in the sink
+		cp.LastStart = lastStart
+		err = s.checkpoints.Save(cp)
+		if err != nil {
+			return report, err
+		}
+		if !advanced {
+			s.logger.WarnContext(ctx, "page did not start after the last synced activity, stopping", slog.Time("after", after))
+			break
+		}
+	}
+	s.logger.InfoContext(ctx, "incremental sync complete", slog.Int("created", report.Created), slog.Int("updated", report.Updated))
+	return report, nil
+}
+
+// Scan every activity of the athlete and reconcile it with the checkpoint.
+//
+// New and edited activities are written to the sink. Activities that are in the checkpoint but no longer exist on strava are deleted from the sink.
+// This costs one request per 200 activities, so it should be run far less often than `Sync`.
+//
+// Deletions are only applied once the whole history has been listed. If the scan is interrupted, nothing is deleted.
+func (s *Syncer) FullScan(ctx context.Context, token *oauth2.Token, athleteID int) (*Report, error) {
+	defer s.lock(athleteID)()
+	cp, err := s.checkpoints.Load(athleteID)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "starting full scan", slog.Int("athlete id", athleteID))
+	report := &Report{}
+	seen := map[int]bool{}
+	for page := 1; ; page++ {
+		activities, err := s.api.GetActivitiesPage(ctx, token, page, perPage, nil, nil)
+		if err != nil {
+			return report, err
+		}
+		if len(activities) == 0 {
+			break
+		}
+		for _, activity := range activities {
+			seen[int(activity.Id)] = true
+			err := s.put(ctx, cp, activity, report)
+			if err != nil {
+				return report, err
+			}
+			if activity.StartDate.After(cp.LastStart) {
+				cp.LastStart = activity.StartDate
+			}
+		}
+		// saving here means the edits and creates are not repeated if the scan is interrupted
+		err = s.checkpoints.Save(cp)
+		if err != nil {
+			return report, err
+		}
+	}
+	for id := range cp.Known {
+		if seen[id] {
+			continue
+		}
+		err := s.sink.Delete(ctx, athleteID, id)
+		if err != nil {
+			return report, fmt.Errorf("failed to delete activity %d: %w", id, err)
+		}
+		delete(cp.Known, id)
+		report.Deleted++
+	}
+	cp.LastFullScan = time.Now()
+	err = s.checkpoints.Save(cp)
+	if err != nil {
+		return report, err
+	}
+	s.logger.InfoContext(ctx, "full scan complete", slog.Int("created", report.Created), slog.Int("updated", report.Updated), slog.Int("deleted", report.Deleted))
+	return report, nil
+}
+
+// convert a detailed activity into a summary activity
+//
+// a detailed activity has every field of a summary activity, so this is just a json round trip
+func summaryFromDetailed(activity *swagger.DetailedActivity) (swagger.SummaryActivity, error) {
+	var summary swagger.SummaryActivity
+	data, err := json.Marshal(activity)
+	if err != nil {
+		return summary, err
+	}
+	err = json.Unmarshal(data, &summary)
+	return summary, err
+}
+
+// Apply a webhook event to the sink and checkpoint.
+//
+// This is intended to be called from an `app.WebhookEventHandler`, which runs every event in its own goroutine.
+// Events (and syncs) for the same athlete are applied one at a time.
+// Create and update events fetch the activity (1 request). Delete events remove it from the sink.
+// Athlete events are ignored.
+func (s *Syncer) HandleEvent(ctx context.Context, token *oauth2.Token, event app.StravaEvent) error {
+	if event.ObjectType != "activity" {
+		s.logger.DebugContext(ctx, "ignoring non-activity event", slog.String("object type", event.ObjectType))
+		return nil
+	}
+	defer s.lock(event.OwnerID)()
+	cp, err := s.checkpoints.Load(event.OwnerID)
+	if err != nil {
+		return err
+	}
+	s.logger.DebugContext(ctx, "handling event", slog.String("aspect type", event.AspectType), slog.Int("activity id", event.ObjectID))
+	report := &Report{}
+	switch event.AspectType {
+	case app.AspectTypeCreate, app.AspectTypeUpdate:
+		detailed, err := s.api.GetActivity(ctx, token, event.ObjectID, false)
+		if err != nil {
+			return err
+		}
+		activity, err := summaryFromDetailed(detailed)
+		if err != nil {
+			return err
+		}
+		// LastStart is deliberately left alone. older activities may not have been synced yet.
+		// the next incremental sync will list this activity again, but it won't be rewritten since it is unchanged.
+		err = s.put(ctx, cp, activity, report)
+		if err != nil {
+			return err
+		}
+	case app.AspectTypeDelete:
+		err := s.sink.Delete(ctx, event.OwnerID, event.ObjectID)
+		if err != nil {
+			return err
+		}
+		delete(cp.Known, event.ObjectID)
+	default:
+		s.logger.WarnContext(ctx, "unknown aspect type", slog.String("aspect type", event.AspectType))
+		return nil
+	}
+	return s.checkpoints.Save(cp)
+}
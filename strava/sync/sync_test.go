@@ -0,0 +1,231 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sort"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/app"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"golang.org/x/oauth2"
+)
+
+// fakeStrava serves activities from memory. pages are 2 activities long regardless of perPage.
+type fakeStrava struct {
+	activities []swagger.SummaryActivity
+	// fail the call with this number (1 indexed)
+	failOnCall int
+	calls      int
+}
+
+func (f *fakeStrava) GetActivitiesPage(ctx context.Context, token *oauth2.Token, page int, perPage int, before, after *time.Time) ([]swagger.SummaryActivity, error) {
+	f.calls++
+	if f.calls == f.failOnCall {
+		return nil, errors.New("interrupted")
+	}
+	matching := []swagger.SummaryActivity{}
+	for _, a := range f.activities {
+		if after == nil || a.StartDate.After(*after) {
+			matching = append(matching, a)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].StartDate.Before(matching[j].StartDate) })
+	const size = 2
+	start := (page - 1) * size
+	if start >= len(matching) {
+		return nil, nil
+	}
+	return matching[start:min(start+size, len(matching))], nil
+}
+
+func (f *fakeStrava) GetActivity(ctx context.Context, token *oauth2.Token, activityID int, includeAllEfforts bool) (*swagger.DetailedActivity, error) {
+	for _, a := range f.activities {
+		if int(a.Id) == activityID {
+			return &swagger.DetailedActivity{Id: a.Id, Name: a.Name, StartDate: a.StartDate}, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+type memoryCheckpoints map[int]Checkpoint
+
+func (m memoryCheckpoints) Load(athleteID int) (*Checkpoint, error) {
+	cp, ok := m[athleteID]
+	if !ok {
+		return NewCheckpoint(athleteID), nil
+	}
+	known := map[int]KnownActivity{}
+	for k, v := range cp.Known {
+		known[k] = v
+	}
+	cp.Known = known
+	return &cp, nil
+}
+
+func (m memoryCheckpoints) Save(cp *Checkpoint) error {
+	m[cp.AthleteID] = *cp
+	return nil
+}
+
+func testActivities(n int) []swagger.SummaryActivity {
+	base := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	l := []swagger.SummaryActivity{}
+	for i := 1; i <= n; i++ {
+		l = append(l, swagger.SummaryActivity{Id: int64(i), Name: "run", StartDate: base.AddDate(0, 0, i)})
+	}
+	return l
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	strava := &fakeStrava{activities: testActivities(5), failOnCall: 2}
+	checkpoints := memoryCheckpoints{}
+	sink := MemorySink{}
+	s := NewSyncer(strava, checkpoints, sink, nil)
+
+	// the first run is interrupted after the first page
+	_, err := s.Sync(context.Background(), nil, 1)
+	if err == nil {
+		t.Fatalf("Sync() expected an error")
+	}
+	if len(sink) != 2 || len(checkpoints[1].Known) != 2 {
+		t.Fatalf("after interruption sink = %d, known = %d, want 2, 2", len(sink), len(checkpoints[1].Known))
+	}
+
+	// the second run resumes
+	report, err := s.Sync(context.Background(), nil, 1)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Created != 3 || len(sink) != 5 {
+		t.Errorf("Sync() created = %d, sink = %d, want 3, 5", report.Created, len(sink))
+	}
+
+	// nothing new
+	report, err = s.Sync(context.Background(), nil, 1)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Created != 0 {
+		t.Errorf("Sync() created = %d, want 0", report.Created)
+	}
+}
+
+// ignoresAfter always returns the same page, as if the `after` filter was not applied
+type ignoresAfter struct {
+	fakeStrava
+}
+
+func (f *ignoresAfter) GetActivitiesPage(ctx context.Context, token *oauth2.Token, page int, perPage int, before, after *time.Time) ([]swagger.SummaryActivity, error) {
+	if f.calls >= 10 {
+		return nil, errors.New("the same page was requested forever")
+	}
+	return f.fakeStrava.GetActivitiesPage(ctx, token, page, perPage, before, nil)
+}
+
+func TestSyncer_Sync_noProgress(t *testing.T) {
+	strava := &ignoresAfter{fakeStrava{activities: testActivities(2)}}
+	sink := MemorySink{}
+	s := NewSyncer(strava, memoryCheckpoints{}, sink, nil)
+	report, err := s.Sync(context.Background(), nil, 1)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Created != 2 || len(sink) != 2 {
+		t.Errorf("Sync() created = %d, sink = %d, want 2, 2", report.Created, len(sink))
+	}
+	if strava.calls != 2 {
+		t.Errorf("Sync() made %d calls, want 2", strava.calls)
+	}
+}
+
+func TestSyncer_FullScan(t *testing.T) {
+	strava := &fakeStrava{activities: testActivities(4)}
+	checkpoints := memoryCheckpoints{}
+	sink := MemorySink{}
+	s := NewSyncer(strava, checkpoints, sink, nil)
+	if _, err := s.Sync(context.Background(), nil, 1); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	// activity 2 is deleted and activity 3 is renamed on strava
+	strava.activities = append(strava.activities[:1], strava.activities[2:]...)
+	strava.activities[1].Name = "renamed"
+
+	report, err := s.FullScan(context.Background(), nil, 1)
+	if err != nil {
+		t.Fatalf("FullScan() error = %v", err)
+	}
+	if report.Deleted != 1 || report.Updated != 1 || report.Created != 0 {
+		t.Errorf("FullScan() = %+v, want 1 deleted, 1 updated", report)
+	}
+	if _, ok := sink[2]; ok {
+		t.Errorf("FullScan() did not delete activity 2 from the sink")
+	}
+	if sink[3].Name != "renamed" {
+		t.Errorf("FullScan() did not update activity 3: %v", sink[3].Name)
+	}
+}
+
+func TestSyncer_HandleEvent(t *testing.T) {
+	strava := &fakeStrava{activities: testActivities(2)}
+	checkpoints := memoryCheckpoints{}
+	sink := MemorySink{}
+	s := NewSyncer(strava, checkpoints, sink, nil)
+	ctx := context.Background()
+
+	err := s.HandleEvent(ctx, nil, app.StravaEvent{ObjectType: "activity", ObjectID: 2, AspectType: app.AspectTypeCreate, OwnerID: 1})
+	if err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+	if _, ok := sink[2]; !ok {
+		t.Errorf("HandleEvent() did not write the created activity")
+	}
+	// the activity was already synced by the event, so an incremental sync should only write the other one
+	report, err := s.Sync(ctx, nil, 1)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if report.Created != 1 || report.Updated != 0 {
+		t.Errorf("Sync() = %+v after event, want 1 created", report)
+	}
+	err = s.HandleEvent(ctx, nil, app.StravaEvent{ObjectType: "activity", ObjectID: 2, AspectType: app.AspectTypeDelete, OwnerID: 1})
+	if err != nil {
+		t.Fatalf("HandleEvent() error = %v", err)
+	}
+	if _, ok := sink[2]; ok {
+		t.Errorf("HandleEvent() did not delete the activity")
+	}
+	if _, ok := checkpoints[1].Known[2]; ok {
+		t.Errorf("HandleEvent() did not remove the activity from the checkpoint")
+	}
+}
+
+func TestSyncer_HandleEvent_concurrent(t *testing.T) {
+	strava := &fakeStrava{activities: testActivities(20)}
+	checkpoints := NewFileCheckpointStore(t.TempDir())
+	sink := MemorySink{}
+	s := NewSyncer(strava, checkpoints, sink, nil)
+
+	// the webhook handler runs every event in its own goroutine
+	var wg gosync.WaitGroup
+	for _, a := range strava.activities {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.HandleEvent(context.Background(), nil, app.StravaEvent{ObjectType: "activity", ObjectID: int(a.Id), AspectType: app.AspectTypeCreate, OwnerID: 1})
+			if err != nil {
+				t.Errorf("HandleEvent() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	cp, err := checkpoints.Load(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.Known) != 20 || len(sink) != 20 {
+		t.Errorf("after 20 concurrent events the checkpoint knows %d activities and the sink has %d, want 20", len(cp.Known), len(sink))
+	}
+}
@@ -0,0 +1,191 @@
+// Package streamset builds the streams that strava's `GetActivityStreams` returns out of the samples of an activity file.
+//
+// Every file decoder (fit, gpx, tcx, ...) produces a list of `Point`s, so activity files from any source end up in the same shape as strava streams.
+package streamset
+
+import (
+	"math"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+// The mean radius of the earth, used for distances between gps points
+const EarthRadiusMeters = 6371000.0
+
+// the speed (in meters per second) below which an athlete is considered to be stopped
+const MovingThreshold = 0.5
+
+// A Point is a single sample of an activity file. Values that the sample does not have are nil.
+type Point struct {
+	Time time.Time
+	// latitude, longitude in degrees
+	LatLng swagger.LatLng
+	// meters
+	Altitude *float32
+	// cumulative, in meters
+	Distance *float32
+	// meters per second
+	Speed *float32
+	// beats per minute
+	Heartrate *int32
+	// rotations (or steps) per minute
+	Cadence *int32
+	Watts   *int32
+	// celsius
+	Temp *int32
+}
+
+// The distance between two points in meters
+func Haversine(a, b swagger.LatLng) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return math.Inf(1)
+	}
+	toRad := func(deg float32) float64 { return float64(deg) * math.Pi / 180 }
+	lat1, lat2 := toRad(a[0]), toRad(b[0])
+	dLat := lat2 - lat1
+	dLng := toRad(b[1]) - toRad(a[1])
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusMeters * math.Asin(math.Sqrt(h))
+}
+
+// Build the streams of a list of points, ordered by time.
+//
+// A stream is only included if at least one point has a value for it. Points that are missing a value get 0,
+// except for positions, which carry the last known position forward so that every stream lines up with the time stream.
+// If the points have no distance, the distance stream is derived from the positions (or failing that, the speed).
+// The moving stream is always derived, since no file format records it.
+func Build(points []Point) *swagger.StreamSet {
+	n := int32(len(points))
+	set := &swagger.StreamSet{}
+	if n == 0 {
+		return set
+	}
+	var has struct{ latlng, altitude, distance, speed, hr, cadence, watts, temp bool }
+	for _, p := range points {
+		has.latlng = has.latlng || p.LatLng != nil
+		has.altitude = has.altitude || p.Altitude != nil
+		has.distance = has.distance || p.Distance != nil
+		has.speed = has.speed || p.Speed != nil
+		has.hr = has.hr || p.Heartrate != nil
+		has.cadence = has.cadence || p.Cadence != nil
+		has.watts = has.watts || p.Watts != nil
+		has.temp = has.temp || p.Temp != nil
+	}
+	start := points[0].Time
+	set.Time = &swagger.TimeStream{OriginalSize: n, Resolution: "high", SeriesType: "time"}
+	for _, p := range points {
+		set.Time.Data = append(set.Time.Data, int32(p.Time.Sub(start).Seconds()))
+	}
+	if has.latlng {
+		set.Latlng = &swagger.LatLngStream{OriginalSize: n, Resolution: "high", SeriesType: "time"}
+		var last swagger.LatLng
+		for _, p := range points {
+			if p.LatLng != nil {
+				last = p.LatLng
+			}
+			set.Latlng.Data = append(set.Latlng.Data, last)
+		}
+	}
+	distance, hasDistance := distances(points, has.distance, has.latlng, has.speed)
+	if hasDistance {
+		set.Distance = &swagger.DistanceStream{OriginalSize: n, Resolution: "high", SeriesType: "time", Data: distance}
+		set.Moving = &swagger.MovingStream{OriginalSize: n, Resolution: "high", SeriesType: "time", Data: moving(points, distance)}
+	}
+	if has.altitude {
+		set.Altitude = &swagger.AltitudeStream{OriginalSize: n, Resolution: "high", SeriesType: "time", Data: float32s(points, func(p Point) *float32 { return p.Altitude })}
+	}
+	if has.speed {
+		set.VelocitySmooth = &swagger.SmoothVelocityStream{OriginalSize: n, Resolution: "high", SeriesType: "time", Data: float32s(points, func(p Point) *float32 { return p.Speed })}
+	}
+	if has.hr {
+		set.Heartrate = &swagger.HeartrateStream{OriginalSize: n, Resolution: "high", SeriesType: "time", Data: int32s(points, func(p Point) *int32 { return p.Heartrate })}
+	}
+	if has.cadence {
+		set.Cadence = &swagger.CadenceStream{OriginalSize: n, Resolution: "high", SeriesType: "time", Data: int32s(points, func(p Point) *int32 { return p.Cadence })}
+	}
+	if has.watts {
+		set.Watts = &swagger.PowerStream{OriginalSize: n, Resolution: "high", SeriesType: "time", Data: int32s(points, func(p Point) *int32 { return p.Watts })}
+	}
+	if has.temp {
+		set.Temp = &swagger.TemperatureStream{OriginalSize: n, Resolution: "high", SeriesType: "time", Data: int32s(points, func(p Point) *int32 { return p.Temp })}
+	}
+	return set
+}
+
+// the cumulative distance at each point. returns false if there is no way to know the distance
+func distances(points []Point, hasDistance, hasLatLng, hasSpeed bool) ([]float32, bool) {
+	if !hasDistance && !hasLatLng && !hasSpeed {
+		return nil, false
+	}
+	data := make([]float32, len(points))
+	var total float64
+	var last swagger.LatLng
+	for i, p := range points {
+		switch {
+		case hasDistance:
+			if p.Distance != nil {
+				total = float64(*p.Distance)
+			}
+		case hasLatLng:
+			if p.LatLng != nil {
+				if last != nil {
+					total += Haversine(last, p.LatLng)
+				}
+				last = p.LatLng
+			}
+		default:
+			// integrate the speed (e.g. a treadmill or trainer with a speed sensor)
+			if i > 0 && p.Speed != nil {
+				total += float64(*p.Speed) * p.Time.Sub(points[i-1].Time).Seconds()
+			}
+		}
+		data[i] = float32(total)
+	}
+	return data, true
+}
+
+// whether the athlete was moving at each point. uses the recorded speed if there is one, otherwise the change in distance
+func moving(points []Point, distance []float32) []bool {
+	data := make([]bool, len(points))
+	for i, p := range points {
+		if p.Speed != nil {
+			data[i] = *p.Speed >= MovingThreshold
+			continue
+		}
+		if i == 0 {
+			continue
+		}
+		dt := p.Time.Sub(points[i-1].Time).Seconds()
+		if dt <= 0 {
+			data[i] = data[i-1]
+			continue
+		}
+		data[i] = float64(distance[i]-distance[i-1])/dt >= MovingThreshold
+	}
+	// the first point has nothing to compare to, so it takes after the second
+	if len(data) > 1 && points[0].Speed == nil {
+		data[0] = data[1]
+	}
+	return data
+}
+
+func float32s(points []Point, value func(Point) *float32) []float32 {
+	data := make([]float32, len(points))
+	for i, p := range points {
+		if v := value(p); v != nil {
+			data[i] = *v
+		}
+	}
+	return data
+}
+
+func int32s(points []Point, value func(Point) *int32) []int32 {
+	data := make([]int32, len(points))
+	for i, p := range points {
+		if v := value(p); v != nil {
+			data[i] = *v
+		}
+	}
+	return data
+}
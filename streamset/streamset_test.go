@@ -0,0 +1,79 @@
+package streamset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+)
+
+func f32(v float32) *float32 { return &v }
+
+func TestBuild(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+	tests := []struct {
+		name         string
+		points       []Point
+		wantDistance []float32
+		wantMoving   []bool
+	}{
+		{
+			name: "recorded distance",
+			points: []Point{
+				{Time: at(0), Distance: f32(0)},
+				{Time: at(10), Distance: f32(30)},
+				{Time: at(20), Distance: f32(30)}, // stopped
+				{Time: at(30), Distance: f32(60)},
+			},
+			wantDistance: []float32{0, 30, 30, 60},
+			wantMoving:   []bool{true, true, false, true},
+		},
+		{
+			name: "distance from positions",
+			points: []Point{
+				{Time: at(0), LatLng: swagger.LatLng{40, -105}},
+				{Time: at(30), LatLng: swagger.LatLng{40.001, -105}},
+				{Time: at(60)}, // a gap in the positions
+				{Time: at(90), LatLng: swagger.LatLng{40.002, -105}},
+			},
+			wantDistance: []float32{0, 111.19, 111.19, 222.39},
+			wantMoving:   []bool{true, true, false, true},
+		},
+		{
+			name: "distance from speed",
+			points: []Point{
+				{Time: at(0), Speed: f32(2)},
+				{Time: at(10), Speed: f32(3)},
+				{Time: at(20), Speed: f32(0)},
+			},
+			wantDistance: []float32{0, 30, 30},
+			wantMoving:   []bool{true, true, false},
+		},
+		{
+			name:   "no distance",
+			points: []Point{{Time: at(0)}, {Time: at(1)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := Build(tt.points)
+			if tt.wantDistance == nil {
+				if set.Distance != nil || set.Moving != nil {
+					t.Errorf("Build() should not have distance or moving streams")
+				}
+				return
+			}
+			for i, want := range tt.wantDistance {
+				if got := set.Distance.Data[i]; got < want-0.5 || got > want+0.5 {
+					t.Errorf("distance[%d] = %v, want %v", i, got, want)
+				}
+			}
+			for i, want := range tt.wantMoving {
+				if got := set.Moving.Data[i]; got != want {
+					t.Errorf("moving[%d] = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Package tcx decodes garmin Training Center (TCX) files.
+//
+// Watts and run cadence are read from the garmin ActivityExtension (TPX on trackpoints, LX on laps).
+// XML namespaces are ignored, so the extension is found whatever prefix the file gives it.
+package tcx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/streamset"
+)
+
+// A File is a decoded tcx file
+type File struct {
+	Activities []Activity
+}
+
+// An Activity is a single recorded activity. Most files have exactly one.
+type Activity struct {
+	// "Running", "Biking" or "Other"
+	Sport string
+	// the start time of the activity, as written by the device
+	ID   string
+	Laps []Lap
+}
+
+// A Lap is a summary of part of an activity, along with its trackpoints. Values that were not recorded are 0.
+type Lap struct {
+	StartTime time.Time
+	// seconds
+	TotalTimeSeconds float64
+	// meters
+	DistanceMeters float64
+	// meters per second
+	MaximumSpeed     float64
+	Calories         int
+	AverageHeartRate int
+	MaximumHeartRate int
+	Cadence          int
+	// "Manual", "Distance", "Location", "Time" or "HeartRate"
+	TriggerMethod string
+	// from the activity extension
+	AvgSpeed    float64
+	AvgWatts    int
+	MaxWatts    int
+	Trackpoints []Trackpoint
+}
+
+// A Trackpoint is a single sample. Values that were not recorded are nil.
+type Trackpoint struct {
+	Time time.Time
+	// degrees
+	Lat *float64
+	Lon *float64
+	// meters
+	Altitude *float64
+	// cumulative, in meters
+	Distance *float64
+	// beats per minute
+	HeartRate *int
+	// rotations (or steps) per minute. run cadence from the extension is used when there is no cadence
+	Cadence *int
+	// meters per second
+	Speed *float64
+	Watts *int
+}
+
+type tcxXML struct {
+	Activities []struct {
+		Sport string `xml:"Sport,attr"`
+		ID    string `xml:"Id"`
+		Laps  []struct {
+			StartTime        string          `xml:"StartTime,attr"`
+			TotalTimeSeconds float64         `xml:"TotalTimeSeconds"`
+			DistanceMeters   float64         `xml:"DistanceMeters"`
+			MaximumSpeed     float64         `xml:"MaximumSpeed"`
+			Calories         int             `xml:"Calories"`
+			AverageHeartRate int             `xml:"AverageHeartRateBpm>Value"`
+			MaximumHeartRate int             `xml:"MaximumHeartRateBpm>Value"`
+			Cadence          int             `xml:"Cadence"`
+			TriggerMethod    string          `xml:"TriggerMethod"`
+			Trackpoints      []trackpointXML `xml:"Track>Trackpoint"`
+			Extensions       struct {
+				AvgSpeed float64 `xml:"LX>AvgSpeed"`
+				AvgWatts int     `xml:"LX>AvgWatts"`
+				MaxWatts int     `xml:"LX>MaxWatts"`
+			} `xml:"Extensions"`
+		} `xml:"Lap"`
+	} `xml:"Activities>Activity"`
+}
+
+type trackpointXML struct {
+	Time     string `xml:"Time"`
+	Position *struct {
+		Lat float64 `xml:"LatitudeDegrees"`
+		Lon float64 `xml:"LongitudeDegrees"`
+	} `xml:"Position"`
+	Altitude   *float64 `xml:"AltitudeMeters"`
+	Distance   *float64 `xml:"DistanceMeters"`
+	HeartRate  *int     `xml:"HeartRateBpm>Value"`
+	Cadence    *int     `xml:"Cadence"`
+	Extensions struct {
+		Speed      *float64 `xml:"TPX>Speed"`
+		Watts      *int     `xml:"TPX>Watts"`
+		RunCadence *int     `xml:"TPX>RunCadence"`
+	} `xml:"Extensions"`
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339, strings.TrimSpace(s))
+}
+
+// Decode a tcx file. Trackpoints without a valid time are skipped, since they cannot be placed in a stream.
+func Decode(r io.Reader) (*File, error) {
+	var x tcxXML
+	err := xml.NewDecoder(r).Decode(&x)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tcx: %w", err)
+	}
+	f := &File{}
+	for _, ax := range x.Activities {
+		activity := Activity{Sport: ax.Sport, ID: strings.TrimSpace(ax.ID)}
+		for _, lx := range ax.Laps {
+			lap := Lap{
+				TotalTimeSeconds: lx.TotalTimeSeconds,
+				DistanceMeters:   lx.DistanceMeters,
+				MaximumSpeed:     lx.MaximumSpeed,
+				Calories:         lx.Calories,
+				AverageHeartRate: lx.AverageHeartRate,
+				MaximumHeartRate: lx.MaximumHeartRate,
+				Cadence:          lx.Cadence,
+				TriggerMethod:    strings.TrimSpace(lx.TriggerMethod),
+				AvgSpeed:         lx.Extensions.AvgSpeed,
+				AvgWatts:         lx.Extensions.AvgWatts,
+				MaxWatts:         lx.Extensions.MaxWatts,
+			}
+			lap.StartTime, _ = parseTime(lx.StartTime)
+			for _, tx := range lx.Trackpoints {
+				t, err := parseTime(tx.Time)
+				if err != nil {
+					continue
+				}
+				tp := Trackpoint{
+					Time:      t,
+					Altitude:  tx.Altitude,
+					Distance:  tx.Distance,
+					HeartRate: tx.HeartRate,
+					Cadence:   tx.Cadence,
+					Speed:     tx.Extensions.Speed,
+					Watts:     tx.Extensions.Watts,
+				}
+				if tx.Position != nil {
+					tp.Lat, tp.Lon = &tx.Position.Lat, &tx.Position.Lon
+				}
+				if tp.Cadence == nil {
+					tp.Cadence = tx.Extensions.RunCadence
+				}
+				lap.Trackpoints = append(lap.Trackpoints, tp)
+			}
+			activity.Laps = append(activity.Laps, lap)
+		}
+		f.Activities = append(f.Activities, activity)
+	}
+	return f, nil
+}
+
+// Convert every trackpoint (of every activity and lap, in order) into stream points
+func (f *File) Points() []streamset.Point {
+	points := []streamset.Point{}
+	for _, a := range f.Activities {
+		for _, l := range a.Laps {
+			for _, tp := range l.Trackpoints {
+				p := streamset.Point{Time: tp.Time}
+				if tp.Lat != nil && tp.Lon != nil {
+					p.LatLng = swagger.LatLng{float32(*tp.Lat), float32(*tp.Lon)}
+				}
+				if tp.Altitude != nil {
+					v := float32(*tp.Altitude)
+					p.Altitude = &v
+				}
+				if tp.Distance != nil {
+					v := float32(*tp.Distance)
+					p.Distance = &v
+				}
+				if tp.Speed != nil {
+					v := float32(*tp.Speed)
+					p.Speed = &v
+				}
+				if tp.HeartRate != nil {
+					v := int32(*tp.HeartRate)
+					p.Heartrate = &v
+				}
+				if tp.Cadence != nil {
+					v := int32(*tp.Cadence)
+					p.Cadence = &v
+				}
+				if tp.Watts != nil {
+					v := int32(*tp.Watts)
+					p.Watts = &v
+				}
+				points = append(points, p)
+			}
+		}
+	}
+	return points
+}
+
+// Convert the file into the streams that strava's `GetActivityStreams` returns. Distance (if absent) and moving are derived.
+func (f *File) StreamSet() *swagger.StreamSet {
+	return streamset.Build(f.Points())
+}
+
+// Convert the laps into strava's lap model.
+//
+// The start and end index of each lap point into the trackpoints (and so into the streams from `StreamSet`).
+func (f *File) StravaLaps() []swagger.Lap {
+	laps := []swagger.Lap{}
+	index := 0
+	for _, a := range f.Activities {
+		for _, l := range a.Laps {
+			lap := swagger.Lap{
+				Name:           fmt.Sprintf("Lap %d", len(laps)+1),
+				LapIndex:       int32(len(laps) + 1),
+				StartDate:      l.StartTime,
+				ElapsedTime:    int32(math.Round(l.TotalTimeSeconds)),
+				MovingTime:     int32(math.Round(l.TotalTimeSeconds)),
+				Distance:       float32(l.DistanceMeters),
+				AverageSpeed:   float32(l.AvgSpeed),
+				MaxSpeed:       float32(l.MaximumSpeed),
+				AverageCadence: float32(l.Cadence),
+				StartIndex:     int32(index),
+				EndIndex:       int32(index),
+			}
+			if lap.AverageSpeed == 0 && l.TotalTimeSeconds > 0 {
+				lap.AverageSpeed = float32(l.DistanceMeters / l.TotalTimeSeconds)
+			}
+			if len(l.Trackpoints) > 0 {
+				lap.EndIndex = int32(index + len(l.Trackpoints) - 1)
+			}
+			index += len(l.Trackpoints)
+			laps = append(laps, lap)
+		}
+	}
+	return laps
+}
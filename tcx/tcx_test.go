@@ -0,0 +1,106 @@
+package tcx
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"
+  xmlns:ns3="http://www.garmin.com/xmlschemas/ActivityExtension/v2">
+  <Activities>
+    <Activity Sport="Biking">
+      <Id>2024-05-01T12:00:00Z</Id>
+      <Lap StartTime="2024-05-01T12:00:00Z">
+        <TotalTimeSeconds>10.0</TotalTimeSeconds>
+        <DistanceMeters>80.0</DistanceMeters>
+        <MaximumSpeed>9.0</MaximumSpeed>
+        <Calories>5</Calories>
+        <AverageHeartRateBpm><Value>130</Value></AverageHeartRateBpm>
+        <MaximumHeartRateBpm><Value>135</Value></MaximumHeartRateBpm>
+        <Cadence>88</Cadence>
+        <TriggerMethod>Manual</TriggerMethod>
+        <Track>
+          <Trackpoint>
+            <Time>2024-05-01T12:00:00Z</Time>
+            <Position><LatitudeDegrees>40.0</LatitudeDegrees><LongitudeDegrees>-105.0</LongitudeDegrees></Position>
+            <AltitudeMeters>1600.0</AltitudeMeters>
+            <DistanceMeters>0.0</DistanceMeters>
+            <HeartRateBpm><Value>128</Value></HeartRateBpm>
+            <Cadence>87</Cadence>
+            <Extensions><ns3:TPX><ns3:Speed>8.0</ns3:Speed><ns3:Watts>210</ns3:Watts></ns3:TPX></Extensions>
+          </Trackpoint>
+          <Trackpoint>
+            <Time>2024-05-01T12:00:10Z</Time>
+            <DistanceMeters>80.0</DistanceMeters>
+            <HeartRateBpm><Value>135</Value></HeartRateBpm>
+            <Extensions><ns3:TPX><ns3:Watts>230</ns3:Watts></ns3:TPX></Extensions>
+          </Trackpoint>
+        </Track>
+        <Extensions><ns3:LX><ns3:AvgSpeed>8.0</ns3:AvgSpeed><ns3:AvgWatts>220</ns3:AvgWatts><ns3:MaxWatts>230</ns3:MaxWatts></ns3:LX></Extensions>
+      </Lap>
+      <Lap StartTime="2024-05-01T12:00:10Z">
+        <TotalTimeSeconds>5.0</TotalTimeSeconds>
+        <DistanceMeters>20.0</DistanceMeters>
+        <Track>
+          <Trackpoint>
+            <Time>2024-05-01T12:00:15Z</Time>
+            <DistanceMeters>100.0</DistanceMeters>
+            <Extensions><ns3:TPX><ns3:RunCadence>90</ns3:RunCadence></ns3:TPX></Extensions>
+          </Trackpoint>
+        </Track>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`
+
+func TestDecode(t *testing.T) {
+	f, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(f.Activities) != 1 || f.Activities[0].Sport != "Biking" || len(f.Activities[0].Laps) != 2 {
+		t.Fatalf("Decode() = %+v", f)
+	}
+	lap := f.Activities[0].Laps[0]
+	if lap.AverageHeartRate != 130 || lap.Cadence != 88 || lap.AvgWatts != 220 || lap.MaxWatts != 230 || lap.TriggerMethod != "Manual" {
+		t.Errorf("lap 0 = %+v", lap)
+	}
+	tp := lap.Trackpoints[0]
+	if *tp.Lat != 40 || *tp.Speed != 8 || *tp.Watts != 210 || *tp.Cadence != 87 {
+		t.Errorf("trackpoint 0 = %+v", tp)
+	}
+	if lap.Trackpoints[1].Lat != nil {
+		t.Errorf("trackpoint 1 has a position, want none")
+	}
+	// run cadence fills in for a missing cadence
+	if c := f.Activities[0].Laps[1].Trackpoints[0].Cadence; c == nil || *c != 90 {
+		t.Errorf("lap 1 trackpoint cadence = %v, want 90", c)
+	}
+}
+
+func TestStreamSetAndLaps(t *testing.T) {
+	f, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := f.StreamSet()
+	if len(set.Time.Data) != 3 || set.Distance.Data[2] != 100 || set.Watts.Data[1] != 230 || set.Moving == nil {
+		t.Errorf("StreamSet() = %+v", set)
+	}
+	// the position is carried forward
+	if set.Latlng.Data[1][0] != 40 {
+		t.Errorf("latlng = %v", set.Latlng.Data)
+	}
+	laps := f.StravaLaps()
+	if len(laps) != 2 {
+		t.Fatalf("StravaLaps() = %d laps, want 2", len(laps))
+	}
+	if laps[0].StartIndex != 0 || laps[0].EndIndex != 1 || laps[1].StartIndex != 2 || laps[1].EndIndex != 2 {
+		t.Errorf("lap indexes = %d-%d, %d-%d", laps[0].StartIndex, laps[0].EndIndex, laps[1].StartIndex, laps[1].EndIndex)
+	}
+	// the second lap has no extension, so its average speed is computed
+	if laps[0].AverageSpeed != 8 || laps[1].AverageSpeed != 4 || laps[1].Name != "Lap 2" {
+		t.Errorf("laps = %+v", laps)
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/plan"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/trainingPeaks/client"
+	"github.com/jcocozza/ratelimit"
+	"golang.org/x/oauth2"
+)
+
+// if the trainingpeaks api returns 404 not found, will throw this error
+var NotFoundError = errors.New("Object not found")
+
+// if the rate limiter throws an error
+var RateLimitError = errors.New("Rate Limit Error. (this likely means context expired while waiting for rate limits to be reset)")
+
+const (
+	// trainingpeaks does not publish its limits to partners ahead of time, so these are conservative
+	ReadLimitMinute         = 60.0
+	ReadLimitMinuteDuration = time.Duration(time.Minute)
+	ReadLimitDaily          = 2000.0
+	ReadLimitDailyDuration  = time.Duration(24 * time.Hour)
+)
+
+// the most days trainingpeaks will list at once
+const MaxRangeDays = 90
+
+// The TrainingPeaksAPI struct is the primary means of interacting with the trainingpeaks api.
+//
+// It wraps the lower level `client` with token refreshing and rate limiting. Planned workouts are mapped into `plan.PlannedWorkout`
+// and completed workouts into the strava models.
+//
+// # Whenever possible, this will return the NotFoundError when the underlying trainingpeaks api returns a 404
+//
+// Make sure that every context has a timeout, otherwise the program will block until the rate limits refreshes.
+type TrainingPeaksAPI struct {
+	baseURL       string
+	logger        *slog.Logger
+	oauth         *oauth2.Config
+	limiterMinute *ratelimit.FixedWindow
+	limiterDaily  *ratelimit.FixedWindow
+}
+
+func NewTrainingPeaksAPI(baseURL string, cfg *oauth2.Config, logger *slog.Logger) *TrainingPeaksAPI {
+	return &TrainingPeaksAPI{
+		baseURL:       baseURL,
+		logger:        logger,
+		oauth:         cfg,
+		limiterMinute: ratelimit.NewFixedWindow(ReadLimitMinuteDuration, ReadLimitMinute),
+		limiterDaily:  ratelimit.NewFixedWindow(ReadLimitDailyDuration, ReadLimitDaily),
+	}
+}
+
+// check to see if the limits have been surpassed
+//
+// if you have exceeded the rate limit, will sleep until the next time interval
+//
+// ** should be called before every api call **
+func (api *TrainingPeaksAPI) checkRateLimits(ctx context.Context) error {
+	err := api.limiterDaily.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed daily rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	err = api.limiterMinute.WaitRequest(ctx)
+	if err != nil {
+		api.logger.ErrorContext(ctx, "failed minute rate limits", slog.String("error", err.Error()))
+		return RateLimitError
+	}
+	return nil
+}
+
+// Return the number of requests remaining in the minute and daily windows respectively
+func (api *TrainingPeaksAPI) RemainingRequests() (int, int) {
+	return api.limiterMinute.RequestsRemaining(), api.limiterDaily.RequestsRemaining()
+}
+
+// a client that authenticates with the token (refreshing it when it expires)
+func (api *TrainingPeaksAPI) client(ctx context.Context, token *oauth2.Token) *client.Client {
+	return client.New(api.baseURL, oauth2.NewClient(ctx, api.oauth.TokenSource(ctx, token)))
+}
+
+// map the client's errors onto this package's
+func (api *TrainingPeaksAPI) wrap(ctx context.Context, err error) error {
+	if errors.Is(err, client.NotFoundError) {
+		api.logger.DebugContext(ctx, "object not found")
+		return NotFoundError
+	}
+	api.logger.ErrorContext(ctx, "request failed", slog.String("error", err.Error()))
+	return err
+}
+
+// Get every workout (planned and completed) on the athlete's calendar from the day of `start` to the day of `end` (both included).
+//
+// Ranges longer than `MaxRangeDays` are split into several requests. Workouts are in calendar order.
+func (api *TrainingPeaksAPI) GetWorkouts(ctx context.Context, token *oauth2.Token, start, end time.Time) ([]client.Workout, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	if end.Before(start) {
+		return []client.Workout{}, nil
+	}
+	all := []client.Workout{}
+	for from := start; !from.After(end); from = from.AddDate(0, 0, MaxRangeDays) {
+		to := from.AddDate(0, 0, MaxRangeDays-1)
+		if to.After(end) {
+			to = end
+		}
+		err := api.checkRateLimits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		api.logger.DebugContext(ctx, "listing workouts", slog.String("start", from.Format(client.DateLayout)), slog.String("end", to.Format(client.DateLayout)))
+		workouts, err := api.client(ctx, token).ListWorkouts(ctx, from, to)
+		if err != nil {
+			return nil, api.wrap(ctx, err)
+		}
+		all = append(all, workouts...)
+	}
+	slices.SortStableFunc(all, func(a, b client.Workout) int {
+		return a.Start().Compare(b.Start())
+	})
+	return all, nil
+}
+
+// Get a single workout as trainingpeaks returns it
+func (api *TrainingPeaksAPI) GetWorkout(ctx context.Context, token *oauth2.Token, workoutID int64) (*client.Workout, error) {
+	err := api.checkRateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	api.logger.DebugContext(ctx, "getting workout", slog.Int64("workout id", workoutID))
+	workout, err := api.client(ctx, token).GetWorkout(ctx, workoutID)
+	if err != nil {
+		return nil, api.wrap(ctx, err)
+	}
+	return workout, nil
+}
+
+// Get the planned workouts between the days of `start` and `end` (both included).
+//
+// Workouts that were completed without a plan are left out.
+func (api *TrainingPeaksAPI) GetPlannedWorkouts(ctx context.Context, token *oauth2.Token, start, end time.Time) ([]plan.PlannedWorkout, error) {
+	workouts, err := api.GetWorkouts(ctx, token, start, end)
+	if err != nil {
+		return nil, err
+	}
+	planned := []plan.PlannedWorkout{}
+	for _, w := range workouts {
+		if w.IsPlanned() {
+			planned = append(planned, PlannedWorkout(w))
+		}
+	}
+	return planned, nil
+}
+
+// Get a single planned workout
+func (api *TrainingPeaksAPI) GetPlannedWorkout(ctx context.Context, token *oauth2.Token, workoutID int64) (*plan.PlannedWorkout, error) {
+	workout, err := api.GetWorkout(ctx, token, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	planned := PlannedWorkout(*workout)
+	return &planned, nil
+}
+
+// Get the completed workouts between the days of `start` and `end` (both included) as strava summaries, in calendar order.
+//
+// trainingpeaks times have no zone, so `StartDate` is the athlete's local time as if it were utc.
+func (api *TrainingPeaksAPI) GetCompletedWorkouts(ctx context.Context, token *oauth2.Token, start, end time.Time) ([]swagger.SummaryActivity, error) {
+	workouts, err := api.GetWorkouts(ctx, token, start, end)
+	if err != nil {
+		return nil, err
+	}
+	completed := []swagger.SummaryActivity{}
+	for _, w := range workouts {
+		if w.IsCompleted() {
+			completed = append(completed, toSummary(w))
+		}
+	}
+	return completed, nil
+}
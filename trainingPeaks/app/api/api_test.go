@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/fakeoauth"
+	"github.com/jcocozza/cassidy-connector/plan"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/trainingPeaks/client"
+	"github.com/jcocozza/cassidy-connector/trainingPeaks/fake"
+	"golang.org/x/oauth2"
+)
+
+// start a demo server and go through the oauth flow
+func setup(t *testing.T) (*fake.Server, *TrainingPeaksAPI, *oauth2.Token) {
+	t.Helper()
+	srv := fake.NewDemoServer("id", "secret")
+	tc := fakeoauth.NewTestConfig(t, srv, oauth2.Endpoint{AuthURL: "/oauth/authorize", TokenURL: "/oauth/token"})
+	return srv, NewTrainingPeaksAPI(tc.URL, tc.Config, slog.New(slog.NewTextHandler(io.Discard, nil))), tc.Token
+}
+
+func day(s string) time.Time {
+	d, _ := time.Parse(client.DateLayout, s)
+	return d
+}
+
+func TestOAuth(t *testing.T) {
+	srv := fake.NewDemoServer("id", "secret")
+	tc := fakeoauth.NewTestConfig(t, srv, oauth2.Endpoint{AuthURL: "/oauth/authorize", TokenURL: "/oauth/token"})
+	api := NewTrainingPeaksAPI(tc.URL, tc.Config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tc.CheckRefresh(t, func(token *oauth2.Token) error {
+		_, err := api.GetWorkouts(context.Background(), token, day("2024-05-06"), day("2024-05-06"))
+		return err
+	})
+}
+
+func TestGetWorkouts(t *testing.T) {
+	_, api, token := setup(t)
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       []int64
+	}{
+		{"single day", day("2024-05-07"), day("2024-05-07"), []int64{fake.DemoRideID}},
+		{"week", day("2024-05-06"), day("2024-05-12"), []int64{fake.DemoSwimID, fake.DemoRideID, fake.DemoIntervalsID}},
+		// the fake refuses ranges over 90 days, like trainingpeaks
+		{"year", day("2024-01-01"), day("2024-12-31"), []int64{fake.DemoSwimID, fake.DemoRideID, fake.DemoIntervalsID}},
+		{"end before start", day("2024-05-12"), day("2024-05-06"), []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workouts, err := api.GetWorkouts(ctx, token, tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("GetWorkouts() error = %v", err)
+			}
+			got := []int64{}
+			for _, w := range workouts {
+				got = append(got, w.Id)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetWorkouts() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("GetWorkouts() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPlannedWorkouts(t *testing.T) {
+	_, api, token := setup(t)
+	ctx := context.Background()
+	planned, err := api.GetPlannedWorkouts(ctx, token, day("2024-05-06"), day("2024-05-12"))
+	if err != nil {
+		t.Fatalf("GetPlannedWorkouts() error = %v", err)
+	}
+	// the swim was not planned
+	if len(planned) != 2 {
+		t.Fatalf("GetPlannedWorkouts() = %d workouts, want 2", len(planned))
+	}
+	ride := planned[0]
+	if ride.Source != PlanSource || ride.Sport != swagger.RIDE_SportType || ride.PlannedDuration != 7200 || ride.PlannedDistance != 60000 || ride.PlannedLoad != 110 || ride.Structured() {
+		t.Errorf("ride = %+v", ride)
+	}
+	run, err := api.GetPlannedWorkout(ctx, token, fake.DemoIntervalsID)
+	if err != nil {
+		t.Fatalf("GetPlannedWorkout() error = %v", err)
+	}
+	if !run.Date.Equal(day("2024-05-08")) || run.Sport != swagger.RUN_SportType || run.PlannedDuration != 2700 {
+		t.Errorf("run = %+v", run)
+	}
+	if run.Description != "flat route\n\nhold back on the first rep" {
+		t.Errorf("run description = %q", run.Description)
+	}
+	if len(run.Steps) != 3 || run.Steps[1].Repeat != 5 || len(run.Steps[1].Steps) != 2 {
+		t.Fatalf("run steps = %+v", run.Steps)
+	}
+	threshold := run.Steps[1].Steps[0]
+	want := plan.Step{
+		Name:      "Threshold",
+		Intensity: plan.Active,
+		Duration:  plan.DurationTime,
+		Value:     180,
+		Target:    plan.Target{Type: plan.TargetPace, Low: 98, High: 102, OfThreshold: true},
+	}
+	if threshold.Name != want.Name || threshold.Intensity != want.Intensity || threshold.Duration != want.Duration || threshold.Value != want.Value || threshold.Target != want.Target {
+		t.Errorf("threshold step = %+v, want %+v", threshold, want)
+	}
+	if cooldown := run.Steps[2]; cooldown.Intensity != plan.Cooldown || cooldown.Duration != plan.DurationDistance || cooldown.Value != 1000 || cooldown.Target.Type != plan.TargetNone {
+		t.Errorf("cool down step = %+v", cooldown)
+	}
+	seconds, meters := run.StepTotals()
+	if seconds != 2250 || meters != 1000 {
+		t.Errorf("StepTotals() = %v, %v, want 2250, 1000", seconds, meters)
+	}
+	_, err = api.GetPlannedWorkout(ctx, token, 1)
+	if !errors.Is(err, NotFoundError) {
+		t.Errorf("GetPlannedWorkout(unknown) error = %v, want NotFoundError", err)
+	}
+}
+
+func TestGetCompletedWorkouts(t *testing.T) {
+	_, api, token := setup(t)
+	completed, err := api.GetCompletedWorkouts(context.Background(), token, day("2024-05-06"), day("2024-05-12"))
+	if err != nil {
+		t.Fatalf("GetCompletedWorkouts() error = %v", err)
+	}
+	// the run has not been done yet
+	if len(completed) != 2 {
+		t.Fatalf("GetCompletedWorkouts() = %d activities, want 2", len(completed))
+	}
+	ride := completed[1]
+	start, _ := time.Parse(time.RFC3339, "2024-05-07T07:30:00Z")
+	if ride.Id != fake.DemoRideID || *ride.SportType != swagger.RIDE_SportType || !ride.StartDate.Equal(start) {
+		t.Errorf("ride = %v %v %v", ride.Id, *ride.SportType, ride.StartDate)
+	}
+	if ride.MovingTime != 7380 || ride.Distance != 61200 || ride.TotalElevationGain != 540 || ride.AverageWatts != 185 || ride.WeightedAverageWatts != 198 {
+		t.Errorf("ride totals = %+v", ride)
+	}
+	if swim := completed[0]; *swim.SportType != swagger.SWIM_SportType || swim.MovingTime != 3600 || swim.DeviceWatts {
+		t.Errorf("swim = %+v", swim)
+	}
+}
+
+func TestStructureString(t *testing.T) {
+	data := `{"Id": 1, "WorkoutDay": "2024-05-08T00:00:00", "WorkoutType": "Bike", "Structure": "{\"primaryIntensityMetric\":\"percentOfFtp\",\"structure\":[{\"type\":\"repetition\",\"length\":{\"value\":3,\"unit\":\"repetition\"},\"steps\":[{\"name\":\"On\",\"length\":{\"value\":5,\"unit\":\"minute\"},\"targets\":[{\"minValue\":105,\"maxValue\":110}],\"intensityClass\":\"active\"},{\"name\":\"Off\",\"openDuration\":true,\"intensityClass\":\"rest\"}]}]}"}`
+	var w client.Workout
+	err := json.Unmarshal([]byte(data), &w)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	planned := PlannedWorkout(w)
+	if len(planned.Steps) != 1 || planned.Steps[0].Repeat != 3 {
+		t.Fatalf("steps = %+v", planned.Steps)
+	}
+	on, off := planned.Steps[0].Steps[0], planned.Steps[0].Steps[1]
+	if on.Value != 300 || on.Target != (plan.Target{Type: plan.TargetPower, Low: 105, High: 110, OfThreshold: true}) {
+		t.Errorf("on = %+v", on)
+	}
+	if off.Duration != plan.DurationOpen || off.Intensity != plan.Rest {
+		t.Errorf("off = %+v", off)
+	}
+	// a percent of max heart rate is not a percent of threshold heart rate
+	maxHr := strings.Replace(data, `percentOfFtp`, `percentOfMaxHr`, 1)
+	var maxHrWorkout client.Workout
+	err = json.Unmarshal([]byte(maxHr), &maxHrWorkout)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if on := PlannedWorkout(maxHrWorkout).Steps[0].Steps[0]; on.Target != (plan.Target{Type: plan.TargetHeartRate, Low: 105, High: 110, OfMax: true}) {
+		t.Errorf("on (max hr) = %+v", on)
+	}
+
+	var empty client.Workout
+	err = json.Unmarshal([]byte(`{"Id": 2, "Structure": ""}`), &empty)
+	if err != nil || empty.Structure == nil || len(PlannedWorkout(empty).Steps) != 0 {
+		t.Errorf("empty structure = %+v, %v", empty.Structure, err)
+	}
+}
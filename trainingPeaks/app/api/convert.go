@@ -0,0 +1,206 @@
+package api
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jcocozza/cassidy-connector/plan"
+	"github.com/jcocozza/cassidy-connector/strava/swagger"
+	"github.com/jcocozza/cassidy-connector/trainingPeaks/client"
+)
+
+// the source of planned workouts from trainingpeaks (see `plan.PlannedWorkout`)
+const PlanSource = "trainingpeaks"
+
+// map a trainingpeaks workout type onto a strava sport type
+func sportType(workoutType string) swagger.SportType {
+	switch strings.ToLower(workoutType) {
+	case "run":
+		return swagger.RUN_SportType
+	case "bike":
+		return swagger.RIDE_SportType
+	case "mtb":
+		return swagger.MOUNTAIN_BIKE_RIDE_SportType
+	case "swim":
+		return swagger.SWIM_SportType
+	case "walk":
+		return swagger.WALK_SportType
+	case "rowing":
+		return swagger.ROWING_SportType
+	case "strength":
+		return swagger.WEIGHT_TRAINING_SportType
+	case "x-country ski":
+		return swagger.NORDIC_SKI_SportType
+	}
+	return swagger.WORKOUT_SportType
+}
+
+func value(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// the seconds in a trainingpeaks length unit. false if it is not a unit of time
+func unitSeconds(unit string) (float64, bool) {
+	switch unit {
+	case "second":
+		return 1, true
+	case "minute":
+		return 60, true
+	case "hour":
+		return 3600, true
+	}
+	return 0, false
+}
+
+// the meters in a trainingpeaks length unit. false if it is not a unit of distance
+func unitMeters(unit string) (float64, bool) {
+	switch unit {
+	case "meter":
+		return 1, true
+	case "kilometer":
+		return 1000, true
+	case "mile":
+		return 1609.344, true
+	case "yard":
+		return 0.9144, true
+	}
+	return 0, false
+}
+
+func intensity(class string) plan.Intensity {
+	switch class {
+	case "warmUp":
+		return plan.Warmup
+	case "active":
+		return plan.Active
+	case "rest":
+		return plan.Rest
+	case "recovery":
+		return plan.Recovery
+	case "coolDown":
+		return plan.Cooldown
+	}
+	return plan.Other
+}
+
+// map a trainingpeaks intensity metric onto a target (without its band): its type, and what it is relative to
+func metricTarget(metric string) plan.Target {
+	switch metric {
+	case "percentOfFtp":
+		return plan.Target{Type: plan.TargetPower, OfThreshold: true}
+	case "percentOfThresholdHr":
+		return plan.Target{Type: plan.TargetHeartRate, OfThreshold: true}
+	case "percentOfMaxHr":
+		return plan.Target{Type: plan.TargetHeartRate, OfMax: true}
+	case "percentOfThresholdPace":
+		return plan.Target{Type: plan.TargetPace, OfThreshold: true}
+	case "power":
+		return plan.Target{Type: plan.TargetPower}
+	case "heartRate":
+		return plan.Target{Type: plan.TargetHeartRate}
+	case "cadence":
+		return plan.Target{Type: plan.TargetCadence}
+	}
+	return plan.Target{Type: plan.TargetNone}
+}
+
+func planStep(s client.Step, metric string) plan.Step {
+	step := plan.Step{
+		Name:      s.Name,
+		Intensity: intensity(s.IntensityClass),
+		Target:    plan.Target{Type: plan.TargetNone},
+	}
+	if s.OpenDuration {
+		step.Duration = plan.DurationOpen
+	} else if f, ok := unitSeconds(s.Length.Unit); ok {
+		step.Duration = plan.DurationTime
+		step.Value = s.Length.Value * f
+	} else if f, ok := unitMeters(s.Length.Unit); ok {
+		step.Duration = plan.DurationDistance
+		step.Value = s.Length.Value * f
+	} else {
+		step.Duration = plan.DurationOpen
+	}
+	if len(s.Targets) > 0 {
+		if t := metricTarget(metric); t.Type != plan.TargetNone {
+			t.Low, t.High = s.Targets[0].MinValue, s.Targets[0].MaxValue
+			step.Target = t
+		}
+	}
+	return step
+}
+
+// map a trainingpeaks structure onto steps. a repetition block becomes a single repeat step
+func planSteps(s *client.Structure) []plan.Step {
+	if s == nil {
+		return nil
+	}
+	steps := []plan.Step{}
+	for _, block := range s.Structure {
+		if block.Type == "repetition" {
+			repeat := plan.Step{Repeat: max(int(block.Length.Value), 1), Target: plan.Target{Type: plan.TargetNone}}
+			for _, inner := range block.Steps {
+				repeat.Steps = append(repeat.Steps, planStep(inner, s.PrimaryIntensityMetric))
+			}
+			steps = append(steps, repeat)
+			continue
+		}
+		for _, inner := range block.Steps {
+			steps = append(steps, planStep(inner, s.PrimaryIntensityMetric))
+		}
+	}
+	return steps
+}
+
+// Map a trainingpeaks workout onto the common planned workout model.
+//
+// Only the planned values are used; the description is joined with the coach's comments.
+func PlannedWorkout(w client.Workout) plan.PlannedWorkout {
+	description := w.Description
+	if w.CoachComments != "" {
+		description = strings.TrimSpace(description + "\n\n" + w.CoachComments)
+	}
+	return plan.PlannedWorkout{
+		Source:          PlanSource,
+		ID:              strconv.FormatInt(w.Id, 10),
+		Name:            w.Title,
+		Description:     description,
+		Sport:           sportType(w.WorkoutType),
+		Date:            w.Day(),
+		PlannedDuration: value(w.TotalTimePlanned) * 3600,
+		PlannedDistance: value(w.DistancePlanned),
+		PlannedLoad:     value(w.TssPlanned),
+		Steps:           planSteps(w.Structure),
+	}
+}
+
+// map a completed trainingpeaks workout onto a strava summary
+func toSummary(w client.Workout) swagger.SummaryActivity {
+	sport := sportType(w.WorkoutType)
+	at := swagger.ActivityType(sport)
+	seconds := int32(math.Round(value(w.TotalTime) * 3600))
+	return swagger.SummaryActivity{
+		Id:                   w.Id,
+		ExternalId:           strconv.FormatInt(w.Id, 10),
+		Name:                 w.Title,
+		Distance:             float32(value(w.Distance)),
+		MovingTime:           seconds,
+		ElapsedTime:          seconds,
+		TotalElevationGain:   float32(value(w.ElevationGain)),
+		Type_:                &at,
+		SportType:            &sport,
+		StartDate:            w.Start(),
+		StartDateLocal:       w.Start(),
+		AverageSpeed:         float32(value(w.VelocityAverage)),
+		MaxSpeed:             float32(value(w.VelocityMaximum)),
+		AverageWatts:         float32(value(w.PowerAverage)),
+		DeviceWatts:          w.PowerAverage != nil,
+		MaxWatts:             int32(math.Round(value(w.PowerMaximum))),
+		WeightedAverageWatts: int32(math.Round(value(w.NormalizedPower))),
+		Kilojoules:           float32(value(w.PowerAverage) * float64(seconds) / 1000),
+	}
+}
@@ -0,0 +1,93 @@
+// Package client is the lower level implementation of a trainingpeaks style api: one method per endpoint, with no rate limiting or token handling.
+//
+// The http client passed to `New` is responsible for authentication (e.g. one from `oauth2.Config.Client`).
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultBaseURL  = "https://api.trainingpeaks.com"
+	DefaultOAuthURL = "https://oauth.trainingpeaks.com"
+)
+
+const (
+	workoutsPath = "/v2/workouts/%s/%s"
+	workoutPath  = "/v2/workouts/id/%d"
+)
+
+// trainingpeaks dates (for calendar ranges)
+const DateLayout = "2006-01-02"
+
+// if trainingpeaks returns 404 not found, will throw this error
+var NotFoundError = errors.New("Object not found")
+
+// if trainingpeaks returns any other non 200 status, will throw this error (wrapped with the status)
+var StatusError = errors.New("Unexpected status")
+
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func New(baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{BaseURL: baseURL, HTTPClient: httpClient}
+}
+
+// send a GET and decode the json response into `out`
+func (c *Client) getJSON(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return NotFoundError
+	default:
+		return fmt.Errorf("%w: %s", StatusError, resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// List the workouts (planned, completed or both) on the athlete's calendar between the days of `start` and `end` (both included)
+func (c *Client) ListWorkouts(ctx context.Context, start, end time.Time) ([]Workout, error) {
+	workouts := []Workout{}
+	err := c.getJSON(ctx, fmt.Sprintf(workoutsPath, start.Format(DateLayout), end.Format(DateLayout)), &workouts)
+	if err != nil {
+		return nil, err
+	}
+	return workouts, nil
+}
+
+// Get a single workout
+func (c *Client) GetWorkout(ctx context.Context, workoutID int64) (*Workout, error) {
+	var workout Workout
+	err := c.getJSON(ctx, fmt.Sprintf(workoutPath, workoutID), &workout)
+	if err != nil {
+		return nil, err
+	}
+	return &workout, nil
+}
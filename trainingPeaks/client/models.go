@@ -0,0 +1,128 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// trainingpeaks times are the athlete's local time with no zone, e.g. "2024-05-01T07:30:00"
+const LocalTimeLayout = "2006-01-02T15:04:05"
+
+// A Workout is a single item on the calendar. It is planned if it has planned values (or a structure), and completed if it has actual values.
+//
+// Times are in hours, distances in meters and speeds in meters per second. Values that are not set are nil.
+type Workout struct {
+	Id        int64  `json:"Id"`
+	AthleteId int64  `json:"AthleteId"`
+	Title     string `json:"Title"`
+	// e.g. "Run", "Bike", "Swim", "Strength" or "Day Off"
+	WorkoutType   string `json:"WorkoutType"`
+	Description   string `json:"Description"`
+	CoachComments string `json:"CoachComments"`
+	// the day the workout is on, e.g. "2024-05-01T00:00:00"
+	WorkoutDay string `json:"WorkoutDay"`
+	// when the workout was started (only for completed workouts)
+	StartTime string `json:"StartTime"`
+	Completed *bool  `json:"Completed"`
+
+	TotalTimePlanned *float64 `json:"TotalTimePlanned"`
+	DistancePlanned  *float64 `json:"DistancePlanned"`
+	TssPlanned       *float64 `json:"TssPlanned"`
+	// the structured version of the plan
+	Structure *Structure `json:"Structure"`
+
+	TotalTime        *float64 `json:"TotalTime"`
+	Distance         *float64 `json:"Distance"`
+	TssActual        *float64 `json:"TssActual"`
+	ElevationGain    *float64 `json:"ElevationGain"`
+	VelocityAverage  *float64 `json:"VelocityAverage"`
+	VelocityMaximum  *float64 `json:"VelocityMaximum"`
+	HeartRateAverage *float64 `json:"HeartRateAverage"`
+	HeartRateMaximum *float64 `json:"HeartRateMaximum"`
+	PowerAverage     *float64 `json:"PowerAverage"`
+	PowerMaximum     *float64 `json:"PowerMaximum"`
+	NormalizedPower  *float64 `json:"NormalizedPower"`
+	CadenceAverage   *float64 `json:"CadenceAverage"`
+	Calories         *float64 `json:"Calories"`
+}
+
+// The day the workout is on. zero if it is not a date
+func (w Workout) Day() time.Time {
+	t, _ := time.Parse(DateLayout, w.WorkoutDay[:min(len(w.WorkoutDay), 10)])
+	return t
+}
+
+// When the workout was started, as if the local time were utc. The day (at midnight) if there is no start time.
+func (w Workout) Start() time.Time {
+	t, err := time.Parse(LocalTimeLayout, strings.TrimSuffix(w.StartTime, "Z"))
+	if err != nil {
+		return w.Day()
+	}
+	return t
+}
+
+// Whether the workout has planned values or a structure
+func (w Workout) IsPlanned() bool {
+	return w.TotalTimePlanned != nil || w.DistancePlanned != nil || w.TssPlanned != nil || w.Structure != nil
+}
+
+// Whether the workout has been done
+func (w Workout) IsCompleted() bool {
+	if w.Completed != nil {
+		return *w.Completed
+	}
+	return w.TotalTime != nil && *w.TotalTime > 0
+}
+
+// The length of a block or step
+type Length struct {
+	Value float64 `json:"value"`
+	// "repetition" (blocks), or "second", "minute", "hour", "meter", "kilometer", "mile" (steps)
+	Unit string `json:"unit"`
+}
+
+// The band of a step, in the structure's `PrimaryIntensityMetric`
+type Target struct {
+	MinValue float64 `json:"minValue"`
+	MaxValue float64 `json:"maxValue"`
+}
+
+type Step struct {
+	Name    string   `json:"name"`
+	Length  Length   `json:"length"`
+	Targets []Target `json:"targets"`
+	// e.g. "warmUp", "active", "rest", "recovery" or "coolDown"
+	IntensityClass string `json:"intensityClass"`
+	// the step lasts until the athlete presses lap
+	OpenDuration bool `json:"openDuration"`
+}
+
+// A Block is a single step ("step") or a set of steps that is repeated `Length.Value` times ("repetition")
+type Block struct {
+	Type   string `json:"type"`
+	Length Length `json:"length"`
+	Steps  []Step `json:"steps"`
+}
+
+type Structure struct {
+	Structure []Block `json:"structure"`
+	// "duration" or "distance"
+	PrimaryLengthMetric string `json:"primaryLengthMetric"`
+	// e.g. "percentOfFtp", "percentOfThresholdHr", "percentOfMaxHr", "percentOfThresholdPace", "power" or "heartRate"
+	PrimaryIntensityMetric string `json:"primaryIntensityMetric"`
+}
+
+// trainingpeaks sends the structure as a json encoded string, so both a string and an object are accepted
+func (s *Structure) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if json.Unmarshal(data, &encoded) == nil {
+		if encoded == "" {
+			return nil
+		}
+		data = []byte(encoded)
+	}
+	// a different type, so this method is not called again
+	type structure Structure
+	return json.Unmarshal(data, (*structure)(s))
+}
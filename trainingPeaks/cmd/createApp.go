@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/jcocozza/cassidy-connector/trainingPeaks/app"
+	"golang.org/x/oauth2"
+)
+
+// Create the app based on the passed flag settings
+func createApp() (*app.App, *oauth2.Token, error) {
+	tpApp := app.NewApp(clientId, clientSecret, redirectURL, baseURL, oauthURL, scopes, nil)
+	if tokenPath == "" {
+		return tpApp, nil, nil
+	}
+	tkn, err := tpApp.ReadTokenFromFile(tokenPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tpApp, tkn, nil
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/jcocozza/cassidy-connector/oauthcli"
+	"github.com/jcocozza/cassidy-connector/trainingPeaks/fake"
+)
+
+// approval-url, initial-access and fake-server
+func init() {
+	oauthcli.AddOAuthCommands(RootCmd, oauthcli.Provider{
+		CreateApp: func() (oauthcli.App, error) {
+			tpApp, _, err := createApp()
+			if err != nil {
+				return nil, err
+			}
+			return tpApp, nil
+		},
+		OutputPath: &outputPath,
+	})
+	oauthcli.AddFakeServerCommand(RootCmd, oauthcli.FakeServer{
+		Platform:     "trainingpeaks",
+		API:          "the trainingpeaks api",
+		Demo:         "a few demo workouts",
+		URLFlags:     "--base-url and --oauth-url",
+		DefaultAddr:  defaultFakeAddr,
+		ClientID:     &clientId,
+		ClientSecret: &clientSecret,
+		NewDemoServer: func(clientID string, clientSecret string) http.Handler {
+			return fake.NewDemoServer(clientID, clientSecret)
+		},
+	})
+}
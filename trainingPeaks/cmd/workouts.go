@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/utils"
+	"github.com/spf13/cobra"
+)
+
+const layout string = "2006-01-02"
+const layoutInterpretation string = "YYYY-MM-DD"
+
+var start string
+var end string
+
+// parse --start and --end. --end defaults to --start
+func parseRange() (time.Time, time.Time, error) {
+	startTime, err := time.Parse(layout, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end == "" {
+		return startTime, startTime, nil
+	}
+	endTime, err := time.Parse(layout, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return startTime, endTime, nil
+}
+
+// print (and save, if --path is set) anything as json
+func printJSON(v interface{}) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if outputPath != "" {
+		utils.WriteOutput(outputPath, jsonBytes)
+	}
+	fmt.Println(string(jsonBytes))
+}
+
+var getWorkouts = &cobra.Command{
+	Use:   "workouts",
+	Short: "Get the workouts (planned and completed) on the calendar, as trainingpeaks returns them.",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		tpApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		startTime, endTime, err := parseRange()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		workouts, err := tpApp.Api.GetWorkouts(context.TODO(), tkn, startTime, endTime)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		printJSON(workouts)
+	},
+}
+
+var getPlanned = &cobra.Command{
+	Use:   "planned",
+	Short: "Get the planned workouts on the calendar, in the common plan model.",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		tpApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		startTime, endTime, err := parseRange()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		planned, err := tpApp.Api.GetPlannedWorkouts(context.TODO(), tkn, startTime, endTime)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		printJSON(planned)
+	},
+}
+
+var getCompleted = &cobra.Command{
+	Use:   "completed",
+	Short: "Get the completed workouts on the calendar, in the same shape as strava's activities.",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		tpApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		startTime, endTime, err := parseRange()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		completed, err := tpApp.Api.GetCompletedWorkouts(context.TODO(), tkn, startTime, endTime)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		printJSON(completed)
+	},
+}
+
+var asPlan bool
+var getWorkout = &cobra.Command{
+	Use:   "workout [workout id]",
+	Short: "Get a single workout.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tpApp, tkn, err := createApp()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		workoutId, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if asPlan {
+			planned, err := tpApp.Api.GetPlannedWorkout(context.TODO(), tkn, workoutId)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			printJSON(planned)
+			return
+		}
+		workout, err := tpApp.Api.GetWorkout(context.TODO(), tkn, workoutId)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		printJSON(workout)
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{getWorkouts, getPlanned, getCompleted} {
+		c.Flags().StringVarP(&start, "start", "s", "", fmt.Sprintf("The first day to get. Must be of the format: %s", layoutInterpretation))
+		c.Flags().StringVarP(&end, "end", "e", "", fmt.Sprintf("The last day to get (defaults to --start). Must be of the format: %s", layoutInterpretation))
+		c.MarkFlagRequired("start")
+		tokenCmdGroup.AddCommand(c)
+	}
+	getWorkout.Flags().BoolVar(&asPlan, "plan", false, "Return the workout in the common plan model.")
+	tokenCmdGroup.AddCommand(getWorkout)
+}
@@ -0,0 +1,102 @@
+package fake
+
+import "github.com/jcocozza/cassidy-connector/trainingPeaks/client"
+
+const (
+	// a planned (not yet done) structured run
+	DemoIntervalsID int64 = 3100000003
+	// a planned ride that was done
+	DemoRideID int64 = 3100000002
+	// a swim that was done without a plan
+	DemoSwimID int64 = 3100000001
+)
+
+func ptr(f float64) *float64 {
+	return &f
+}
+
+// A swim that was not planned, a planned ride that was done and a planned structured run that was not, in calendar order
+func DemoWorkouts() []client.Workout {
+	completed := true
+	return []client.Workout{
+		{
+			Id:               DemoSwimID,
+			AthleteId:        420001,
+			Title:            "Masters swim",
+			WorkoutType:      "Swim",
+			WorkoutDay:       "2024-05-06T00:00:00",
+			StartTime:        "2024-05-06T06:15:00",
+			Completed:        &completed,
+			TotalTime:        ptr(1.0),
+			Distance:         ptr(3000),
+			TssActual:        ptr(55),
+			VelocityAverage:  ptr(0.83),
+			HeartRateAverage: ptr(132),
+		},
+		{
+			Id:               DemoRideID,
+			AthleteId:        420001,
+			Title:            "Endurance ride",
+			WorkoutType:      "Bike",
+			Description:      "keep it in zone 2",
+			WorkoutDay:       "2024-05-07T00:00:00",
+			StartTime:        "2024-05-07T07:30:00",
+			Completed:        &completed,
+			TotalTimePlanned: ptr(2.0),
+			DistancePlanned:  ptr(60000),
+			TssPlanned:       ptr(110),
+			TotalTime:        ptr(2.05),
+			Distance:         ptr(61200),
+			TssActual:        ptr(118),
+			ElevationGain:    ptr(540),
+			VelocityAverage:  ptr(8.29),
+			VelocityMaximum:  ptr(15.1),
+			HeartRateAverage: ptr(138),
+			HeartRateMaximum: ptr(162),
+			PowerAverage:     ptr(185),
+			PowerMaximum:     ptr(610),
+			NormalizedPower:  ptr(198),
+			CadenceAverage:   ptr(88),
+			Calories:         ptr(1360),
+		},
+		{
+			Id:               DemoIntervalsID,
+			AthleteId:        420001,
+			Title:            "5 x 3' at threshold",
+			WorkoutType:      "Run",
+			Description:      "flat route",
+			CoachComments:    "hold back on the first rep",
+			WorkoutDay:       "2024-05-08T00:00:00",
+			TotalTimePlanned: ptr(0.75),
+			TssPlanned:       ptr(62),
+			Structure: &client.Structure{
+				PrimaryLengthMetric:    "duration",
+				PrimaryIntensityMetric: "percentOfThresholdPace",
+				Structure: []client.Block{
+					{
+						Type:   "step",
+						Length: client.Length{Value: 1, Unit: "repetition"},
+						Steps: []client.Step{
+							{Name: "Warm up", Length: client.Length{Value: 15, Unit: "minute"}, Targets: []client.Target{{MinValue: 70, MaxValue: 80}}, IntensityClass: "warmUp"},
+						},
+					},
+					{
+						Type:   "repetition",
+						Length: client.Length{Value: 5, Unit: "repetition"},
+						Steps: []client.Step{
+							{Name: "Threshold", Length: client.Length{Value: 180, Unit: "second"}, Targets: []client.Target{{MinValue: 98, MaxValue: 102}}, IntensityClass: "active"},
+							{Name: "Float", Length: client.Length{Value: 90, Unit: "second"}, Targets: []client.Target{{MinValue: 60, MaxValue: 70}}, IntensityClass: "recovery"},
+						},
+					},
+					{
+						Type:   "step",
+						Length: client.Length{Value: 1, Unit: "repetition"},
+						Steps: []client.Step{
+							{Name: "Cool down", Length: client.Length{Value: 1, Unit: "kilometer"}, IntensityClass: "coolDown"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,117 @@
+// Package fake is a local stand-in for the trainingpeaks api, so the trainingPeaks package can be developed and tested without network (or a trainingpeaks partner account).
+//
+// It implements the same oauth flow (see `fakeoauth`) and endpoints as `client`: the calendar range and workout detail.
+//
+// A `Server` is an `http.Handler`, so it can be used with `httptest.NewServer` or served as is (see `cassidy-training-peaks fake-server`).
+// Both the oauth and api endpoints are served, so the same url is used for both.
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jcocozza/cassidy-connector/fakeoauth"
+	"github.com/jcocozza/cassidy-connector/trainingPeaks/client"
+)
+
+// A Server pretends to be trainingpeaks. Tokens are handled by the embedded oauth server (e.g. `ExpireTokens`).
+type Server struct {
+	*fakeoauth.Server
+
+	mux *http.ServeMux
+	mu  sync.Mutex
+	// in calendar order
+	workouts []client.Workout
+}
+
+// Create a server with no workouts. Only `clientID` and `clientSecret` can get tokens.
+func NewServer(clientID string, clientSecret string) *Server {
+	s := &Server{
+		Server: fakeoauth.New(clientID, clientSecret),
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /oauth/authorize", s.Authorize)
+	s.mux.HandleFunc("POST /oauth/token", s.Token)
+	s.mux.HandleFunc("GET /v2/workouts/{start}/{end}", s.Authenticated(s.listWorkouts))
+	s.mux.HandleFunc("GET /v2/workouts/id/{id}", s.Authenticated(s.getWorkout))
+	return s
+}
+
+// Create a server with a few demo workouts (see `DemoWorkouts`)
+func NewDemoServer(clientID string, clientSecret string) *Server {
+	s := NewServer(clientID, clientSecret)
+	for _, w := range DemoWorkouts() {
+		s.AddWorkout(w)
+	}
+	return s
+}
+
+// Add a workout to the calendar
+func (s *Server) AddWorkout(workout client.Workout) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workouts = append(s.workouts, workout)
+	slices.SortStableFunc(s.workouts, func(a, b client.Workout) int {
+		return a.Start().Compare(b.Start())
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// workouts on the days from start to end (both included). like trainingpeaks, ranges over 90 days are refused
+func (s *Server) listWorkouts(w http.ResponseWriter, r *http.Request) {
+	start, err := time.Parse(client.DateLayout, r.PathValue("start"))
+	if err != nil {
+		http.Error(w, "invalid start date", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse(client.DateLayout, r.PathValue("end"))
+	if err != nil || end.Before(start) {
+		http.Error(w, "invalid end date", http.StatusBadRequest)
+		return
+	}
+	if end.Sub(start) >= 90*24*time.Hour {
+		http.Error(w, "range is longer than 90 days", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workouts := []client.Workout{}
+	for _, wk := range s.workouts {
+		day := wk.Day()
+		if day.Before(start) || day.After(end) {
+			continue
+		}
+		workouts = append(workouts, wk)
+	}
+	writeJSON(w, http.StatusOK, workouts)
+}
+
+func (s *Server) getWorkout(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "workout not found", http.StatusNotFound)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, wk := range s.workouts {
+		if wk.Id == id {
+			writeJSON(w, http.StatusOK, wk)
+			return
+		}
+	}
+	http.Error(w, "workout not found", http.StatusNotFound)
+}
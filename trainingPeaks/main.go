@@ -0,0 +1,7 @@
+package main
+
+import "github.com/jcocozza/cassidy-connector/trainingPeaks/cmd"
+
+func main() {
+	cmd.Execute()
+}